@@ -17,15 +17,67 @@ limitations under the License.
 package controller
 
 import (
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/pkg/tracing"
 	"github.com/kubernetes-incubator/external-dns/plan"
+	"github.com/kubernetes-incubator/external-dns/provider"
 	"github.com/kubernetes-incubator/external-dns/registry"
 	"github.com/kubernetes-incubator/external-dns/source"
 )
 
+var (
+	syncDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "sync_duration_seconds",
+			Help:      "Duration of the synchronizations, including failed ones.",
+		},
+	)
+	syncErrorsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "sync_errors_total",
+			Help:      "Number of failed synchronizations.",
+		},
+	)
+	lastSyncTimestamp = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "last_successful_sync_timestamp_seconds",
+			Help:      "Unix timestamp of the last successful synchronization.",
+		},
+	)
+	recordChangesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "record_changes_total",
+			Help:      "Number of records created, updated and deleted.",
+		},
+		[]string{"action"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(syncDuration)
+	prometheus.MustRegister(syncErrorsTotal)
+	prometheus.MustRegister(lastSyncTimestamp)
+	prometheus.MustRegister(recordChangesTotal)
+}
+
 // Controller is responsible for orchestrating the different components.
 // It works in the following way:
 // * Ask the DNS provider for current list of endpoints.
@@ -35,46 +87,339 @@ import (
 type Controller struct {
 	Source   source.Source
 	Registry registry.Registry
-	// The policy that defines which changes to DNS records are allowed
+	// The policy that defines which changes to DNS records are allowed, all changes if nil
 	Policy plan.Policy
+	// ConflictResolver chooses between resources wanting the same DNS name, per resource if nil
+	ConflictResolver plan.ConflictResolver
+	// PreferCNAME keeps CNAME records instead of A and AAAA records desired for the same DNS name
+	PreferCNAME bool
+	// ProviderManagedProperties are the provider specific properties added to the records by the provider itself
+	ProviderManagedProperties []string
+	// PerProvider plans the records of every provider separately, e.g. of the MultiProvider
+	PerProvider bool
+	// MergeGeoLocations merges the desired endpoints of a name with different geo locations into a geo record set,
+	// for providers supporting geo locations
+	MergeGeoLocations bool
+	// DomainFilter optionally restricts the records managed, records not matching it are neither created nor deleted
+	DomainFilter provider.DomainFilter
+	// ManagedRecordTypes optionally restricts the records managed to these types, records of other types are left untouched
+	ManagedRecordTypes []string
 	// The interval between individual synchronizations
 	Interval time.Duration
+	// IntervalJitter adds a random delay of up to this duration to every interval, so that many instances
+	// don't call the DNS provider at the same time
+	IntervalJitter time.Duration
+	// EndpointsAdjuster optionally lets the provider adjust the desired endpoints before planning
+	EndpointsAdjuster provider.EndpointsAdjuster
+	// MaxChangesPerSync limits the number of changes applied in a single synchronization, unlimited if 0
+	MaxChangesPerSync int
+	// DryRun disables applying the changes, they're written to DiffOutput instead
+	DryRun bool
+	// DiffFormat is the format of the changes written in dry-run mode, text or json
+	DiffFormat string
+	// DiffOutput receives the changes in dry-run mode, defaults to os.Stdout
+	DiffOutput io.Writer
+	// Tracer optionally traces the synchronizations, with a span for every call to the source, registry and provider
+	Tracer *tracing.Tracer
+	// FinalSync runs a last synchronization when Run is stopped
+	FinalSync bool
+	// StatusReporter is optionally informed about the state of the records of the desired endpoints after every
+	// synchronization which got to plan the changes
+	StatusReporter source.StatusReporter
+	// LivenessIntervals is the number of intervals without a completed synchronization after which Run
+	// is considered stuck and Alive fails, disabled if 0
+	LivenessIntervals int
+
+	// mu protects the health of the synchronization loop and the fields changed by Reconfigure
+	mu sync.Mutex
+	// ready is set once the records and endpoints were listed successfully
+	ready bool
+	// lastSync is the time the last synchronization of Run completed, zero if Run isn't running
+	lastSync time.Time
 }
 
 // RunOnce runs a single iteration of a reconciliation loop.
 func (c *Controller) RunOnce() error {
+	start := time.Now()
+	span := c.Tracer.Start("sync")
+	err := c.runOnce(span)
+	span.End(err)
+	syncDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		syncErrorsTotal.Inc()
+		return err
+	}
+	lastSyncTimestamp.Set(float64(time.Now().Unix()))
+	return nil
+}
+
+func (c *Controller) runOnce(trace *tracing.Span) error {
+	src, policy, domainFilter := c.config()
+	if policy == nil {
+		policy = &plan.SyncPolicy{}
+	}
+
+	span := trace.StartChild("registry.records")
 	records, err := c.Registry.Records()
+	span.SetAttribute("records", int64(len(records)))
+	span.End(err)
 	if err != nil {
 		return err
 	}
 
-	endpoints, err := c.Source.Endpoints()
+	span = trace.StartChild("source.endpoints")
+	endpoints, err := src.Endpoints()
+	span.SetAttribute("endpoints", int64(len(endpoints)))
+	span.End(err)
 	if err != nil {
 		return err
 	}
+	c.setReady()
+
+	if c.EndpointsAdjuster != nil {
+		endpoints, err = c.EndpointsAdjuster.AdjustEndpoints(endpoints)
+		if err != nil {
+			return err
+		}
+	}
+
+	if domainFilter.IsConfigured() {
+		records = filterEndpoints(records, domainFilter)
+		endpoints = filterEndpoints(endpoints, domainFilter)
+	}
+
+	if len(c.ManagedRecordTypes) > 0 {
+		records = filterRecordTypes(records, c.ManagedRecordTypes)
+		endpoints = filterRecordTypes(endpoints, c.ManagedRecordTypes)
+	}
 
 	plan := &plan.Plan{
-		Policies: []plan.Policy{c.Policy},
-		Current:  records,
-		Desired:  endpoints,
+		Policies:                  []plan.Policy{policy},
+		Current:                   records,
+		Desired:                   endpoints,
+		ConflictResolver:          c.ConflictResolver,
+		PreferCNAME:               c.PreferCNAME,
+		ProviderManagedProperties: c.ProviderManagedProperties,
+		PerProvider:               c.PerProvider,
+		MergeGeoLocations:         c.MergeGeoLocations,
 	}
 
+	span = trace.StartChild("plan.calculate")
 	plan = plan.Calculate()
+	span.SetAttribute("changes", int64(plan.Changes.Size()))
+	span.End(nil)
+
+	changes := plan.Changes.Limit(c.MaxChangesPerSync)
+	if changes != plan.Changes {
+		log.Infof("Applying %d of %d changes, the remaining ones are applied in the next synchronizations", changes.Size(), plan.Changes.Size())
+	}
+
+	if c.DryRun {
+		c.reportStatus(records, plan.Desired, plan.Changes, changes, nil)
+		return c.writeDiff(changes)
+	}
+
+	span = trace.StartChild("registry.apply_changes")
+	span.SetAttribute("create", int64(len(changes.Create)))
+	span.SetAttribute("update", int64(len(changes.UpdateNew)))
+	span.SetAttribute("delete", int64(len(changes.Delete)))
+	err = c.Registry.ApplyChanges(changes)
+	span.End(err)
+	c.reportStatus(records, plan.Desired, plan.Changes, changes, err)
+	if err != nil {
+		return err
+	}
+	recordChangesTotal.WithLabelValues("create").Add(float64(len(changes.Create)))
+	recordChangesTotal.WithLabelValues("update").Add(float64(len(changes.UpdateNew)))
+	recordChangesTotal.WithLabelValues("delete").Add(float64(len(changes.Delete)))
+	return nil
+}
+
+// filterEndpoints returns the endpoints whose DNS name matches the domain filter
+func filterEndpoints(endpoints []*endpoint.Endpoint, domainFilter provider.DomainFilter) []*endpoint.Endpoint {
+	filtered := []*endpoint.Endpoint{}
+	for _, ep := range endpoints {
+		if domainFilter.Match(ep.DNSName) {
+			filtered = append(filtered, ep)
+		} else {
+			log.WithFields(ep.LogFields()).Debug("Skipping record because it doesn't match the domain filter")
+		}
+	}
+	return filtered
+}
+
+// filterRecordTypes returns the endpoints of the given record types
+func filterRecordTypes(endpoints []*endpoint.Endpoint, recordTypes []string) []*endpoint.Endpoint {
+	filtered := []*endpoint.Endpoint{}
+	for _, ep := range endpoints {
+		if isManagedRecordType(ep.RecordType, recordTypes) {
+			filtered = append(filtered, ep)
+		} else {
+			log.WithFields(ep.LogFields()).Debug("Skipping record because its record type isn't managed")
+		}
+	}
+	return filtered
+}
 
-	return c.Registry.ApplyChanges(plan.Changes)
+func isManagedRecordType(recordType string, recordTypes []string) bool {
+	for _, t := range recordTypes {
+		if t == recordType {
+			return true
+		}
+	}
+	return false
+}
+
+// recordKey identifies the record of an endpoint
+type recordKey struct {
+	dnsName       string
+	recordType    string
+	setIdentifier string
+}
+
+func newRecordKey(ep *endpoint.Endpoint) recordKey {
+	return recordKey{dnsName: ep.DNSName, recordType: ep.RecordType, setIdentifier: ep.SetIdentifier}
+}
+
+// reportStatus informs the StatusReporter about the state of the record of every desired endpoint.
+// planned are all changes of the plan, applied the ones passed to the registry and err the error applying them.
+func (c *Controller) reportStatus(records, endpoints []*endpoint.Endpoint, planned, applied *plan.Changes, err error) {
+	if c.StatusReporter == nil {
+		return
+	}
+	current := map[recordKey]*endpoint.Endpoint{}
+	for _, record := range records {
+		current[newRecordKey(record)] = record
+	}
+	changed := func(changes *plan.Changes) map[recordKey]bool {
+		keys := map[recordKey]bool{}
+		for _, ep := range append(append([]*endpoint.Endpoint{}, changes.Create...), changes.UpdateNew...) {
+			keys[newRecordKey(ep)] = true
+		}
+		return keys
+	}
+	plannedKeys, appliedKeys := changed(planned), changed(applied)
+
+	statuses := make([]*source.RecordStatus, 0, len(endpoints))
+	for _, ep := range endpoints {
+		key := newRecordKey(ep)
+		status := &source.RecordStatus{Endpoint: ep, State: source.RecordSynced}
+		switch {
+		case appliedKeys[key] && c.DryRun:
+			status.State, status.Message = source.RecordPending, "changes aren't applied in dry-run mode"
+		case appliedKeys[key] && err != nil:
+			status.State, status.Message = source.RecordFailed, err.Error()
+		case appliedKeys[key]:
+		case plannedKeys[key]:
+			status.State, status.Message = source.RecordPending, "the change is applied in a later synchronization"
+		case current[key] == nil || !current[key].Targets.Same(ep.Targets):
+			status.State, status.Message = source.RecordRejected, "the record isn't changed, another resource owns its name or the policy doesn't allow the change"
+		}
+		statuses = append(statuses, status)
+	}
+	c.StatusReporter.ReportStatus(statuses)
+}
+
+// writeDiff writes the changes in the configured format instead of applying them
+func (c *Controller) writeDiff(changes *plan.Changes) error {
+	output := c.DiffOutput
+	if output == nil {
+		output = os.Stdout
+	}
+	diff := plan.NewDiff(changes)
+	switch c.DiffFormat {
+	case "", "text":
+		return diff.WriteText(output)
+	case "json":
+		return diff.WriteJSON(output)
+	default:
+		return fmt.Errorf("unknown diff format: %s", c.DiffFormat)
+	}
+}
+
+// Reconfigure replaces the source, the policy and the domain filter, e.g. after the config file changed.
+// It's safe to call while Run is running, the next synchronization uses the new values.
+func (c *Controller) Reconfigure(src source.Source, policy plan.Policy, domainFilter provider.DomainFilter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Source = src
+	c.Policy = policy
+	c.DomainFilter = domainFilter
+}
+
+// config returns the fields changed by Reconfigure
+func (c *Controller) config() (source.Source, plan.Policy, provider.DomainFilter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Source, c.Policy, c.DomainFilter
+}
+
+// nextInterval returns the delay until the next synchronization
+func (c *Controller) nextInterval() time.Duration {
+	if c.IntervalJitter <= 0 {
+		return c.Interval
+	}
+	return c.Interval + time.Duration(rand.Int63n(int64(c.IntervalJitter)))
+}
+
+// setReady marks the controller ready after listing the records and endpoints
+func (c *Controller) setReady() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ready = true
+}
+
+// setLastSync records the completion of a synchronization of Run
+func (c *Controller) setLastSync(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastSync = t
+}
+
+// Ready returns an error until the records of the registry and the endpoints of the source were listed once
+func (c *Controller) Ready() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.ready {
+		return fmt.Errorf("the records and endpoints haven't been listed yet")
+	}
+	return nil
+}
+
+// Alive returns an error if Run didn't complete a synchronization within LivenessIntervals intervals
+func (c *Controller) Alive() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.LivenessIntervals <= 0 || c.lastSync.IsZero() {
+		return nil
+	}
+	deadline := time.Duration(c.LivenessIntervals) * (c.Interval + c.IntervalJitter)
+	if since := time.Since(c.lastSync); since > deadline {
+		return fmt.Errorf("the last synchronization completed %s ago", since)
+	}
+	return nil
 }
 
 // Run runs RunOnce in a loop with a delay until stopChan receives a value.
+// A running synchronization is always completed, optionally followed by a final one.
 func (c *Controller) Run(stopChan <-chan struct{}) {
+	c.setLastSync(time.Now())
 	for {
 		err := c.RunOnce()
 		if err != nil {
 			log.Error(err)
 		}
+		c.setLastSync(time.Now())
 
 		select {
-		case <-time.After(c.Interval):
+		case <-time.After(c.nextInterval()):
 		case <-stopChan:
+			if c.FinalSync {
+				log.Info("Running a final synchronization before terminating")
+				if err := c.RunOnce(); err != nil {
+					log.Error(err)
+				}
+			}
 			log.Info("Terminating main controller loop")
 			return
 		}
@@ -17,14 +17,19 @@ limitations under the License.
 package controller
 
 import (
+	"bytes"
 	"errors"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/kubernetes-incubator/external-dns/endpoint"
 	"github.com/kubernetes-incubator/external-dns/internal/testutils"
+	"github.com/kubernetes-incubator/external-dns/pkg/tracing"
 	"github.com/kubernetes-incubator/external-dns/plan"
 	"github.com/kubernetes-incubator/external-dns/provider"
 	"github.com/kubernetes-incubator/external-dns/registry"
+	"github.com/kubernetes-incubator/external-dns/source"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -142,3 +147,483 @@ func TestRunOnce(t *testing.T) {
 	// Validate that the mock source was called.
 	source.AssertExpectations(t)
 }
+
+// suffixAdjuster appends a suffix to all desired endpoints.
+type suffixAdjuster struct {
+	suffix string
+}
+
+func (a *suffixAdjuster) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	for _, ep := range endpoints {
+		ep.DNSName += a.suffix
+	}
+	return endpoints, nil
+}
+
+// TestRunOnceAdjustsEndpoints tests that RunOnce plans with the endpoints returned by the EndpointsAdjuster.
+func TestRunOnceAdjustsEndpoints(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		{
+			DNSName: "create-record",
+			Targets: endpoint.Targets{"1.2.3.4"},
+		},
+	}, nil)
+
+	provider := newMockProvider(
+		[]*endpoint.Endpoint{},
+		&plan.Changes{
+			Create: []*endpoint.Endpoint{
+				{DNSName: "create-record.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
+			},
+		},
+	)
+
+	r, err := registry.NewNoopRegistry(provider)
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:            source,
+		Registry:          r,
+		Policy:            &plan.SyncPolicy{},
+		EndpointsAdjuster: &suffixAdjuster{suffix: ".example.org"},
+	}
+
+	assert.NoError(t, ctrl.RunOnce())
+
+	source.AssertExpectations(t)
+}
+
+// failingRegistry fails the test if changes are applied
+type failingRegistry struct {
+	t       *testing.T
+	records []*endpoint.Endpoint
+}
+
+func (r *failingRegistry) Records() ([]*endpoint.Endpoint, error) {
+	return r.records, nil
+}
+
+func (r *failingRegistry) ApplyChanges(changes *plan.Changes) error {
+	r.t.Error("changes must not be applied in dry-run mode")
+	return nil
+}
+
+// TestRunOnceDryRun tests that RunOnce writes the changes instead of applying them in dry-run mode.
+func TestRunOnceDryRun(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		{DNSName: "create-record", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "update-record", Targets: endpoint.Targets{"8.8.4.4"}, RecordType: endpoint.RecordTypeA},
+	}, nil)
+	r := &failingRegistry{t: t, records: []*endpoint.Endpoint{
+		{DNSName: "update-record", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "delete-record", Targets: endpoint.Targets{"4.3.2.1"}, RecordType: endpoint.RecordTypeA},
+	}}
+
+	output := &bytes.Buffer{}
+	ctrl := &Controller{
+		Source:     source,
+		Registry:   r,
+		Policy:     &plan.SyncPolicy{},
+		DryRun:     true,
+		DiffOutput: output,
+	}
+	require.NoError(t, ctrl.RunOnce())
+	assert.Equal(t, `+ create-record 0 IN A 1.2.3.4
+~ update-record 0 IN A 8.8.8.8
+    targets: 8.8.8.8 -> 8.8.4.4
+- delete-record 0 IN A 4.3.2.1
+Plan: 1 to create, 1 to update, 1 to delete.
+`, output.String())
+
+	output.Reset()
+	ctrl.DiffFormat = "json"
+	require.NoError(t, ctrl.RunOnce())
+	assert.Contains(t, output.String(), `"create": [`)
+
+	ctrl.DiffFormat = "yaml"
+	assert.Error(t, ctrl.RunOnce())
+}
+
+// TestRunOnceEndpointsSource tests that the records of endpoints set by code are created and deleted,
+// like by an operator embedding the controller.
+func TestRunOnceEndpointsSource(t *testing.T) {
+	p := provider.NewInMemoryProvider(provider.InMemoryInitZones([]string{"example.org"}))
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+
+	endpoints := source.NewEndpointsSource(endpoint.NewEndpoint("foo.example.org", "1.2.3.4", endpoint.RecordTypeA))
+	ctrl := &Controller{
+		Source:   endpoints,
+		Registry: r,
+	}
+	require.NoError(t, ctrl.RunOnce())
+	records, err := p.Records()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "foo.example.org", records[0].DNSName)
+
+	endpoints.SetEndpoints(nil)
+	require.NoError(t, ctrl.RunOnce())
+	records, err = p.Records()
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+// TestRunOnceDomainFilter tests that RunOnce neither creates nor deletes records not matching the domain filter.
+func TestRunOnceDomainFilter(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		{DNSName: "create.prod.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "create.sandbox.prod.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+	}, nil)
+	r := &failingRegistry{t: t, records: []*endpoint.Endpoint{
+		{DNSName: "delete.prod.example.org", Targets: endpoint.Targets{"4.3.2.1"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "delete.staging.example.org", Targets: endpoint.Targets{"4.3.2.1"}, RecordType: endpoint.RecordTypeA},
+	}}
+
+	output := &bytes.Buffer{}
+	ctrl := &Controller{
+		Source:       source,
+		Registry:     r,
+		Policy:       &plan.SyncPolicy{},
+		DomainFilter: provider.NewRegexDomainFilter(nil, nil, regexp.MustCompile(`\.prod\.`), regexp.MustCompile(`\.sandbox\.`)),
+		DryRun:       true,
+		DiffOutput:   output,
+	}
+	require.NoError(t, ctrl.RunOnce())
+	assert.Equal(t, `+ create.prod.example.org 0 IN A 1.2.3.4
+- delete.prod.example.org 0 IN A 4.3.2.1
+Plan: 1 to create, 0 to update, 1 to delete.
+`, output.String())
+}
+
+// TestRunOnceExcludeDomains tests that RunOnce doesn't touch records of excluded domains, neither desired nor owned ones.
+func TestRunOnceExcludeDomains(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		{DNSName: "create.example.com", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "create.corp.example.com", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+	}, nil)
+	r := &failingRegistry{t: t, records: []*endpoint.Endpoint{
+		{DNSName: "corp.example.com", Targets: endpoint.Targets{"4.3.2.1"}, RecordType: endpoint.RecordTypeA},
+	}}
+
+	output := &bytes.Buffer{}
+	ctrl := &Controller{
+		Source:       source,
+		Registry:     r,
+		Policy:       &plan.SyncPolicy{},
+		DomainFilter: provider.NewDomainFilterWithExclusions([]string{"example.com"}, []string{"corp.example.com"}),
+		DryRun:       true,
+		DiffOutput:   output,
+	}
+	require.NoError(t, ctrl.RunOnce())
+	assert.Equal(t, `+ create.example.com 0 IN A 1.2.3.4
+Plan: 1 to create, 0 to update, 0 to delete.
+`, output.String())
+}
+
+// TestReconfigure tests that RunOnce uses the source, policy and domain filter passed to Reconfigure.
+func TestReconfigure(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		{DNSName: "create.example.com", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+	}, nil)
+	r := &failingRegistry{t: t, records: []*endpoint.Endpoint{
+		{DNSName: "delete.example.com", Targets: endpoint.Targets{"4.3.2.1"}, RecordType: endpoint.RecordTypeA},
+	}}
+
+	output := &bytes.Buffer{}
+	ctrl := &Controller{
+		Source:       source,
+		Registry:     r,
+		Policy:       &plan.SyncPolicy{},
+		DomainFilter: provider.NewDomainFilter([]string{"org"}),
+		DryRun:       true,
+		DiffOutput:   output,
+	}
+	require.NoError(t, ctrl.RunOnce())
+	assert.Equal(t, "Plan: 0 to create, 0 to update, 0 to delete.\n", output.String())
+
+	reconfigured := new(testutils.MockSource)
+	reconfigured.On("Endpoints").Return([]*endpoint.Endpoint{
+		{DNSName: "reconfigured.example.com", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+	}, nil)
+	ctrl.Reconfigure(reconfigured, &plan.UpsertOnlyPolicy{}, provider.NewDomainFilter([]string{"example.com"}))
+
+	output.Reset()
+	require.NoError(t, ctrl.RunOnce())
+	assert.Equal(t, `+ reconfigured.example.com 0 IN A 1.2.3.4
+Plan: 1 to create, 0 to update, 0 to delete.
+`, output.String())
+	source.AssertNumberOfCalls(t, "Endpoints", 1)
+}
+
+// TestRunOnceManagedRecordTypes tests that RunOnce neither creates nor deletes records of unmanaged types.
+func TestRunOnceManagedRecordTypes(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		{DNSName: "create.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "create-txt.example.org", Targets: endpoint.Targets{"text"}, RecordType: endpoint.RecordTypeTXT},
+	}, nil)
+	r := &failingRegistry{t: t, records: []*endpoint.Endpoint{
+		{DNSName: "delete.example.org", Targets: endpoint.Targets{"foo.elb.com"}, RecordType: endpoint.RecordTypeCNAME},
+		{DNSName: "delete-txt.example.org", Targets: endpoint.Targets{"text"}, RecordType: endpoint.RecordTypeTXT},
+	}}
+
+	output := &bytes.Buffer{}
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+		DryRun:             true,
+		DiffOutput:         output,
+	}
+	require.NoError(t, ctrl.RunOnce())
+	assert.Equal(t, `+ create.example.org 0 IN A 1.2.3.4
+- delete.example.org 0 IN CNAME foo.elb.com
+Plan: 1 to create, 0 to update, 1 to delete.
+`, output.String())
+}
+
+// TestRunOnceMaxChangesPerSync tests that RunOnce applies large diffs in chunks over multiple synchronizations.
+func TestRunOnceMaxChangesPerSync(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		{DNSName: "a.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "b.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "c.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+	}, nil)
+
+	p := provider.NewInMemoryProvider(provider.InMemoryInitZones([]string{"example.org"}))
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:            source,
+		Registry:          r,
+		Policy:            &plan.SyncPolicy{},
+		MaxChangesPerSync: 2,
+	}
+
+	require.NoError(t, ctrl.RunOnce())
+	records, err := p.Records()
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	require.NoError(t, ctrl.RunOnce())
+	records, err = p.Records()
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+}
+
+// statusRecorder keeps the states reported after the last synchronization by DNS name
+type statusRecorder struct {
+	states map[string]source.RecordState
+}
+
+func (r *statusRecorder) ReportStatus(statuses []*source.RecordStatus) {
+	r.states = map[string]source.RecordState{}
+	for _, status := range statuses {
+		r.states[status.Endpoint.DNSName] = status.State
+	}
+}
+
+// TestRunOnceReportsStatus tests that RunOnce reports the state of the record of every desired endpoint.
+func TestRunOnceReportsStatus(t *testing.T) {
+	p := provider.NewInMemoryProvider(provider.InMemoryInitZones([]string{"example.org"}))
+	require.NoError(t, p.ApplyChanges(&plan.Changes{Create: []*endpoint.Endpoint{
+		endpoint.NewEndpoint("synced.example.org", "1.2.3.4", endpoint.RecordTypeA),
+		endpoint.NewEndpoint("rejected.example.org", "5.6.7.8", endpoint.RecordTypeA),
+	}}))
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+
+	endpoints := source.NewEndpointsSource(
+		endpoint.NewEndpoint("synced.example.org", "1.2.3.4", endpoint.RecordTypeA),
+		endpoint.NewEndpoint("rejected.example.org", "1.2.3.4", endpoint.RecordTypeA),
+		endpoint.NewEndpoint("created.example.org", "1.2.3.4", endpoint.RecordTypeA),
+	)
+	recorder := &statusRecorder{}
+	ctrl := &Controller{
+		Source:         endpoints,
+		Registry:       r,
+		Policy:         &plan.CreateOnlyPolicy{},
+		StatusReporter: recorder,
+	}
+	require.NoError(t, ctrl.RunOnce())
+	assert.Equal(t, map[string]source.RecordState{
+		"synced.example.org":   source.RecordSynced,
+		"rejected.example.org": source.RecordRejected,
+		"created.example.org":  source.RecordSynced,
+	}, recorder.states)
+
+	// the changes exceeding the limit are pending, as are all changes in dry-run mode
+	endpoints.SetEndpoints([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("a.example.org", "1.2.3.4", endpoint.RecordTypeA),
+		endpoint.NewEndpoint("b.example.org", "1.2.3.4", endpoint.RecordTypeA),
+	})
+	ctrl.MaxChangesPerSync = 1
+	require.NoError(t, ctrl.RunOnce())
+	created, pending := "a.example.org", "b.example.org"
+	if recorder.states[created] == source.RecordPending {
+		created, pending = pending, created
+	}
+	expected := map[string]source.RecordState{created: source.RecordSynced, pending: source.RecordPending}
+	assert.Equal(t, expected, recorder.states)
+
+	ctrl.DryRun = true
+	ctrl.DiffOutput = &bytes.Buffer{}
+	require.NoError(t, ctrl.RunOnce())
+	assert.Equal(t, expected, recorder.states)
+
+	// the changes are failed if the registry fails to apply them
+	ctrl.Registry = &erroringRegistry{Registry: r}
+	ctrl.DryRun = false
+	require.Error(t, ctrl.RunOnce())
+	assert.Equal(t, map[string]source.RecordState{created: source.RecordSynced, pending: source.RecordFailed}, recorder.states)
+}
+
+// erroringRegistry fails to apply any changes
+type erroringRegistry struct {
+	registry.Registry
+}
+
+func (r *erroringRegistry) ApplyChanges(changes *plan.Changes) error {
+	return errors.New("failed to apply the changes")
+}
+
+// TestRunOnceTraces tests that RunOnce traces every step of the synchronization and marks failed ones.
+func TestRunOnceTraces(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		{DNSName: "create.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+	}, nil).Once()
+	source.On("Endpoints").Return(nil, errors.New("source failed"))
+
+	p := provider.NewInMemoryProvider(provider.InMemoryInitZones([]string{"example.org"}))
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+
+	exporter := &traceRecorder{}
+	ctrl := &Controller{
+		Source:   source,
+		Registry: r,
+		Policy:   &plan.SyncPolicy{},
+		Tracer:   tracing.NewTracer(exporter),
+	}
+
+	require.NoError(t, ctrl.RunOnce())
+	require.Len(t, exporter.traces, 1)
+	names := []string{}
+	for _, span := range exporter.traces[0] {
+		names = append(names, span.Name())
+		assert.NoError(t, span.Err())
+	}
+	assert.Equal(t, []string{"registry.records", "source.endpoints", "plan.calculate", "registry.apply_changes", "sync"}, names)
+	assert.Equal(t, map[string]int64{"create": 1, "update": 0, "delete": 0}, exporter.traces[0][3].Attributes())
+
+	require.Error(t, ctrl.RunOnce())
+	require.Len(t, exporter.traces, 2)
+	spans := exporter.traces[1]
+	require.Len(t, spans, 3)
+	assert.Equal(t, "source.endpoints", spans[1].Name())
+	assert.Error(t, spans[1].Err())
+	assert.Equal(t, "sync", spans[2].Name())
+	assert.Error(t, spans[2].Err())
+}
+
+// traceRecorder keeps the exported traces
+type traceRecorder struct {
+	traces [][]*tracing.Span
+}
+
+func (r *traceRecorder) Export(spans []*tracing.Span) error {
+	r.traces = append(r.traces, spans)
+	return nil
+}
+
+func TestRunFinalSync(t *testing.T) {
+	for _, finalSync := range []bool{false, true} {
+		source := new(testutils.MockSource)
+		source.On("Endpoints").Return([]*endpoint.Endpoint{}, nil)
+
+		p := provider.NewInMemoryProvider(provider.InMemoryInitZones([]string{"example.org"}))
+		r, err := registry.NewNoopRegistry(p)
+		require.NoError(t, err)
+
+		ctrl := &Controller{
+			Source:    source,
+			Registry:  r,
+			Policy:    &plan.SyncPolicy{},
+			Interval:  time.Minute,
+			FinalSync: finalSync,
+		}
+
+		stopChan := make(chan struct{})
+		close(stopChan)
+		ctrl.Run(stopChan)
+
+		if finalSync {
+			source.AssertNumberOfCalls(t, "Endpoints", 2)
+		} else {
+			source.AssertNumberOfCalls(t, "Endpoints", 1)
+		}
+	}
+}
+
+func TestReady(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return(nil, errors.New("source failed")).Once()
+	source.On("Endpoints").Return([]*endpoint.Endpoint{}, nil)
+
+	p := provider.NewInMemoryProvider(provider.InMemoryInitZones([]string{"example.org"}))
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:   source,
+		Registry: r,
+		Policy:   &plan.SyncPolicy{},
+	}
+	assert.Error(t, ctrl.Ready())
+
+	assert.Error(t, ctrl.RunOnce())
+	assert.Error(t, ctrl.Ready())
+
+	assert.NoError(t, ctrl.RunOnce())
+	assert.NoError(t, ctrl.Ready())
+}
+
+func TestAlive(t *testing.T) {
+	ctrl := &Controller{
+		Interval:          time.Minute,
+		IntervalJitter:    time.Minute,
+		LivenessIntervals: 3,
+	}
+	// not running
+	assert.NoError(t, ctrl.Alive())
+
+	ctrl.setLastSync(time.Now().Add(-5 * time.Minute))
+	assert.NoError(t, ctrl.Alive())
+
+	ctrl.setLastSync(time.Now().Add(-7 * time.Minute))
+	assert.Error(t, ctrl.Alive())
+
+	ctrl.LivenessIntervals = 0
+	assert.NoError(t, ctrl.Alive())
+}
+
+func TestNextInterval(t *testing.T) {
+	ctrl := &Controller{Interval: time.Minute}
+	assert.Equal(t, time.Minute, ctrl.nextInterval())
+
+	ctrl.IntervalJitter = 10 * time.Second
+	for i := 0; i < 100; i++ {
+		interval := ctrl.nextInterval()
+		assert.True(t, interval >= time.Minute && interval < time.Minute+10*time.Second, "interval %s out of range", interval)
+	}
+}
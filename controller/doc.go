@@ -0,0 +1,22 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package controller runs the synchronization of the desired endpoints of a source.Source with the records of a
+// registry.Registry.
+//
+// The Controller and its fields are a stable API following semantic versioning, so that other programs can embed
+// the synchronization of ExternalDNS, see docs/embedding.md. New fields keep the previous behavior with their zero value.
+package controller
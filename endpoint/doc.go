@@ -0,0 +1,22 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package endpoint holds the model of the DNS records managed by ExternalDNS. An Endpoint is a DNS name with its
+// record type, targets and TTL, optionally distinguished by a set identifier and restricted to a GeoLocation.
+//
+// The exported types, their fields and their JSON representation are a stable API following semantic versioning,
+// they are shared by all sources, registries and providers and are the wire format of out-of-process providers.
+package endpoint
@@ -25,6 +25,8 @@ import (
 const (
 	// RecordTypeA is a RecordType enum value
 	RecordTypeA = "A"
+	// RecordTypeAAAA is a RecordType enum value
+	RecordTypeAAAA = "AAAA"
 	// RecordTypeCNAME is a RecordType enum value
 	RecordTypeCNAME = "CNAME"
 	// RecordTypeTXT is a RecordType enum value
@@ -105,18 +107,57 @@ func (t Targets) IsLess(o Targets) bool {
 	return false
 }
 
+// ProviderSpecificProperty holds the name and value of a configuration which is specific to individual DNS providers
+type ProviderSpecificProperty struct {
+	Name  string `json:"name,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// ProviderSpecific holds configuration which is specific to individual DNS providers
+type ProviderSpecific []ProviderSpecificProperty
+
+// Get returns the value of the property with the given name and whether it exists
+func (p ProviderSpecific) Get(name string) (string, bool) {
+	for _, property := range p {
+		if property.Name == name {
+			return property.Value, true
+		}
+	}
+	return "", false
+}
+
+// Same returns true if both contain the same properties regardless of their order
+func (p ProviderSpecific) Same(o ProviderSpecific) bool {
+	if len(p) != len(o) {
+		return false
+	}
+	for _, property := range p {
+		if value, ok := o.Get(property.Name); !ok || value != property.Value {
+			return false
+		}
+	}
+	return true
+}
+
 // Endpoint is a high-level way of a connection between a service and an IP
+// The JSON representation is used as wire format by out-of-process providers and must be kept stable.
 type Endpoint struct {
 	// The hostname of the DNS record
-	DNSName string
+	DNSName string `json:"dnsName,omitempty"`
 	// The targets the DNS record points to
-	Targets Targets
+	Targets Targets `json:"targets,omitempty"`
 	// RecordType type of record, e.g. CNAME, A, TXT etc
-	RecordType string
+	RecordType string `json:"recordType,omitempty"`
 	// TTL for the record
-	RecordTTL TTL
+	RecordTTL TTL `json:"recordTTL,omitempty"`
 	// Labels stores labels defined for the Endpoint
-	Labels Labels
+	Labels Labels `json:"labels,omitempty"`
+	// SetIdentifier distinguishes endpoints sharing DNS name and record type, e.g. per geo location
+	SetIdentifier string `json:"setIdentifier,omitempty"`
+	// GeoLocation restricts the endpoint to queries from a location, nil means no restriction
+	GeoLocation *GeoLocation `json:"geoLocation,omitempty"`
+	// ProviderSpecific stores provider specific config, the names of its properties are prefixed with the provider, e.g. aws/failover
+	ProviderSpecific ProviderSpecific `json:"providerSpecific,omitempty"`
 }
 
 // NewEndpoint initialization method to be used to create an endpoint
@@ -135,6 +176,96 @@ func NewEndpointWithTTL(dnsName, target, recordType string, ttl TTL) *Endpoint {
 	}
 }
 
+// DeepCopy returns a copy of the endpoint which shares no data with it
+func (e *Endpoint) DeepCopy() *Endpoint {
+	c := *e
+	if e.Targets != nil {
+		c.Targets = append(Targets{}, e.Targets...)
+	}
+	if e.Labels != nil {
+		c.Labels = make(Labels, len(e.Labels))
+		for k, v := range e.Labels {
+			c.Labels[k] = v
+		}
+	}
+	if e.GeoLocation != nil {
+		geo := *e.GeoLocation
+		c.GeoLocation = &geo
+	}
+	if e.ProviderSpecific != nil {
+		c.ProviderSpecific = append(ProviderSpecific{}, e.ProviderSpecific...)
+	}
+	return &c
+}
+
+// WithSetIdentifier sets the set identifier of the endpoint
+func (e *Endpoint) WithSetIdentifier(setIdentifier string) *Endpoint {
+	e.SetIdentifier = setIdentifier
+	return e
+}
+
+// WithGeoLocation sets the geo location of the endpoint
+func (e *Endpoint) WithGeoLocation(geo *GeoLocation) *Endpoint {
+	e.GeoLocation = geo
+	return e
+}
+
+// WithProviderSpecific sets a provider specific property of the endpoint, replacing an existing one of the same name
+func (e *Endpoint) WithProviderSpecific(name, value string) *Endpoint {
+	for i := range e.ProviderSpecific {
+		if e.ProviderSpecific[i].Name == name {
+			e.ProviderSpecific[i].Value = value
+			return e
+		}
+	}
+	e.ProviderSpecific = append(e.ProviderSpecific, ProviderSpecificProperty{Name: name, Value: value})
+	return e
+}
+
+// GetProviderSpecificProperty returns the value of the provider specific property with the given name and whether it exists
+func (e *Endpoint) GetProviderSpecificProperty(name string) (string, bool) {
+	return e.ProviderSpecific.Get(name)
+}
+
+// LogFields returns the fields identifying the endpoint in structured logs, e.g. with logrus.WithFields
+func (e *Endpoint) LogFields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"dnsName":    e.DNSName,
+		"recordType": e.RecordType,
+	}
+	if e.SetIdentifier != "" {
+		fields["setIdentifier"] = e.SetIdentifier
+	}
+	if owner := e.Labels[OwnerLabelKey]; owner != "" {
+		fields["owner"] = owner
+	}
+	if resource := e.Labels[ResourceLabelKey]; resource != "" {
+		fields["resource"] = resource
+	}
+	if e.GeoLocation != nil {
+		if e.GeoLocation.ContinentCode != "" {
+			fields["continentCode"] = e.GeoLocation.ContinentCode
+		}
+		if e.GeoLocation.CountryCode != "" {
+			fields["countryCode"] = e.GeoLocation.CountryCode
+		}
+		if e.GeoLocation.SubdivisionCode != "" {
+			fields["subdivisionCode"] = e.GeoLocation.SubdivisionCode
+		}
+	}
+	return fields
+}
+
 func (e *Endpoint) String() string {
-	return fmt.Sprintf("%s %d IN %s %s", e.DNSName, e.RecordTTL, e.RecordType, e.Targets)
+	s := fmt.Sprintf("%s %d IN %s %s", e.DNSName, e.RecordTTL, e.RecordType, e.Targets)
+	if e.SetIdentifier != "" {
+		s += fmt.Sprintf(" [id:%s]", e.SetIdentifier)
+	}
+	if e.GeoLocation != nil {
+		s += fmt.Sprintf(" [%s]", e.GeoLocation)
+	}
+	for _, property := range e.ProviderSpecific {
+		s += fmt.Sprintf(" [%s=%s]", property.Name, property.Value)
+	}
+	return s
 }
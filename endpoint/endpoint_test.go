@@ -17,6 +17,8 @@ limitations under the License.
 package endpoint
 
 import (
+	"encoding/json"
+	"reflect"
 	"testing"
 )
 
@@ -34,3 +36,106 @@ func TestNewEndpoint(t *testing.T) {
 		t.Error("endpoint is not initialized correctly")
 	}
 }
+
+func TestEndpointJSON(t *testing.T) {
+	e := &Endpoint{
+		DNSName:    "example.org",
+		Targets:    Targets{"1.2.3.4", "5.6.7.8"},
+		RecordType: RecordTypeA,
+		RecordTTL:  300,
+		Labels:     Labels{OwnerLabelKey: "owner"},
+	}
+	e.WithSetIdentifier("eu").WithGeoLocation(&GeoLocation{ContinentCode: "EU"}).WithProviderSpecific("aws/failover", "PRIMARY")
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"dnsName":"example.org","targets":["1.2.3.4","5.6.7.8"],"recordType":"A","recordTTL":300,"labels":{"owner":"owner"},"setIdentifier":"eu","geoLocation":{"continentCode":"EU"},"providerSpecific":[{"name":"aws/failover","value":"PRIMARY"}]}`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+
+	decoded := &Endpoint{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(e, decoded) {
+		t.Errorf("expected %v, got %v", e, decoded)
+	}
+}
+
+func TestProviderSpecific(t *testing.T) {
+	e := NewEndpoint("example.org", "1.2.3.4", RecordTypeA)
+	if _, ok := e.GetProviderSpecificProperty("aws/failover"); ok {
+		t.Error("expected no property on a new endpoint")
+	}
+
+	e.WithProviderSpecific("aws/failover", "PRIMARY").WithProviderSpecific("aws/health-check-type", "HTTP")
+	e.WithProviderSpecific("aws/failover", "SECONDARY")
+	if value, ok := e.GetProviderSpecificProperty("aws/failover"); !ok || value != "SECONDARY" {
+		t.Errorf("expected replaced property SECONDARY, got %q", value)
+	}
+	if len(e.ProviderSpecific) != 2 {
+		t.Errorf("expected 2 properties, got %d", len(e.ProviderSpecific))
+	}
+
+	reordered := ProviderSpecific{
+		{Name: "aws/health-check-type", Value: "HTTP"},
+		{Name: "aws/failover", Value: "SECONDARY"},
+	}
+	if !e.ProviderSpecific.Same(reordered) {
+		t.Error("expected properties in different order to be the same")
+	}
+	if e.ProviderSpecific.Same(reordered[:1]) || e.ProviderSpecific.Same(nil) {
+		t.Error("expected properties to differ")
+	}
+	if !ProviderSpecific(nil).Same(ProviderSpecific{}) {
+		t.Error("expected nil and empty properties to be the same")
+	}
+}
+
+func TestLogFields(t *testing.T) {
+	e := NewEndpoint("example.org", "1.2.3.4", RecordTypeA)
+	expected := map[string]interface{}{"dnsName": "example.org", "recordType": "A"}
+	if fields := e.LogFields(); !reflect.DeepEqual(fields, expected) {
+		t.Errorf("expected %v, got %v", expected, fields)
+	}
+
+	e.WithSetIdentifier("eu").WithGeoLocation(&GeoLocation{ContinentCode: "EU", CountryCode: "DE"})
+	e.Labels[OwnerLabelKey] = "default"
+	e.Labels[ResourceLabelKey] = "service/default/foo"
+	expected = map[string]interface{}{
+		"dnsName":       "example.org",
+		"recordType":    "A",
+		"setIdentifier": "eu",
+		"owner":         "default",
+		"resource":      "service/default/foo",
+		"continentCode": "EU",
+		"countryCode":   "DE",
+	}
+	if fields := e.LogFields(); !reflect.DeepEqual(fields, expected) {
+		t.Errorf("expected %v, got %v", expected, fields)
+	}
+}
+
+func TestDeepCopy(t *testing.T) {
+	e := NewEndpoint("example.org", "1.2.3.4", RecordTypeA).
+		WithSetIdentifier("eu").
+		WithGeoLocation(&GeoLocation{ContinentCode: "EU"}).
+		WithProviderSpecific("aws/evaluate-target-health", "true")
+	e.Labels[OwnerLabelKey] = "default"
+
+	c := e.DeepCopy()
+	if !reflect.DeepEqual(c, e) {
+		t.Fatalf("expected %v, got %v", e, c)
+	}
+
+	c.Targets[0] = "4.3.2.1"
+	c.Labels[OwnerLabelKey] = "other"
+	c.GeoLocation.ContinentCode = "NA"
+	c.ProviderSpecific[0].Value = "false"
+	if e.Targets[0] != "1.2.3.4" || e.Labels[OwnerLabelKey] != "default" || e.GeoLocation.ContinentCode != "EU" || e.ProviderSpecific[0].Value != "true" {
+		t.Errorf("expected the copy to share no data, got %v", e)
+	}
+}
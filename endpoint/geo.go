@@ -0,0 +1,118 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// GeoDefaultCountryCode is the country code of the record answering queries from locations
+	// that aren't matched by any other record of the same name
+	GeoDefaultCountryCode = "*"
+)
+
+var (
+	// geoContinentCodes are the continent codes accepted by geo routing DNS providers
+	geoContinentCodes = map[string]bool{"AF": true, "AN": true, "AS": true, "EU": true, "NA": true, "OC": true, "SA": true}
+	// geoCountryCodeRegex matches ISO 3166-1 alpha-2 country codes
+	geoCountryCodeRegex = regexp.MustCompile(`^[A-Z]{2}$`)
+	// geoSubdivisionCodeRegex matches the subdivision part of ISO 3166-2 codes, e.g. "CA" of "US-CA"
+	geoSubdivisionCodeRegex = regexp.MustCompile(`^[A-Z0-9]{1,3}$`)
+)
+
+// GeoLocation describes from which locations DNS queries are answered with an endpoint.
+// Either the continent or the country, optionally narrowed down to a subdivision, is set.
+// Use the setters to populate it, they validate and normalize the codes.
+type GeoLocation struct {
+	// ContinentCode is a two letter continent code, e.g. EU
+	ContinentCode string `json:"continentCode,omitempty"`
+	// CountryCode is an ISO 3166-1 alpha-2 country code, e.g. DE, or "*" for the default location
+	CountryCode string `json:"countryCode,omitempty"`
+	// SubdivisionCode is the subdivision of the country, e.g. CA for California in the US
+	SubdivisionCode string `json:"subdivisionCode,omitempty"`
+}
+
+// SetContinentCode validates and sets the continent code
+func (g *GeoLocation) SetContinentCode(code string) error {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !geoContinentCodes[code] {
+		return fmt.Errorf("invalid geo continent code %q", code)
+	}
+	if g.CountryCode != "" {
+		return fmt.Errorf("geo continent code %q can't be combined with country code %q", code, g.CountryCode)
+	}
+	g.ContinentCode = code
+	return nil
+}
+
+// SetCountryCode validates and sets the country code
+func (g *GeoLocation) SetCountryCode(code string) error {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code != GeoDefaultCountryCode && !geoCountryCodeRegex.MatchString(code) {
+		return fmt.Errorf("invalid geo country code %q", code)
+	}
+	if g.ContinentCode != "" {
+		return fmt.Errorf("geo country code %q can't be combined with continent code %q", code, g.ContinentCode)
+	}
+	g.CountryCode = code
+	return nil
+}
+
+// SetSubdivisionCode validates and sets the subdivision code, the country code has to be set before
+func (g *GeoLocation) SetSubdivisionCode(code string) error {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if g.CountryCode == "" || g.CountryCode == GeoDefaultCountryCode {
+		return fmt.Errorf("geo subdivision code %q requires a country code", code)
+	}
+	// accept the full ISO 3166-2 code as well, e.g. US-CA
+	code = strings.TrimPrefix(code, g.CountryCode+"-")
+	if !geoSubdivisionCodeRegex.MatchString(code) {
+		return fmt.Errorf("invalid geo subdivision code %q", code)
+	}
+	g.SubdivisionCode = code
+	return nil
+}
+
+// IsDefault returns true if the location matches all queries not matched by a more specific location
+func (g *GeoLocation) IsDefault() bool {
+	return g != nil && g.CountryCode == GeoDefaultCountryCode
+}
+
+// Same returns true if both locations are identical, nil locations are only the same as other nil locations
+func (g *GeoLocation) Same(o *GeoLocation) bool {
+	if g == nil || o == nil {
+		return g == o
+	}
+	return *g == *o
+}
+
+func (g *GeoLocation) String() string {
+	if g == nil {
+		return ""
+	}
+	switch {
+	case g.ContinentCode != "":
+		return "continent:" + g.ContinentCode
+	case g.SubdivisionCode != "":
+		return "country:" + g.CountryCode + "-" + g.SubdivisionCode
+	default:
+		return "country:" + g.CountryCode
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"testing"
+)
+
+func TestGeoLocationSetters(t *testing.T) {
+	for _, tc := range []struct {
+		title       string
+		continent   string
+		country     string
+		subdivision string
+		expected    *GeoLocation
+		expectError bool
+	}{
+		{title: "continent", continent: "eu", expected: &GeoLocation{ContinentCode: "EU"}},
+		{title: "unknown continent", continent: "XX", expectError: true},
+		{title: "country", country: "de", expected: &GeoLocation{CountryCode: "DE"}},
+		{title: "default country", country: "*", expected: &GeoLocation{CountryCode: "*"}},
+		{title: "invalid country", country: "DEU", expectError: true},
+		{title: "continent and country", continent: "EU", country: "DE", expectError: true},
+		{title: "subdivision", country: "US", subdivision: "ca", expected: &GeoLocation{CountryCode: "US", SubdivisionCode: "CA"}},
+		{title: "full subdivision", country: "US", subdivision: "US-CA", expected: &GeoLocation{CountryCode: "US", SubdivisionCode: "CA"}},
+		{title: "subdivision without country", subdivision: "CA", expectError: true},
+		{title: "subdivision of default country", country: "*", subdivision: "CA", expectError: true},
+		{title: "invalid subdivision", country: "US", subdivision: "CALI", expectError: true},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			geo := &GeoLocation{}
+			var errs []error
+			if tc.continent != "" {
+				errs = append(errs, geo.SetContinentCode(tc.continent))
+			}
+			if tc.country != "" {
+				errs = append(errs, geo.SetCountryCode(tc.country))
+			}
+			if tc.subdivision != "" {
+				errs = append(errs, geo.SetSubdivisionCode(tc.subdivision))
+			}
+
+			failed := false
+			for _, err := range errs {
+				if err != nil {
+					failed = true
+				}
+			}
+			if failed != tc.expectError {
+				t.Fatalf("expected error: %v, got %v", tc.expectError, errs)
+			}
+			if !tc.expectError && *geo != *tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, geo)
+			}
+		})
+	}
+}
+
+func TestGeoLocationSame(t *testing.T) {
+	var none *GeoLocation
+	eu := &GeoLocation{ContinentCode: "EU"}
+
+	if !none.Same(nil) {
+		t.Error("nil locations should be the same")
+	}
+	if none.Same(eu) || eu.Same(nil) {
+		t.Error("nil and non-nil locations should differ")
+	}
+	if !eu.Same(&GeoLocation{ContinentCode: "EU"}) {
+		t.Error("equal locations should be the same")
+	}
+	if eu.Same(&GeoLocation{CountryCode: "DE"}) {
+		t.Error("different locations should differ")
+	}
+}
@@ -34,6 +34,21 @@ const (
 	OwnerLabelKey = "owner"
 	// ResourceLabelKey is the name of the label that identifies k8s resource which wants to acquire the DNS name
 	ResourceLabelKey = "resource"
+	// ResourceCreatedLabelKey is the name of the label that carries the creation time of the k8s resource in RFC 3339 format,
+	// it's only used to resolve conflicts and isn't stored by the registry
+	ResourceCreatedLabelKey = "resource-created"
+	// PriorityLabelKey is the name of the label that carries the priority of the k8s resource from its annotation,
+	// it's only used to resolve conflicts and isn't stored by the registry
+	PriorityLabelKey = "priority"
+	// HealthCheckLabelKey is the name of the label that carries the health check of the k8s resource from its annotation,
+	// it's only used to probe the targets and isn't stored by the registry
+	HealthCheckLabelKey = "health-check"
+	// ProviderLabelKey is the name of the label that carries the DNS provider of the record chosen by the annotation of the
+	// k8s resource, it's stored by the registry so that later changes of the record go to the same provider
+	ProviderLabelKey = "provider"
+	// AWSSDDescriptionLabel is the name of the label that carries the description of an AWS Cloud Map service,
+	// which stores the serialized labels of its records
+	AWSSDDescriptionLabel = "aws-sd-description"
 )
 
 // Labels store metadata related to the endpoint
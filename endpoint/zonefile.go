@@ -0,0 +1,271 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The attributes of endpoints written to the comments of zone file lines, as they have no representation in a zone file
+const (
+	zoneFileSetIdentifier   = "set-identifier"
+	zoneFileContinentCode   = "continent-code"
+	zoneFileCountryCode     = "country-code"
+	zoneFileSubdivisionCode = "subdivision-code"
+	zoneFileLabelPrefix     = "label:"
+	zoneFilePropertyPrefix  = "property:"
+)
+
+// WriteZoneFile writes the endpoints as lines of an RFC 1035 zone file, one line per target, e.g.
+// "foo.example.org. 300 IN A 1.2.3.4 ; set-identifier=eu continent-code=EU label:owner=default".
+// The set identifier, geo location, labels and provider specific properties are written to the comment of the line,
+// so that ReadZoneFile restores the endpoints completely. A TTL of 0 means the TTL isn't configured.
+func WriteZoneFile(w io.Writer, endpoints []*Endpoint) error {
+	for _, ep := range endpoints {
+		attributes, err := zoneFileAttributes(ep)
+		if err != nil {
+			return err
+		}
+		for _, target := range ep.Targets {
+			switch {
+			case ep.RecordType == RecordTypeTXT:
+				target = strconv.Quote(target)
+			case strings.ContainsAny(target, ";\"\r\n"):
+				return fmt.Errorf("%s %s: target %q can't be written to a zone file", ep.DNSName, ep.RecordType, target)
+			case ep.RecordType == RecordTypeCNAME && !strings.HasSuffix(target, "."):
+				target += "."
+			}
+			line := fmt.Sprintf("%s. %d IN %s %s", ep.DNSName, ep.RecordTTL, ep.RecordType, target)
+			if len(attributes) > 0 {
+				line += " ; " + strings.Join(attributes, " ")
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// zoneFileAttributes returns the attributes of the endpoint written to the comment of its lines
+func zoneFileAttributes(ep *Endpoint) ([]string, error) {
+	attributes := []string{}
+	add := func(name, value string) error {
+		if value == "" {
+			return nil
+		}
+		if strings.ContainsAny(name, " \t\r\n;=") || strings.ContainsAny(value, " \t\r\n;") {
+			return fmt.Errorf("%s %s: %s=%q can't be written to a zone file", ep.DNSName, ep.RecordType, name, value)
+		}
+		attributes = append(attributes, name+"="+value)
+		return nil
+	}
+
+	if err := add(zoneFileSetIdentifier, ep.SetIdentifier); err != nil {
+		return nil, err
+	}
+	if ep.GeoLocation != nil {
+		if err := add(zoneFileContinentCode, ep.GeoLocation.ContinentCode); err != nil {
+			return nil, err
+		}
+		if err := add(zoneFileCountryCode, ep.GeoLocation.CountryCode); err != nil {
+			return nil, err
+		}
+		if err := add(zoneFileSubdivisionCode, ep.GeoLocation.SubdivisionCode); err != nil {
+			return nil, err
+		}
+	}
+	keys := []string{}
+	for key := range ep.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := add(zoneFileLabelPrefix+key, ep.Labels[key]); err != nil {
+			return nil, err
+		}
+	}
+	for _, property := range ep.ProviderSpecific {
+		if err := add(zoneFilePropertyPrefix+property.Name, property.Value); err != nil {
+			return nil, err
+		}
+	}
+	return attributes, nil
+}
+
+// zoneFileKey identifies the lines of a zone file belonging to the same endpoint
+type zoneFileKey struct {
+	dnsName       string
+	recordType    string
+	setIdentifier string
+}
+
+// ReadZoneFile reads the endpoints of the lines of a zone file as written by WriteZoneFile. Lines of the same
+// DNS name, record type and set identifier are merged into a single endpoint with multiple targets.
+// The DNS names have to be fully qualified, directives like $ORIGIN aren't supported.
+func ReadZoneFile(r io.Reader) ([]*Endpoint, error) {
+	endpoints := []*Endpoint{}
+	byKey := map[zoneFileKey]*Endpoint{}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		ep, err := parseZoneFileLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if ep == nil {
+			continue
+		}
+		key := zoneFileKey{dnsName: ep.DNSName, recordType: ep.RecordType, setIdentifier: ep.SetIdentifier}
+		if existing, ok := byKey[key]; ok {
+			existing.Targets = append(existing.Targets, ep.Targets...)
+			continue
+		}
+		byKey[key] = ep
+		endpoints = append(endpoints, ep)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+// parseZoneFileLine returns the endpoint of a single line of a zone file, nil for empty lines and comments
+func parseZoneFileLine(line string) (*Endpoint, error) {
+	fields, comment, err := splitZoneFileLine(line)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	if strings.HasPrefix(fields[0], "$") {
+		return nil, fmt.Errorf("directive %s isn't supported", fields[0])
+	}
+	if len(fields) < 5 || fields[2] != "IN" {
+		return nil, fmt.Errorf("expected \"<name> <ttl> IN <type> <data>\", got %q", line)
+	}
+	if !strings.HasSuffix(fields[0], ".") {
+		return nil, fmt.Errorf("DNS name %s isn't fully qualified", fields[0])
+	}
+	ttl, err := strconv.ParseUint(fields[1], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TTL %s", fields[1])
+	}
+
+	ep := &Endpoint{
+		DNSName:    strings.TrimSuffix(fields[0], "."),
+		RecordType: fields[3],
+		RecordTTL:  TTL(ttl),
+		Labels:     NewLabels(),
+	}
+	if ep.RecordType == RecordTypeTXT {
+		// the character strings of a TXT record are concatenated
+		target := ""
+		for _, field := range fields[4:] {
+			s, err := strconv.Unquote(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid TXT data %s", field)
+			}
+			target += s
+		}
+		ep.Targets = Targets{target}
+	} else {
+		ep.Targets = Targets{strings.TrimSuffix(strings.Join(fields[4:], " "), ".")}
+	}
+
+	for _, attribute := range strings.Fields(comment) {
+		parts := strings.SplitN(attribute, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid attribute %q", attribute)
+		}
+		if err := setZoneFileAttribute(ep, parts[0], parts[1]); err != nil {
+			return nil, err
+		}
+	}
+	return ep, nil
+}
+
+// setZoneFileAttribute sets an attribute written by zoneFileAttributes, validating the geo codes
+func setZoneFileAttribute(ep *Endpoint, name, value string) error {
+	geo := func() *GeoLocation {
+		if ep.GeoLocation == nil {
+			ep.GeoLocation = &GeoLocation{}
+		}
+		return ep.GeoLocation
+	}
+	switch {
+	case name == zoneFileSetIdentifier:
+		ep.SetIdentifier = value
+	case name == zoneFileContinentCode:
+		return geo().SetContinentCode(value)
+	case name == zoneFileCountryCode:
+		return geo().SetCountryCode(value)
+	case name == zoneFileSubdivisionCode:
+		return geo().SetSubdivisionCode(value)
+	case strings.HasPrefix(name, zoneFileLabelPrefix):
+		ep.Labels[strings.TrimPrefix(name, zoneFileLabelPrefix)] = value
+	case strings.HasPrefix(name, zoneFilePropertyPrefix):
+		ep.WithProviderSpecific(strings.TrimPrefix(name, zoneFilePropertyPrefix), value)
+	default:
+		return fmt.Errorf("unknown attribute %q", name)
+	}
+	return nil
+}
+
+// splitZoneFileLine splits a line of a zone file into its whitespace separated fields and its comment.
+// Quoted fields keep their quotes and may contain whitespace and semicolons.
+func splitZoneFileLine(line string) ([]string, string, error) {
+	fields := []string{}
+	field := []rune{}
+	quoted, escaped := false, false
+	for i, c := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == ';':
+			if len(field) > 0 {
+				fields = append(fields, string(field))
+			}
+			return fields, line[i+1:], nil
+		case c == ' ' || c == '\t' || c == '\r':
+			if len(field) > 0 {
+				fields = append(fields, string(field))
+				field = field[:0]
+			}
+			continue
+		}
+		field = append(field, c)
+	}
+	if quoted {
+		return nil, "", fmt.Errorf("unterminated quote in %q", line)
+	}
+	if len(field) > 0 {
+		fields = append(fields, string(field))
+	}
+	return fields, "", nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteZoneFile(t *testing.T) {
+	geo := &GeoLocation{}
+	if err := geo.SetCountryCode("US"); err != nil {
+		t.Fatal(err)
+	}
+	if err := geo.SetSubdivisionCode("CA"); err != nil {
+		t.Fatal(err)
+	}
+	a := NewEndpointWithTTL("api.example.org", "1.2.3.4", RecordTypeA, 300).
+		WithSetIdentifier("us-ca").
+		WithGeoLocation(geo).
+		WithProviderSpecific("aws/evaluate-target-health", "true")
+	a.Targets = append(a.Targets, "1.2.3.5")
+	a.Labels[OwnerLabelKey] = "default"
+	a.Labels[ResourceLabelKey] = "service/default/api"
+	cname := NewEndpoint("www.example.org", "lb.example.com", RecordTypeCNAME)
+	txt := NewEndpoint("example.org", "v=spf1 include:example.com; ~all", RecordTypeTXT)
+
+	buf := &bytes.Buffer{}
+	if err := WriteZoneFile(buf, []*Endpoint{a, cname, txt}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `api.example.org. 300 IN A 1.2.3.4 ; set-identifier=us-ca country-code=US subdivision-code=CA label:owner=default label:resource=service/default/api property:aws/evaluate-target-health=true
+api.example.org. 300 IN A 1.2.3.5 ; set-identifier=us-ca country-code=US subdivision-code=CA label:owner=default label:resource=service/default/api property:aws/evaluate-target-health=true
+www.example.org. 0 IN CNAME lb.example.com.
+example.org. 0 IN TXT "v=spf1 include:example.com; ~all"
+`
+	if buf.String() != expected {
+		t.Errorf("expected %s, got %s", expected, buf.String())
+	}
+
+	endpoints, err := ReadZoneFile(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(endpoints, []*Endpoint{a, cname, txt}) {
+		t.Errorf("expected the endpoints to be restored, got %v", endpoints)
+	}
+}
+
+func TestWriteZoneFileRejectsWhitespace(t *testing.T) {
+	ep := NewEndpoint("example.org", "1.2.3.4", RecordTypeA)
+	ep.Labels[ResourceLabelKey] = "service/default/with space"
+	if err := WriteZoneFile(&bytes.Buffer{}, []*Endpoint{ep}); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestReadZoneFile(t *testing.T) {
+	endpoints, err := ReadZoneFile(strings.NewReader(`; exported records
+
+foo.example.org.	60	IN	A	1.2.3.4
+foo.example.org. 60 IN A 1.2.3.5
+foo.example.org. 60 IN A 5.6.7.8 ; set-identifier=eu continent-code=eu
+example.org. 0 IN TXT "part one, " "part two"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*Endpoint{
+		{DNSName: "foo.example.org", Targets: Targets{"1.2.3.4", "1.2.3.5"}, RecordType: RecordTypeA, RecordTTL: 60, Labels: Labels{}},
+		{DNSName: "foo.example.org", Targets: Targets{"5.6.7.8"}, RecordType: RecordTypeA, RecordTTL: 60, Labels: Labels{}, SetIdentifier: "eu", GeoLocation: &GeoLocation{ContinentCode: "EU"}},
+		{DNSName: "example.org", Targets: Targets{"part one, part two"}, RecordType: RecordTypeTXT, Labels: Labels{}},
+	}
+	if !reflect.DeepEqual(endpoints, expected) {
+		t.Errorf("expected %v, got %v", expected, endpoints)
+	}
+}
+
+func TestReadZoneFileErrors(t *testing.T) {
+	for _, line := range []string{
+		"$ORIGIN example.org.",
+		"foo.example.org 60 IN A 1.2.3.4",
+		"foo.example.org. 60 A 1.2.3.4",
+		"foo.example.org. -1 IN A 1.2.3.4",
+		"foo.example.org. 60 IN TXT unquoted",
+		"foo.example.org. 60 IN TXT \"unterminated",
+		"foo.example.org. 60 IN A 1.2.3.4 ; unknown=value",
+		"foo.example.org. 60 IN A 1.2.3.4 ; continent-code=XX",
+		"foo.example.org. 60 IN A 1.2.3.4 ; subdivision-code=CA",
+	} {
+		if _, err := ReadZoneFile(strings.NewReader(line)); err == nil {
+			t.Errorf("expected an error for %q", line)
+		}
+	}
+}
@@ -35,6 +35,9 @@ func (b byAllFields) Less(i, j int) bool {
 	if b[i].DNSName == b[j].DNSName {
 		// This rather bad, we need a more complex comparison for Targets, which considers all elements
 		if b[i].Targets.Same(b[j].Targets) {
+			if b[i].RecordType == b[j].RecordType {
+				return b[i].SetIdentifier <= b[j].SetIdentifier
+			}
 			return b[i].RecordType <= b[j].RecordType
 		}
 		return b[i].Targets.String() <= b[j].Targets.String()
@@ -48,7 +51,9 @@ func (b byAllFields) Less(i, j int) bool {
 func SameEndpoint(a, b *endpoint.Endpoint) bool {
 	return a.DNSName == b.DNSName && a.Targets.Same(b.Targets) && a.RecordType == b.RecordType &&
 		a.Labels[endpoint.OwnerLabelKey] == b.Labels[endpoint.OwnerLabelKey] && a.RecordTTL == b.RecordTTL &&
-		a.Labels[endpoint.ResourceLabelKey] == b.Labels[endpoint.ResourceLabelKey]
+		a.Labels[endpoint.ResourceLabelKey] == b.Labels[endpoint.ResourceLabelKey] &&
+		a.SetIdentifier == b.SetIdentifier && a.GeoLocation.Same(b.GeoLocation) &&
+		a.ProviderSpecific.Same(b.ProviderSpecific)
 }
 
 // SameEndpoints compares two slices of endpoints regardless of order
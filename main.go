@@ -17,19 +17,44 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
 	"net/http"
+	"net/http/pprof"
 	"os"
 	"os/signal"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/kubernetes-incubator/external-dns/controller"
+	"github.com/kubernetes-incubator/external-dns/endpoint"
 	"github.com/kubernetes-incubator/external-dns/pkg/apis/externaldns"
 	"github.com/kubernetes-incubator/external-dns/pkg/apis/externaldns/validation"
+	"github.com/kubernetes-incubator/external-dns/pkg/tracing"
 	"github.com/kubernetes-incubator/external-dns/plan"
 	"github.com/kubernetes-incubator/external-dns/provider"
 	"github.com/kubernetes-incubator/external-dns/registry"
@@ -37,6 +62,11 @@ import (
 )
 
 func main() {
+	// validate the annotations of manifests instead of running, e.g. in CI
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(validateManifests(os.Args[2:], os.Stdin, os.Stderr))
+	}
+
 	cfg := externaldns.NewConfig()
 	if err := cfg.ParseFlags(os.Args[1:]); err != nil {
 		log.Fatalf("flag parsing error: %v", err)
@@ -62,9 +92,450 @@ func main() {
 
 	stopChan := make(chan struct{}, 1)
 
-	go serveMetrics(cfg.MetricsAddress)
+	health := &healthChecks{}
+	go serveMetrics(cfg.MetricsAddress, health, cfg.EnablePprof)
 	go handleSigterm(stopChan)
 
+	clientGenerator := &source.SingletonClientGenerator{
+		KubeConfig: cfg.KubeConfig,
+		KubeMaster: cfg.Master,
+	}
+
+	var eventRecorder *source.EventRecorder
+	if cfg.Events {
+		client, err := clientGenerator.KubeClient()
+		if err != nil {
+			log.Fatal(err)
+		}
+		eventRecorder, err = source.NewEventRecorder(client, stopChan)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// exporting and importing records doesn't need the sources, e.g. to restore the records of a lost cluster
+	var endpointsSource source.Source
+	if cfg.ExportRecords == "" && cfg.ImportRecords == "" {
+		endpointsSource, err = newSource(clientGenerator, cfg, eventRecorder)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	domainFilter := newDomainFilter(cfg)
+
+	p, err := newProvider(cfg.Provider, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(cfg.AdditionalProviders) > 0 {
+		// every record goes to the provider whose domain filter matches it most specifically
+		routes := []provider.ProviderRoute{{Name: cfg.Provider, Provider: p, DomainFilter: newProviderDomainFilter(cfg, cfg.Provider)}}
+		for _, name := range cfg.AdditionalProviders {
+			additional, err := newProvider(name, cfg)
+			if err != nil {
+				log.Fatal(err)
+			}
+			routes = append(routes, provider.ProviderRoute{Name: name, Provider: additional, DomainFilter: newProviderDomainFilter(cfg, name)})
+		}
+		p = provider.NewMultiProvider(routes)
+	}
+	// the events are emitted for the changes applied to the provider, i.e. after the registry filtered them by owner
+	if eventRecorder != nil {
+		p = provider.NewRecordingProvider(p, eventRecorder)
+	}
+
+	r, err := newRegistry(cfg.Registry, p, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if cfg.MigrateRegistryFrom != "" {
+		from, err := newRegistry(cfg.MigrateRegistryFrom, p, cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := registry.MigrateOwnership(from, r, cfg.TXTOwnerID, cfg.DryRun); err != nil {
+			log.Fatalf("registry migration failed: %v", err)
+		}
+
+		os.Exit(0)
+	}
+
+	if cfg.ExportRecords != "" {
+		if err := exportRecords(r, cfg); err != nil {
+			log.Fatalf("export failed: %v", err)
+		}
+
+		os.Exit(0)
+	}
+
+	policy, exists := plan.Policies[cfg.Policy]
+	if !exists {
+		log.Fatalf("unknown policy: %s", cfg.Policy)
+	}
+
+	resolver, exists := plan.ConflictResolvers[cfg.ConflictResolution]
+	if !exists {
+		log.Fatalf("unknown conflict resolution: %s", cfg.ConflictResolution)
+	}
+
+	ctrl := controller.Controller{
+		Source:           endpointsSource,
+		Registry:         r,
+		Policy:           policy,
+		ConflictResolver: resolver,
+		PreferCNAME:      cfg.CNAMEConflictPreference == "cname",
+		// e.g. the ids of the health checks created by the provider aren't desired
+		ProviderManagedProperties: provider.ProviderManagedProperties,
+		// the records of every provider are planned separately, they're labeled with their provider
+		PerProvider: len(cfg.AdditionalProviders) > 0,
+		// e.g. the endpoints of clusters in different regions become a geo record set on AWS
+		MergeGeoLocations: provider.SupportsGeoLocations(p),
+		// the zones are filtered by the provider, the records of the registry and the source by the controller
+		DomainFilter:       domainFilter,
+		ManagedRecordTypes: cfg.ManagedRecordTypes,
+		Interval:           cfg.Interval,
+		IntervalJitter:     cfg.IntervalJitter,
+		// large diffs are applied in chunks over multiple synchronizations
+		MaxChangesPerSync: cfg.MaxChangesPerSync,
+		LivenessIntervals: cfg.LivenessIntervals,
+		FinalSync:         cfg.FinalSync,
+		// in dry-run mode the planned changes are printed to stdout, the logs go to stderr
+		DryRun:     cfg.DryRun,
+		DiffFormat: cfg.DryRunFormat,
+	}
+	if adjuster, ok := p.(provider.EndpointsAdjuster); ok {
+		ctrl.EndpointsAdjuster = adjuster
+	}
+	if cfg.TracingEndpoint != "" {
+		exporter, err := tracing.NewOTLPExporter(cfg.TracingEndpoint, "external-dns", externaldns.Version)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ctrl.Tracer = tracing.NewTracer(exporter)
+	}
+
+	// seed the jitter of the interval differently for every instance
+	rand.Seed(time.Now().UnixNano())
+
+	if cfg.ImportRecords != "" {
+		records, err := readRecords(cfg.ImportRecords, cfg.RecordsFormat)
+		if err != nil {
+			log.Fatalf("import failed: %v", err)
+		}
+		// the records of the file are created or updated in a single synchronization, other records are kept
+		ctrl.Reconfigure(source.NewEndpointsSource(records...), &plan.UpsertOnlyPolicy{}, domainFilter)
+		ctrl.MaxChangesPerSync = 0
+		err = ctrl.RunOnce()
+		if err != nil {
+			log.Fatalf("import failed: %v", err)
+		}
+		log.Infof("Imported %d records from %s", len(records), cfg.ImportRecords)
+
+		os.Exit(0)
+	}
+
+	if cfg.Once {
+		err := ctrl.RunOnce()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		os.Exit(0)
+	}
+
+	if cfg.ConfigFile != "" {
+		go watchConfigFile(cfg, &ctrl, clientGenerator, eventRecorder, stopChan)
+	}
+
+	if cfg.LeaderElection {
+		client, err := clientGenerator.KubeClient()
+		if err != nil {
+			log.Fatal(err)
+		}
+		health.setController(&ctrl, true)
+		runUntilStopped(func(stopChan <-chan struct{}) {
+			runWithLeaderElection(client, cfg, func(stopChan <-chan struct{}) {
+				health.setController(&ctrl, false)
+				ctrl.Run(stopChan)
+			}, stopChan)
+		}, stopChan, cfg.ShutdownTimeout)
+		return
+	}
+
+	health.setController(&ctrl, false)
+	runUntilStopped(ctrl.Run, stopChan, cfg.ShutdownTimeout)
+}
+
+// exportRecords writes the records owned by --txt-owner-id to the file given by --export-records, - for stdout
+func exportRecords(r registry.Registry, cfg *externaldns.Config) error {
+	records, err := registry.OwnedRecords(r, cfg.TXTOwnerID)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].DNSName != records[j].DNSName {
+			return records[i].DNSName < records[j].DNSName
+		}
+		if records[i].RecordType != records[j].RecordType {
+			return records[i].RecordType < records[j].RecordType
+		}
+		return records[i].SetIdentifier < records[j].SetIdentifier
+	})
+
+	if cfg.ExportRecords == "-" {
+		return writeRecords(os.Stdout, records, cfg.RecordsFormat)
+	}
+	f, err := os.Create(cfg.ExportRecords)
+	if err != nil {
+		return err
+	}
+	if err := writeRecords(f, records, cfg.RecordsFormat); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	log.Infof("Exported %d records to %s", len(records), cfg.ExportRecords)
+	return nil
+}
+
+// writeRecords writes the records as zone file or JSON
+func writeRecords(w io.Writer, records []*endpoint.Endpoint, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	}
+	return endpoint.WriteZoneFile(w, records)
+}
+
+// readRecords reads the records of a zone file or JSON file, - for stdin
+func readRecords(path, format string) ([]*endpoint.Endpoint, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	if format != "json" {
+		return endpoint.ReadZoneFile(r)
+	}
+
+	records := []*endpoint.Endpoint{}
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	for _, record := range records {
+		// the registry labels the records it creates
+		if record.Labels == nil {
+			record.Labels = endpoint.NewLabels()
+		}
+	}
+	return records, nil
+}
+
+// configFileCheckInterval is the interval between checks of the config file for changes
+const configFileCheckInterval = 10 * time.Second
+
+// watchConfigFile reconfigures the controller when the content of the config file changes until stopChan is closed.
+// The content is compared instead of the modification time, as a mounted ConfigMap is updated by swapping a symlink.
+func watchConfigFile(cfg *externaldns.Config, ctrl *controller.Controller, clientGenerator source.ClientGenerator, eventRecorder *source.EventRecorder, stopChan <-chan struct{}) {
+	content, err := ioutil.ReadFile(cfg.ConfigFile)
+	if err != nil {
+		log.Errorf("failed to read the config file: %v", err)
+	}
+	for {
+		select {
+		case <-time.After(configFileCheckInterval):
+		case <-stopChan:
+			return
+		}
+		changed, err := ioutil.ReadFile(cfg.ConfigFile)
+		if err != nil {
+			log.Errorf("failed to read the config file: %v", err)
+			continue
+		}
+		if bytes.Equal(changed, content) {
+			continue
+		}
+		content = changed
+		if err := reloadConfig(cfg, ctrl, clientGenerator, eventRecorder); err != nil {
+			log.Errorf("failed to reload the config file %s, keeping the current config: %v", cfg.ConfigFile, err)
+		}
+	}
+}
+
+// reloadConfig parses the flags again and passes the changed domain filters, annotation filter and policy to the
+// controller. Changes of other flags are only applied by a restart.
+func reloadConfig(cfg *externaldns.Config, ctrl *controller.Controller, clientGenerator source.ClientGenerator, eventRecorder *source.EventRecorder) error {
+	newCfg := externaldns.NewConfig()
+	if err := newCfg.ParseFlags(os.Args[1:]); err != nil {
+		return err
+	}
+	if err := validation.ValidateConfig(newCfg); err != nil {
+		return err
+	}
+
+	reloaded := *cfg
+	reloaded.DomainFilter = newCfg.DomainFilter
+	reloaded.ExcludeDomains = newCfg.ExcludeDomains
+	reloaded.RegexDomainFilter = newCfg.RegexDomainFilter
+	reloaded.RegexDomainExclusion = newCfg.RegexDomainExclusion
+	reloaded.AnnotationFilter = newCfg.AnnotationFilter
+	reloaded.Policy = newCfg.Policy
+	if !reflect.DeepEqual(&reloaded, newCfg) {
+		log.Warn("Only the domain filters, the annotation filter and the policy are reloaded, other changes of the config file are applied by a restart")
+	}
+	// the providers keep the zones of the domain filters they were started with, including the routes of the MultiProvider
+	if err := checkDomainFilterReload(cfg, &reloaded); err != nil {
+		log.Warnf("The domain filters aren't reloaded, they can only be tightened without a restart: %v", err)
+		reloaded.DomainFilter = cfg.DomainFilter
+		reloaded.ExcludeDomains = cfg.ExcludeDomains
+		reloaded.RegexDomainFilter = cfg.RegexDomainFilter
+		reloaded.RegexDomainExclusion = cfg.RegexDomainExclusion
+	}
+
+	policy, exists := plan.Policies[reloaded.Policy]
+	if !exists {
+		return fmt.Errorf("unknown policy: %s", reloaded.Policy)
+	}
+	endpointsSource, err := newSource(clientGenerator, &reloaded, eventRecorder)
+	if err != nil {
+		return err
+	}
+	ctrl.Reconfigure(endpointsSource, policy, newDomainFilter(&reloaded))
+
+	log.WithFields(log.Fields{
+		"domainFilter":         reloaded.DomainFilter,
+		"excludeDomains":       reloaded.ExcludeDomains,
+		"regexDomainFilter":    reloaded.RegexDomainFilter,
+		"regexDomainExclusion": reloaded.RegexDomainExclusion,
+		"annotationFilter":     reloaded.AnnotationFilter,
+		"policy":               reloaded.Policy,
+	}).Infof("Reloaded the config file %s", cfg.ConfigFile)
+	return nil
+}
+
+// checkDomainFilterReload returns an error if the reloaded domain filters match domains the ones the providers were
+// started with don't. The providers only list the zones of their startup filters, so the records of other domains
+// can't be managed before a restart.
+func checkDomainFilterReload(startup, reloaded *externaldns.Config) error {
+	if current := provider.NewDomainFilter(startup.DomainFilter); current.IsConfigured() {
+		if !provider.NewDomainFilter(reloaded.DomainFilter).IsConfigured() {
+			return errors.New("removing --domain-filter widens it to all domains")
+		}
+		for _, domain := range reloaded.DomainFilter {
+			if !current.Match(domain) {
+				return fmt.Errorf("--domain-filter %s isn't within the domains the providers were started with", domain)
+			}
+		}
+	}
+	excluded := provider.NewDomainFilterWithExclusions(nil, reloaded.ExcludeDomains)
+	for _, domain := range startup.ExcludeDomains {
+		if domain != "" && excluded.Match(domain) {
+			return fmt.Errorf("removing --exclude-domains %s widens the domains", domain)
+		}
+	}
+	if startup.RegexDomainFilter != "" && reloaded.RegexDomainFilter != startup.RegexDomainFilter {
+		return errors.New("--regex-domain-filter can only be added, not changed")
+	}
+	if startup.RegexDomainExclusion != "" && reloaded.RegexDomainExclusion != startup.RegexDomainExclusion {
+		return errors.New("--regex-domain-exclusion can only be added, not changed")
+	}
+	return nil
+}
+
+// runUntilStopped runs the controller loop until stopChan is closed and waits up to timeout, unlimited if 0,
+// for it to complete its current synchronization, so that changes are rarely interrupted halfway.
+func runUntilStopped(run func(stopChan <-chan struct{}), stopChan <-chan struct{}, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		run(stopChan)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-stopChan:
+	}
+
+	if timeout == 0 {
+		<-done
+		return
+	}
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Errorf("The synchronization didn't complete within the shutdown timeout of %s, terminating anyway", timeout)
+	}
+}
+
+// runWithLeaderElection runs the controller loop only while this instance is the elected leader of the replicas
+// sharing the lock object. Losing the leadership terminates the process, so that it restarts as a follower.
+func runWithLeaderElection(client kubernetes.Interface, cfg *externaldns.Config, run func(stopChan <-chan struct{}), stopChan <-chan struct{}) {
+	identity, err := os.Hostname()
+	if err != nil {
+		log.Fatalf("failed to determine the identity for the leader election: %v", err)
+	}
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events(cfg.LeaderElectionNamespace)})
+
+	// the lease is kept in the annotations of an Endpoints object
+	lock := &resourcelock.EndpointsLock{
+		EndpointsMeta: metav1.ObjectMeta{Namespace: cfg.LeaderElectionNamespace, Name: cfg.LeaderElectionID},
+		Client:        client.CoreV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity:      identity,
+			EventRecorder: broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "external-dns"}),
+		},
+	}
+
+	var leading int32
+	finished := make(chan struct{})
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: cfg.LeaderElectionLeaseDuration,
+		RenewDeadline: cfg.LeaderElectionLeaseDuration * 2 / 3,
+		RetryPeriod:   cfg.LeaderElectionLeaseDuration / 5,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(<-chan struct{}) {
+				log.Infof("%s became the leader, synchronizing the records", identity)
+				atomic.StoreInt32(&leading, 1)
+				run(stopChan)
+				close(finished)
+			},
+			OnStoppedLeading: func() {
+				log.Fatalf("%s lost the leadership, terminating", identity)
+			},
+			OnNewLeader: func(leader string) {
+				if leader != identity {
+					log.Infof("%s is the leader, waiting for the leadership", leader)
+				}
+			},
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	go elector.Run()
+
+	<-stopChan
+	// let the leader finish its current synchronization
+	if atomic.LoadInt32(&leading) == 1 {
+		<-finished
+	}
+}
+
+// newSource returns the combined source of the sources selected by the flags
+func newSource(clientGenerator source.ClientGenerator, cfg *externaldns.Config, eventRecorder *source.EventRecorder) (source.Source, error) {
 	// Create a source.Config from the flags passed by the user.
 	sourceCfg := &source.Config{
 		Namespace:                cfg.Namespace,
@@ -73,38 +544,135 @@ func main() {
 		CombineFQDNAndAnnotation: cfg.CombineFQDNAndAnnotation,
 		Compatibility:            cfg.Compatibility,
 		PublishInternal:          cfg.PublishInternal,
+		IPFamilyPolicy:           cfg.IPFamilyPolicy,
+		StaticRecordsConfigMap:   cfg.StaticRecordsConfigMap,
+		StaticRecordsSecret:      cfg.StaticRecordsSecret,
+		StaticRecordsKey:         cfg.StaticRecordsKey,
+		EventRecorder:            eventRecorder,
 	}
 
 	// Lookup all the selected sources by names and pass them the desired configuration.
-	sources, err := source.ByNames(&source.SingletonClientGenerator{
-		KubeConfig: cfg.KubeConfig,
-		KubeMaster: cfg.Master,
-	}, cfg.Sources, sourceCfg)
+	sources, err := source.ByNames(clientGenerator, cfg.Sources, sourceCfg)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	// Combine multiple sources into a single, deduplicated source.
 	endpointsSource := source.NewDedupSource(source.NewMultiSource(sources))
+	if cfg.EnableHealthChecks {
+		endpointsSource = source.NewHealthCheckSource(endpointsSource, cfg.HealthCheckTimeout)
+	}
+	hooks := []source.Hook{}
+	for _, hookURL := range cfg.HookURLs {
+		if hookURL != "" {
+			hooks = append(hooks, source.NewWebhookHook(hookURL, cfg.HookTimeout))
+		}
+	}
+	if len(hooks) > 0 {
+		endpointsSource = source.NewHookSource(endpointsSource, hooks...)
+	}
+	// Enforce the default and minimum TTL, so that the plan corrects records drifting from them.
+	return source.NewTTLSource(endpointsSource, endpoint.TTL(cfg.DefaultTTL), endpoint.TTL(cfg.MinTTL)), nil
+}
 
-	domainFilter := provider.NewDomainFilter(cfg.DomainFilter)
+// newDomainFilter returns the domain filter of the records managed, i.e. of the zones of all providers
+func newDomainFilter(cfg *externaldns.Config) provider.DomainFilter {
+	domains := []string{}
+	for _, name := range append([]string{cfg.Provider}, cfg.AdditionalProviders...) {
+		if !provider.NewDomainFilter(providerDomains(cfg, name)).IsConfigured() {
+			// a provider without domain filter manages all records
+			domains = nil
+			break
+		}
+		domains = append(domains, providerDomains(cfg, name)...)
+	}
+	return provider.NewRegexDomainFilter(domains, cfg.ExcludeDomains, optionalRegexp(cfg.RegexDomainFilter), optionalRegexp(cfg.RegexDomainExclusion))
+}
+
+// newProviderDomainFilter returns the domain filter of the zones of a provider
+func newProviderDomainFilter(cfg *externaldns.Config, name string) provider.DomainFilter {
+	return provider.NewRegexDomainFilter(providerDomains(cfg, name), cfg.ExcludeDomains, optionalRegexp(cfg.RegexDomainFilter), optionalRegexp(cfg.RegexDomainExclusion))
+}
+
+// providerDomains returns the domains of a provider given by --provider-domain-filter, --domain-filter otherwise
+func providerDomains(cfg *externaldns.Config, name string) []string {
+	domains := []string{}
+	for _, filter := range cfg.ProviderDomainFilters {
+		if parts := strings.SplitN(filter, "=", 2); len(parts) == 2 && parts[0] == name {
+			domains = append(domains, parts[1])
+		}
+	}
+	if len(domains) == 0 {
+		return cfg.DomainFilter
+	}
+	return domains
+}
+
+// newProvider returns the provider of the given name, wrapped to count, retry and cache its calls as configured
+func newProvider(name string, cfg *externaldns.Config) (provider.Provider, error) {
+	domainFilter := newProviderDomainFilter(cfg, name)
 	zoneIDFilter := provider.NewZoneIDFilter(cfg.ZoneIDFilter)
 	zoneTypeFilter := provider.NewZoneTypeFilter(cfg.AWSZoneType)
+	// every request to the DNS API waits for the rate limit, including the retries
+	var rateLimiter *provider.RateLimiter
+	if cfg.ProviderQPS > 0 {
+		rateLimiter = provider.NewRateLimiter(cfg.ProviderQPS, cfg.ProviderBurst)
+	}
 
 	var p provider.Provider
-	switch cfg.Provider {
+	var err error
+	switch name {
 	case "aws":
-		p, err = provider.NewAWSProvider(domainFilter, zoneIDFilter, zoneTypeFilter, cfg.DryRun)
+		p, err = provider.NewAWSProvider(
+			provider.AWSConfig{
+				DomainFilter:         domainFilter,
+				ZoneIDFilter:         zoneIDFilter,
+				ZoneTypeFilter:       zoneTypeFilter,
+				AssumeRole:           cfg.AWSAssumeRole,
+				AssumeRoleExternalID: cfg.AWSAssumeRoleExternalID,
+				ZoneRoles:            awsZoneRoles(cfg.AWSZoneRoles),
+				RateLimiter:          rateLimiter,
+				DryRun:               cfg.DryRun,
+			},
+		)
+	case "aws-sd":
+		p, err = provider.NewAWSSDProvider(
+			provider.AWSSDConfig{
+				DomainFilter:         domainFilter,
+				NamespaceType:        cfg.AWSZoneType,
+				AssumeRole:           cfg.AWSAssumeRole,
+				AssumeRoleExternalID: cfg.AWSAssumeRoleExternalID,
+				RateLimiter:          rateLimiter,
+				DryRun:               cfg.DryRun,
+			},
+		)
 	case "azure":
 		p, err = provider.NewAzureProvider(cfg.AzureConfigFile, domainFilter, zoneIDFilter, cfg.AzureResourceGroup, cfg.DryRun)
 	case "cloudflare":
-		p, err = provider.NewCloudFlareProvider(domainFilter, zoneIDFilter, cfg.CloudflareProxied, cfg.DryRun)
+		p, err = provider.NewCloudFlareProvider(domainFilter, zoneIDFilter, cfg.CloudflareProxied, rateLimiter, cfg.DryRun)
 	case "google":
-		p, err = provider.NewGoogleProvider(cfg.GoogleProject, domainFilter, zoneIDFilter, cfg.DryRun)
+		p, err = provider.NewGoogleProvider(cfg.GoogleProject, domainFilter, zoneIDFilter, rateLimiter, cfg.DryRun)
 	case "digitalocean":
-		p, err = provider.NewDigitalOceanProvider(domainFilter, cfg.DryRun)
+		p, err = provider.NewDigitalOceanProvider(domainFilter, rateLimiter, cfg.DryRun)
+	case "linode":
+		p, err = provider.NewLinodeProvider(domainFilter, rateLimiter, cfg.DryRun)
+	case "ovh":
+		p, err = provider.NewOVHProvider(domainFilter, cfg.OVHEndpoint, cfg.DryRun)
+	case "akamai":
+		p, err = provider.NewAkamaiProvider(
+			provider.AkamaiConfig{
+				DomainFilter:          domainFilter,
+				ZoneIDFilter:          zoneIDFilter,
+				ServiceConsumerDomain: cfg.AkamaiServiceConsumerDomain,
+				ClientToken:           cfg.AkamaiClientToken,
+				ClientSecret:          cfg.AkamaiClientSecret,
+				AccessToken:           cfg.AkamaiAccessToken,
+				RateLimiter:           rateLimiter,
+				DryRun:                cfg.DryRun,
+			},
+		)
 	case "dnsimple":
-		p, err = provider.NewDnsimpleProvider(domainFilter, zoneIDFilter, cfg.DryRun)
+		p, err = provider.NewDnsimpleProvider(domainFilter, zoneIDFilter, cfg.DnsimpleSandbox, cfg.DryRun)
 	case "infoblox":
 		p, err = provider.NewInfobloxProvider(
 			provider.InfobloxConfig{
@@ -132,70 +700,227 @@ func main() {
 				AppVersion:    externaldns.Version,
 			},
 		)
+	case "oci":
+		var config *provider.OCIConfig
+		if cfg.OCIAuthInstancePrincipal {
+			config = &provider.OCIConfig{UseInstancePrincipal: true}
+		} else {
+			config, err = provider.LoadOCIConfig(cfg.OCIConfigFile)
+		}
+		if err == nil {
+			if cfg.OCICompartmentOCID != "" {
+				config.CompartmentID = cfg.OCICompartmentOCID
+			}
+			p, err = provider.NewOCIProvider(*config, domainFilter, zoneIDFilter, cfg.DryRun)
+		}
+	case "exoscale":
+		p, err = provider.NewExoscaleProvider(cfg.ExoscaleEndpoint, cfg.ExoscaleAPIKey, cfg.ExoscaleAPISecret, domainFilter, zoneIDFilter, cfg.DryRun)
+	case "pihole":
+		p, err = provider.NewPiholeProvider(cfg.PiholeServer, cfg.PiholeAPIToken, domainFilter, rateLimiter, cfg.DryRun)
+	case "godaddy":
+		p, err = provider.NewGoDaddyProvider(cfg.GoDaddyAPIKey, cfg.GoDaddyAPISecret, cfg.GoDaddyOTE, domainFilter, zoneIDFilter, rateLimiter, cfg.DryRun)
+	case "gandi":
+		p, err = provider.NewGandiProvider(cfg.GandiPAT, domainFilter, rateLimiter, cfg.DryRun)
+	case "transip":
+		p, err = provider.NewTransIPProvider(cfg.TransIPAccountName, cfg.TransIPPrivateKeyFile, domainFilter, rateLimiter, cfg.DryRun)
 	case "inmemory":
 		p, err = provider.NewInMemoryProvider(provider.InMemoryInitZones(cfg.InMemoryZones), provider.InMemoryWithDomain(domainFilter), provider.InMemoryWithLogging()), nil
 	case "designate":
 		p, err = provider.NewDesignateProvider(domainFilter, cfg.DryRun)
+	case "webhook":
+		p, err = provider.NewWebhookProvider(cfg.WebhookProviderURL)
 	default:
-		log.Fatalf("unknown dns provider: %s", cfg.Provider)
+		return nil, fmt.Errorf("unknown dns provider: %s", name)
 	}
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	if rateLimiter != nil {
+		switch name {
+		case "aws", "aws-sd", "google", "linode", "cloudflare", "digitalocean", "akamai", "pihole", "godaddy", "gandi", "transip":
+		default:
+			// the HTTP requests of the API clients of the other providers can't be limited, every call takes a token
+			p = provider.NewRateLimitedProvider(p, rateLimiter)
+		}
+	}
+	// count every call to the provider, including retries
+	p = provider.NewInstrumentedProvider(p)
+	if cfg.ProviderMaxRetries > 0 {
+		p = provider.NewRetryProvider(p, cfg.ProviderMaxRetries, cfg.ProviderRetryDelay)
 	}
+	if cfg.ProviderCacheTime > 0 {
+		p = provider.NewCachedProvider(p, cfg.ProviderCacheTime)
+	}
+	return p, nil
+}
 
-	var r registry.Registry
-	switch cfg.Registry {
+// newRegistry returns the registry of the given name keeping track of the ownership of the records of the provider
+func newRegistry(name string, p provider.Provider, cfg *externaldns.Config) (registry.Registry, error) {
+	switch name {
 	case "noop":
-		r, err = registry.NewNoopRegistry(p)
+		log.Warn("running with the noop registry. Ownership isn't tracked, any record in the managed zones may be updated or deleted.")
+		return registry.NewNoopRegistry(p)
 	case "txt":
-		r, err = registry.NewTXTRegistry(p, cfg.TXTPrefix, cfg.TXTOwnerID)
+		var txtEncryptor *registry.TXTEncryptor
+		if cfg.TXTEncryptAESKey != "" || strings.Join(cfg.TXTDecryptAESKeys, "") != "" {
+			var err error
+			txtEncryptor, err = registry.NewTXTEncryptor(cfg.TXTEncryptAESKey, cfg.TXTDecryptAESKeys)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return registry.NewTXTRegistry(p, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTWildcardReplacement, cfg.TXTOwnerID, cfg.TXTCacheInterval, txtEncryptor, cfg.TXTWriteLegacy)
+	case "dynamodb":
+		return registry.NewDynamoDBRegistry(p, cfg.TXTOwnerID,
+			registry.DynamoDBConfig{
+				Table:                cfg.DynamoDBTable,
+				Region:               cfg.DynamoDBRegion,
+				AssumeRole:           cfg.AWSAssumeRole,
+				AssumeRoleExternalID: cfg.AWSAssumeRoleExternalID,
+				DryRun:               cfg.DryRun,
+			},
+		)
+	case "aws-sd":
+		return registry.NewAWSSDRegistry(p, cfg.TXTOwnerID)
 	default:
-		log.Fatalf("unknown registry: %s", cfg.Registry)
+		return nil, fmt.Errorf("unknown registry: %s", name)
 	}
+}
 
-	if err != nil {
-		log.Fatal(err)
+// validateManifests validates the annotations of the Services and Ingresses in the manifests of the given files,
+// reading stdin if there are none or for "-". It prints the errors as FILE:LINE: RESOURCE: ERROR and returns the exit
+// code, which is 1 if any manifest is invalid.
+func validateManifests(paths []string, stdin io.Reader, out io.Writer) int {
+	if len(paths) == 0 {
+		paths = []string{"-"}
 	}
 
-	policy, exists := plan.Policies[cfg.Policy]
-	if !exists {
-		log.Fatalf("unknown policy: %s", cfg.Policy)
+	code := 0
+	for _, path := range paths {
+		if path == "-" {
+			code |= validateManifest("<stdin>", stdin, out)
+			continue
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(out, err)
+			code = 1
+			continue
+		}
+		code |= validateManifest(path, f, out)
+		f.Close()
 	}
+	return code
+}
 
-	ctrl := controller.Controller{
-		Source:   endpointsSource,
-		Registry: r,
-		Policy:   policy,
-		Interval: cfg.Interval,
+// validateManifest validates the manifest read from r and prints its errors, it returns 1 if it's invalid
+func validateManifest(name string, r io.Reader, out io.Writer) int {
+	errs, err := source.ValidateManifest(r)
+	if err != nil {
+		fmt.Fprintf(out, "%s: %v\n", name, err)
+		return 1
 	}
+	for _, err := range errs {
+		fmt.Fprintf(out, "%s:%d: %v\n", name, err.Line, err)
+	}
+	if len(errs) > 0 {
+		return 1
+	}
+	return 0
+}
 
-	if cfg.Once {
-		err := ctrl.RunOnce()
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		os.Exit(0)
+// optionalRegexp compiles the validated regular expression, it returns nil if the expression is empty
+func optionalRegexp(expr string) *regexp.Regexp {
+	if expr == "" {
+		return nil
 	}
+	return regexp.MustCompile(expr)
+}
 
-	ctrl.Run(stopChan)
+// awsZoneRoles parses the hosted zone to IAM role mappings given as ZONEID=ROLEARN
+func awsZoneRoles(zoneRoles []string) map[string]string {
+	roles := map[string]string{}
+	for _, zoneRole := range zoneRoles {
+		parts := strings.SplitN(zoneRole, "=", 2)
+		if len(parts) == 2 {
+			roles[parts[0]] = parts[1]
+		}
+	}
+	return roles
 }
 
 func handleSigterm(stopChan chan struct{}) {
 	signals := make(chan os.Signal, 1)
-	signal.Notify(signals, syscall.SIGTERM)
-	<-signals
-	log.Info("Received SIGTERM. Terminating...")
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	sig := <-signals
+	log.Infof("Received %s. Terminating...", sig)
 	close(stopChan)
 }
 
-func serveMetrics(address string) {
-	http.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+// healthChecks reports the health of the controller, which is only created after the metrics are served
+type healthChecks struct {
+	mu         sync.Mutex
+	controller *controller.Controller
+	// standby is set while waiting for the leadership, a standby replica is ready without synchronizing
+	standby bool
+}
+
+func (h *healthChecks) setController(ctrl *controller.Controller, standby bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.controller = ctrl
+	h.standby = standby
+}
+
+// alive fails if the synchronization loop is stuck
+func (h *healthChecks) alive() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.controller == nil {
+		return nil
+	}
+	return h.controller.Alive()
+}
+
+// ready fails until the controller listed the records and endpoints once, unless it's waiting for the leadership
+func (h *healthChecks) ready() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.controller == nil {
+		return errors.New("the controller hasn't been created yet")
+	}
+	if h.standby {
+		return nil
+	}
+	return h.controller.Ready()
+}
+
+func serveHealthCheck(check func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if err := check(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
-	})
+	}
+}
 
-	http.Handle("/metrics", promhttp.Handler())
+func serveMetrics(address string, health *healthChecks, enablePprof bool) {
+	// net/http/pprof registers its handlers on the default mux, they're only served if enabled
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", serveHealthCheck(health.alive))
+	mux.HandleFunc("/readyz", serveHealthCheck(health.ready))
+
+	mux.Handle("/metrics", promhttp.Handler())
+
+	if enablePprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
 
-	log.Fatal(http.ListenAndServe(address, nil))
+	log.Fatal(http.ListenAndServe(address, mux))
 }
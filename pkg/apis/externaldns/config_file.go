@@ -0,0 +1,112 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externaldns
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/kingpin"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	configFlag = "config"
+	envPrefix  = "EXTERNAL_DNS_"
+)
+
+// configFileArgs reads the flags of the config file given by --config or its env var and returns them as arguments
+// to be parsed before args. Flags given by args or their env var are skipped, so that these take precedence.
+func configFileArgs(app *kingpin.Application, args []string) ([]string, error) {
+	path, ok := argValue(args, configFlag)
+	if !ok {
+		path = os.Getenv(envName(configFlag))
+	}
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the config file: %v", err)
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse the config file %s: %v", path, err)
+	}
+
+	// sort the flags, so that errors are reported deterministically
+	names := []string{}
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fileArgs := []string{}
+	for _, name := range names {
+		if name == configFlag || app.GetFlag(name) == nil {
+			return nil, fmt.Errorf("unknown flag %q in the config file %s", name, path)
+		}
+		if _, ok := argValue(args, name); ok {
+			continue
+		}
+		// like kingpin, ignore empty env vars
+		if os.Getenv(envName(name)) != "" {
+			continue
+		}
+		switch value := values[name].(type) {
+		case bool:
+			if value {
+				fileArgs = append(fileArgs, "--"+name)
+			} else {
+				fileArgs = append(fileArgs, "--no-"+name)
+			}
+		case []interface{}:
+			for _, v := range value {
+				fileArgs = append(fileArgs, fmt.Sprintf("--%s=%v", name, v))
+			}
+		case nil:
+		default:
+			fileArgs = append(fileArgs, fmt.Sprintf("--%s=%v", name, value))
+		}
+	}
+	return fileArgs, nil
+}
+
+// argValue returns the value of the flag with the given name in args and whether it's given at all
+func argValue(args []string, name string) (string, bool) {
+	for i, arg := range args {
+		switch {
+		case arg == "--"+name || arg == "--no-"+name:
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+				return args[i+1], true
+			}
+			return "", true
+		case strings.HasPrefix(arg, "--"+name+"="):
+			return strings.TrimPrefix(arg, "--"+name+"="), true
+		}
+	}
+	return "", false
+}
+
+// envName returns the name of the env var of a flag as set up by kingpin's DefaultEnvars
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externaldns
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "external-dns-config")
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString(content)
+	require.NoError(t, err)
+	return f.Name()
+}
+
+func TestParseFlagsConfigFile(t *testing.T) {
+	path := writeConfigFile(t, `
+source:
+  - service
+  - ingress
+provider: google
+domain-filter: [example.org, company.com]
+once: true
+interval: 10m
+txt-owner-id: file
+txt-prefix: file-
+`)
+	defer os.Remove(path)
+
+	originalEnv := setEnv(t, map[string]string{"EXTERNAL_DNS_TXT_OWNER_ID": "env"})
+	defer func() { restoreEnv(t, originalEnv) }()
+
+	cfg := NewConfig()
+	require.NoError(t, cfg.ParseFlags([]string{"--config=" + path, "--provider=aws", "--domain-filter=example.com"}))
+
+	assert.Equal(t, path, cfg.ConfigFile)
+	assert.Equal(t, []string{"service", "ingress"}, cfg.Sources)
+	assert.True(t, cfg.Once)
+	assert.Equal(t, 10*time.Minute, cfg.Interval)
+	assert.Equal(t, "file-", cfg.TXTPrefix)
+	// flags and env vars take precedence over the config file
+	assert.Equal(t, "aws", cfg.Provider)
+	assert.Equal(t, []string{"example.com"}, cfg.DomainFilter)
+	assert.Equal(t, "env", cfg.TXTOwnerID)
+}
+
+func TestParseFlagsConfigFileFromEnv(t *testing.T) {
+	path := writeConfigFile(t, "source: [service]\nprovider: google\ndry-run: false\n")
+	defer os.Remove(path)
+
+	originalEnv := setEnv(t, map[string]string{"EXTERNAL_DNS_CONFIG": path})
+	defer func() { restoreEnv(t, originalEnv) }()
+
+	cfg := NewConfig()
+	require.NoError(t, cfg.ParseFlags([]string{}))
+	assert.Equal(t, []string{"service"}, cfg.Sources)
+	assert.Equal(t, "google", cfg.Provider)
+	assert.False(t, cfg.DryRun)
+}
+
+func TestParseFlagsConfigFileErrors(t *testing.T) {
+	path := writeConfigFile(t, "source: [service]\nprovider: google\nunknown-flag: foo\n")
+	defer os.Remove(path)
+	assert.Error(t, NewConfig().ParseFlags([]string{"--config", path}))
+
+	invalid := writeConfigFile(t, "source: [service\n")
+	defer os.Remove(invalid)
+	assert.Error(t, NewConfig().ParseFlags([]string{"--config", invalid}))
+
+	assert.Error(t, NewConfig().ParseFlags([]string{"--config=/does/not/exist.yaml"}))
+}
@@ -36,80 +36,226 @@ var (
 
 // Config is a project-wide configuration
 type Config struct {
-	Master                   string
-	KubeConfig               string
-	Sources                  []string
-	Namespace                string
-	AnnotationFilter         string
-	FQDNTemplate             string
-	CombineFQDNAndAnnotation bool
-	Compatibility            string
-	PublishInternal          bool
-	Provider                 string
-	GoogleProject            string
-	DomainFilter             []string
-	ZoneIDFilter             []string
-	AWSZoneType              string
-	AzureConfigFile          string
-	AzureResourceGroup       string
-	CloudflareProxied        bool
-	InfobloxGridHost         string
-	InfobloxWapiPort         int
-	InfobloxWapiUsername     string
-	InfobloxWapiPassword     string
-	InfobloxWapiVersion      string
-	InfobloxSSLVerify        bool
-	DynCustomerName          string
-	DynUsername              string
-	DynPassword              string
-	DynMinTTLSeconds         int
-	InMemoryZones            []string
-	Policy                   string
-	Registry                 string
-	TXTOwnerID               string
-	TXTPrefix                string
-	Interval                 time.Duration
-	Once                     bool
-	DryRun                   bool
-	LogFormat                string
-	MetricsAddress           string
-	LogLevel                 string
+	ConfigFile                  string
+	Master                      string
+	KubeConfig                  string
+	Sources                     []string
+	Namespace                   string
+	AnnotationFilter            string
+	FQDNTemplate                string
+	CombineFQDNAndAnnotation    bool
+	Compatibility               string
+	PublishInternal             bool
+	IPFamilyPolicy              string
+	StaticRecordsConfigMap      string
+	StaticRecordsSecret         string
+	StaticRecordsKey            string
+	EnableHealthChecks          bool
+	HealthCheckTimeout          time.Duration
+	HookURLs                    []string
+	HookTimeout                 time.Duration
+	Provider                    string
+	AdditionalProviders         []string
+	ProviderDomainFilters       []string
+	ProviderCacheTime           time.Duration
+	ProviderMaxRetries          int
+	ProviderRetryDelay          time.Duration
+	ProviderQPS                 float64
+	ProviderBurst               int
+	GoogleProject               string
+	DomainFilter                []string
+	ExcludeDomains              []string
+	RegexDomainFilter           string
+	RegexDomainExclusion        string
+	ZoneIDFilter                []string
+	AWSZoneType                 string
+	AWSAssumeRole               string
+	AWSAssumeRoleExternalID     string
+	AWSZoneRoles                []string
+	AzureConfigFile             string
+	AzureResourceGroup          string
+	CloudflareProxied           bool
+	DnsimpleSandbox             bool
+	OVHEndpoint                 string
+	AkamaiServiceConsumerDomain string
+	AkamaiClientToken           string
+	AkamaiClientSecret          string
+	AkamaiAccessToken           string
+	WebhookProviderURL          string
+	InfobloxGridHost            string
+	InfobloxWapiPort            int
+	InfobloxWapiUsername        string
+	InfobloxWapiPassword        string
+	InfobloxWapiVersion         string
+	InfobloxSSLVerify           bool
+	OCIConfigFile               string
+	OCIAuthInstancePrincipal    bool
+	OCICompartmentOCID          string
+	ExoscaleEndpoint            string
+	ExoscaleAPIKey              string
+	ExoscaleAPISecret           string
+	PiholeServer                string
+	PiholeAPIToken              string
+	GoDaddyAPIKey               string
+	GoDaddyAPISecret            string
+	GoDaddyOTE                  bool
+	GandiPAT                    string
+	TransIPAccountName          string
+	TransIPPrivateKeyFile       string
+	DynCustomerName             string
+	DynUsername                 string
+	DynPassword                 string
+	DynMinTTLSeconds            int
+	InMemoryZones               []string
+	Policy                      string
+	ConflictResolution          string
+	CNAMEConflictPreference     string
+	ManagedRecordTypes          []string
+	DefaultTTL                  int64
+	MinTTL                      int64
+	Registry                    string
+	TXTOwnerID                  string
+	TXTPrefix                   string
+	TXTSuffix                   string
+	TXTWildcardReplacement      string
+	TXTCacheInterval            time.Duration
+	TXTEncryptAESKey            string
+	TXTDecryptAESKeys           []string
+	TXTWriteLegacy              bool
+	DynamoDBTable               string
+	DynamoDBRegion              string
+	NoopRegistryConfirm         bool
+	MigrateRegistryFrom         string
+	ExportRecords               string
+	ImportRecords               string
+	RecordsFormat               string
+	Interval                    time.Duration
+	IntervalJitter              time.Duration
+	MaxChangesPerSync           int
+	LivenessIntervals           int
+	ShutdownTimeout             time.Duration
+	FinalSync                   bool
+	Once                        bool
+	LeaderElection              bool
+	LeaderElectionNamespace     string
+	LeaderElectionID            string
+	LeaderElectionLeaseDuration time.Duration
+	Events                      bool
+	DryRun                      bool
+	DryRunFormat                string
+	LogFormat                   string
+	MetricsAddress              string
+	EnablePprof                 bool
+	TracingEndpoint             string
+	LogLevel                    string
 }
 
 var defaultConfig = &Config{
-	Master:                   "",
-	KubeConfig:               "",
-	Sources:                  nil,
-	Namespace:                "",
-	AnnotationFilter:         "",
-	FQDNTemplate:             "",
-	CombineFQDNAndAnnotation: false,
-	Compatibility:            "",
-	PublishInternal:          false,
-	Provider:                 "",
-	GoogleProject:            "",
-	DomainFilter:             []string{},
-	AWSZoneType:              "",
-	AzureConfigFile:          "/etc/kubernetes/azure.json",
-	AzureResourceGroup:       "",
-	CloudflareProxied:        false,
-	InfobloxGridHost:         "",
-	InfobloxWapiPort:         443,
-	InfobloxWapiUsername:     "admin",
-	InfobloxWapiPassword:     "",
-	InfobloxWapiVersion:      "2.3.1",
-	InfobloxSSLVerify:        true,
-	InMemoryZones:            []string{},
-	Policy:                   "sync",
-	Registry:                 "txt",
-	TXTOwnerID:               "default",
-	TXTPrefix:                "",
-	Interval:                 time.Minute,
-	Once:                     false,
-	DryRun:                   false,
-	LogFormat:                "text",
-	MetricsAddress:           ":7979",
-	LogLevel:                 logrus.InfoLevel.String(),
+	ConfigFile:                  "",
+	Master:                      "",
+	KubeConfig:                  "",
+	Sources:                     nil,
+	Namespace:                   "",
+	AnnotationFilter:            "",
+	FQDNTemplate:                "",
+	CombineFQDNAndAnnotation:    false,
+	Compatibility:               "",
+	PublishInternal:             false,
+	IPFamilyPolicy:              "dual",
+	StaticRecordsConfigMap:      "",
+	StaticRecordsSecret:         "",
+	StaticRecordsKey:            "records.yaml",
+	EnableHealthChecks:          false,
+	HealthCheckTimeout:          5 * time.Second,
+	HookURLs:                    []string{},
+	HookTimeout:                 10 * time.Second,
+	Provider:                    "",
+	ProviderDomainFilters:       []string{},
+	ProviderCacheTime:           0,
+	ProviderMaxRetries:          3,
+	ProviderRetryDelay:          time.Second,
+	ProviderQPS:                 0,
+	ProviderBurst:               1,
+	GoogleProject:               "",
+	DomainFilter:                []string{},
+	ExcludeDomains:              []string{},
+	RegexDomainFilter:           "",
+	RegexDomainExclusion:        "",
+	AWSZoneType:                 "",
+	AWSAssumeRole:               "",
+	AWSAssumeRoleExternalID:     "",
+	AWSZoneRoles:                []string{},
+	AzureConfigFile:             "/etc/kubernetes/azure.json",
+	AzureResourceGroup:          "",
+	CloudflareProxied:           false,
+	DnsimpleSandbox:             false,
+	OVHEndpoint:                 "ovh-eu",
+	AkamaiServiceConsumerDomain: "",
+	AkamaiClientToken:           "",
+	AkamaiClientSecret:          "",
+	AkamaiAccessToken:           "",
+	WebhookProviderURL:          "http://localhost:8888",
+	InfobloxGridHost:            "",
+	InfobloxWapiPort:            443,
+	InfobloxWapiUsername:        "admin",
+	InfobloxWapiPassword:        "",
+	InfobloxWapiVersion:         "2.3.1",
+	InfobloxSSLVerify:           true,
+	OCIConfigFile:               "/etc/kubernetes/oci.yaml",
+	OCIAuthInstancePrincipal:    false,
+	OCICompartmentOCID:          "",
+	ExoscaleEndpoint:            "https://api.exoscale.ch/dns",
+	ExoscaleAPIKey:              "",
+	ExoscaleAPISecret:           "",
+	PiholeServer:                "",
+	PiholeAPIToken:              "",
+	GoDaddyAPIKey:               "",
+	GoDaddyAPISecret:            "",
+	GoDaddyOTE:                  false,
+	GandiPAT:                    "",
+	TransIPAccountName:          "",
+	TransIPPrivateKeyFile:       "",
+	InMemoryZones:               []string{},
+	Policy:                      "sync",
+	ConflictResolution:          "per-resource",
+	CNAMEConflictPreference:     "address",
+	DefaultTTL:                  0,
+	MinTTL:                      0,
+	Registry:                    "txt",
+	TXTOwnerID:                  "default",
+	TXTPrefix:                   "",
+	TXTSuffix:                   "",
+	TXTWildcardReplacement:      "",
+	TXTCacheInterval:            0,
+	TXTEncryptAESKey:            "",
+	TXTDecryptAESKeys:           []string{},
+	TXTWriteLegacy:              true,
+	DynamoDBTable:               "external-dns",
+	DynamoDBRegion:              "",
+	NoopRegistryConfirm:         false,
+	MigrateRegistryFrom:         "",
+	ExportRecords:               "",
+	ImportRecords:               "",
+	RecordsFormat:               "zone",
+	Interval:                    time.Minute,
+	IntervalJitter:              0,
+	MaxChangesPerSync:           0,
+	LivenessIntervals:           5,
+	ShutdownTimeout:             20 * time.Second,
+	FinalSync:                   false,
+	Once:                        false,
+	LeaderElection:              false,
+	LeaderElectionNamespace:     "default",
+	LeaderElectionID:            "external-dns",
+	LeaderElectionLeaseDuration: 15 * time.Second,
+	Events:                      false,
+	DryRun:                      false,
+	DryRunFormat:                "text",
+	LogFormat:                   "text",
+	MetricsAddress:              ":7979",
+	EnablePprof:                 false,
+	TracingEndpoint:             "",
+	LogLevel:                    logrus.InfoLevel.String(),
 }
 
 // NewConfig returns new Config object
@@ -126,6 +272,34 @@ func (cfg *Config) String() string {
 	if temp.InfobloxWapiPassword != "" {
 		temp.InfobloxWapiPassword = passwordMask
 	}
+	if temp.AkamaiClientSecret != "" {
+		temp.AkamaiClientSecret = passwordMask
+	}
+	if temp.AkamaiAccessToken != "" {
+		temp.AkamaiAccessToken = passwordMask
+	}
+	if temp.ExoscaleAPISecret != "" {
+		temp.ExoscaleAPISecret = passwordMask
+	}
+	if temp.PiholeAPIToken != "" {
+		temp.PiholeAPIToken = passwordMask
+	}
+	if temp.GoDaddyAPISecret != "" {
+		temp.GoDaddyAPISecret = passwordMask
+	}
+	if temp.GandiPAT != "" {
+		temp.GandiPAT = passwordMask
+	}
+	if temp.TXTEncryptAESKey != "" {
+		temp.TXTEncryptAESKey = passwordMask
+	}
+	temp.TXTDecryptAESKeys = nil
+	for _, key := range cfg.TXTDecryptAESKeys {
+		if key != "" {
+			key = passwordMask
+		}
+		temp.TXTDecryptAESKeys = append(temp.TXTDecryptAESKeys, key)
+	}
 
 	return fmt.Sprintf("%+v", temp)
 }
@@ -145,60 +319,140 @@ func (cfg *Config) ParseFlags(args []string) error {
 	app.Version(Version)
 	app.DefaultEnvars()
 
+	app.Flag(configFlag, "Read flags from this YAML file mapping flag names to their values, e.g. 'domain-filter: [example.org]'; flags given on the command line or as env vars take precedence (optional)").Default(defaultConfig.ConfigFile).StringVar(&cfg.ConfigFile)
+
 	// Flags related to Kubernetes
 	app.Flag("master", "The Kubernetes API server to connect to (default: auto-detect)").Default(defaultConfig.Master).StringVar(&cfg.Master)
 	app.Flag("kubeconfig", "Retrieve target cluster configuration from a Kubernetes configuration file (default: auto-detect)").Default(defaultConfig.KubeConfig).StringVar(&cfg.KubeConfig)
 
 	// Flags related to processing sources
-	app.Flag("source", "The resource types that are queried for endpoints; specify multiple times for multiple sources (required, options: service, ingress, fake)").Required().PlaceHolder("source").EnumsVar(&cfg.Sources, "service", "ingress", "fake")
+	app.Flag("source", "The resource types that are queried for endpoints; specify multiple times for multiple sources (required, options: service, ingress, static-records, fake)").Required().PlaceHolder("source").EnumsVar(&cfg.Sources, "service", "ingress", "static-records", "fake")
 	app.Flag("namespace", "Limit sources of endpoints to a specific namespace (default: all namespaces)").Default(defaultConfig.Namespace).StringVar(&cfg.Namespace)
 	app.Flag("annotation-filter", "Filter sources managed by external-dns via annotation using label selector semantics (default: all sources)").Default(defaultConfig.AnnotationFilter).StringVar(&cfg.AnnotationFilter)
 	app.Flag("fqdn-template", "A templated string that's used to generate DNS names from sources that don't define a hostname themselves, or to add a hostname suffix when paired with the fake source (optional). Accepts comma separated list for multiple global FQDN.").Default(defaultConfig.FQDNTemplate).StringVar(&cfg.FQDNTemplate)
 	app.Flag("combine-fqdn-annotation", "Combine FQDN template and Annotations instead of overwriting").BoolVar(&cfg.CombineFQDNAndAnnotation)
 	app.Flag("compatibility", "Process annotation semantics from legacy implementations (optional, options: mate, molecule)").Default(defaultConfig.Compatibility).EnumVar(&cfg.Compatibility, "", "mate", "molecule")
 	app.Flag("publish-internal-services", "Allow external-dns to publish DNS records for ClusterIP services (optional)").BoolVar(&cfg.PublishInternal)
+	app.Flag("ip-family-policy", "Which records to publish for the IP addresses of resources: A records for ipv4, AAAA records for ipv6 or both for dual; resources may override it with the ip-family-policy annotation (default: dual, options: ipv4, ipv6, dual)").Default(defaultConfig.IPFamilyPolicy).EnumVar(&cfg.IPFamilyPolicy, "ipv4", "ipv6", "dual")
+	app.Flag("static-records-configmap", "When using the static-records source, the ConfigMap holding the records in the format namespace/name (optional)").Default(defaultConfig.StaticRecordsConfigMap).StringVar(&cfg.StaticRecordsConfigMap)
+	app.Flag("static-records-secret", "When using the static-records source, the Secret holding the records in the format namespace/name, as an alternative to a ConfigMap (optional)").Default(defaultConfig.StaticRecordsSecret).StringVar(&cfg.StaticRecordsSecret)
+	app.Flag("static-records-key", "When using the static-records source, the key of the ConfigMap or Secret holding the records (default: records.yaml)").Default(defaultConfig.StaticRecordsKey).StringVar(&cfg.StaticRecordsKey)
+	app.Flag("enable-health-checks", "Probe the targets of resources with the health-check annotation and withdraw the targets failing the check, e.g. for providers without native health checks (default: disabled)").BoolVar(&cfg.EnableHealthChecks)
+	app.Flag("health-check-timeout", "The timeout of a single health check of a target (default: 5s)").Default(defaultConfig.HealthCheckTimeout.String()).DurationVar(&cfg.HealthCheckTimeout)
+	app.Flag("hook-url", "Post the endpoints of the sources to this webhook before planning and publish the endpoints it returns, e.g. to enforce naming conventions; specify multiple times for multiple hooks applied in order (optional)").Default("").StringsVar(&cfg.HookURLs)
+	app.Flag("hook-timeout", "The timeout of a single call of a webhook given by --hook-url (default: 10s)").Default(defaultConfig.HookTimeout.String()).DurationVar(&cfg.HookTimeout)
 
 	// Flags related to providers
-	app.Flag("provider", "The DNS provider where the DNS records will be created (required, options: aws, google, azure, cloudflare, digitalocean, dnsimple, infoblox, dyn, designate, inmemory)").Required().PlaceHolder("provider").EnumVar(&cfg.Provider, "aws", "google", "azure", "cloudflare", "digitalocean", "dnsimple", "infoblox", "dyn", "desginate", "inmemory")
+	app.Flag("provider", "The DNS provider where the DNS records will be created (required, options: aws, aws-sd, google, azure, cloudflare, digitalocean, dnsimple, linode, ovh, akamai, infoblox, dyn, designate, oci, exoscale, pihole, godaddy, gandi, transip, inmemory, webhook)").Required().PlaceHolder("provider").EnumVar(&cfg.Provider, "aws", "aws-sd", "google", "azure", "cloudflare", "digitalocean", "dnsimple", "linode", "ovh", "akamai", "infoblox", "dyn", "designate", "oci", "exoscale", "pihole", "godaddy", "gandi", "transip", "inmemory", "webhook")
+	app.Flag("additional-provider", "Publish records to this DNS provider as well, every record goes to the provider with the most specific domain filter matching its name; specify multiple times for multiple providers (optional, options: same as --provider)").EnumsVar(&cfg.AdditionalProviders, "aws", "aws-sd", "google", "azure", "cloudflare", "digitalocean", "dnsimple", "linode", "ovh", "akamai", "infoblox", "dyn", "designate", "oci", "exoscale", "pihole", "godaddy", "gandi", "transip", "inmemory", "webhook")
+	app.Flag("provider-domain-filter", "Limit the zones of a provider to a domain suffix instead of --domain-filter, in the form PROVIDER=DOMAIN, e.g. inmemory=internal.example.org; specify multiple times for multiple domains (optional)").Default("").StringsVar(&cfg.ProviderDomainFilters)
 	app.Flag("domain-filter", "Limit possible target zones by a domain suffix; specify multiple times for multiple domains (optional)").Default("").StringsVar(&cfg.DomainFilter)
+	app.Flag("exclude-domains", "Exclude sub-domains of the target zones from being managed; specify multiple times for multiple domains (optional)").Default("").StringsVar(&cfg.ExcludeDomains)
+	app.Flag("regex-domain-filter", "Limit possible target zones and records to domains matching this regular expression, in addition to --domain-filter (optional)").Default("").StringVar(&cfg.RegexDomainFilter)
+	app.Flag("regex-domain-exclusion", "Exclude zones and records matching this regular expression, e.g. ^sandbox\\. (optional)").Default("").StringVar(&cfg.RegexDomainExclusion)
 	app.Flag("zone-id-filter", "Filter target zones by hosted zone id; specify multiple times for multiple zones (optional)").Default("").StringsVar(&cfg.ZoneIDFilter)
+	app.Flag("provider-cache-time", "Cache the records listed by the provider for this duration, the cache is dropped whenever changes are applied (default: 0, disabled)").Default(defaultConfig.ProviderCacheTime.String()).DurationVar(&cfg.ProviderCacheTime)
+	app.Flag("provider-max-retries", "Retry provider calls failing with transient errors, e.g. caused by API throttling, this many times with exponential backoff (default: 3, 0 disables retries)").Default(strconv.Itoa(defaultConfig.ProviderMaxRetries)).IntVar(&cfg.ProviderMaxRetries)
+	app.Flag("provider-retry-delay", "The delay before the first retry of a provider call, doubled for every further retry (default: 1s)").Default(defaultConfig.ProviderRetryDelay.String()).DurationVar(&cfg.ProviderRetryDelay)
+	app.Flag("provider-qps", "Limit the requests to the DNS API to this number per second on average, e.g. to stay below the API rate limit of an account shared by multiple instances; the providers without a limit per HTTP request limit their calls (default: 0, unlimited)").Default(strconv.FormatFloat(defaultConfig.ProviderQPS, 'f', -1, 64)).Float64Var(&cfg.ProviderQPS)
+	app.Flag("provider-burst", "When limiting the requests with --provider-qps, the number of requests allowed at once (default: 1)").Default(strconv.Itoa(defaultConfig.ProviderBurst)).IntVar(&cfg.ProviderBurst)
 	app.Flag("google-project", "When using the Google provider, current project is auto-detected, when running on GCP. Specify other project with this. Must be specified when running outside GCP.").Default(defaultConfig.GoogleProject).StringVar(&cfg.GoogleProject)
-	app.Flag("aws-zone-type", "When using the AWS provider, filter for zones of this type (optional, options: public, private)").Default(defaultConfig.AWSZoneType).EnumVar(&cfg.AWSZoneType, "", "public", "private")
+	app.Flag("aws-zone-type", "When using the AWS provider, filter for zones of this type; when using the AWS Cloud Map provider, for namespaces of this type (optional, options: public, private)").Default(defaultConfig.AWSZoneType).EnumVar(&cfg.AWSZoneType, "", "public", "private")
+	app.Flag("aws-assume-role", "When using the AWS provider, assume this IAM role for all API calls (optional)").Default(defaultConfig.AWSAssumeRole).StringVar(&cfg.AWSAssumeRole)
+	app.Flag("aws-assume-role-external-id", "When using the AWS provider, pass this external ID when assuming IAM roles (optional)").Default(defaultConfig.AWSAssumeRoleExternalID).StringVar(&cfg.AWSAssumeRoleExternalID)
+	app.Flag("aws-zone-role", "When using the AWS provider, assume an IAM role to manage a hosted zone of another account, in the form ZONEID=ROLEARN; specify multiple times for multiple zones (optional)").Default("").StringsVar(&cfg.AWSZoneRoles)
 	app.Flag("azure-config-file", "When using the Azure provider, specify the Azure configuration file (required when --provider=azure").Default(defaultConfig.AzureConfigFile).StringVar(&cfg.AzureConfigFile)
 	app.Flag("azure-resource-group", "When using the Azure provider, override the Azure resource group to use (optional)").Default(defaultConfig.AzureResourceGroup).StringVar(&cfg.AzureResourceGroup)
 	app.Flag("cloudflare-proxied", "When using the Cloudflare provider, specify if the proxy mode must be enabled (default: disabled)").BoolVar(&cfg.CloudflareProxied)
+	app.Flag("dnsimple-sandbox", "When using the DNSimple provider, talk to the DNSimple sandbox API instead of production (default: disabled)").BoolVar(&cfg.DnsimpleSandbox)
+	app.Flag("ovh-endpoint", "When using the OVH provider, specify the API endpoint to use (default: ovh-eu, options: ovh-eu, ovh-ca, ovh-us, kimsufi-eu, kimsufi-ca, soyoustart-eu, soyoustart-ca)").Default(defaultConfig.OVHEndpoint).StringVar(&cfg.OVHEndpoint)
+	app.Flag("akamai-serviceconsumerdomain", "When using the Akamai provider, specify the EdgeGrid host of your API client (required when --provider=akamai)").Default(defaultConfig.AkamaiServiceConsumerDomain).StringVar(&cfg.AkamaiServiceConsumerDomain)
+	app.Flag("akamai-client-token", "When using the Akamai provider, specify the EdgeGrid client token (required when --provider=akamai)").Default(defaultConfig.AkamaiClientToken).StringVar(&cfg.AkamaiClientToken)
+	app.Flag("akamai-client-secret", "When using the Akamai provider, specify the EdgeGrid client secret (required when --provider=akamai)").Default(defaultConfig.AkamaiClientSecret).StringVar(&cfg.AkamaiClientSecret)
+	app.Flag("akamai-access-token", "When using the Akamai provider, specify the EdgeGrid access token (required when --provider=akamai)").Default(defaultConfig.AkamaiAccessToken).StringVar(&cfg.AkamaiAccessToken)
 	app.Flag("infoblox-grid-host", "When using the Infoblox provider, specify the Grid Manager host (required when --provider=infoblox)").Default(defaultConfig.InfobloxGridHost).StringVar(&cfg.InfobloxGridHost)
 	app.Flag("infoblox-wapi-port", "When using the Infoblox provider, specify the WAPI port (default: 443)").Default(strconv.Itoa(defaultConfig.InfobloxWapiPort)).IntVar(&cfg.InfobloxWapiPort)
 	app.Flag("infoblox-wapi-username", "When using the Infoblox provider, specify the WAPI username (default: admin)").Default(defaultConfig.InfobloxWapiUsername).StringVar(&cfg.InfobloxWapiUsername)
 	app.Flag("infoblox-wapi-password", "When using the Infoblox provider, specify the WAPI password (required when --provider=infoblox)").Default(defaultConfig.InfobloxWapiPassword).StringVar(&cfg.InfobloxWapiPassword)
 	app.Flag("infoblox-wapi-version", "When using the Infoblox provider, specify the WAPI version (default: 2.3.1)").Default(defaultConfig.InfobloxWapiVersion).StringVar(&cfg.InfobloxWapiVersion)
 	app.Flag("infoblox-ssl-verify", "When using the Infoblox provider, specify whether to verify the SSL certificate (default: true, disable with --no-infoblox-ssl-verify)").Default(strconv.FormatBool(defaultConfig.InfobloxSSLVerify)).BoolVar(&cfg.InfobloxSSLVerify)
+	app.Flag("oci-config-file", "When using the OCI provider, specify the OCI configuration file (required when --provider=oci unless --oci-auth-instance-principal is set)").Default(defaultConfig.OCIConfigFile).StringVar(&cfg.OCIConfigFile)
+	app.Flag("oci-auth-instance-principal", "When using the OCI provider, authenticate as the instance principal of the node ExternalDNS runs on, e.g. on OKE, instead of using the credentials of the configuration file (default: false)").Default(strconv.FormatBool(defaultConfig.OCIAuthInstancePrincipal)).BoolVar(&cfg.OCIAuthInstancePrincipal)
+	app.Flag("oci-compartment-ocid", "When using the OCI provider, specify the OCID of the compartment holding the zones to manage, overrides the compartment of the configuration file (required when --oci-auth-instance-principal is set)").Default(defaultConfig.OCICompartmentOCID).StringVar(&cfg.OCICompartmentOCID)
+	app.Flag("exoscale-endpoint", "When using the Exoscale provider, specify the endpoint of the Exoscale DNS API (default: https://api.exoscale.ch/dns)").Default(defaultConfig.ExoscaleEndpoint).StringVar(&cfg.ExoscaleEndpoint)
+	app.Flag("exoscale-apikey", "When using the Exoscale provider, specify the API key (required when --provider=exoscale)").Default(defaultConfig.ExoscaleAPIKey).StringVar(&cfg.ExoscaleAPIKey)
+	app.Flag("exoscale-apisecret", "When using the Exoscale provider, specify the API secret (required when --provider=exoscale)").Default(defaultConfig.ExoscaleAPISecret).StringVar(&cfg.ExoscaleAPISecret)
+	app.Flag("pihole-server", "When using the Pi-hole provider, specify the URL of the Pi-hole admin interface, e.g. http://pi.hole (required when --provider=pihole)").Default(defaultConfig.PiholeServer).StringVar(&cfg.PiholeServer)
+	app.Flag("pihole-api-token", "When using the Pi-hole provider, specify the API token found in the API settings of the admin interface (required when --provider=pihole)").Default(defaultConfig.PiholeAPIToken).StringVar(&cfg.PiholeAPIToken)
+	app.Flag("godaddy-api-key", "When using the GoDaddy provider, specify the API key (required when --provider=godaddy)").Default(defaultConfig.GoDaddyAPIKey).StringVar(&cfg.GoDaddyAPIKey)
+	app.Flag("godaddy-api-secret", "When using the GoDaddy provider, specify the API secret (required when --provider=godaddy)").Default(defaultConfig.GoDaddyAPISecret).StringVar(&cfg.GoDaddyAPISecret)
+	app.Flag("godaddy-api-ote", "When using the GoDaddy provider, use the OTE test environment instead of production (default: false)").Default(strconv.FormatBool(defaultConfig.GoDaddyOTE)).BoolVar(&cfg.GoDaddyOTE)
+	app.Flag("gandi-pat", "When using the Gandi provider, specify the personal access token with LiveDNS permissions (required when --provider=gandi)").Default(defaultConfig.GandiPAT).StringVar(&cfg.GandiPAT)
+	app.Flag("transip-account", "When using the TransIP provider, specify the account name (required when --provider=transip)").Default(defaultConfig.TransIPAccountName).StringVar(&cfg.TransIPAccountName)
+	app.Flag("transip-keyfile", "When using the TransIP provider, specify the path to the private key of the API key pair (required when --provider=transip)").Default(defaultConfig.TransIPPrivateKeyFile).StringVar(&cfg.TransIPPrivateKeyFile)
 	app.Flag("dyn-customer-name", "When using the Dyn provider, specify the Customer Name").Default("").StringVar(&cfg.DynCustomerName)
 	app.Flag("dyn-username", "When using the Dyn provider, specify the Username").Default("").StringVar(&cfg.DynUsername)
 	app.Flag("dyn-password", "When using the Dyn provider, specify the pasword").Default("").StringVar(&cfg.DynPassword)
 	app.Flag("dyn-min-ttl", "Minimal TTL (in seconds) for records. This value will be used if the provided TTL for a service/ingress is lower than this.").IntVar(&cfg.DynMinTTLSeconds)
 
+	app.Flag("webhook-provider-url", "When using the webhook provider, specify the URL of the webhook provider (default: http://localhost:8888)").Default(defaultConfig.WebhookProviderURL).StringVar(&cfg.WebhookProviderURL)
 	app.Flag("inmemory-zone", "Provide a list of pre-configured zones for the inmemory provider; specify multiple times for multiple zones (optional)").Default("").StringsVar(&cfg.InMemoryZones)
 
 	// Flags related to policies
-	app.Flag("policy", "Modify how DNS records are sychronized between sources and providers (default: sync, options: sync, upsert-only)").Default(defaultConfig.Policy).EnumVar(&cfg.Policy, "sync", "upsert-only")
+	app.Flag("policy", "Modify how DNS records are sychronized between sources and providers (default: sync, options: sync, upsert-only, create-only)").Default(defaultConfig.Policy).EnumVar(&cfg.Policy, "sync", "upsert-only", "create-only")
+	app.Flag("conflict-resolution", "How to choose between resources wanting the same DNS name with different targets: keep the resource owning it, let the newest resource or the one with the highest external-dns.alpha.kubernetes.io/priority annotation win, or skip the name and log an error (default: per-resource, options: per-resource, newest-resource, priority, skip)").Default(defaultConfig.ConflictResolution).EnumVar(&cfg.ConflictResolution, "per-resource", "newest-resource", "priority", "skip")
+	app.Flag("cname-conflict-preference", "Which records to keep if both a CNAME and A or AAAA records are desired for the same DNS name, which DNS doesn't allow (default: address, options: address, cname)").Default(defaultConfig.CNAMEConflictPreference).EnumVar(&cfg.CNAMEConflictPreference, "address", "cname")
+	app.Flag("managed-record-types", "Restrict the records managed to this type, records of other types are neither created, updated nor deleted; specify multiple times for multiple types (default: all types, options: A, AAAA, CNAME, TXT)").EnumsVar(&cfg.ManagedRecordTypes, "A", "AAAA", "CNAME", "TXT")
+	app.Flag("default-ttl", "The TTL in seconds of records without a TTL annotation (default: 0, the provider default)").Default("0").Int64Var(&cfg.DefaultTTL)
+	app.Flag("min-ttl", "The minimum TTL in seconds of records, lower TTLs are raised to it (default: 0, disabled)").Default("0").Int64Var(&cfg.MinTTL)
 
 	// Flags related to the registry
-	app.Flag("registry", "The registry implementation to use to keep track of DNS record ownership (default: txt, options: txt, noop)").Default(defaultConfig.Registry).EnumVar(&cfg.Registry, "txt", "noop")
-	app.Flag("txt-owner-id", "When using the TXT registry, a name that identifies this instance of ExternalDNS (default: default)").Default(defaultConfig.TXTOwnerID).StringVar(&cfg.TXTOwnerID)
-	app.Flag("txt-prefix", "When using the TXT registry, a custom string that's prefixed to each ownership DNS record (optional)").Default(defaultConfig.TXTPrefix).StringVar(&cfg.TXTPrefix)
+	app.Flag("registry", "The registry implementation to use to keep track of DNS record ownership (default: txt, options: txt, dynamodb, aws-sd, noop)").Default(defaultConfig.Registry).EnumVar(&cfg.Registry, "txt", "dynamodb", "aws-sd", "noop")
+	app.Flag("txt-owner-id", "When using the TXT, DynamoDB or AWS Cloud Map registry, a name that identifies this instance of ExternalDNS (default: default)").Default(defaultConfig.TXTOwnerID).StringVar(&cfg.TXTOwnerID)
+	app.Flag("txt-prefix", "When using the TXT registry, a custom string that's prefixed to each ownership DNS record, may contain %{record_type} (optional)").Default(defaultConfig.TXTPrefix).StringVar(&cfg.TXTPrefix)
+	app.Flag("txt-suffix", "When using the TXT registry, a custom string that's appended to the first label of each ownership DNS record, may contain %{record_type}; mutually exclusive with --txt-prefix (optional)").Default(defaultConfig.TXTSuffix).StringVar(&cfg.TXTSuffix)
+	app.Flag("txt-wildcard-replacement", "When using the TXT registry, a label that replaces the wildcard of wildcard records in the names of their ownership DNS records, for providers which don't accept names like a-*.example.org (optional)").Default(defaultConfig.TXTWildcardReplacement).StringVar(&cfg.TXTWildcardReplacement)
+	app.Flag("txt-cache-interval", "When using the TXT registry, cache the records for this duration between full refreshes, the cache is updated with the applied changes (default: 0, disabled)").Default(defaultConfig.TXTCacheInterval.String()).DurationVar(&cfg.TXTCacheInterval)
+	app.Flag("txt-encrypt-aes-key", "When using the TXT registry, encrypt the ownership DNS records with AES-GCM using this base64 encoded key of 16, 24 or 32 bytes (optional)").Default(defaultConfig.TXTEncryptAESKey).StringVar(&cfg.TXTEncryptAESKey)
+	app.Flag("txt-decrypt-aes-key", "When using the TXT registry, a previous base64 encoded AES key to decrypt ownership DNS records with, which are then encrypted with the current key; specify multiple times for multiple keys (optional)").Default("").StringsVar(&cfg.TXTDecryptAESKeys)
+	app.Flag("txt-write-legacy", "When using the TXT registry, keep an ownership DNS record of the legacy format for every name owned by this instance, so that previous releases still recognize the records after a rollback (default: true, disable with --no-txt-write-legacy)").Default(strconv.FormatBool(defaultConfig.TXTWriteLegacy)).BoolVar(&cfg.TXTWriteLegacy)
+	app.Flag("dynamodb-table", "When using the DynamoDB registry, the name of the table storing the ownership of the DNS records (default: external-dns)").Default(defaultConfig.DynamoDBTable).StringVar(&cfg.DynamoDBTable)
+	app.Flag("dynamodb-region", "When using the DynamoDB registry, the AWS region of the table, defaults to the region of the AWS SDK configuration (optional)").Default(defaultConfig.DynamoDBRegion).StringVar(&cfg.DynamoDBRegion)
+	app.Flag("noop-registry-confirm", "Confirm the use of the noop registry, which doesn't track ownership: ExternalDNS then updates and deletes any record in the managed zones, including the ones it didn't create (default: disabled)").BoolVar(&cfg.NoopRegistryConfirm)
+	app.Flag("migrate-registry-from", "Migrate the ownership of the records of --txt-owner-id from this registry to the one given by --registry, verifying every record before the ownership is deleted from this registry, and exit (default: disabled, options: txt, dynamodb)").Default(defaultConfig.MigrateRegistryFrom).EnumVar(&cfg.MigrateRegistryFrom, "", "txt", "dynamodb")
+	app.Flag("export-records", "Write the records owned by --txt-owner-id with their TTL, labels and geo locations to this file, - for stdout, and exit (default: disabled)").Default(defaultConfig.ExportRecords).StringVar(&cfg.ExportRecords)
+	app.Flag("import-records", "Create or update the records of this file, e.g. written by --export-records, - for stdin, through the registry and exit, other records are kept (default: disabled)").Default(defaultConfig.ImportRecords).StringVar(&cfg.ImportRecords)
+	app.Flag("records-format", "The format of the files of --export-records and --import-records (default: zone, options: zone, json)").Default(defaultConfig.RecordsFormat).EnumVar(&cfg.RecordsFormat, "zone", "json")
 
 	// Flags related to the main control loop
 	app.Flag("interval", "The interval between two consecutive synchronizations in duration format (default: 1m)").Default(defaultConfig.Interval.String()).DurationVar(&cfg.Interval)
+	app.Flag("interval-jitter", "Add a random delay of up to this duration to every interval, e.g. to spread the API calls of many clusters (default: 0, disabled)").Default(defaultConfig.IntervalJitter.String()).DurationVar(&cfg.IntervalJitter)
+	app.Flag("max-changes-per-sync", "Limit the number of record changes applied in a single synchronization, the remaining ones are applied in the next synchronizations, e.g. when first adopting a large zone (default: 0, unlimited)").Default(strconv.Itoa(defaultConfig.MaxChangesPerSync)).IntVar(&cfg.MaxChangesPerSync)
+	app.Flag("liveness-intervals", "Fail the liveness probe on /healthz if no synchronization completed within this many intervals, e.g. because the synchronization is stuck (default: 5, 0 disables)").Default(strconv.Itoa(defaultConfig.LivenessIntervals)).IntVar(&cfg.LivenessIntervals)
+	app.Flag("shutdown-timeout", "When terminating, wait this long for the current synchronization to apply its changes, it should be shorter than the terminationGracePeriodSeconds of the pod (default: 20s, 0 waits without timeout)").Default(defaultConfig.ShutdownTimeout.String()).DurationVar(&cfg.ShutdownTimeout)
+	app.Flag("final-sync", "When enabled, runs a final synchronization when terminating, within the shutdown timeout (default: disabled)").BoolVar(&cfg.FinalSync)
 	app.Flag("once", "When enabled, exits the synchronization loop after the first iteration (default: disabled)").BoolVar(&cfg.Once)
-	app.Flag("dry-run", "When enabled, prints DNS record changes rather than actually performing them (default: disabled)").BoolVar(&cfg.DryRun)
+	app.Flag("leader-election", "When enabled, only the elected leader of multiple replicas synchronizes the records (default: disabled)").BoolVar(&cfg.LeaderElection)
+	app.Flag("leader-election-namespace", "The namespace of the lock object used for the leader election (default: default)").Default(defaultConfig.LeaderElectionNamespace).StringVar(&cfg.LeaderElectionNamespace)
+	app.Flag("leader-election-id", "The name of the lock object used for the leader election, replicas sharing it elect a single leader (default: external-dns)").Default(defaultConfig.LeaderElectionID).StringVar(&cfg.LeaderElectionID)
+	app.Flag("leader-election-lease-duration", "The duration after which another replica takes over if the leader stops renewing its lease (default: 15s)").Default(defaultConfig.LeaderElectionLeaseDuration.String()).DurationVar(&cfg.LeaderElectionLeaseDuration)
+	app.Flag("events", "When enabled, emits Kubernetes events on the Services and Ingresses whose records are changed or whose annotations are invalid (default: disabled)").BoolVar(&cfg.Events)
+	app.Flag("dry-run", "When enabled, prints the planned DNS record changes rather than actually performing them (default: disabled)").BoolVar(&cfg.DryRun)
+	app.Flag("dry-run-format", "The format of the planned changes printed in dry-run mode, json is meant for scripts, e.g. in CI pipelines (default: text, options: text, json)").Default(defaultConfig.DryRunFormat).EnumVar(&cfg.DryRunFormat, "text", "json")
 
 	// Miscellaneous flags
 	app.Flag("log-format", "The format in which log messages are printed (default: text, options: text, json)").Default(defaultConfig.LogFormat).EnumVar(&cfg.LogFormat, "text", "json")
 	app.Flag("metrics-address", "Specify where to serve the metrics and health check endpoint (default: :7979)").Default(defaultConfig.MetricsAddress).StringVar(&cfg.MetricsAddress)
+	app.Flag("enable-pprof", "When enabled, serves CPU and memory profiles of net/http/pprof on /debug/pprof/ of the metrics address, e.g. to debug the memory usage of many endpoints (default: disabled)").BoolVar(&cfg.EnablePprof)
+	app.Flag("tracing-endpoint", "The URL of an OpenTelemetry collector to export traces of the synchronizations to with OTLP over HTTP, e.g. http://otel-collector:4318 (default: disabled)").Default(defaultConfig.TracingEndpoint).StringVar(&cfg.TracingEndpoint)
 	app.Flag("log-level", "Set the level of logging. (default: info, options: panic, debug, info, warn, error, fatal").Default(defaultConfig.LogLevel).EnumVar(&cfg.LogLevel, allLogLevelsAsStrings()...)
 
-	_, err := app.Parse(args)
+	fileArgs, err := configFileArgs(app, args)
+	if err != nil {
+		return err
+	}
+
+	_, err = app.Parse(append(fileArgs, args...))
 	if err != nil {
 		return err
 	}
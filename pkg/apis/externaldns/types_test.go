@@ -29,71 +29,209 @@ import (
 
 var (
 	minimalConfig = &Config{
-		Master:               "",
-		KubeConfig:           "",
-		Sources:              []string{"service"},
-		Namespace:            "",
-		FQDNTemplate:         "",
-		Compatibility:        "",
-		Provider:             "google",
-		GoogleProject:        "",
-		DomainFilter:         []string{""},
-		ZoneIDFilter:         []string{""},
-		AWSZoneType:          "",
-		AzureConfigFile:      "/etc/kubernetes/azure.json",
-		AzureResourceGroup:   "",
-		CloudflareProxied:    false,
-		InfobloxGridHost:     "",
-		InfobloxWapiPort:     443,
-		InfobloxWapiUsername: "admin",
-		InfobloxWapiPassword: "",
-		InfobloxWapiVersion:  "2.3.1",
-		InfobloxSSLVerify:    true,
-		InMemoryZones:        []string{""},
-		Policy:               "sync",
-		Registry:             "txt",
-		TXTOwnerID:           "default",
-		TXTPrefix:            "",
-		Interval:             time.Minute,
-		Once:                 false,
-		DryRun:               false,
-		LogFormat:            "text",
-		MetricsAddress:       ":7979",
-		LogLevel:             logrus.InfoLevel.String(),
+		Master:                      "",
+		KubeConfig:                  "",
+		Sources:                     []string{"service"},
+		Namespace:                   "",
+		FQDNTemplate:                "",
+		Compatibility:               "",
+		StaticRecordsConfigMap:      "",
+		StaticRecordsSecret:         "",
+		StaticRecordsKey:            "records.yaml",
+		EnableHealthChecks:          false,
+		HealthCheckTimeout:          5 * time.Second,
+		HookURLs:                    []string{""},
+		HookTimeout:                 10 * time.Second,
+		Provider:                    "google",
+		AdditionalProviders:         nil,
+		ProviderDomainFilters:       []string{""},
+		ProviderCacheTime:           0,
+		ProviderMaxRetries:          3,
+		ProviderRetryDelay:          time.Second,
+		ProviderQPS:                 0,
+		ProviderBurst:               1,
+		GoogleProject:               "",
+		DomainFilter:                []string{""},
+		ExcludeDomains:              []string{""},
+		RegexDomainFilter:           "",
+		RegexDomainExclusion:        "",
+		ZoneIDFilter:                []string{""},
+		AWSZoneType:                 "",
+		AWSAssumeRole:               "",
+		AWSAssumeRoleExternalID:     "",
+		AWSZoneRoles:                []string{""},
+		AzureConfigFile:             "/etc/kubernetes/azure.json",
+		AzureResourceGroup:          "",
+		CloudflareProxied:           false,
+		DnsimpleSandbox:             false,
+		OVHEndpoint:                 "ovh-eu",
+		InfobloxGridHost:            "",
+		InfobloxWapiPort:            443,
+		InfobloxWapiUsername:        "admin",
+		InfobloxWapiPassword:        "",
+		InfobloxWapiVersion:         "2.3.1",
+		InfobloxSSLVerify:           true,
+		OCIConfigFile:               "/etc/kubernetes/oci.yaml",
+		OCIAuthInstancePrincipal:    false,
+		OCICompartmentOCID:          "",
+		ExoscaleEndpoint:            "https://api.exoscale.ch/dns",
+		ExoscaleAPIKey:              "",
+		ExoscaleAPISecret:           "",
+		PiholeServer:                "",
+		PiholeAPIToken:              "",
+		GoDaddyAPIKey:               "",
+		GoDaddyAPISecret:            "",
+		GoDaddyOTE:                  false,
+		GandiPAT:                    "",
+		TransIPAccountName:          "",
+		TransIPPrivateKeyFile:       "",
+		InMemoryZones:               []string{""},
+		WebhookProviderURL:          "http://localhost:8888",
+		Policy:                      "sync",
+		ConflictResolution:          "per-resource",
+		CNAMEConflictPreference:     "address",
+		ManagedRecordTypes:          nil,
+		DefaultTTL:                  0,
+		MinTTL:                      0,
+		Registry:                    "txt",
+		TXTOwnerID:                  "default",
+		TXTPrefix:                   "",
+		TXTSuffix:                   "",
+		TXTWildcardReplacement:      "",
+		TXTCacheInterval:            0,
+		TXTEncryptAESKey:            "",
+		TXTDecryptAESKeys:           []string{""},
+		TXTWriteLegacy:              true,
+		DynamoDBTable:               "external-dns",
+		DynamoDBRegion:              "",
+		NoopRegistryConfirm:         false,
+		MigrateRegistryFrom:         "",
+		ExportRecords:               "",
+		ImportRecords:               "",
+		RecordsFormat:               "zone",
+		Interval:                    time.Minute,
+		IntervalJitter:              0,
+		MaxChangesPerSync:           0,
+		LivenessIntervals:           5,
+		ShutdownTimeout:             20 * time.Second,
+		FinalSync:                   false,
+		Once:                        false,
+		LeaderElection:              false,
+		LeaderElectionNamespace:     "default",
+		LeaderElectionID:            "external-dns",
+		LeaderElectionLeaseDuration: 15 * time.Second,
+		Events:                      false,
+		DryRun:                      false,
+		DryRunFormat:                "text",
+		LogFormat:                   "text",
+		MetricsAddress:              ":7979",
+		EnablePprof:                 false,
+		TracingEndpoint:             "",
+		LogLevel:                    logrus.InfoLevel.String(),
+		IPFamilyPolicy:              "dual",
 	}
 
 	overriddenConfig = &Config{
-		Master:               "http://127.0.0.1:8080",
-		KubeConfig:           "/some/path",
-		Sources:              []string{"service", "ingress"},
-		Namespace:            "namespace",
-		FQDNTemplate:         "{{.Name}}.service.example.com",
-		Compatibility:        "mate",
-		Provider:             "google",
-		GoogleProject:        "project",
-		DomainFilter:         []string{"example.org", "company.com"},
-		ZoneIDFilter:         []string{"/hostedzone/ZTST1", "/hostedzone/ZTST2"},
-		AWSZoneType:          "private",
-		AzureConfigFile:      "azure.json",
-		AzureResourceGroup:   "arg",
-		CloudflareProxied:    true,
-		InfobloxGridHost:     "127.0.0.1",
-		InfobloxWapiPort:     8443,
-		InfobloxWapiUsername: "infoblox",
-		InfobloxWapiPassword: "infoblox",
-		InfobloxWapiVersion:  "2.6.1",
-		InfobloxSSLVerify:    false,
-		InMemoryZones:        []string{"example.org", "company.com"},
-		Policy:               "upsert-only",
-		Registry:             "noop",
-		TXTOwnerID:           "owner-1",
-		TXTPrefix:            "associated-txt-record",
-		Interval:             10 * time.Minute,
-		Once:                 true,
-		DryRun:               true,
-		LogFormat:            "json",
-		MetricsAddress:       "127.0.0.1:9099",
-		LogLevel:             logrus.DebugLevel.String(),
+		Master:                      "http://127.0.0.1:8080",
+		KubeConfig:                  "/some/path",
+		Sources:                     []string{"service", "ingress"},
+		Namespace:                   "namespace",
+		FQDNTemplate:                "{{.Name}}.service.example.com",
+		Compatibility:               "mate",
+		StaticRecordsConfigMap:      "kube-system/dns-records",
+		StaticRecordsSecret:         "",
+		StaticRecordsKey:            "dns.yaml",
+		EnableHealthChecks:          true,
+		HealthCheckTimeout:          10 * time.Second,
+		HookURLs:                    []string{"http://localhost:8889/hook"},
+		HookTimeout:                 30 * time.Second,
+		Provider:                    "google",
+		AdditionalProviders:         []string{"inmemory"},
+		ProviderDomainFilters:       []string{"inmemory=internal.example.org"},
+		ProviderCacheTime:           5 * time.Minute,
+		ProviderMaxRetries:          5,
+		ProviderRetryDelay:          2 * time.Second,
+		ProviderQPS:                 0.5,
+		ProviderBurst:               5,
+		GoogleProject:               "project",
+		DomainFilter:                []string{"example.org", "company.com"},
+		ExcludeDomains:              []string{"corp.example.org", "sandbox.company.com"},
+		RegexDomainFilter:           `\.prod\.`,
+		RegexDomainExclusion:        `^sandbox\.`,
+		ZoneIDFilter:                []string{"/hostedzone/ZTST1", "/hostedzone/ZTST2"},
+		AWSZoneType:                 "private",
+		AWSAssumeRole:               "arn:aws:iam::123456789012:role/external-dns",
+		AWSAssumeRoleExternalID:     "external-dns",
+		AWSZoneRoles:                []string{"/hostedzone/ZTST1=arn:aws:iam::123456789012:role/dns", "ZTST2=arn:aws:iam::210987654321:role/dns"},
+		AzureConfigFile:             "azure.json",
+		AzureResourceGroup:          "arg",
+		CloudflareProxied:           true,
+		DnsimpleSandbox:             true,
+		OVHEndpoint:                 "ovh-ca",
+		InfobloxGridHost:            "127.0.0.1",
+		InfobloxWapiPort:            8443,
+		InfobloxWapiUsername:        "infoblox",
+		InfobloxWapiPassword:        "infoblox",
+		InfobloxWapiVersion:         "2.6.1",
+		InfobloxSSLVerify:           false,
+		OCIConfigFile:               "oci.yaml",
+		OCIAuthInstancePrincipal:    true,
+		OCICompartmentOCID:          "ocid1.compartment.oc1..test",
+		ExoscaleEndpoint:            "https://api.example.com/dns",
+		ExoscaleAPIKey:              "EXO123",
+		ExoscaleAPISecret:           "secret",
+		PiholeServer:                "http://pi.hole",
+		PiholeAPIToken:              "pihole-token",
+		GoDaddyAPIKey:               "godaddy-key",
+		GoDaddyAPISecret:            "godaddy-secret",
+		GoDaddyOTE:                  true,
+		GandiPAT:                    "gandi-token",
+		TransIPAccountName:          "transip",
+		TransIPPrivateKeyFile:       "/path/to/transip.key",
+		InMemoryZones:               []string{"example.org", "company.com"},
+		WebhookProviderURL:          "http://127.0.0.1:9999",
+		Policy:                      "upsert-only",
+		ConflictResolution:          "priority",
+		CNAMEConflictPreference:     "cname",
+		ManagedRecordTypes:          []string{"A", "CNAME"},
+		DefaultTTL:                  300,
+		MinTTL:                      60,
+		Registry:                    "noop",
+		TXTOwnerID:                  "owner-1",
+		TXTPrefix:                   "associated-txt-record",
+		TXTSuffix:                   "-%{record_type}-txt",
+		TXTWildcardReplacement:      "wildcard",
+		TXTCacheInterval:            5 * time.Minute,
+		TXTEncryptAESKey:            "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+		TXTDecryptAESKeys:           []string{"ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="},
+		TXTWriteLegacy:              false,
+		DynamoDBTable:               "ownership",
+		DynamoDBRegion:              "eu-central-1",
+		NoopRegistryConfirm:         true,
+		MigrateRegistryFrom:         "txt",
+		ExportRecords:               "records.zone",
+		ImportRecords:               "backup.json",
+		RecordsFormat:               "json",
+		Interval:                    10 * time.Minute,
+		IntervalJitter:              30 * time.Second,
+		MaxChangesPerSync:           100,
+		LivenessIntervals:           10,
+		ShutdownTimeout:             time.Minute,
+		FinalSync:                   true,
+		Once:                        true,
+		LeaderElection:              true,
+		LeaderElectionNamespace:     "kube-system",
+		LeaderElectionID:            "external-dns-public",
+		LeaderElectionLeaseDuration: 30 * time.Second,
+		Events:                      true,
+		DryRun:                      true,
+		DryRunFormat:                "json",
+		LogFormat:                   "json",
+		MetricsAddress:              "127.0.0.1:9099",
+		EnablePprof:                 true,
+		TracingEndpoint:             "http://otel-collector:4318",
+		LogLevel:                    logrus.DebugLevel.String(),
+		IPFamilyPolicy:              "ipv4",
 	}
 )
 
@@ -128,6 +266,8 @@ func TestParseFlags(t *testing.T) {
 				"--azure-config-file=azure.json",
 				"--azure-resource-group=arg",
 				"--cloudflare-proxied",
+				"--dnsimple-sandbox",
+				"--ovh-endpoint=ovh-ca",
 				"--infoblox-grid-host=127.0.0.1",
 				"--infoblox-wapi-port=8443",
 				"--infoblox-wapi-username=infoblox",
@@ -135,6 +275,7 @@ func TestParseFlags(t *testing.T) {
 				"--infoblox-wapi-version=2.6.1",
 				"--inmemory-zone=example.org",
 				"--inmemory-zone=company.com",
+				"--webhook-provider-url=http://127.0.0.1:9999",
 				"--no-infoblox-ssl-verify",
 				"--domain-filter=example.org",
 				"--domain-filter=company.com",
@@ -150,6 +291,74 @@ func TestParseFlags(t *testing.T) {
 				"--dry-run",
 				"--log-format=json",
 				"--metrics-address=127.0.0.1:9099",
+				"--provider-cache-time=5m",
+				"--provider-max-retries=5",
+				"--provider-retry-delay=2s",
+				"--aws-assume-role=arn:aws:iam::123456789012:role/external-dns",
+				"--aws-assume-role-external-id=external-dns",
+				"--aws-zone-role=/hostedzone/ZTST1=arn:aws:iam::123456789012:role/dns",
+				"--aws-zone-role=ZTST2=arn:aws:iam::210987654321:role/dns",
+				"--oci-config-file=oci.yaml",
+				"--oci-auth-instance-principal",
+				"--oci-compartment-ocid=ocid1.compartment.oc1..test",
+				"--exoscale-endpoint=https://api.example.com/dns",
+				"--exoscale-apikey=EXO123",
+				"--exoscale-apisecret=secret",
+				"--pihole-server=http://pi.hole",
+				"--pihole-api-token=pihole-token",
+				"--godaddy-api-key=godaddy-key",
+				"--godaddy-api-secret=godaddy-secret",
+				"--godaddy-api-ote",
+				"--gandi-pat=gandi-token",
+				"--transip-account=transip",
+				"--transip-keyfile=/path/to/transip.key",
+				"--static-records-configmap=kube-system/dns-records",
+				"--static-records-key=dns.yaml",
+				"--txt-suffix=-%{record_type}-txt",
+				"--txt-encrypt-aes-key=MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+				"--txt-decrypt-aes-key=ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=",
+				"--dynamodb-table=ownership",
+				"--dynamodb-region=eu-central-1",
+				"--noop-registry-confirm",
+				"--txt-wildcard-replacement=wildcard",
+				"--txt-cache-interval=5m",
+				"--migrate-registry-from=txt",
+				"--dry-run-format=json",
+				"--conflict-resolution=priority",
+				"--regex-domain-filter=\\.prod\\.",
+				"--regex-domain-exclusion=^sandbox\\.",
+				"--exclude-domains=corp.example.org",
+				"--exclude-domains=sandbox.company.com",
+				"--default-ttl=300",
+				"--min-ttl=60",
+				"--interval-jitter=30s",
+				"--leader-election",
+				"--leader-election-namespace=kube-system",
+				"--leader-election-id=external-dns-public",
+				"--leader-election-lease-duration=30s",
+				"--events",
+				"--max-changes-per-sync=100",
+				"--managed-record-types=A",
+				"--managed-record-types=CNAME",
+				"--cname-conflict-preference=cname",
+				"--liveness-intervals=10",
+				"--enable-pprof",
+				"--tracing-endpoint=http://otel-collector:4318",
+				"--shutdown-timeout=1m",
+				"--final-sync",
+				"--export-records=records.zone",
+				"--import-records=backup.json",
+				"--records-format=json",
+				"--enable-health-checks",
+				"--health-check-timeout=10s",
+				"--additional-provider=inmemory",
+				"--provider-domain-filter=inmemory=internal.example.org",
+				"--ip-family-policy=ipv4",
+				"--hook-url=http://localhost:8889/hook",
+				"--hook-timeout=30s",
+				"--provider-qps=0.5",
+				"--provider-burst=5",
+				"--no-txt-write-legacy",
 				"--log-level=debug",
 			},
 			envVars:  map[string]string{},
@@ -159,37 +368,105 @@ func TestParseFlags(t *testing.T) {
 			title: "override everything via environment variables",
 			args:  []string{},
 			envVars: map[string]string{
-				"EXTERNAL_DNS_MASTER":                 "http://127.0.0.1:8080",
-				"EXTERNAL_DNS_KUBECONFIG":             "/some/path",
-				"EXTERNAL_DNS_SOURCE":                 "service\ningress",
-				"EXTERNAL_DNS_NAMESPACE":              "namespace",
-				"EXTERNAL_DNS_FQDN_TEMPLATE":          "{{.Name}}.service.example.com",
-				"EXTERNAL_DNS_COMPATIBILITY":          "mate",
-				"EXTERNAL_DNS_PROVIDER":               "google",
-				"EXTERNAL_DNS_GOOGLE_PROJECT":         "project",
-				"EXTERNAL_DNS_AZURE_CONFIG_FILE":      "azure.json",
-				"EXTERNAL_DNS_AZURE_RESOURCE_GROUP":   "arg",
-				"EXTERNAL_DNS_CLOUDFLARE_PROXIED":     "1",
-				"EXTERNAL_DNS_INFOBLOX_GRID_HOST":     "127.0.0.1",
-				"EXTERNAL_DNS_INFOBLOX_WAPI_PORT":     "8443",
-				"EXTERNAL_DNS_INFOBLOX_WAPI_USERNAME": "infoblox",
-				"EXTERNAL_DNS_INFOBLOX_WAPI_PASSWORD": "infoblox",
-				"EXTERNAL_DNS_INFOBLOX_WAPI_VERSION":  "2.6.1",
-				"EXTERNAL_DNS_INFOBLOX_SSL_VERIFY":    "0",
-				"EXTERNAL_DNS_INMEMORY_ZONE":          "example.org\ncompany.com",
-				"EXTERNAL_DNS_DOMAIN_FILTER":          "example.org\ncompany.com",
-				"EXTERNAL_DNS_ZONE_ID_FILTER":         "/hostedzone/ZTST1\n/hostedzone/ZTST2",
-				"EXTERNAL_DNS_AWS_ZONE_TYPE":          "private",
-				"EXTERNAL_DNS_POLICY":                 "upsert-only",
-				"EXTERNAL_DNS_REGISTRY":               "noop",
-				"EXTERNAL_DNS_TXT_OWNER_ID":           "owner-1",
-				"EXTERNAL_DNS_TXT_PREFIX":             "associated-txt-record",
-				"EXTERNAL_DNS_INTERVAL":               "10m",
-				"EXTERNAL_DNS_ONCE":                   "1",
-				"EXTERNAL_DNS_DRY_RUN":                "1",
-				"EXTERNAL_DNS_LOG_FORMAT":             "json",
-				"EXTERNAL_DNS_METRICS_ADDRESS":        "127.0.0.1:9099",
-				"EXTERNAL_DNS_LOG_LEVEL":              "debug",
+				"EXTERNAL_DNS_MASTER":                         "http://127.0.0.1:8080",
+				"EXTERNAL_DNS_KUBECONFIG":                     "/some/path",
+				"EXTERNAL_DNS_SOURCE":                         "service\ningress",
+				"EXTERNAL_DNS_NAMESPACE":                      "namespace",
+				"EXTERNAL_DNS_FQDN_TEMPLATE":                  "{{.Name}}.service.example.com",
+				"EXTERNAL_DNS_COMPATIBILITY":                  "mate",
+				"EXTERNAL_DNS_PROVIDER":                       "google",
+				"EXTERNAL_DNS_GOOGLE_PROJECT":                 "project",
+				"EXTERNAL_DNS_AZURE_CONFIG_FILE":              "azure.json",
+				"EXTERNAL_DNS_AZURE_RESOURCE_GROUP":           "arg",
+				"EXTERNAL_DNS_CLOUDFLARE_PROXIED":             "1",
+				"EXTERNAL_DNS_DNSIMPLE_SANDBOX":               "1",
+				"EXTERNAL_DNS_OVH_ENDPOINT":                   "ovh-ca",
+				"EXTERNAL_DNS_INFOBLOX_GRID_HOST":             "127.0.0.1",
+				"EXTERNAL_DNS_INFOBLOX_WAPI_PORT":             "8443",
+				"EXTERNAL_DNS_INFOBLOX_WAPI_USERNAME":         "infoblox",
+				"EXTERNAL_DNS_INFOBLOX_WAPI_PASSWORD":         "infoblox",
+				"EXTERNAL_DNS_INFOBLOX_WAPI_VERSION":          "2.6.1",
+				"EXTERNAL_DNS_INFOBLOX_SSL_VERIFY":            "0",
+				"EXTERNAL_DNS_INMEMORY_ZONE":                  "example.org\ncompany.com",
+				"EXTERNAL_DNS_WEBHOOK_PROVIDER_URL":           "http://127.0.0.1:9999",
+				"EXTERNAL_DNS_DOMAIN_FILTER":                  "example.org\ncompany.com",
+				"EXTERNAL_DNS_ZONE_ID_FILTER":                 "/hostedzone/ZTST1\n/hostedzone/ZTST2",
+				"EXTERNAL_DNS_AWS_ZONE_TYPE":                  "private",
+				"EXTERNAL_DNS_POLICY":                         "upsert-only",
+				"EXTERNAL_DNS_REGISTRY":                       "noop",
+				"EXTERNAL_DNS_TXT_OWNER_ID":                   "owner-1",
+				"EXTERNAL_DNS_TXT_PREFIX":                     "associated-txt-record",
+				"EXTERNAL_DNS_INTERVAL":                       "10m",
+				"EXTERNAL_DNS_ONCE":                           "1",
+				"EXTERNAL_DNS_DRY_RUN":                        "1",
+				"EXTERNAL_DNS_LOG_FORMAT":                     "json",
+				"EXTERNAL_DNS_METRICS_ADDRESS":                "127.0.0.1:9099",
+				"EXTERNAL_DNS_PROVIDER_CACHE_TIME":            "5m",
+				"EXTERNAL_DNS_PROVIDER_MAX_RETRIES":           "5",
+				"EXTERNAL_DNS_PROVIDER_RETRY_DELAY":           "2s",
+				"EXTERNAL_DNS_AWS_ASSUME_ROLE":                "arn:aws:iam::123456789012:role/external-dns",
+				"EXTERNAL_DNS_AWS_ASSUME_ROLE_EXTERNAL_ID":    "external-dns",
+				"EXTERNAL_DNS_AWS_ZONE_ROLE":                  "/hostedzone/ZTST1=arn:aws:iam::123456789012:role/dns\nZTST2=arn:aws:iam::210987654321:role/dns",
+				"EXTERNAL_DNS_OCI_CONFIG_FILE":                "oci.yaml",
+				"EXTERNAL_DNS_OCI_AUTH_INSTANCE_PRINCIPAL":    "1",
+				"EXTERNAL_DNS_OCI_COMPARTMENT_OCID":           "ocid1.compartment.oc1..test",
+				"EXTERNAL_DNS_EXOSCALE_ENDPOINT":              "https://api.example.com/dns",
+				"EXTERNAL_DNS_EXOSCALE_APIKEY":                "EXO123",
+				"EXTERNAL_DNS_EXOSCALE_APISECRET":             "secret",
+				"EXTERNAL_DNS_PIHOLE_SERVER":                  "http://pi.hole",
+				"EXTERNAL_DNS_PIHOLE_API_TOKEN":               "pihole-token",
+				"EXTERNAL_DNS_GODADDY_API_KEY":                "godaddy-key",
+				"EXTERNAL_DNS_GODADDY_API_SECRET":             "godaddy-secret",
+				"EXTERNAL_DNS_GODADDY_API_OTE":                "1",
+				"EXTERNAL_DNS_GANDI_PAT":                      "gandi-token",
+				"EXTERNAL_DNS_TRANSIP_ACCOUNT":                "transip",
+				"EXTERNAL_DNS_TRANSIP_KEYFILE":                "/path/to/transip.key",
+				"EXTERNAL_DNS_STATIC_RECORDS_CONFIGMAP":       "kube-system/dns-records",
+				"EXTERNAL_DNS_STATIC_RECORDS_KEY":             "dns.yaml",
+				"EXTERNAL_DNS_TXT_SUFFIX":                     "-%{record_type}-txt",
+				"EXTERNAL_DNS_TXT_ENCRYPT_AES_KEY":            "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+				"EXTERNAL_DNS_TXT_DECRYPT_AES_KEY":            "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=",
+				"EXTERNAL_DNS_DYNAMODB_TABLE":                 "ownership",
+				"EXTERNAL_DNS_DYNAMODB_REGION":                "eu-central-1",
+				"EXTERNAL_DNS_NOOP_REGISTRY_CONFIRM":          "1",
+				"EXTERNAL_DNS_TXT_WILDCARD_REPLACEMENT":       "wildcard",
+				"EXTERNAL_DNS_TXT_CACHE_INTERVAL":             "5m",
+				"EXTERNAL_DNS_MIGRATE_REGISTRY_FROM":          "txt",
+				"EXTERNAL_DNS_DRY_RUN_FORMAT":                 "json",
+				"EXTERNAL_DNS_CONFLICT_RESOLUTION":            "priority",
+				"EXTERNAL_DNS_REGEX_DOMAIN_FILTER":            "\\.prod\\.",
+				"EXTERNAL_DNS_REGEX_DOMAIN_EXCLUSION":         "^sandbox\\.",
+				"EXTERNAL_DNS_EXCLUDE_DOMAINS":                "corp.example.org\nsandbox.company.com",
+				"EXTERNAL_DNS_DEFAULT_TTL":                    "300",
+				"EXTERNAL_DNS_MIN_TTL":                        "60",
+				"EXTERNAL_DNS_INTERVAL_JITTER":                "30s",
+				"EXTERNAL_DNS_LEADER_ELECTION":                "1",
+				"EXTERNAL_DNS_LEADER_ELECTION_NAMESPACE":      "kube-system",
+				"EXTERNAL_DNS_LEADER_ELECTION_ID":             "external-dns-public",
+				"EXTERNAL_DNS_LEADER_ELECTION_LEASE_DURATION": "30s",
+				"EXTERNAL_DNS_EVENTS":                         "1",
+				"EXTERNAL_DNS_MAX_CHANGES_PER_SYNC":           "100",
+				"EXTERNAL_DNS_MANAGED_RECORD_TYPES":           "A\nCNAME",
+				"EXTERNAL_DNS_CNAME_CONFLICT_PREFERENCE":      "cname",
+				"EXTERNAL_DNS_LIVENESS_INTERVALS":             "10",
+				"EXTERNAL_DNS_ENABLE_PPROF":                   "1",
+				"EXTERNAL_DNS_TRACING_ENDPOINT":               "http://otel-collector:4318",
+				"EXTERNAL_DNS_SHUTDOWN_TIMEOUT":               "1m",
+				"EXTERNAL_DNS_FINAL_SYNC":                     "1",
+				"EXTERNAL_DNS_EXPORT_RECORDS":                 "records.zone",
+				"EXTERNAL_DNS_IMPORT_RECORDS":                 "backup.json",
+				"EXTERNAL_DNS_RECORDS_FORMAT":                 "json",
+				"EXTERNAL_DNS_ENABLE_HEALTH_CHECKS":           "1",
+				"EXTERNAL_DNS_HEALTH_CHECK_TIMEOUT":           "10s",
+				"EXTERNAL_DNS_ADDITIONAL_PROVIDER":            "inmemory",
+				"EXTERNAL_DNS_PROVIDER_DOMAIN_FILTER":         "inmemory=internal.example.org",
+				"EXTERNAL_DNS_IP_FAMILY_POLICY":               "ipv4",
+				"EXTERNAL_DNS_HOOK_URL":                       "http://localhost:8889/hook",
+				"EXTERNAL_DNS_HOOK_TIMEOUT":                   "30s",
+				"EXTERNAL_DNS_PROVIDER_QPS":                   "0.5",
+				"EXTERNAL_DNS_PROVIDER_BURST":                 "5",
+				"EXTERNAL_DNS_TXT_WRITE_LEGACY":               "0",
+				"EXTERNAL_DNS_LOG_LEVEL":                      "debug",
 			},
 			expected: overriddenConfig,
 		},
@@ -228,10 +505,26 @@ func TestPasswordsNotLogged(t *testing.T) {
 	cfg := Config{
 		DynPassword:          "dyn-pass",
 		InfobloxWapiPassword: "infoblox-pass",
+		AkamaiClientSecret:   "akamai-secret",
+		AkamaiAccessToken:    "akamai-token",
+		ExoscaleAPISecret:    "exoscale-secret",
+		PiholeAPIToken:       "pihole-token",
+		GoDaddyAPISecret:     "godaddy-secret",
+		GandiPAT:             "gandi-token",
+		TXTEncryptAESKey:     "txt-encrypt-key",
+		TXTDecryptAESKeys:    []string{"txt-decrypt-key"},
 	}
 
 	s := cfg.String()
 
 	assert.False(t, strings.Contains(s, "dyn-pass"))
 	assert.False(t, strings.Contains(s, "infoblox-pass"))
+	assert.False(t, strings.Contains(s, "akamai-secret"))
+	assert.False(t, strings.Contains(s, "akamai-token"))
+	assert.False(t, strings.Contains(s, "exoscale-secret"))
+	assert.False(t, strings.Contains(s, "pihole-token"))
+	assert.False(t, strings.Contains(s, "godaddy-secret"))
+	assert.False(t, strings.Contains(s, "gandi-token"))
+	assert.False(t, strings.Contains(s, "txt-encrypt-key"))
+	assert.False(t, strings.Contains(s, "txt-decrypt-key"))
 }
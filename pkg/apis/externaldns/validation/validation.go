@@ -19,6 +19,10 @@ package validation
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/kubernetes-incubator/external-dns/pkg/apis/externaldns"
 )
@@ -36,15 +40,160 @@ func ValidateConfig(cfg *externaldns.Config) error {
 		return errors.New("no provider specified")
 	}
 
+	if _, err := regexp.Compile(cfg.RegexDomainFilter); err != nil {
+		return fmt.Errorf("invalid --regex-domain-filter: %v", err)
+	}
+	if _, err := regexp.Compile(cfg.RegexDomainExclusion); err != nil {
+		return fmt.Errorf("invalid --regex-domain-exclusion: %v", err)
+	}
+
+	if cfg.IntervalJitter < 0 {
+		return errors.New("--interval-jitter must not be negative")
+	}
+	if cfg.MaxChangesPerSync < 0 {
+		return errors.New("--max-changes-per-sync must not be negative")
+	}
+	if cfg.LivenessIntervals < 0 {
+		return errors.New("--liveness-intervals must not be negative")
+	}
+	if cfg.ShutdownTimeout < 0 {
+		return errors.New("--shutdown-timeout must not be negative")
+	}
+	if cfg.LeaderElection && cfg.LeaderElectionLeaseDuration < time.Second {
+		return errors.New("--leader-election-lease-duration must be at least one second")
+	}
+	if cfg.EnableHealthChecks && cfg.HealthCheckTimeout <= 0 {
+		return errors.New("--health-check-timeout must be positive")
+	}
+	if cfg.ProviderQPS < 0 {
+		return errors.New("--provider-qps must not be negative")
+	}
+	if cfg.ProviderQPS > 0 && cfg.ProviderBurst < 1 {
+		return errors.New("--provider-burst must be at least 1")
+	}
+	for _, hookURL := range cfg.HookURLs {
+		if hookURL == "" {
+			continue
+		}
+		if u, err := url.Parse(hookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("--hook-url %q must be an http or https URL", hookURL)
+		}
+		if cfg.HookTimeout <= 0 {
+			return errors.New("--hook-timeout must be positive")
+		}
+	}
+	if cfg.DefaultTTL < 0 || cfg.MinTTL < 0 {
+		return errors.New("--default-ttl and --min-ttl must not be negative")
+	}
+	if cfg.DefaultTTL > 0 && cfg.DefaultTTL < cfg.MinTTL {
+		return errors.New("--default-ttl must not be lower than --min-ttl")
+	}
+
+	// Static records source specific validations
+	for _, source := range cfg.Sources {
+		if source != "static-records" {
+			continue
+		}
+		if (cfg.StaticRecordsConfigMap == "") == (cfg.StaticRecordsSecret == "") {
+			return errors.New("exactly one of --static-records-configmap or --static-records-secret must be specified when using the static-records source")
+		}
+		for _, ref := range []string{cfg.StaticRecordsConfigMap, cfg.StaticRecordsSecret} {
+			if ref == "" {
+				continue
+			}
+			parts := strings.SplitN(ref, "/", 2)
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				return fmt.Errorf("invalid static records reference %q, expected namespace/name", ref)
+			}
+		}
+		if cfg.StaticRecordsKey == "" {
+			return errors.New("no static records key specified")
+		}
+	}
+
+	providers := map[string]bool{cfg.Provider: true}
+	for _, provider := range cfg.AdditionalProviders {
+		if providers[provider] {
+			return fmt.Errorf("provider %s is configured more than once", provider)
+		}
+		providers[provider] = true
+	}
+	for _, filter := range cfg.ProviderDomainFilters {
+		if filter == "" {
+			continue
+		}
+		parts := strings.SplitN(filter, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return fmt.Errorf("invalid provider domain filter %q, expected PROVIDER=DOMAIN", filter)
+		}
+		if !providers[parts[0]] {
+			return fmt.Errorf("provider domain filter %q refers to a provider which isn't configured", filter)
+		}
+	}
+	for _, provider := range append([]string{cfg.Provider}, cfg.AdditionalProviders...) {
+		if err := validateProvider(cfg, provider); err != nil {
+			return err
+		}
+	}
+
+	if cfg.TXTPrefix != "" && cfg.TXTSuffix != "" {
+		return errors.New("--txt-prefix and --txt-suffix are mutually exclusive")
+	}
+	if strings.ContainsAny(cfg.TXTWildcardReplacement, ".*") {
+		return errors.New("--txt-wildcard-replacement must be a single label without wildcard")
+	}
+	if (cfg.Registry == "dynamodb" || cfg.MigrateRegistryFrom == "dynamodb") && cfg.DynamoDBTable == "" {
+		return errors.New("no DynamoDB table specified")
+	}
+	if cfg.Registry == "noop" && !cfg.NoopRegistryConfirm {
+		return errors.New("the noop registry doesn't track ownership and modifies any record in the managed zones, confirm its use with --noop-registry-confirm")
+	}
+	if cfg.Registry == "aws-sd" && cfg.Provider != "aws-sd" {
+		return errors.New("the aws-sd registry requires the aws-sd provider")
+	}
+	if cfg.MigrateRegistryFrom != "" {
+		if cfg.Registry != "txt" && cfg.Registry != "dynamodb" {
+			return errors.New("--migrate-registry-from requires --registry to be txt or dynamodb")
+		}
+		if cfg.MigrateRegistryFrom == cfg.Registry {
+			return errors.New("--migrate-registry-from must differ from --registry")
+		}
+	}
+	if cfg.ExportRecords != "" && cfg.ImportRecords != "" {
+		return errors.New("--export-records and --import-records are mutually exclusive")
+	}
+	return nil
+}
+
+// validateProvider validates the config specific to a provider
+func validateProvider(cfg *externaldns.Config, provider string) error {
+	// AWS provider specific validations
+	if provider == "aws" {
+		hasZoneRoles := false
+		for _, zoneRole := range cfg.AWSZoneRoles {
+			if zoneRole == "" {
+				continue
+			}
+			parts := strings.SplitN(zoneRole, "=", 2)
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				return fmt.Errorf("invalid AWS zone role %q, expected ZONEID=ROLEARN", zoneRole)
+			}
+			hasZoneRoles = true
+		}
+		if cfg.AWSAssumeRoleExternalID != "" && cfg.AWSAssumeRole == "" && !hasZoneRoles {
+			return errors.New("AWS assume role external ID specified without a role to assume")
+		}
+	}
+
 	// Azure provider specific validations
-	if cfg.Provider == "azure" {
+	if provider == "azure" {
 		if cfg.AzureConfigFile == "" {
 			return errors.New("no Azure config file specified")
 		}
 	}
 
 	// Infoblox provider specific validations
-	if cfg.Provider == "infoblox" {
+	if provider == "infoblox" {
 		if cfg.InfobloxGridHost == "" {
 			return errors.New("no Infoblox Grid Manager host specified")
 		}
@@ -53,7 +202,58 @@ func ValidateConfig(cfg *externaldns.Config) error {
 		}
 	}
 
-	if cfg.Provider == "dyn" {
+	if provider == "akamai" {
+		if cfg.AkamaiServiceConsumerDomain == "" {
+			return errors.New("no Akamai service consumer domain specified")
+		}
+		if cfg.AkamaiClientToken == "" || cfg.AkamaiClientSecret == "" || cfg.AkamaiAccessToken == "" {
+			return errors.New("incomplete Akamai EdgeGrid credentials specified")
+		}
+	}
+
+	if provider == "oci" {
+		if cfg.OCIAuthInstancePrincipal && cfg.OCICompartmentOCID == "" {
+			return errors.New("no OCI compartment specified, required when authenticating as instance principal")
+		}
+		if !cfg.OCIAuthInstancePrincipal && cfg.OCIConfigFile == "" {
+			return errors.New("no OCI config file specified")
+		}
+	}
+
+	if provider == "exoscale" {
+		if cfg.ExoscaleAPIKey == "" || cfg.ExoscaleAPISecret == "" {
+			return errors.New("no Exoscale API key and secret specified")
+		}
+	}
+
+	if provider == "pihole" {
+		if cfg.PiholeServer == "" {
+			return errors.New("no Pi-hole server specified")
+		}
+	}
+
+	if provider == "godaddy" {
+		if cfg.GoDaddyAPIKey == "" || cfg.GoDaddyAPISecret == "" {
+			return errors.New("no GoDaddy API key and secret specified")
+		}
+	}
+
+	if provider == "gandi" {
+		if cfg.GandiPAT == "" {
+			return errors.New("no Gandi personal access token specified")
+		}
+	}
+
+	if provider == "transip" {
+		if cfg.TransIPAccountName == "" {
+			return errors.New("no TransIP account name specified")
+		}
+		if cfg.TransIPPrivateKeyFile == "" {
+			return errors.New("no TransIP private key file specified")
+		}
+	}
+
+	if provider == "dyn" {
 		if cfg.DynUsername == "" {
 			return errors.New("no Dyn username specified")
 		}
@@ -18,6 +18,7 @@ package validation
 
 import (
 	"testing"
+	"time"
 
 	"github.com/kubernetes-incubator/external-dns/pkg/apis/externaldns"
 
@@ -116,3 +117,363 @@ func TestValidateGoodDynConfig(t *testing.T) {
 		assert.Nil(t, err, "Configuration should be valid, got this error instead", err)
 	}
 }
+
+func TestValidateAkamaiConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Provider = "akamai"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.AkamaiServiceConsumerDomain = "akab-xxx.luna.akamaiapis.net"
+	cfg.AkamaiClientToken = "client-token"
+	cfg.AkamaiClientSecret = "client-secret"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.AkamaiAccessToken = "access-token"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateAWSConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Provider = "aws"
+	cfg.AWSZoneRoles = []string{""}
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.AWSAssumeRoleExternalID = "external-dns"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.AWSAssumeRole = "arn:aws:iam::123456789012:role/external-dns"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	for _, zoneRole := range []string{"ZTST1", "ZTST1=", "=arn:aws:iam::123456789012:role/dns"} {
+		cfg.AWSZoneRoles = []string{zoneRole}
+		assert.Error(t, ValidateConfig(cfg), zoneRole)
+	}
+
+	cfg.AWSAssumeRole = ""
+	cfg.AWSZoneRoles = []string{"/hostedzone/ZTST1=arn:aws:iam::123456789012:role/dns"}
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateOCIConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Provider = "oci"
+	cfg.OCIConfigFile = "/etc/kubernetes/oci.yaml"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.OCIConfigFile = ""
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.OCIAuthInstancePrincipal = true
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.OCICompartmentOCID = "ocid1.compartment.oc1..test"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateExoscaleConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Provider = "exoscale"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.ExoscaleAPIKey = "EXO123"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.ExoscaleAPISecret = "secret"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidatePiholeConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Provider = "pihole"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.PiholeServer = "http://pi.hole"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateGoDaddyConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Provider = "godaddy"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.GoDaddyAPIKey = "godaddy-key"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.GoDaddyAPISecret = "godaddy-secret"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateGandiConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Provider = "gandi"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.GandiPAT = "gandi-token"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateTransIPConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Provider = "transip"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.TransIPAccountName = "transip"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.TransIPPrivateKeyFile = "/path/to/transip.key"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateStaticRecordsConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"service", "static-records"}
+	cfg.StaticRecordsKey = "records.yaml"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.StaticRecordsConfigMap = "dns-records"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.StaticRecordsConfigMap = "kube-system/dns-records"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.StaticRecordsSecret = "kube-system/dns-records"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.StaticRecordsConfigMap = ""
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.StaticRecordsKey = ""
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateTXTAffixConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.TXTPrefix = "txt."
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.TXTSuffix = "-txt"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.TXTPrefix = ""
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateTXTWildcardReplacementConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.TXTWildcardReplacement = "wildcard"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.TXTWildcardReplacement = "wildcard.txt"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.TXTWildcardReplacement = "*"
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateDynamoDBConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "dynamodb"
+	cfg.DynamoDBTable = "external-dns"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.DynamoDBTable = ""
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateNoopRegistryConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "noop"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.NoopRegistryConfirm = true
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateAWSSDConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "aws-sd"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.Provider = "aws-sd"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateMigrateRegistryConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "txt"
+	cfg.DynamoDBTable = "external-dns"
+	cfg.MigrateRegistryFrom = "txt"
+	assert.Error(t, ValidateConfig(cfg), "should differ from the registry")
+
+	cfg.Registry = "aws-sd"
+	cfg.Provider = "aws-sd"
+	assert.Error(t, ValidateConfig(cfg), "should require a registry supporting the migration")
+
+	cfg.Registry = "dynamodb"
+	cfg.Provider = "aws"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.Registry = "txt"
+	cfg.MigrateRegistryFrom = "dynamodb"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.DynamoDBTable = ""
+	assert.Error(t, ValidateConfig(cfg), "should require a table to migrate from")
+}
+
+func TestValidateRegexDomainFilterConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.RegexDomainFilter = `\.prod\.`
+	cfg.RegexDomainExclusion = `^sandbox\.`
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.RegexDomainFilter = "(prod"
+	assert.Error(t, ValidateConfig(cfg), "should reject an invalid regular expression")
+
+	cfg.RegexDomainFilter = ""
+	cfg.RegexDomainExclusion = "[sandbox"
+	assert.Error(t, ValidateConfig(cfg), "should reject an invalid regular expression")
+}
+
+func TestValidateTTLConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.DefaultTTL = 300
+	cfg.MinTTL = 60
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.DefaultTTL = 30
+	assert.Error(t, ValidateConfig(cfg), "should reject a default below the minimum")
+
+	cfg.DefaultTTL = 0
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.MinTTL = -1
+	assert.Error(t, ValidateConfig(cfg), "should reject a negative TTL")
+}
+
+func TestValidateIntervalJitterConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.IntervalJitter = 30 * time.Second
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.IntervalJitter = -time.Second
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateMaxChangesPerSyncConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.MaxChangesPerSync = 100
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.MaxChangesPerSync = -1
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateLivenessIntervalsConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.LivenessIntervals = 0
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.LivenessIntervals = -1
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateShutdownTimeoutConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.ShutdownTimeout = time.Minute
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.ShutdownTimeout = -time.Second
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateHealthCheckConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.EnableHealthChecks = true
+	cfg.HealthCheckTimeout = 5 * time.Second
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.HealthCheckTimeout = 0
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.EnableHealthChecks = false
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateProviderRateLimitConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.ProviderQPS = 0.5
+	cfg.ProviderBurst = 1
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.ProviderBurst = 0
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.ProviderQPS = -1
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.ProviderQPS = 0
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateHookConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.HookURLs = []string{"http://localhost:8889/hook"}
+	cfg.HookTimeout = 10 * time.Second
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.HookTimeout = 0
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.HookTimeout = 10 * time.Second
+	for _, invalid := range []string{"localhost:8889", "ftp://localhost/hook", "http://"} {
+		cfg.HookURLs = []string{invalid}
+		assert.Error(t, ValidateConfig(cfg), invalid)
+	}
+}
+
+func TestValidateLeaderElectionConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.LeaderElection = true
+	cfg.LeaderElectionLeaseDuration = 15 * time.Second
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.LeaderElectionLeaseDuration = 0
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.LeaderElection = false
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateExportImportRecordsConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.ExportRecords = "records.zone"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.ImportRecords = "records.zone"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.ExportRecords = ""
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateAdditionalProvidersConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.AdditionalProviders = []string{"inmemory"}
+	cfg.ProviderDomainFilters = []string{"inmemory=internal.example.org", "test-provider=example.org"}
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.ProviderDomainFilters = []string{"inmemory"}
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.ProviderDomainFilters = []string{"aws=example.org"}
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.ProviderDomainFilters = nil
+	cfg.AdditionalProviders = []string{"inmemory", "inmemory"}
+	assert.Error(t, ValidateConfig(cfg))
+
+	// the provider specific validations apply to additional providers
+	cfg.AdditionalProviders = []string{"pihole"}
+	assert.Error(t, ValidateConfig(cfg))
+}
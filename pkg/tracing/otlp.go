@@ -0,0 +1,183 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// otlpTracesPath is the default path of the traces endpoint of an OTLP collector
+const otlpTracesPath = "/v1/traces"
+
+// OTLPExporter exports traces to an OpenTelemetry collector with OTLP over HTTP in the JSON encoding
+type OTLPExporter struct {
+	url     string
+	service string
+	version string
+	client  *http.Client
+}
+
+// NewOTLPExporter returns an OTLPExporter sending the traces to the collector at the given URL, e.g.
+// http://otel-collector:4318, whose path defaults to /v1/traces. The traces are attributed to the service of
+// the given name and version.
+func NewOTLPExporter(endpoint, service, version string) (*OTLPExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tracing endpoint %q: %v", endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid tracing endpoint %q: the scheme must be http or https", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = otlpTracesPath
+	}
+	return &OTLPExporter{
+		url:     u.String(),
+		service: service,
+		version: version,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Export sends the spans to the collector
+func (e *OTLPExporter) Export(spans []*Span) error {
+	body, err := json.Marshal(e.request(spans))
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// The types below are the subset of the OTLP JSON encoding of an ExportTraceServiceRequest needed by the exporter.
+// Ids are hex encoded and 64 bit integers are encoded as strings.
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	// otlpSpanKindInternal is the kind of spans of internal operations
+	otlpSpanKindInternal = 1
+	// otlpStatusCodeError marks failed spans
+	otlpStatusCodeError = 2
+)
+
+func stringAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func intAttribute(key string, value int64) otlpAttribute {
+	encoded := strconv.FormatInt(value, 10)
+	return otlpAttribute{Key: key, Value: otlpValue{IntValue: &encoded}}
+}
+
+// request returns the export request of the spans
+func (e *OTLPExporter) request(spans []*Span) *otlpRequest {
+	scope := otlpScopeSpans{Scope: otlpScope{Name: "github.com/kubernetes-incubator/external-dns"}}
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           s.traceID,
+			SpanID:            s.spanID,
+			ParentSpanID:      s.parentID,
+			Name:              s.name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		}
+		keys := make([]string, 0, len(s.attributes))
+		for key := range s.attributes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			span.Attributes = append(span.Attributes, intAttribute(key, s.attributes[key]))
+		}
+		if s.err != nil {
+			span.Status = otlpStatus{Code: otlpStatusCodeError, Message: s.err.Error()}
+		}
+		scope.Spans = append(scope.Spans, span)
+	}
+
+	resource := otlpResource{Attributes: []otlpAttribute{stringAttribute("service.name", e.service)}}
+	if e.version != "" {
+		resource.Attributes = append(resource.Attributes, stringAttribute("service.version", e.version))
+	}
+	return &otlpRequest{ResourceSpans: []otlpResourceSpans{{Resource: resource, ScopeSpans: []otlpScopeSpans{scope}}}}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Exporter exports the spans of a completed trace
+type Exporter interface {
+	Export(spans []*Span) error
+}
+
+// Tracer records traces made of spans and hands every trace to the exporter once its root span ended.
+// A nil Tracer records nothing, its spans are nil and can be used like recorded ones.
+type Tracer struct {
+	exporter Exporter
+}
+
+// NewTracer returns a Tracer handing the traces to the given exporter
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// Start starts the root span of a new trace
+func (t *Tracer) Start(name string) *Span {
+	if t == nil {
+		return nil
+	}
+	root := newSpan(name, newID(16), "")
+	root.tracer = t
+	root.trace = &trace{}
+	return root
+}
+
+// trace holds the ended spans of a trace until its root span ends
+type trace struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// Span is a timed step of a trace, e.g. a call to the DNS provider.
+// Its attributes count what the step processed, e.g. the number of records.
+type Span struct {
+	tracer     *Tracer
+	trace      *trace
+	name       string
+	traceID    string
+	spanID     string
+	parentID   string
+	start      time.Time
+	end        time.Time
+	attributes map[string]int64
+	err        error
+}
+
+func newSpan(name, traceID, parentID string) *Span {
+	return &Span{
+		name:       name,
+		traceID:    traceID,
+		spanID:     newID(8),
+		parentID:   parentID,
+		start:      time.Now(),
+		attributes: map[string]int64{},
+	}
+}
+
+// StartChild starts a span of a step within the span
+func (s *Span) StartChild(name string) *Span {
+	if s == nil {
+		return nil
+	}
+	child := newSpan(name, s.traceID, s.spanID)
+	child.trace = s.trace
+	return child
+}
+
+// SetAttribute sets an attribute of the span
+func (s *Span) SetAttribute(key string, value int64) {
+	if s == nil {
+		return
+	}
+	s.attributes[key] = value
+}
+
+// End ends the span, marking it failed if err is set. Ending the root span exports the trace.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.end = time.Now()
+	s.err = err
+
+	s.trace.mu.Lock()
+	s.trace.spans = append(s.trace.spans, s)
+	spans := s.trace.spans
+	s.trace.mu.Unlock()
+
+	if s.tracer != nil {
+		if err := s.tracer.exporter.Export(spans); err != nil {
+			log.Warnf("Failed to export the trace of %s: %v", s.name, err)
+		}
+	}
+}
+
+// Name returns the name of the span
+func (s *Span) Name() string {
+	return s.name
+}
+
+// Err returns the error the span failed with, nil if it succeeded
+func (s *Span) Err() error {
+	return s.err
+}
+
+// Attributes returns the attributes of the span
+func (s *Span) Attributes() map[string]int64 {
+	return s.attributes
+}
+
+// newID returns a random hex encoded id of the given number of bytes
+func newID(size int) string {
+	id := make([]byte, size)
+	// the ids only need to be unique, a failure leaves them zero
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
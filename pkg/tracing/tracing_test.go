@@ -0,0 +1,133 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingExporter keeps the exported traces
+type recordingExporter struct {
+	traces [][]*Span
+}
+
+func (e *recordingExporter) Export(spans []*Span) error {
+	e.traces = append(e.traces, spans)
+	return nil
+}
+
+func TestTracer(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := NewTracer(exporter)
+
+	root := tracer.Start("sync")
+	child := root.StartChild("registry.records")
+	child.SetAttribute("records", 3)
+	child.End(nil)
+	failed := root.StartChild("source.endpoints")
+	failed.End(errors.New("source failed"))
+	assert.Empty(t, exporter.traces, "the trace is exported once the root span ends")
+	root.End(failed.Err())
+
+	require.Len(t, exporter.traces, 1)
+	spans := exporter.traces[0]
+	require.Len(t, spans, 3)
+	assert.Equal(t, "registry.records", spans[0].Name())
+	assert.Equal(t, map[string]int64{"records": 3}, spans[0].Attributes())
+	assert.EqualError(t, spans[1].Err(), "source failed")
+	assert.Equal(t, "sync", spans[2].Name())
+	for _, span := range spans {
+		assert.Equal(t, root.traceID, span.traceID)
+		assert.Len(t, span.spanID, 16)
+	}
+	assert.Len(t, root.traceID, 32)
+	assert.Equal(t, root.spanID, child.parentID)
+	assert.Empty(t, root.parentID)
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	root := tracer.Start("sync")
+	assert.Nil(t, root)
+	child := root.StartChild("registry.records")
+	child.SetAttribute("records", 3)
+	child.End(nil)
+	root.End(errors.New("failed"))
+}
+
+func TestNewOTLPExporter(t *testing.T) {
+	for _, tc := range []struct {
+		endpoint string
+		url      string
+	}{
+		{"http://otel-collector:4318", "http://otel-collector:4318/v1/traces"},
+		{"https://otel-collector:4318/", "https://otel-collector:4318/v1/traces"},
+		{"http://gateway/otlp/traces", "http://gateway/otlp/traces"},
+	} {
+		exporter, err := NewOTLPExporter(tc.endpoint, "external-dns", "")
+		require.NoError(t, err)
+		assert.Equal(t, tc.url, exporter.url)
+	}
+
+	_, err := NewOTLPExporter("otel-collector:4318", "external-dns", "")
+	assert.Error(t, err)
+}
+
+func TestOTLPExporterExport(t *testing.T) {
+	var request map[string]interface{}
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &request))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	exporter, err := NewOTLPExporter(server.URL, "external-dns", "v0.5.0")
+	require.NoError(t, err)
+	root := NewTracer(exporter).Start("sync")
+	root.SetAttribute("changes", 2)
+	root.End(errors.New("provider failed"))
+
+	resourceSpans := request["resourceSpans"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "external-dns"}},
+		map[string]interface{}{"key": "service.version", "value": map[string]interface{}{"stringValue": "v0.5.0"}},
+	}, resourceSpans["resource"].(map[string]interface{})["attributes"])
+	span := resourceSpans["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "sync", span["name"])
+	assert.Equal(t, root.traceID, span["traceId"])
+	assert.Nil(t, span["parentSpanId"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"key": "changes", "value": map[string]interface{}{"intValue": "2"}},
+	}, span["attributes"])
+	assert.Equal(t, map[string]interface{}{"code": float64(2), "message": "provider failed"}, span["status"])
+
+	status = http.StatusBadRequest
+	assert.EqualError(t, exporter.Export([]*Span{root}), "collector responded with 400 Bad Request")
+}
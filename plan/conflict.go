@@ -18,17 +18,32 @@ package plan
 
 import (
 	"sort"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
 
 	"github.com/kubernetes-incubator/external-dns/endpoint"
 )
 
 // ConflictResolver is used to make a decision in case of two or more different kubernetes resources
-// are trying to acquire same DNS name
+// are trying to acquire same DNS name. A resolver may return nil to skip the DNS name.
 type ConflictResolver interface {
 	ResolveCreate(candidates []*endpoint.Endpoint) *endpoint.Endpoint
 	ResolveUpdate(current *endpoint.Endpoint, candidates []*endpoint.Endpoint) *endpoint.Endpoint
 }
 
+// ConflictResolvers is a registry of available conflict resolvers.
+var ConflictResolvers = map[string]ConflictResolver{
+	"per-resource":    PerResource{},
+	"newest-resource": NewestResource{},
+	"priority":        Priority{},
+	"skip":            SkipConflicts{},
+}
+
+// resolutionLabelKeys are the labels only needed to resolve conflicts, they're dropped from the resolved endpoints
+var resolutionLabelKeys = []string{endpoint.ResourceCreatedLabelKey, endpoint.PriorityLabelKey}
+
 // PerResource allows only one resource to own a given dns name
 type PerResource struct{}
 
@@ -67,4 +82,108 @@ func (s PerResource) less(x, y *endpoint.Endpoint) bool {
 	return x.Targets.IsLess(y.Targets)
 }
 
-// TODO: with cross-resource/cross-cluster setup alternative variations of ConflictResolver can be used
+// NewestResource lets the most recently created resource own a given dns name, e.g. to move a dns name
+// to a new resource by just creating it. Endpoints without a creation time are considered the oldest.
+type NewestResource struct{}
+
+// ResolveCreate takes the endpoint of the newest resource, the "minimal" one if several are equally new
+func (s NewestResource) ResolveCreate(candidates []*endpoint.Endpoint) *endpoint.Endpoint {
+	var newest *endpoint.Endpoint
+	for _, ep := range candidates {
+		if newest == nil || s.newer(ep, newest) || (!s.newer(newest, ep) && PerResource{}.less(ep, newest)) {
+			newest = ep
+		}
+	}
+	return newest
+}
+
+// ResolveUpdate takes the endpoint of the newest resource regardless of the resource owning the dns name
+func (s NewestResource) ResolveUpdate(current *endpoint.Endpoint, candidates []*endpoint.Endpoint) *endpoint.Endpoint {
+	return s.ResolveCreate(candidates)
+}
+
+// newer returns true if the resource of endpoint x was created after the one of y
+func (s NewestResource) newer(x, y *endpoint.Endpoint) bool {
+	return resourceCreated(x).After(resourceCreated(y))
+}
+
+func resourceCreated(ep *endpoint.Endpoint) time.Time {
+	created, err := time.Parse(time.RFC3339, ep.Labels[endpoint.ResourceCreatedLabelKey])
+	if err != nil {
+		return time.Time{}
+	}
+	return created
+}
+
+// Priority lets the resource with the highest priority own a given dns name, the priority being set by an annotation.
+// Endpoints without a priority have priority 0. Conflicts between resources of the same priority are resolved per resource.
+type Priority struct{}
+
+// ResolveCreate takes the "minimal" endpoint of the highest priority
+func (s Priority) ResolveCreate(candidates []*endpoint.Endpoint) *endpoint.Endpoint {
+	return PerResource{}.ResolveCreate(s.highest(candidates))
+}
+
+// ResolveUpdate keeps the resource owning the dns name if it still has the highest priority
+func (s Priority) ResolveUpdate(current *endpoint.Endpoint, candidates []*endpoint.Endpoint) *endpoint.Endpoint {
+	return PerResource{}.ResolveUpdate(current, s.highest(candidates))
+}
+
+// highest returns the candidates of the highest priority
+func (s Priority) highest(candidates []*endpoint.Endpoint) []*endpoint.Endpoint {
+	highest := []*endpoint.Endpoint{}
+	max := 0
+	for i, ep := range candidates {
+		priority := resourcePriority(ep)
+		if i == 0 || priority > max {
+			highest, max = nil, priority
+		}
+		if priority == max {
+			highest = append(highest, ep)
+		}
+	}
+	return highest
+}
+
+func resourcePriority(ep *endpoint.Endpoint) int {
+	priority, err := strconv.Atoi(ep.Labels[endpoint.PriorityLabelKey])
+	if err != nil {
+		return 0
+	}
+	return priority
+}
+
+// SkipConflicts refuses to choose between different resources: a dns name wanted by several resources with different
+// targets is logged as an error and left unchanged until the conflict is fixed. Several endpoints of the same resource
+// are resolved per resource.
+type SkipConflicts struct{}
+
+// ResolveCreate returns nil if the candidates conflict
+func (s SkipConflicts) ResolveCreate(candidates []*endpoint.Endpoint) *endpoint.Endpoint {
+	if s.conflict(candidates) {
+		return nil
+	}
+	return PerResource{}.ResolveCreate(candidates)
+}
+
+// ResolveUpdate returns nil if the candidates conflict
+func (s SkipConflicts) ResolveUpdate(current *endpoint.Endpoint, candidates []*endpoint.Endpoint) *endpoint.Endpoint {
+	if s.conflict(candidates) {
+		return nil
+	}
+	return PerResource{}.ResolveUpdate(current, candidates)
+}
+
+// conflict returns true and logs the conflict if candidates of different resources have different targets
+func (s SkipConflicts) conflict(candidates []*endpoint.Endpoint) bool {
+	for i, x := range candidates {
+		for _, y := range candidates[i+1:] {
+			if x.Labels[endpoint.ResourceLabelKey] != y.Labels[endpoint.ResourceLabelKey] && !x.Targets.Same(y.Targets) {
+				log.WithFields(x.LogFields()).Errorf("Skipping the name because %s and %s want different targets: %s and %s",
+					x.Labels[endpoint.ResourceLabelKey], y.Labels[endpoint.ResourceLabelKey], x.Targets, y.Targets)
+				return true
+			}
+		}
+	}
+	return false
+}
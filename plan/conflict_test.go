@@ -23,7 +23,12 @@ import (
 	"github.com/stretchr/testify/suite"
 )
 
-var _ ConflictResolver = PerResource{}
+var (
+	_ ConflictResolver = PerResource{}
+	_ ConflictResolver = NewestResource{}
+	_ ConflictResolver = Priority{}
+	_ ConflictResolver = SkipConflicts{}
+)
 
 type ResolverSuite struct {
 	// resolvers
@@ -132,6 +137,49 @@ func (suite *ResolverSuite) TestStrictResolver() {
 	suite.Equal(suite.bar127A, suite.perResource.ResolveUpdate(suite.legacyBar192A, []*endpoint.Endpoint{suite.bar127A, suite.bar192A}), " legacy record's resource value will not match, should pick minimum")
 }
 
+// withLabel returns a copy of the endpoint with the label set
+func withLabel(ep *endpoint.Endpoint, key, value string) *endpoint.Endpoint {
+	c := *ep
+	c.Labels = map[string]string{}
+	for k, v := range ep.Labels {
+		c.Labels[k] = v
+	}
+	c.Labels[key] = value
+	return &c
+}
+
+func (suite *ResolverSuite) TestNewestResourceResolver() {
+	resolver := NewestResource{}
+	older := withLabel(suite.bar127A, endpoint.ResourceCreatedLabelKey, "2018-01-01T00:00:00Z")
+	newer := withLabel(suite.bar192A, endpoint.ResourceCreatedLabelKey, "2018-02-01T00:00:00Z")
+
+	suite.Equal(newer, resolver.ResolveCreate([]*endpoint.Endpoint{older, newer}), "should pick the newest resource")
+	suite.Equal(newer, resolver.ResolveUpdate(older, []*endpoint.Endpoint{older, newer}), "should pick the newest resource over the current one")
+	suite.Equal(older, resolver.ResolveCreate([]*endpoint.Endpoint{older, suite.bar192A}), "should consider resources without creation time the oldest")
+	suite.Equal(suite.bar127A, resolver.ResolveCreate([]*endpoint.Endpoint{suite.bar192A, suite.bar127A}), "should pick min one if equally new")
+}
+
+func (suite *ResolverSuite) TestPriorityResolver() {
+	resolver := Priority{}
+	high := withLabel(suite.bar192A, endpoint.PriorityLabelKey, "10")
+	low := withLabel(suite.bar127A, endpoint.PriorityLabelKey, "-1")
+
+	suite.Equal(high, resolver.ResolveCreate([]*endpoint.Endpoint{suite.bar127A, high}), "should pick the highest priority")
+	suite.Equal(suite.bar192A, resolver.ResolveCreate([]*endpoint.Endpoint{low, suite.bar192A}), "should consider resources without priority to have priority 0")
+	suite.Equal(high, resolver.ResolveUpdate(suite.bar127A, []*endpoint.Endpoint{suite.bar127A, high}), "should pick the highest priority over the current resource")
+	suite.Equal(suite.bar192A, resolver.ResolveUpdate(suite.bar192A, []*endpoint.Endpoint{suite.bar127A, suite.bar192A}), "should keep the current resource of the same priority")
+}
+
+func (suite *ResolverSuite) TestSkipConflictsResolver() {
+	resolver := SkipConflicts{}
+
+	suite.Nil(resolver.ResolveCreate([]*endpoint.Endpoint{suite.bar127A, suite.bar192A}), "should skip different resources with different targets")
+	suite.Nil(resolver.ResolveUpdate(suite.bar127A, []*endpoint.Endpoint{suite.bar127A, suite.bar192A}), "should skip different resources with different targets")
+	suite.Equal(suite.fooV2Cname, resolver.ResolveUpdate(suite.fooV2Cname, []*endpoint.Endpoint{suite.fooV2Cname, suite.fooV2CnameDuplicate}), "should accept different resources with the same targets")
+	suite.Equal(suite.bar127A, resolver.ResolveCreate([]*endpoint.Endpoint{suite.bar127AAnother, suite.bar127A}), "should accept several endpoints of the same resource")
+	suite.Equal(suite.bar192A, resolver.ResolveCreate([]*endpoint.Endpoint{suite.bar192A}), "should accept a single candidate")
+}
+
 func TestConflictResolver(t *testing.T) {
 	suite.Run(t, new(ResolverSuite))
 }
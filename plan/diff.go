@@ -0,0 +1,163 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+)
+
+// Diff describes the changes of a plan for humans and machines, e.g. for a review in a CI pipeline.
+// Its JSON representation is meant to be consumed by scripts and must be kept stable.
+type Diff struct {
+	Create []*endpoint.Endpoint `json:"create"`
+	Update []*UpdateDiff        `json:"update"`
+	Delete []*endpoint.Endpoint `json:"delete"`
+}
+
+// UpdateDiff is a single update of a record
+type UpdateDiff struct {
+	Old *endpoint.Endpoint `json:"old"`
+	New *endpoint.Endpoint `json:"new"`
+	// Fields lists the names of the changed fields, e.g. targets or ttl
+	Fields []string `json:"fields"`
+}
+
+// NewDiff returns the diff of the changes, sorted by name, type and set identifier of the records.
+// It relies on UpdateOld and UpdateNew being ordered alike, as computed by the plan.
+func NewDiff(changes *Changes) *Diff {
+	diff := &Diff{
+		Create: append([]*endpoint.Endpoint{}, changes.Create...),
+		Update: []*UpdateDiff{},
+		Delete: append([]*endpoint.Endpoint{}, changes.Delete...),
+	}
+	for i := range changes.UpdateNew {
+		if i >= len(changes.UpdateOld) {
+			break
+		}
+		current, desired := changes.UpdateOld[i], changes.UpdateNew[i]
+		diff.Update = append(diff.Update, &UpdateDiff{Old: current, New: desired, Fields: changedFields(current, desired)})
+	}
+
+	sortEndpoints(diff.Create)
+	sortEndpoints(diff.Delete)
+	sort.SliceStable(diff.Update, func(i, j int) bool {
+		return endpointLess(diff.Update[i].New, diff.Update[j].New)
+	})
+	return diff
+}
+
+// HasChanges returns true if the diff contains at least one change
+func (d *Diff) HasChanges() bool {
+	return len(d.Create)+len(d.Update)+len(d.Delete) > 0
+}
+
+// WriteJSON writes the diff as JSON
+func (d *Diff) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(d)
+}
+
+// WriteText writes the diff in a human-readable format, one record per line prefixed with +, ~ or - for
+// created, updated and deleted records. Updated records are followed by their changed fields, e.g.
+// "targets: 1.2.3.4 -> 5.6.7.8", and a summary concludes the diff.
+func (d *Diff) WriteText(w io.Writer) error {
+	for _, ep := range d.Create {
+		if _, err := fmt.Fprintf(w, "+ %s\n", ep); err != nil {
+			return err
+		}
+	}
+	for _, update := range d.Update {
+		if _, err := fmt.Fprintf(w, "~ %s\n", update.Old); err != nil {
+			return err
+		}
+		for _, field := range update.Fields {
+			if _, err := fmt.Fprintf(w, "    %s: %s -> %s\n", field, fieldValue(update.Old, field), fieldValue(update.New, field)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, ep := range d.Delete {
+		if _, err := fmt.Fprintf(w, "- %s\n", ep); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "Plan: %d to create, %d to update, %d to delete.\n", len(d.Create), len(d.Update), len(d.Delete))
+	return err
+}
+
+// changedFields returns the names of the fields differing between the current and the desired record
+func changedFields(current, desired *endpoint.Endpoint) []string {
+	fields := []string{}
+	for _, field := range []string{"type", "targets", "ttl", "geolocation", "providerSpecific"} {
+		if fieldValue(current, field) != fieldValue(desired, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// fieldValue returns the printable value of the field of the record
+func fieldValue(ep *endpoint.Endpoint, field string) string {
+	switch field {
+	case "type":
+		return ep.RecordType
+	case "targets":
+		targets := append(endpoint.Targets{}, ep.Targets...)
+		sort.Sort(targets)
+		return targets.String()
+	case "ttl":
+		if !ep.RecordTTL.IsConfigured() {
+			return "default"
+		}
+		return fmt.Sprintf("%d", ep.RecordTTL)
+	case "geolocation":
+		if ep.GeoLocation == nil {
+			return "none"
+		}
+		return ep.GeoLocation.String()
+	case "providerSpecific":
+		properties := []string{}
+		for _, property := range ep.ProviderSpecific {
+			properties = append(properties, property.Name+"="+property.Value)
+		}
+		sort.Strings(properties)
+		return fmt.Sprintf("%v", properties)
+	}
+	return ""
+}
+
+func sortEndpoints(endpoints []*endpoint.Endpoint) {
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		return endpointLess(endpoints[i], endpoints[j])
+	})
+}
+
+func endpointLess(a, b *endpoint.Endpoint) bool {
+	if a.DNSName != b.DNSName {
+		return a.DNSName < b.DNSName
+	}
+	if a.RecordType != b.RecordType {
+		return a.RecordType < b.RecordType
+	}
+	return a.SetIdentifier < b.SetIdentifier
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDiffTestChanges() *Changes {
+	return &Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("foo.example.org", "1.2.3.4", endpoint.RecordTypeA, 300),
+			endpoint.NewEndpoint("bar.example.org", "1.2.3.4", endpoint.RecordTypeA),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("geo.example.org", "1.2.3.4", endpoint.RecordTypeA, 300).
+				WithSetIdentifier("europe").
+				WithGeoLocation(&endpoint.GeoLocation{ContinentCode: "EU"}),
+			endpoint.NewEndpoint("baz.example.org", "1.2.3.4", endpoint.RecordTypeA),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("geo.example.org", "1.2.3.4", endpoint.RecordTypeA, 600).
+				WithSetIdentifier("europe").
+				WithGeoLocation(&endpoint.GeoLocation{CountryCode: "DE"}),
+			endpoint.NewEndpoint("baz.example.org", "5.6.7.8", endpoint.RecordTypeA).
+				WithProviderSpecific("aws/failover", "PRIMARY"),
+		},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("qux.example.org", "qux.elb.com", endpoint.RecordTypeCNAME),
+		},
+	}
+}
+
+func TestDiffText(t *testing.T) {
+	output := &bytes.Buffer{}
+	diff := NewDiff(newDiffTestChanges())
+	require.NoError(t, diff.WriteText(output))
+
+	assert.Equal(t, `+ bar.example.org 0 IN A 1.2.3.4
++ foo.example.org 300 IN A 1.2.3.4
+~ baz.example.org 0 IN A 1.2.3.4
+    targets: 1.2.3.4 -> 5.6.7.8
+    providerSpecific: [] -> [aws/failover=PRIMARY]
+~ geo.example.org 300 IN A 1.2.3.4 [id:europe] [continent:EU]
+    ttl: 300 -> 600
+    geolocation: continent:EU -> country:DE
+- qux.example.org 0 IN CNAME qux.elb.com
+Plan: 2 to create, 2 to update, 1 to delete.
+`, output.String())
+}
+
+func TestDiffJSON(t *testing.T) {
+	output := &bytes.Buffer{}
+	diff := NewDiff(newDiffTestChanges())
+	require.NoError(t, diff.WriteJSON(output))
+
+	decoded := &Diff{}
+	require.NoError(t, json.Unmarshal(output.Bytes(), decoded))
+	require.Len(t, decoded.Create, 2)
+	assert.Equal(t, "bar.example.org", decoded.Create[0].DNSName)
+	require.Len(t, decoded.Update, 2)
+	assert.Equal(t, "baz.example.org", decoded.Update[0].New.DNSName)
+	assert.Equal(t, []string{"targets", "providerSpecific"}, decoded.Update[0].Fields)
+	assert.Equal(t, []string{"ttl", "geolocation"}, decoded.Update[1].Fields)
+	assert.Equal(t, endpoint.TTL(300), decoded.Update[1].Old.RecordTTL)
+	assert.Equal(t, "DE", decoded.Update[1].New.GeoLocation.CountryCode)
+	require.Len(t, decoded.Delete, 1)
+	assert.Equal(t, "qux.example.org", decoded.Delete[0].DNSName)
+}
+
+func TestDiffEmpty(t *testing.T) {
+	diff := NewDiff(&Changes{})
+	assert.False(t, diff.HasChanges())
+
+	output := &bytes.Buffer{}
+	require.NoError(t, diff.WriteJSON(output))
+	assert.JSONEq(t, `{"create": [], "update": [], "delete": []}`, output.String())
+}
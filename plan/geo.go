@@ -0,0 +1,90 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"strings"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+)
+
+// geoDefaultSetIdentifier is the set identifier of the default location of a merged geo record set
+const geoDefaultSetIdentifier = "default"
+
+// geoRecordSetKey identifies the endpoints merged into a geo record set
+type geoRecordSetKey struct {
+	dnsName    string
+	recordType string
+	provider   string
+}
+
+func newGeoRecordSetKey(ep *endpoint.Endpoint) geoRecordSetKey {
+	return geoRecordSetKey{dnsName: ep.DNSName, recordType: ep.RecordType, provider: ep.Labels[endpoint.ProviderLabelKey]}
+}
+
+// mergeGeoLocations merges the desired endpoints of a DNS name and record type with different geo locations, e.g. of
+// clusters in different regions sharing a hostname, into a geo record set instead of letting them conflict. It's
+// only done for providers supporting geo locations, the endpoints of different providers aren't merged. An endpoint without set identifier gets one
+// derived from its location, e.g. continent-eu or country-us-ca, and an endpoint without location answers the queries
+// of all other locations as the default location "*". Endpoints of the same location still conflict and are resolved
+// by the ConflictResolver. Endpoints with a set identifier are kept as they are, and changed endpoints are copied, so
+// that the desired endpoints aren't modified.
+func mergeGeoLocations(desired []*endpoint.Endpoint) []*endpoint.Endpoint {
+	byKey := map[geoRecordSetKey][]*endpoint.Endpoint{}
+	for _, ep := range desired {
+		key := newGeoRecordSetKey(ep)
+		byKey[key] = append(byKey[key], ep)
+	}
+
+	merged := make([]*endpoint.Endpoint, 0, len(desired))
+	for _, ep := range desired {
+		key := newGeoRecordSetKey(ep)
+		if ep.SetIdentifier != "" || !hasDifferentGeoLocations(byKey[key]) {
+			merged = append(merged, ep)
+			continue
+		}
+		located := ep.DeepCopy()
+		if located.GeoLocation == nil {
+			located.GeoLocation = &endpoint.GeoLocation{CountryCode: endpoint.GeoDefaultCountryCode}
+		}
+		located.SetIdentifier = geoSetIdentifier(located.GeoLocation)
+		merged = append(merged, located)
+	}
+	return merged
+}
+
+// hasDifferentGeoLocations returns true if at least one of the endpoints has a geo location and they don't all have
+// the same one
+func hasDifferentGeoLocations(endpoints []*endpoint.Endpoint) bool {
+	located := false
+	locations := map[string]bool{}
+	for _, ep := range endpoints {
+		if ep.GeoLocation != nil {
+			located = true
+		}
+		locations[ep.GeoLocation.String()] = true
+	}
+	return located && len(locations) > 1
+}
+
+// geoSetIdentifier returns the set identifier of a location of a merged geo record set
+func geoSetIdentifier(geo *endpoint.GeoLocation) string {
+	if geo.IsDefault() {
+		return geoDefaultSetIdentifier
+	}
+	return strings.ToLower(strings.Replace(geo.String(), ":", "-", 1))
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+)
+
+// newGeoEndpoint returns an A record of api.example.org of the given resource and location
+func newGeoEndpoint(resource, target string, geo *endpoint.GeoLocation) *endpoint.Endpoint {
+	ep := endpoint.NewEndpoint("api.example.org", target, endpoint.RecordTypeA)
+	ep.Labels[endpoint.ResourceLabelKey] = resource
+	ep.GeoLocation = geo
+	return ep
+}
+
+// withGeo returns a copy of the endpoint with the given set identifier and location
+func withGeo(ep *endpoint.Endpoint, setIdentifier string, geo *endpoint.GeoLocation) *endpoint.Endpoint {
+	located := *ep
+	located.SetIdentifier = setIdentifier
+	located.GeoLocation = geo
+	return &located
+}
+
+// withProvider labels the endpoint with the given provider
+func withProvider(ep *endpoint.Endpoint, provider string) *endpoint.Endpoint {
+	ep.Labels[endpoint.ProviderLabelKey] = provider
+	return ep
+}
+
+func TestMergeGeoLocations(t *testing.T) {
+	eu := newGeoEndpoint("service/eu/api", "1.1.1.1", &endpoint.GeoLocation{ContinentCode: "EU"})
+	us := newGeoEndpoint("service/us/api", "2.2.2.2", &endpoint.GeoLocation{CountryCode: "US", SubdivisionCode: "CA"})
+	global := newGeoEndpoint("service/global/api", "3.3.3.3", nil)
+	other := endpoint.NewEndpoint("other.example.org", "4.4.4.4", endpoint.RecordTypeA)
+
+	merged := mergeGeoLocations([]*endpoint.Endpoint{eu, us, global, other})
+	validateEntries(t, merged, []*endpoint.Endpoint{
+		withGeo(eu, "continent-eu", eu.GeoLocation),
+		withGeo(us, "country-us-ca", us.GeoLocation),
+		withGeo(global, "default", &endpoint.GeoLocation{CountryCode: endpoint.GeoDefaultCountryCode}),
+		other,
+	})
+	// the desired endpoints aren't modified
+	assert.Empty(t, eu.SetIdentifier)
+	assert.Nil(t, global.GeoLocation)
+	merged[0].Labels[endpoint.OwnerLabelKey] = "owner"
+	merged[0].WithProviderSpecific("aws/evaluate-target-health", "true")
+	assert.NotContains(t, eu.Labels, endpoint.OwnerLabelKey)
+	assert.Empty(t, eu.ProviderSpecific)
+}
+
+func TestMergeGeoLocationsKeepsEndpoints(t *testing.T) {
+	for _, tc := range []struct {
+		title     string
+		endpoints []*endpoint.Endpoint
+	}{
+		{
+			title: "single location",
+			endpoints: []*endpoint.Endpoint{
+				newGeoEndpoint("service/eu/api", "1.1.1.1", &endpoint.GeoLocation{ContinentCode: "EU"}),
+			},
+		},
+		{
+			title: "same location",
+			endpoints: []*endpoint.Endpoint{
+				newGeoEndpoint("service/eu/api", "1.1.1.1", &endpoint.GeoLocation{ContinentCode: "EU"}),
+				newGeoEndpoint("service/eu/api-v2", "1.1.1.2", &endpoint.GeoLocation{ContinentCode: "EU"}),
+			},
+		},
+		{
+			title: "no location",
+			endpoints: []*endpoint.Endpoint{
+				newGeoEndpoint("service/a/api", "1.1.1.1", nil),
+				newGeoEndpoint("service/b/api", "2.2.2.2", nil),
+			},
+		},
+		{
+			title: "different record types",
+			endpoints: []*endpoint.Endpoint{
+				newGeoEndpoint("service/eu/api", "1.1.1.1", &endpoint.GeoLocation{ContinentCode: "EU"}),
+				endpoint.NewEndpoint("api.example.org", "2001:db8::1", endpoint.RecordTypeAAAA),
+			},
+		},
+		{
+			title: "different providers",
+			endpoints: []*endpoint.Endpoint{
+				withProvider(newGeoEndpoint("service/eu/api", "1.1.1.1", &endpoint.GeoLocation{ContinentCode: "EU"}), "eu"),
+				withProvider(newGeoEndpoint("service/us/api", "2.2.2.2", &endpoint.GeoLocation{CountryCode: "US"}), "us"),
+			},
+		},
+		{
+			title: "set identifiers",
+			endpoints: []*endpoint.Endpoint{
+				newGeoEndpoint("service/eu/api", "1.1.1.1", &endpoint.GeoLocation{ContinentCode: "EU"}).WithSetIdentifier("eu"),
+				newGeoEndpoint("service/us/api", "2.2.2.2", &endpoint.GeoLocation{CountryCode: "US"}).WithSetIdentifier("us"),
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			merged := mergeGeoLocations(tc.endpoints)
+			assert.Equal(t, tc.endpoints, merged)
+		})
+	}
+}
+
+func TestPlanMergesGeoLocations(t *testing.T) {
+	eu := newGeoEndpoint("service/eu/api", "1.1.1.1", &endpoint.GeoLocation{ContinentCode: "EU"})
+	us := newGeoEndpoint("service/us/api", "2.2.2.2", &endpoint.GeoLocation{CountryCode: "US"})
+	usV2 := newGeoEndpoint("service/us/api-v2", "2.2.2.3", &endpoint.GeoLocation{CountryCode: "US"})
+
+	// the record set is created with a record per location, the same location is resolved by the resolver
+	calculated := (&Plan{
+		Policies:          []Policy{&SyncPolicy{}},
+		Desired:           []*endpoint.Endpoint{usV2, eu, us},
+		MergeGeoLocations: true,
+	}).Calculate()
+	euRecord := withGeo(eu, "continent-eu", eu.GeoLocation)
+	usRecord := withGeo(us, "country-us", us.GeoLocation)
+	validateEntries(t, calculated.Changes.Create, []*endpoint.Endpoint{euRecord, usRecord})
+	// the calculated plan holds the merged endpoints
+	validateEntries(t, calculated.Desired, []*endpoint.Endpoint{withGeo(usV2, "country-us", usV2.GeoLocation), euRecord, usRecord})
+
+	// the record set is stable
+	changes := (&Plan{
+		Policies:          []Policy{&SyncPolicy{}},
+		Current:           []*endpoint.Endpoint{euRecord, usRecord},
+		Desired:           []*endpoint.Endpoint{eu, us, usV2},
+		MergeGeoLocations: true,
+	}).Calculate().Changes
+	assert.False(t, changes.HasChanges())
+
+	// a record without location replaced by the record set is deleted
+	current := newGeoEndpoint("service/eu/api", "1.1.1.1", nil)
+	changes = (&Plan{
+		Policies:          []Policy{&SyncPolicy{}},
+		Current:           []*endpoint.Endpoint{current},
+		Desired:           []*endpoint.Endpoint{eu, us},
+		MergeGeoLocations: true,
+	}).Calculate().Changes
+	validateEntries(t, changes.Create, []*endpoint.Endpoint{euRecord, usRecord})
+	validateEntries(t, changes.Delete, []*endpoint.Endpoint{current})
+}
+
+func TestPlanDoesntMergeGeoLocations(t *testing.T) {
+	eu := newGeoEndpoint("service/eu/api", "1.1.1.1", &endpoint.GeoLocation{ContinentCode: "EU"})
+	us := newGeoEndpoint("service/us/api", "2.2.2.2", &endpoint.GeoLocation{CountryCode: "US"})
+
+	// for providers without geo locations the endpoints conflict, the resolver keeps one of them
+	changes := (&Plan{
+		Policies: []Policy{&SyncPolicy{}},
+		Desired:  []*endpoint.Endpoint{eu, us},
+	}).Calculate().Changes
+	validateEntries(t, changes.Create, []*endpoint.Endpoint{eu})
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package plan
 
 import (
+	log "github.com/sirupsen/logrus"
+
 	"github.com/kubernetes-incubator/external-dns/endpoint"
 )
 
@@ -25,10 +27,23 @@ import (
 type Plan struct {
 	// List of current records
 	Current []*endpoint.Endpoint
-	// List of desired records
+	// List of desired records, the plan returned by Calculate holds them as merged by MergeGeoLocations
 	Desired []*endpoint.Endpoint
 	// Policies under which the desired changes are calculated
 	Policies []Policy
+	// ConflictResolver chooses between desired records of the same DNS name, PerResource if nil
+	ConflictResolver ConflictResolver
+	// PreferCNAME keeps the CNAME records instead of the A and AAAA records if both are desired for the same DNS name
+	PreferCNAME bool
+	// ProviderManagedProperties are the provider specific properties the provider adds to the records itself,
+	// e.g. the id of a health check it created, they're only compared if they're desired
+	ProviderManagedProperties []string
+	// PerProvider plans the records of every provider separately, a record is identified by its provider label too,
+	// so that the records of the same DNS name with different providers don't conflict
+	PerProvider bool
+	// MergeGeoLocations merges the desired records of a DNS name with different geo locations into a geo record set
+	// instead of letting them conflict, for providers supporting geo locations
+	MergeGeoLocations bool
 	// List of changes necessary to move towards desired state
 	// Populated after calling Calculate()
 	Changes *Changes
@@ -37,17 +52,50 @@ type Plan struct {
 // Changes holds lists of actions to be executed by dns providers
 type Changes struct {
 	// Records that need to be created
-	Create []*endpoint.Endpoint
+	Create []*endpoint.Endpoint `json:"create,omitempty"`
 	// Records that need to be updated (current data)
-	UpdateOld []*endpoint.Endpoint
+	UpdateOld []*endpoint.Endpoint `json:"updateOld,omitempty"`
 	// Records that need to be updated (desired data)
-	UpdateNew []*endpoint.Endpoint
+	UpdateNew []*endpoint.Endpoint `json:"updateNew,omitempty"`
 	// Records that need to be deleted
-	Delete []*endpoint.Endpoint
+	Delete []*endpoint.Endpoint `json:"delete,omitempty"`
+}
+
+// HasChanges returns true if at least one record needs to be created, updated or deleted
+func (c *Changes) HasChanges() bool {
+	return len(c.Create)+len(c.UpdateOld)+len(c.UpdateNew)+len(c.Delete) > 0
+}
+
+// Size returns the number of changes, an update of a record counts as a single change
+func (c *Changes) Size() int {
+	return len(c.Create) + len(c.UpdateNew) + len(c.Delete)
+}
+
+// Limit returns at most max of the changes, creates first, then updates and deletes last.
+// The remaining changes are left to the next synchronization, which calculates them again.
+func (c *Changes) Limit(max int) *Changes {
+	if max <= 0 || c.Size() <= max {
+		return c
+	}
+	limited := &Changes{}
+	limited.Create = c.Create[:minInt(max, len(c.Create))]
+	max -= len(limited.Create)
+	limited.UpdateOld = c.UpdateOld[:minInt(max, len(c.UpdateOld))]
+	limited.UpdateNew = c.UpdateNew[:minInt(max, len(c.UpdateNew))]
+	max -= len(limited.UpdateNew)
+	limited.Delete = c.Delete[:minInt(max, len(c.Delete))]
+	return limited
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
 }
 
 // planTable is a supplementary struct for Plan
-// each row correspond to a dnsName -> (current record + all desired records)
+// each row correspond to a dnsName, record type and set identifier -> (current record + all desired records)
 /*
 planTable: (-> = target)
 --------------------------------------------------------
@@ -60,12 +108,35 @@ bar.com |                | [->191.1.1.1, ->190.1.1.1]  |  = create (bar.com -> 1
 "=", i.e. result of calculation relies on supplied ConflictResolver
 */
 type planTable struct {
-	rows     map[string]*planTableRow
-	resolver ConflictResolver
+	rows            map[planTableKey]*planTableRow
+	resolver        ConflictResolver
+	preferCNAME     bool
+	providerManaged []string
+	perProvider     bool
+	// skipped are the names whose records are left unchanged, e.g. because of a conflict between record types
+	skipped map[planTableKey]bool
+}
+
+func newPlanTable(resolver ConflictResolver, preferCNAME bool, providerManaged []string, perProvider bool) planTable {
+	if resolver == nil {
+		resolver = PerResource{}
+	}
+	return planTable{map[planTableKey]*planTableRow{}, resolver, preferCNAME, providerManaged, perProvider, map[planTableKey]bool{}}
 }
 
-func newPlanTable() planTable { //TODO: make resolver configurable
-	return planTable{map[string]*planTableRow{}, PerResource{}}
+// planTableKey identifies a row, the records of a DNS name with different record types, e.g. A and AAAA, different
+// set identifiers, e.g. the locations of a geo record set, or with different providers don't conflict with each other
+type planTableKey struct {
+	dnsName       string
+	recordType    string
+	setIdentifier string
+	provider      string
+}
+
+// name returns the key of the name of the row, regardless of its record type
+func (k planTableKey) name() planTableKey {
+	k.recordType = ""
+	return k
 }
 
 // planTableRow
@@ -77,27 +148,45 @@ type planTableRow struct {
 }
 
 func (t planTable) addCurrent(e *endpoint.Endpoint) {
-	if _, ok := t.rows[e.DNSName]; !ok {
-		t.rows[e.DNSName] = &planTableRow{}
-	}
-	t.rows[e.DNSName].current = e
+	t.row(e).current = e
 }
 
 func (t planTable) addCandidate(e *endpoint.Endpoint) {
-	if _, ok := t.rows[e.DNSName]; !ok {
-		t.rows[e.DNSName] = &planTableRow{}
+	row := t.row(e)
+	row.candidates = append(row.candidates, e)
+}
+
+func (t planTable) key(e *endpoint.Endpoint) planTableKey {
+	key := planTableKey{dnsName: e.DNSName, recordType: e.RecordType, setIdentifier: e.SetIdentifier}
+	if t.perProvider {
+		key.provider = e.Labels[endpoint.ProviderLabelKey]
 	}
-	t.rows[e.DNSName].candidates = append(t.rows[e.DNSName].candidates, e)
+	return key
+}
+
+func (t planTable) row(e *endpoint.Endpoint) *planTableRow {
+	key := t.key(e)
+	if _, ok := t.rows[key]; !ok {
+		t.rows[key] = &planTableRow{}
+	}
+	return t.rows[key]
 }
 
-// TODO: allows record type change, which might not be supported by all dns providers
 func (t planTable) getUpdates() (updateNew []*endpoint.Endpoint, updateOld []*endpoint.Endpoint) {
-	for _, row := range t.rows {
+	for key, row := range t.rows {
+		if t.skipped[key.name()] {
+			continue
+		}
 		if row.current != nil && len(row.candidates) > 0 { //dns name is taken
 			update := t.resolver.ResolveUpdate(row.current, row.candidates)
+			if update == nil { // the resolver skipped the dns name
+				continue
+			}
 			// compare "update" to "current" to figure out if actual update is required
-			if shouldUpdateTTL(update, row.current) || targetChanged(update, row.current) {
+			if shouldUpdateTTL(update, row.current) || targetChanged(update, row.current) ||
+				shouldUpdateGeoLocation(update, row.current) || shouldUpdateProviderSpecific(update, row.current, t.providerManaged) {
 				inheritOwner(row.current, update)
+				inheritProvider(row.current, update)
 				updateNew = append(updateNew, update)
 				updateOld = append(updateOld, row.current)
 			}
@@ -108,16 +197,78 @@ func (t planTable) getUpdates() (updateNew []*endpoint.Endpoint, updateOld []*en
 }
 
 func (t planTable) getCreates() (createList []*endpoint.Endpoint) {
-	for _, row := range t.rows {
+	for key, row := range t.rows {
+		if t.skipped[key.name()] {
+			continue
+		}
 		if row.current == nil { //dns name not taken
-			createList = append(createList, t.resolver.ResolveCreate(row.candidates))
+			if create := t.resolver.ResolveCreate(row.candidates); create != nil {
+				createList = append(createList, create)
+			}
 		}
 	}
 	return
 }
 
+// resolveRecordTypes drops either the CNAME or the A and AAAA candidates if both are desired for a DNS name,
+// since a CNAME record can't coexist with other records of the same name. SkipConflicts leaves the records of these
+// names unchanged instead if the CNAME and the other records are desired by different resources.
+func (t planTable) resolveRecordTypes(desired []*endpoint.Endpoint) []*endpoint.Endpoint {
+	cnames, others := map[planTableKey][]*endpoint.Endpoint{}, map[planTableKey][]*endpoint.Endpoint{}
+	for _, ep := range desired {
+		key := t.key(ep).name()
+		if ep.RecordType == endpoint.RecordTypeCNAME {
+			cnames[key] = append(cnames[key], ep)
+		} else {
+			others[key] = append(others[key], ep)
+		}
+	}
+
+	keepCNAME := map[planTableKey]bool{}
+	for key, cnameCandidates := range cnames {
+		otherCandidates, ok := others[key]
+		if !ok {
+			continue
+		}
+		if _, skip := t.resolver.(SkipConflicts); skip && !sameResource(append(cnameCandidates, otherCandidates...)) {
+			log.WithFields(cnameCandidates[0].LogFields()).Errorf("Skipping the name because %s wants a CNAME record and %s an %s record",
+				cnameCandidates[0].Labels[endpoint.ResourceLabelKey], otherCandidates[0].Labels[endpoint.ResourceLabelKey], otherCandidates[0].RecordType)
+			t.skipped[key] = true
+			continue
+		}
+		if t.preferCNAME {
+			log.WithFields(otherCandidates[0].LogFields()).Warn("Skipping record because a CNAME record is desired for the same name")
+		} else {
+			log.WithFields(cnameCandidates[0].LogFields()).Warnf("Skipping record because an %s record is desired for the same name", otherCandidates[0].RecordType)
+		}
+		keepCNAME[key] = t.preferCNAME
+	}
+
+	resolved := []*endpoint.Endpoint{}
+	for _, ep := range desired {
+		if keep, ok := keepCNAME[t.key(ep).name()]; ok && keep != (ep.RecordType == endpoint.RecordTypeCNAME) {
+			continue
+		}
+		resolved = append(resolved, ep)
+	}
+	return resolved
+}
+
+// sameResource returns true if all endpoints are desired by the same resource
+func sameResource(endpoints []*endpoint.Endpoint) bool {
+	for _, ep := range endpoints {
+		if ep.Labels[endpoint.ResourceLabelKey] != endpoints[0].Labels[endpoint.ResourceLabelKey] {
+			return false
+		}
+	}
+	return true
+}
+
 func (t planTable) getDeletes() (deleteList []*endpoint.Endpoint) {
-	for _, row := range t.rows {
+	for key, row := range t.rows {
+		if t.skipped[key.name()] {
+			continue
+		}
 		if row.current != nil && len(row.candidates) == 0 {
 			deleteList = append(deleteList, row.current)
 		}
@@ -129,32 +280,56 @@ func (t planTable) getDeletes() (deleteList []*endpoint.Endpoint) {
 // state. It then passes those changes to the current policy for further
 // processing. It returns a copy of Plan with the changes populated.
 func (p *Plan) Calculate() *Plan {
-	t := newPlanTable()
+	t := newPlanTable(p.ConflictResolver, p.PreferCNAME, p.ProviderManagedProperties, p.PerProvider)
 
 	for _, current := range p.Current {
 		t.addCurrent(current)
 	}
-	for _, desired := range p.Desired {
-		t.addCandidate(desired)
+	desired := p.Desired
+	if p.MergeGeoLocations {
+		desired = mergeGeoLocations(desired)
+	}
+	for _, candidate := range t.resolveRecordTypes(desired) {
+		t.addCandidate(candidate)
 	}
 
 	changes := &Changes{}
 	changes.Create = t.getCreates()
 	changes.Delete = t.getDeletes()
 	changes.UpdateNew, changes.UpdateOld = t.getUpdates()
+	dropTransientLabels(changes.Create)
+	dropTransientLabels(changes.UpdateNew)
 	for _, pol := range p.Policies {
 		changes = pol.Apply(changes)
 	}
 
 	plan := &Plan{
-		Current: p.Current,
-		Desired: p.Desired,
-		Changes: changes,
+		Current:                   p.Current,
+		Desired:                   desired,
+		ConflictResolver:          p.ConflictResolver,
+		PreferCNAME:               p.PreferCNAME,
+		ProviderManagedProperties: p.ProviderManagedProperties,
+		PerProvider:               p.PerProvider,
+		MergeGeoLocations:         p.MergeGeoLocations,
+		Changes:                   changes,
 	}
 
 	return plan
 }
 
+// transientLabelKeys are the labels only needed before planning, they're dropped from the changes
+var transientLabelKeys = append([]string{endpoint.HealthCheckLabelKey}, resolutionLabelKeys...)
+
+// dropTransientLabels removes the labels only needed by the conflict resolvers and the health checks,
+// so that the registry doesn't store them
+func dropTransientLabels(endpoints []*endpoint.Endpoint) {
+	for _, ep := range endpoints {
+		for _, key := range transientLabelKeys {
+			delete(ep.Labels, key)
+		}
+	}
+}
+
 func inheritOwner(from, to *endpoint.Endpoint) {
 	if to.Labels == nil {
 		to.Labels = map[string]string{}
@@ -165,6 +340,15 @@ func inheritOwner(from, to *endpoint.Endpoint) {
 	to.Labels[endpoint.OwnerLabelKey] = from.Labels[endpoint.OwnerLabelKey]
 }
 
+// inheritProvider keeps the provider label of the current record, an update can't move the record to another provider
+func inheritProvider(from, to *endpoint.Endpoint) {
+	if provider, ok := from.Labels[endpoint.ProviderLabelKey]; ok {
+		to.Labels[endpoint.ProviderLabelKey] = provider
+	} else {
+		delete(to.Labels, endpoint.ProviderLabelKey)
+	}
+}
+
 func targetChanged(desired, current *endpoint.Endpoint) bool {
 	return !desired.Targets.Same(current.Targets)
 }
@@ -175,3 +359,37 @@ func shouldUpdateTTL(desired, current *endpoint.Endpoint) bool {
 	}
 	return desired.RecordTTL != current.RecordTTL
 }
+
+// shouldUpdateGeoLocation returns true if the geo location of the record changed. A geo location only takes effect
+// together with a set identifier, so records without one aren't compared, e.g. of providers not supporting it.
+func shouldUpdateGeoLocation(desired, current *endpoint.Endpoint) bool {
+	if desired.SetIdentifier == "" && current.SetIdentifier == "" {
+		return false
+	}
+	return !desired.GeoLocation.Same(current.GeoLocation)
+}
+
+// shouldUpdateProviderSpecific returns true if a provider specific property was added, changed or removed.
+// Properties the provider manages itself, e.g. the id of a health check it created, are only compared if they're desired.
+func shouldUpdateProviderSpecific(desired, current *endpoint.Endpoint, providerManaged []string) bool {
+	for _, property := range desired.ProviderSpecific {
+		if value, ok := current.ProviderSpecific.Get(property.Name); !ok || value != property.Value {
+			return true
+		}
+	}
+	for _, property := range current.ProviderSpecific {
+		if _, ok := desired.ProviderSpecific.Get(property.Name); !ok && !isProviderManaged(property.Name, providerManaged) {
+			return true
+		}
+	}
+	return false
+}
+
+func isProviderManaged(name string, providerManaged []string) bool {
+	for _, managed := range providerManaged {
+		if name == managed {
+			return true
+		}
+	}
+	return false
+}
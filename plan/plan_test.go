@@ -188,6 +188,111 @@ func (suite *PlanTestSuite) TestSyncSecondRoundWithTTLChange() {
 	validateEntries(suite.T(), changes.Delete, expectedDelete)
 }
 
+func (suite *PlanTestSuite) TestSyncSecondRoundWithGeoLocationChange() {
+	current := &endpoint.Endpoint{
+		DNSName:       "bar",
+		Targets:       endpoint.Targets{"127.0.0.1"},
+		RecordType:    "A",
+		SetIdentifier: "eu",
+		GeoLocation:   &endpoint.GeoLocation{ContinentCode: "EU"},
+	}
+	desired := &endpoint.Endpoint{
+		DNSName:       "bar",
+		Targets:       endpoint.Targets{"127.0.0.1"},
+		RecordType:    "A",
+		SetIdentifier: "eu",
+		GeoLocation:   &endpoint.GeoLocation{CountryCode: "DE"},
+	}
+
+	p := &Plan{
+		Policies: []Policy{&SyncPolicy{}},
+		Current:  []*endpoint.Endpoint{current},
+		Desired:  []*endpoint.Endpoint{desired},
+	}
+
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{desired})
+	validateEntries(suite.T(), changes.UpdateOld, []*endpoint.Endpoint{current})
+	validateEntries(suite.T(), changes.Delete, []*endpoint.Endpoint{})
+}
+
+func (suite *PlanTestSuite) TestSyncSecondRoundWithGeoLocationWithoutSetIdentifier() {
+	desired := &endpoint.Endpoint{
+		DNSName:     "bar",
+		Targets:     endpoint.Targets{"127.0.0.1"},
+		RecordType:  "A",
+		GeoLocation: &endpoint.GeoLocation{CountryCode: "DE"},
+	}
+
+	p := &Plan{
+		Policies: []Policy{&SyncPolicy{}},
+		Current:  []*endpoint.Endpoint{suite.bar127A},
+		Desired:  []*endpoint.Endpoint{desired},
+	}
+
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.UpdateOld, []*endpoint.Endpoint{})
+}
+
+func (suite *PlanTestSuite) TestSyncSecondRoundWithProviderSpecificChange() {
+	current := &endpoint.Endpoint{
+		DNSName:    "bar",
+		Targets:    endpoint.Targets{"127.0.0.1"},
+		RecordType: "A",
+		ProviderSpecific: endpoint.ProviderSpecific{
+			{Name: "aws/failover", Value: "PRIMARY"},
+			{Name: "aws/health-check-id", Value: "abc"},
+		},
+	}
+	unchanged := &endpoint.Endpoint{
+		DNSName:          "bar",
+		Targets:          endpoint.Targets{"127.0.0.1"},
+		RecordType:       "A",
+		ProviderSpecific: endpoint.ProviderSpecific{{Name: "aws/failover", Value: "PRIMARY"}},
+	}
+	changed := &endpoint.Endpoint{
+		DNSName:          "bar",
+		Targets:          endpoint.Targets{"127.0.0.1"},
+		RecordType:       "A",
+		ProviderSpecific: endpoint.ProviderSpecific{{Name: "aws/failover", Value: "SECONDARY"}},
+	}
+	removed := &endpoint.Endpoint{
+		DNSName:    "bar",
+		Targets:    endpoint.Targets{"127.0.0.1"},
+		RecordType: "A",
+	}
+
+	// the health check id is added by the provider
+	p := &Plan{
+		Policies:                  []Policy{&SyncPolicy{}},
+		Current:                   []*endpoint.Endpoint{current},
+		Desired:                   []*endpoint.Endpoint{unchanged},
+		ProviderManagedProperties: []string{"aws/health-check-id"},
+	}
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.UpdateOld, []*endpoint.Endpoint{})
+
+	p.Desired = []*endpoint.Endpoint{changed}
+	changes = p.Calculate().Changes
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{changed})
+	validateEntries(suite.T(), changes.UpdateOld, []*endpoint.Endpoint{current})
+
+	p.Desired = []*endpoint.Endpoint{removed}
+	changes = p.Calculate().Changes
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{removed})
+	validateEntries(suite.T(), changes.UpdateOld, []*endpoint.Endpoint{current})
+
+	// properties the provider doesn't manage are removed
+	p.Desired = []*endpoint.Endpoint{unchanged}
+	p.ProviderManagedProperties = nil
+	changes = p.Calculate().Changes
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{unchanged})
+	validateEntries(suite.T(), changes.UpdateOld, []*endpoint.Endpoint{current})
+}
+
 func (suite *PlanTestSuite) TestSyncSecondRoundWithOwnerInherited() {
 	current := []*endpoint.Endpoint{suite.fooV1Cname}
 	desired := []*endpoint.Endpoint{suite.fooV2Cname}
@@ -243,10 +348,10 @@ func (suite *PlanTestSuite) TestIdempotency() {
 func (suite *PlanTestSuite) TestDifferentTypes() {
 	current := []*endpoint.Endpoint{suite.fooV1Cname}
 	desired := []*endpoint.Endpoint{suite.fooV2Cname, suite.fooA5}
-	expectedCreate := []*endpoint.Endpoint{}
-	expectedUpdateOld := []*endpoint.Endpoint{suite.fooV1Cname}
-	expectedUpdateNew := []*endpoint.Endpoint{suite.fooA5}
-	expectedDelete := []*endpoint.Endpoint{}
+	expectedCreate := []*endpoint.Endpoint{suite.fooA5}
+	expectedUpdateOld := []*endpoint.Endpoint{}
+	expectedUpdateNew := []*endpoint.Endpoint{}
+	expectedDelete := []*endpoint.Endpoint{suite.fooV1Cname}
 
 	p := &Plan{
 		Policies: []Policy{&SyncPolicy{}},
@@ -347,6 +452,251 @@ func (suite *PlanTestSuite) TestDuplicatedEndpointsForSameResourceRetain() {
 	validateEntries(suite.T(), changes.Delete, expectedDelete)
 }
 
+func (suite *PlanTestSuite) TestConflictResolverSkipsName() {
+	current := []*endpoint.Endpoint{suite.fooV1Cname}
+	desired := []*endpoint.Endpoint{suite.fooV1Cname, suite.fooA5, suite.bar127A, suite.bar192A}
+
+	p := &Plan{
+		Policies:         []Policy{&SyncPolicy{}},
+		Current:          current,
+		Desired:          desired,
+		ConflictResolver: SkipConflicts{},
+	}
+
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.UpdateOld, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.Delete, []*endpoint.Endpoint{})
+}
+
+func (suite *PlanTestSuite) TestTransientLabelsAreDropped() {
+	bar := &endpoint.Endpoint{
+		DNSName:    "bar",
+		Targets:    endpoint.Targets{"127.0.0.1"},
+		RecordType: "A",
+		Labels: map[string]string{
+			endpoint.ResourceLabelKey:        "ingress/default/bar-127",
+			endpoint.ResourceCreatedLabelKey: "2018-01-01T00:00:00Z",
+			endpoint.PriorityLabelKey:        "10",
+			endpoint.HealthCheckLabelKey:     "tcp://:80",
+		},
+	}
+
+	p := &Plan{
+		Policies:         []Policy{&SyncPolicy{}},
+		Current:          []*endpoint.Endpoint{},
+		Desired:          []*endpoint.Endpoint{bar, suite.bar192A},
+		ConflictResolver: Priority{},
+	}
+
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{suite.bar127A})
+	suite.Equal(endpoint.Labels{endpoint.ResourceLabelKey: "ingress/default/bar-127"}, changes.Create[0].Labels)
+}
+
+func (suite *PlanTestSuite) TestProviderIsInherited() {
+	current := &endpoint.Endpoint{
+		DNSName:    "bar",
+		Targets:    endpoint.Targets{"127.0.0.1"},
+		RecordType: "A",
+		Labels:     map[string]string{endpoint.ProviderLabelKey: "aws"},
+	}
+	desired := &endpoint.Endpoint{
+		DNSName:    "bar",
+		Targets:    endpoint.Targets{"192.168.0.1"},
+		RecordType: "A",
+		Labels:     map[string]string{endpoint.ProviderLabelKey: "cloudflare"},
+	}
+
+	p := &Plan{
+		Policies: []Policy{&SyncPolicy{}},
+		Current:  []*endpoint.Endpoint{current},
+		Desired:  []*endpoint.Endpoint{desired},
+	}
+
+	changes := p.Calculate().Changes
+	suite.Len(changes.UpdateNew, 1)
+	suite.Equal("aws", changes.UpdateNew[0].Labels[endpoint.ProviderLabelKey])
+
+	delete(current.Labels, endpoint.ProviderLabelKey)
+	desired.Labels[endpoint.ProviderLabelKey] = "cloudflare"
+	changes = p.Calculate().Changes
+	suite.Len(changes.UpdateNew, 1)
+	_, ok := changes.UpdateNew[0].Labels[endpoint.ProviderLabelKey]
+	suite.False(ok)
+}
+
+func (suite *PlanTestSuite) TestSetIdentifiersDontConflict() {
+	eu := &endpoint.Endpoint{
+		DNSName:       "bar",
+		Targets:       endpoint.Targets{"127.0.0.1"},
+		RecordType:    "A",
+		SetIdentifier: "eu",
+	}
+	us := &endpoint.Endpoint{
+		DNSName:       "bar",
+		Targets:       endpoint.Targets{"192.168.0.1"},
+		RecordType:    "A",
+		SetIdentifier: "us",
+	}
+
+	p := &Plan{
+		Policies: []Policy{&SyncPolicy{}},
+		Current:  []*endpoint.Endpoint{eu},
+		Desired:  []*endpoint.Endpoint{eu, us},
+	}
+
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{us})
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.UpdateOld, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.Delete, []*endpoint.Endpoint{})
+}
+
+func (suite *PlanTestSuite) TestProvidersDontConflict() {
+	public := &endpoint.Endpoint{
+		DNSName:    "bar",
+		Targets:    endpoint.Targets{"1.2.3.4"},
+		RecordType: "A",
+		Labels:     map[string]string{endpoint.ProviderLabelKey: "aws"},
+	}
+	private := &endpoint.Endpoint{
+		DNSName:    "bar",
+		Targets:    endpoint.Targets{"10.0.0.1"},
+		RecordType: "A",
+		Labels:     map[string]string{endpoint.ProviderLabelKey: "inmemory"},
+	}
+
+	p := &Plan{
+		Policies:    []Policy{&SyncPolicy{}},
+		Current:     []*endpoint.Endpoint{public},
+		Desired:     []*endpoint.Endpoint{public, private},
+		PerProvider: true,
+	}
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{private})
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.Delete, []*endpoint.Endpoint{})
+
+	// the records of both providers are kept
+	p.Current = []*endpoint.Endpoint{public, private}
+	suite.False(p.Calculate().Changes.HasChanges())
+
+	// a record moved to another provider is created there and deleted from the old one
+	p.Desired = []*endpoint.Endpoint{private}
+	p.Current = []*endpoint.Endpoint{public}
+	changes = p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{private})
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.Delete, []*endpoint.Endpoint{public})
+}
+
+func (suite *PlanTestSuite) TestHasChanges() {
+	suite.False((&Changes{}).HasChanges())
+	suite.True((&Changes{Create: []*endpoint.Endpoint{suite.fooV1Cname}}).HasChanges())
+	suite.True((&Changes{UpdateOld: []*endpoint.Endpoint{suite.fooV1Cname}, UpdateNew: []*endpoint.Endpoint{suite.fooV2Cname}}).HasChanges())
+	suite.True((&Changes{Delete: []*endpoint.Endpoint{suite.bar127A}}).HasChanges())
+}
+
+func (suite *PlanTestSuite) TestCNAMEConflictFirstRound() {
+	p := &Plan{
+		Policies: []Policy{&SyncPolicy{}},
+		Current:  []*endpoint.Endpoint{},
+		Desired:  []*endpoint.Endpoint{suite.fooV1Cname, suite.fooA5},
+	}
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{suite.fooA5})
+
+	p.PreferCNAME = true
+	changes = p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{suite.fooV1Cname})
+}
+
+func (suite *PlanTestSuite) TestCNAMEConflictSecondRound() {
+	// the resource owning the CNAME record keeps it by default, unless another resource wants an A record
+	p := &Plan{
+		Policies: []Policy{&SyncPolicy{}},
+		Current:  []*endpoint.Endpoint{suite.fooV1Cname},
+		Desired:  []*endpoint.Endpoint{suite.fooV1Cname, suite.fooA5},
+	}
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{suite.fooA5})
+	validateEntries(suite.T(), changes.UpdateOld, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.Delete, []*endpoint.Endpoint{suite.fooV1Cname})
+
+	p.PreferCNAME = true
+	suite.False(p.Calculate().Changes.HasChanges())
+}
+
+func (suite *PlanTestSuite) TestCNAMEConflictSkipped() {
+	// the records of a name are left unchanged if different resources want a CNAME and an A record
+	p := &Plan{
+		Policies:         []Policy{&SyncPolicy{}},
+		Current:          []*endpoint.Endpoint{suite.fooV1Cname},
+		Desired:          []*endpoint.Endpoint{suite.fooV1Cname, suite.fooA5},
+		ConflictResolver: SkipConflicts{},
+	}
+	suite.False(p.Calculate().Changes.HasChanges())
+}
+
+func (suite *PlanTestSuite) TestDualStack() {
+	a := &endpoint.Endpoint{
+		DNSName:    "bar",
+		Targets:    endpoint.Targets{"127.0.0.1"},
+		RecordType: endpoint.RecordTypeA,
+		Labels:     map[string]string{endpoint.ResourceLabelKey: "service/default/bar"},
+	}
+	aaaa := &endpoint.Endpoint{
+		DNSName:    "bar",
+		Targets:    endpoint.Targets{"2001:db8::1"},
+		RecordType: endpoint.RecordTypeAAAA,
+		Labels:     map[string]string{endpoint.ResourceLabelKey: "service/default/bar"},
+	}
+
+	p := &Plan{
+		Policies: []Policy{&SyncPolicy{}},
+		Desired:  []*endpoint.Endpoint{a, aaaa},
+	}
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{a, aaaa})
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.Delete, []*endpoint.Endpoint{})
+
+	// both records are kept by the next synchronization
+	p.Current = []*endpoint.Endpoint{a, aaaa}
+	suite.False(p.Calculate().Changes.HasChanges())
+}
+
+func (suite *PlanTestSuite) TestLimit() {
+	changes := &Changes{
+		Create:    []*endpoint.Endpoint{suite.fooV1Cname, suite.bar127A},
+		UpdateOld: []*endpoint.Endpoint{suite.fooV1Cname, suite.bar127A},
+		UpdateNew: []*endpoint.Endpoint{suite.fooV2Cname, suite.bar127AWithTTL},
+		Delete:    []*endpoint.Endpoint{suite.bar192A},
+	}
+	suite.Equal(5, changes.Size())
+	suite.Equal(changes, changes.Limit(0))
+	suite.Equal(changes, changes.Limit(5))
+
+	limited := changes.Limit(1)
+	validateEntries(suite.T(), limited.Create, []*endpoint.Endpoint{suite.fooV1Cname})
+	validateEntries(suite.T(), limited.UpdateOld, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), limited.UpdateNew, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), limited.Delete, []*endpoint.Endpoint{})
+
+	limited = changes.Limit(3)
+	validateEntries(suite.T(), limited.Create, []*endpoint.Endpoint{suite.fooV1Cname, suite.bar127A})
+	validateEntries(suite.T(), limited.UpdateOld, []*endpoint.Endpoint{suite.fooV1Cname})
+	validateEntries(suite.T(), limited.UpdateNew, []*endpoint.Endpoint{suite.fooV2Cname})
+	validateEntries(suite.T(), limited.Delete, []*endpoint.Endpoint{})
+
+	limited = changes.Limit(4)
+	suite.Equal(4, limited.Size())
+	validateEntries(suite.T(), limited.Delete, []*endpoint.Endpoint{})
+}
+
 func TestPlan(t *testing.T) {
 	suite.Run(t, new(PlanTestSuite))
 }
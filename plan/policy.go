@@ -16,6 +16,10 @@ limitations under the License.
 
 package plan
 
+import (
+	log "github.com/sirupsen/logrus"
+)
+
 // Policy allows to apply different rules to a set of changes.
 type Policy interface {
 	Apply(changes *Changes) *Changes
@@ -25,6 +29,7 @@ type Policy interface {
 var Policies = map[string]Policy{
 	"sync":        &SyncPolicy{},
 	"upsert-only": &UpsertOnlyPolicy{},
+	"create-only": &CreateOnlyPolicy{},
 }
 
 // SyncPolicy allows for full synchronization of DNS records.
@@ -35,14 +40,35 @@ func (p *SyncPolicy) Apply(changes *Changes) *Changes {
 	return changes
 }
 
-// UpsertOnlyPolicy allows evrything but deleting DNS records.
+// UpsertOnlyPolicy allows everything but deleting DNS records.
 type UpsertOnlyPolicy struct{}
 
 // Apply applies the upsert-only policy which strips out any deletions.
+// The skipped deletions are logged, so that they can be reviewed before switching to full synchronization.
 func (p *UpsertOnlyPolicy) Apply(changes *Changes) *Changes {
+	for _, ep := range changes.Delete {
+		log.WithFields(ep.LogFields()).Info("Skipping deletion because of the upsert-only policy")
+	}
 	return &Changes{
 		Create:    changes.Create,
 		UpdateOld: changes.UpdateOld,
 		UpdateNew: changes.UpdateNew,
 	}
 }
+
+// CreateOnlyPolicy only allows creating DNS records, existing records are never modified.
+type CreateOnlyPolicy struct{}
+
+// Apply applies the create-only policy which strips out any updates and deletions.
+// Skipping them is expected, e.g. for records tuned by hand after their creation, so they're only logged at debug level.
+func (p *CreateOnlyPolicy) Apply(changes *Changes) *Changes {
+	for _, ep := range changes.UpdateNew {
+		log.WithFields(ep.LogFields()).Debug("Skipping update because of the create-only policy")
+	}
+	for _, ep := range changes.Delete {
+		log.WithFields(ep.LogFields()).Debug("Skipping deletion because of the create-only policy")
+	}
+	return &Changes{
+		Create: changes.Create,
+	}
+}
@@ -0,0 +1,335 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/akamai/AkamaiOPEN-edgegrid-golang/edgegrid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+const (
+	// akamaiDefaultTTL is used for records which don't define a TTL, Edge DNS requires one to be set
+	akamaiDefaultTTL = 600
+)
+
+// AkamaiConfig holds the configuration of the Akamai Edge DNS provider.
+type AkamaiConfig struct {
+	DomainFilter          DomainFilter
+	ZoneIDFilter          ZoneIDFilter
+	ServiceConsumerDomain string
+	ClientToken           string
+	ClientSecret          string
+	AccessToken           string
+	// RateLimiter optionally limits the rate of the requests to the API
+	RateLimiter *RateLimiter
+	DryRun      bool
+}
+
+// akamaiZone is an Edge DNS zone as returned by the zone listing.
+type akamaiZone struct {
+	Zone string `json:"zone"`
+	Type string `json:"type"`
+}
+
+// akamaiRecordset is a set of records sharing name and type. Names are fully qualified.
+type akamaiRecordset struct {
+	Name  string   `json:"name"`
+	Type  string   `json:"type"`
+	TTL   int      `json:"ttl"`
+	Rdata []string `json:"rdata"`
+}
+
+// akamaiDNSClient is the subset of the Edge DNS configuration API that we actually use.
+type akamaiDNSClient interface {
+	ListZones() ([]akamaiZone, error)
+	ListRecordsets(zone string) ([]akamaiRecordset, error)
+	CreateRecordset(zone string, recordset akamaiRecordset) error
+	UpdateRecordset(zone string, recordset akamaiRecordset) error
+	DeleteRecordset(zone string, recordset akamaiRecordset) error
+}
+
+// AkamaiProvider is an implementation of Provider for Akamai Edge DNS.
+type AkamaiProvider struct {
+	client akamaiDNSClient
+	// only consider hosted zones managing domains ending in this suffix
+	domainFilter DomainFilter
+	// filter zones by name, Edge DNS uses the zone name as its identifier
+	zoneIDFilter ZoneIDFilter
+	dryRun       bool
+}
+
+// NewAkamaiProvider initializes a new Akamai Edge DNS based Provider.
+func NewAkamaiProvider(config AkamaiConfig) (*AkamaiProvider, error) {
+	if config.ServiceConsumerDomain == "" || config.ClientToken == "" || config.ClientSecret == "" || config.AccessToken == "" {
+		return nil, fmt.Errorf("incomplete Akamai EdgeGrid credentials")
+	}
+
+	edgegridConfig := edgegrid.Config{
+		Host:         config.ServiceConsumerDomain,
+		ClientToken:  config.ClientToken,
+		ClientSecret: config.ClientSecret,
+		AccessToken:  config.AccessToken,
+		MaxBody:      131072,
+	}
+
+	provider := &AkamaiProvider{
+		client:       newAkamaiAPIClient("https://"+config.ServiceConsumerDomain, config.RateLimiter.Client(&http.Client{Timeout: 30 * time.Second}), edgegridConfig),
+		domainFilter: config.DomainFilter,
+		zoneIDFilter: config.ZoneIDFilter,
+		dryRun:       config.DryRun,
+	}
+	return provider, nil
+}
+
+// Zones returns the names of all primary zones managed by this provider.
+func (p *AkamaiProvider) Zones() ([]string, error) {
+	zones, err := p.client.ListZones()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []string{}
+	for _, zone := range zones {
+		// secondary zones are read-only copies that can't be modified through the API
+		if !strings.EqualFold(zone.Type, "primary") {
+			continue
+		}
+		if !p.domainFilter.Match(zone.Zone) || !p.zoneIDFilter.Match(zone.Zone) {
+			continue
+		}
+		result = append(result, zone.Zone)
+	}
+	return result, nil
+}
+
+// Records returns the list of records in all relevant zones.
+func (p *AkamaiProvider) Records() ([]*endpoint.Endpoint, error) {
+	zones, err := p.Zones()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := []*endpoint.Endpoint{}
+	for _, zone := range zones {
+		recordsets, err := p.client.ListRecordsets(zone)
+		if err != nil {
+			return nil, err
+		}
+		for _, rs := range recordsets {
+			if !supportedRecordType(rs.Type) || len(rs.Rdata) == 0 {
+				continue
+			}
+			targets := make(endpoint.Targets, 0, len(rs.Rdata))
+			for _, rdata := range rs.Rdata {
+				if rs.Type == endpoint.RecordTypeTXT {
+					rdata = strings.Trim(rdata, "\"")
+				}
+				targets = append(targets, strings.TrimSuffix(rdata, "."))
+			}
+			endpoints = append(endpoints, &endpoint.Endpoint{
+				DNSName:    strings.TrimSuffix(rs.Name, "."),
+				Targets:    targets,
+				RecordType: rs.Type,
+				RecordTTL:  endpoint.TTL(rs.TTL),
+				Labels:     endpoint.NewLabels(),
+			})
+		}
+	}
+	return endpoints, nil
+}
+
+// ApplyChanges applies a given set of changes in all relevant zones.
+func (p *AkamaiProvider) ApplyChanges(changes *plan.Changes) error {
+	if len(changes.Create)+len(changes.UpdateNew)+len(changes.Delete) == 0 {
+		return nil
+	}
+
+	zones, err := p.Zones()
+	if err != nil {
+		return err
+	}
+	zoneNameIDMapper := zoneIDName{}
+	for _, zone := range zones {
+		zoneNameIDMapper.Add(zone, zone)
+	}
+
+	if err := p.submitChanges(zoneNameIDMapper, "CREATE", changes.Create, p.client.CreateRecordset); err != nil {
+		return err
+	}
+	if err := p.submitChanges(zoneNameIDMapper, "UPDATE", changes.UpdateNew, p.client.UpdateRecordset); err != nil {
+		return err
+	}
+	return p.submitChanges(zoneNameIDMapper, "DELETE", changes.Delete, p.client.DeleteRecordset)
+}
+
+// submitChanges sends one recordset per endpoint to the given API call.
+func (p *AkamaiProvider) submitChanges(zones zoneIDName, action string, endpoints []*endpoint.Endpoint, apply func(string, akamaiRecordset) error) error {
+	for _, ep := range endpoints {
+		zone, _ := zones.FindZone(ep.DNSName)
+		if zone == "" {
+			log.Debugf("Skipping record %s because no hosted zone matching record DNS Name was detected", ep.DNSName)
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"record": ep.DNSName,
+			"type":   ep.RecordType,
+			"action": action,
+			"zone":   zone,
+		}).Info("Changing record.")
+
+		if p.dryRun {
+			continue
+		}
+
+		if err := apply(zone, newAkamaiRecordset(ep)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newAkamaiRecordset converts an endpoint into an Edge DNS recordset.
+func newAkamaiRecordset(ep *endpoint.Endpoint) akamaiRecordset {
+	ttl := akamaiDefaultTTL
+	if ep.RecordTTL.IsConfigured() {
+		ttl = int(ep.RecordTTL)
+	}
+
+	rdata := make([]string, 0, len(ep.Targets))
+	for _, target := range ep.Targets {
+		switch ep.RecordType {
+		case endpoint.RecordTypeTXT:
+			if !strings.HasPrefix(target, "\"") {
+				target = "\"" + target + "\""
+			}
+		case endpoint.RecordTypeCNAME:
+			target = ensureTrailingDot(target)
+		}
+		rdata = append(rdata, target)
+	}
+
+	return akamaiRecordset{
+		Name:  ep.DNSName,
+		Type:  ep.RecordType,
+		TTL:   ttl,
+		Rdata: rdata,
+	}
+}
+
+// akamaiAPIClient is a minimal client for the Edge DNS configuration API v2
+// which signs all requests with EdgeGrid credentials.
+type akamaiAPIClient struct {
+	baseURL    string
+	httpClient *http.Client
+	config     edgegrid.Config
+}
+
+func newAkamaiAPIClient(baseURL string, httpClient *http.Client, config edgegrid.Config) *akamaiAPIClient {
+	return &akamaiAPIClient{
+		baseURL:    baseURL,
+		httpClient: httpClient,
+		config:     config,
+	}
+}
+
+func (c *akamaiAPIClient) ListZones() ([]akamaiZone, error) {
+	var resp struct {
+		Zones []akamaiZone `json:"zones"`
+	}
+	if err := c.do(http.MethodGet, "/config-dns/v2/zones?showAll=true&types=primary", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Zones, nil
+}
+
+func (c *akamaiAPIClient) ListRecordsets(zone string) ([]akamaiRecordset, error) {
+	var resp struct {
+		Recordsets []akamaiRecordset `json:"recordsets"`
+	}
+	if err := c.do(http.MethodGet, fmt.Sprintf("/config-dns/v2/zones/%s/recordsets?showAll=true", url.PathEscape(zone)), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Recordsets, nil
+}
+
+func (c *akamaiAPIClient) CreateRecordset(zone string, recordset akamaiRecordset) error {
+	return c.do(http.MethodPost, akamaiRecordsetPath(zone, recordset), recordset, nil)
+}
+
+func (c *akamaiAPIClient) UpdateRecordset(zone string, recordset akamaiRecordset) error {
+	return c.do(http.MethodPut, akamaiRecordsetPath(zone, recordset), recordset, nil)
+}
+
+func (c *akamaiAPIClient) DeleteRecordset(zone string, recordset akamaiRecordset) error {
+	return c.do(http.MethodDelete, akamaiRecordsetPath(zone, recordset), nil, nil)
+}
+
+func akamaiRecordsetPath(zone string, recordset akamaiRecordset) string {
+	return fmt.Sprintf("/config-dns/v2/zones/%s/names/%s/types/%s", url.PathEscape(zone), url.PathEscape(recordset.Name), url.PathEscape(recordset.Type))
+}
+
+// do sends a single signed request to the Edge DNS API.
+func (c *akamaiAPIClient) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req = edgegrid.AddRequestHeader(c.config, req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("akamai API %s %s failed with status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
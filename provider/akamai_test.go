@@ -0,0 +1,220 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/akamai/AkamaiOPEN-edgegrid-golang/edgegrid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+type mockAkamaiClient struct {
+	zones      []akamaiZone
+	recordsets map[string][]akamaiRecordset
+	calls      []string
+}
+
+func newMockAkamaiClient() *mockAkamaiClient {
+	return &mockAkamaiClient{
+		zones: []akamaiZone{
+			{Zone: "example.com", Type: "PRIMARY"},
+			{Zone: "example.org", Type: "primary"},
+			{Zone: "secondary.com", Type: "SECONDARY"},
+		},
+		recordsets: map[string][]akamaiRecordset{
+			"example.com": {
+				{Name: "www.example.com", Type: "A", TTL: 300, Rdata: []string{"10.0.0.1", "10.0.0.2"}},
+				{Name: "www.example.com", Type: "TXT", TTL: 300, Rdata: []string{"\"heritage=external-dns,external-dns/owner=default\""}},
+				{Name: "example.com", Type: "SOA", TTL: 86400, Rdata: []string{"a1-1.akam.net. hostmaster.example.com. 1 3600 600 604800 300"}},
+			},
+			"example.org": {
+				{Name: "app.example.org", Type: "CNAME", TTL: 600, Rdata: []string{"lb.example.com."}},
+			},
+		},
+	}
+}
+
+func (m *mockAkamaiClient) ListZones() ([]akamaiZone, error) {
+	return m.zones, nil
+}
+
+func (m *mockAkamaiClient) ListRecordsets(zone string) ([]akamaiRecordset, error) {
+	return m.recordsets[zone], nil
+}
+
+func (m *mockAkamaiClient) CreateRecordset(zone string, recordset akamaiRecordset) error {
+	m.record("CREATE", zone, recordset)
+	return nil
+}
+
+func (m *mockAkamaiClient) UpdateRecordset(zone string, recordset akamaiRecordset) error {
+	m.record("UPDATE", zone, recordset)
+	return nil
+}
+
+func (m *mockAkamaiClient) DeleteRecordset(zone string, recordset akamaiRecordset) error {
+	m.record("DELETE", zone, recordset)
+	return nil
+}
+
+func (m *mockAkamaiClient) record(action, zone string, recordset akamaiRecordset) {
+	data, _ := json.Marshal(recordset)
+	m.calls = append(m.calls, action+" "+zone+" "+string(data))
+}
+
+func TestNewAkamaiProvider(t *testing.T) {
+	_, err := NewAkamaiProvider(AkamaiConfig{ServiceConsumerDomain: "akab-xxx.luna.akamaiapis.net"})
+	assert.Error(t, err)
+
+	_, err = NewAkamaiProvider(AkamaiConfig{
+		ServiceConsumerDomain: "akab-xxx.luna.akamaiapis.net",
+		ClientToken:           "client-token",
+		ClientSecret:          "client-secret",
+		AccessToken:           "access-token",
+	})
+	assert.NoError(t, err)
+}
+
+func TestAkamaiZones(t *testing.T) {
+	provider := &AkamaiProvider{
+		client:       newMockAkamaiClient(),
+		domainFilter: NewDomainFilter([]string{""}),
+		zoneIDFilter: NewZoneIDFilter([]string{}),
+	}
+
+	zones, err := provider.Zones()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example.com", "example.org"}, zones)
+
+	provider.zoneIDFilter = NewZoneIDFilter([]string{"example.org"})
+	zones, err = provider.Zones()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example.org"}, zones)
+}
+
+func TestAkamaiRecords(t *testing.T) {
+	provider := &AkamaiProvider{
+		client:       newMockAkamaiClient(),
+		domainFilter: NewDomainFilter([]string{""}),
+		zoneIDFilter: NewZoneIDFilter([]string{}),
+	}
+
+	endpoints, err := provider.Records()
+	require.NoError(t, err)
+
+	expected := []*endpoint.Endpoint{
+		{DNSName: "www.example.com", Targets: endpoint.Targets{"10.0.0.1", "10.0.0.2"}, RecordType: endpoint.RecordTypeA, RecordTTL: 300, Labels: endpoint.NewLabels()},
+		endpoint.NewEndpointWithTTL("www.example.com", "heritage=external-dns,external-dns/owner=default", endpoint.RecordTypeTXT, 300),
+		endpoint.NewEndpointWithTTL("app.example.org", "lb.example.com", endpoint.RecordTypeCNAME, 600),
+	}
+	assert.Equal(t, expected, endpoints)
+}
+
+func TestAkamaiApplyChanges(t *testing.T) {
+	client := newMockAkamaiClient()
+	provider := &AkamaiProvider{
+		client:       client,
+		domainFilter: NewDomainFilter([]string{""}),
+		zoneIDFilter: NewZoneIDFilter([]string{}),
+	}
+
+	err := provider.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("new.example.com", "10.0.0.3", endpoint.RecordTypeA),
+			endpoint.NewEndpoint("new.example.com", "\"heritage=external-dns,external-dns/owner=default\"", endpoint.RecordTypeTXT),
+			endpoint.NewEndpoint("new.unknown.net", "10.0.0.3", endpoint.RecordTypeA),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("app.example.org", "lb2.example.com", endpoint.RecordTypeCNAME, 60),
+		},
+		Delete: []*endpoint.Endpoint{
+			{DNSName: "www.example.com", Targets: endpoint.Targets{"10.0.0.1", "10.0.0.2"}, RecordType: endpoint.RecordTypeA},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`CREATE example.com {"name":"new.example.com","type":"A","ttl":600,"rdata":["10.0.0.3"]}`,
+		`CREATE example.com {"name":"new.example.com","type":"TXT","ttl":600,"rdata":["\"heritage=external-dns,external-dns/owner=default\""]}`,
+		`UPDATE example.org {"name":"app.example.org","type":"CNAME","ttl":60,"rdata":["lb2.example.com."]}`,
+		`DELETE example.com {"name":"www.example.com","type":"A","ttl":600,"rdata":["10.0.0.1","10.0.0.2"]}`,
+	}, client.calls)
+}
+
+func TestAkamaiApplyChangesDryRun(t *testing.T) {
+	client := newMockAkamaiClient()
+	provider := &AkamaiProvider{
+		client:       client,
+		domainFilter: NewDomainFilter([]string{""}),
+		zoneIDFilter: NewZoneIDFilter([]string{}),
+		dryRun:       true,
+	}
+
+	err := provider.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("new.example.com", "10.0.0.3", endpoint.RecordTypeA)},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, client.calls)
+}
+
+func TestAkamaiAPIClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "EG1-HMAC-SHA256"))
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/config-dns/v2/zones":
+			w.Write([]byte(`{"zones": [{"zone": "example.com", "type": "PRIMARY"}]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/config-dns/v2/zones/example.com/recordsets":
+			w.Write([]byte(`{"recordsets": [{"name": "www.example.com", "type": "A", "ttl": 300, "rdata": ["10.0.0.1"]}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/config-dns/v2/zones/example.com/names/www.example.com/types/A":
+			body, _ := ioutil.ReadAll(r.Body)
+			assert.JSONEq(t, `{"name": "www.example.com", "type": "A", "ttl": 300, "rdata": ["10.0.0.1"]}`, string(body))
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := newAkamaiAPIClient(server.URL, server.Client(), edgegrid.Config{
+		ClientToken:  "client-token",
+		ClientSecret: "client-secret",
+		AccessToken:  "access-token",
+	})
+
+	zones, err := client.ListZones()
+	require.NoError(t, err)
+	assert.Equal(t, []akamaiZone{{Zone: "example.com", Type: "PRIMARY"}}, zones)
+
+	recordsets, err := client.ListRecordsets("example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []akamaiRecordset{{Name: "www.example.com", Type: "A", TTL: 300, Rdata: []string{"10.0.0.1"}}}, recordsets)
+
+	rs := akamaiRecordset{Name: "www.example.com", Type: "A", TTL: 300, Rdata: []string{"10.0.0.1"}}
+	require.NoError(t, client.CreateRecordset("example.com", rs))
+	assert.Error(t, client.DeleteRecordset("example.com", rs))
+}
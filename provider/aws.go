@@ -17,10 +17,17 @@ limitations under the License.
 package provider
 
 import (
+	"fmt"
+	"math/rand"
+	"net"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/kubernetes-incubator/external-dns/endpoint"
@@ -30,10 +37,26 @@ import (
 )
 
 const (
+	hostedZonePrefix     = "/hostedzone/"
 	elbHostnameSuffix    = ".elb.amazonaws.com"
 	evaluateTargetHealth = true
 	recordTTL            = 300
 	maxChangeCount       = 4000
+	// healthCheckCallerReferencePrefix marks health checks created and managed by ExternalDNS
+	healthCheckCallerReferencePrefix = "external-dns-"
+)
+
+const (
+	// providerSpecificFailover is the failover role of a record, PRIMARY or SECONDARY
+	providerSpecificFailover = "aws/failover"
+	// providerSpecificHealthCheckID is the id of the health check attached to a record
+	providerSpecificHealthCheckID = "aws/health-check-id"
+	// providerSpecificHealthCheckType is the type of the health check to create for a record, HTTP, HTTPS or TCP
+	providerSpecificHealthCheckType = "aws/health-check-type"
+	// providerSpecificHealthCheckPort is the port the health check connects to, defaults to 80 for HTTP and 443 for HTTPS
+	providerSpecificHealthCheckPort = "aws/health-check-port"
+	// providerSpecificHealthCheckPath is the path requested by HTTP and HTTPS health checks, defaults to /
+	providerSpecificHealthCheckPath = "aws/health-check-path"
 )
 
 var (
@@ -64,6 +87,25 @@ type Route53API interface {
 	ChangeResourceRecordSets(*route53.ChangeResourceRecordSetsInput) (*route53.ChangeResourceRecordSetsOutput, error)
 	CreateHostedZone(*route53.CreateHostedZoneInput) (*route53.CreateHostedZoneOutput, error)
 	ListHostedZonesPages(input *route53.ListHostedZonesInput, fn func(resp *route53.ListHostedZonesOutput, lastPage bool) (shouldContinue bool)) error
+	CreateHealthCheck(*route53.CreateHealthCheckInput) (*route53.CreateHealthCheckOutput, error)
+	DeleteHealthCheck(*route53.DeleteHealthCheckInput) (*route53.DeleteHealthCheckOutput, error)
+	ListHealthChecksPages(input *route53.ListHealthChecksInput, fn func(resp *route53.ListHealthChecksOutput, lastPage bool) (shouldContinue bool)) error
+}
+
+// AWSConfig contains configuration to create a new AWS provider.
+type AWSConfig struct {
+	DomainFilter   DomainFilter
+	ZoneIDFilter   ZoneIDFilter
+	ZoneTypeFilter ZoneTypeFilter
+	// AssumeRole is the ARN of an IAM role to assume for all API calls
+	AssumeRole string
+	// AssumeRoleExternalID is passed when assuming any role
+	AssumeRoleExternalID string
+	// ZoneRoles maps hosted zone ids to IAM roles to assume to manage them, e.g. for zones in a central DNS account
+	ZoneRoles map[string]string
+	// RateLimiter limits the rate of the API requests, unlimited if nil
+	RateLimiter *RateLimiter
+	DryRun      bool
 }
 
 // AWSProvider is an implementation of Provider for AWS Route53.
@@ -76,10 +118,14 @@ type AWSProvider struct {
 	zoneIDFilter ZoneIDFilter
 	// filter hosted zones by type (e.g. private or public)
 	zoneTypeFilter ZoneTypeFilter
+	// IAM roles to assume for hosted zones of other accounts, keyed by hosted zone id without prefix
+	zoneRoles map[string]string
+	// clients for the roles in zoneRoles
+	roleClients map[string]Route53API
 }
 
 // NewAWSProvider initializes a new AWS Route53 based Provider.
-func NewAWSProvider(domainFilter DomainFilter, zoneIDFilter ZoneIDFilter, zoneTypeFilter ZoneTypeFilter, dryRun bool) (*AWSProvider, error) {
+func NewAWSProvider(awsConfig AWSConfig) (*AWSProvider, error) {
 	config := aws.NewConfig()
 
 	config = config.WithHTTPClient(
@@ -98,47 +144,71 @@ func NewAWSProvider(domainFilter DomainFilter, zoneIDFilter ZoneIDFilter, zoneTy
 	if err != nil {
 		return nil, err
 	}
+	if awsConfig.RateLimiter != nil {
+		session.Handlers.Send.PushFrontNamed(awsConfig.RateLimiter.awsHandler())
+	}
+
+	newClient := func(role string) Route53API {
+		if role == "" {
+			return route53.New(session)
+		}
+		log.Infof("Assuming role %s", role)
+		creds := stscreds.NewCredentials(session, role, func(p *stscreds.AssumeRoleProvider) {
+			if awsConfig.AssumeRoleExternalID != "" {
+				p.ExternalID = aws.String(awsConfig.AssumeRoleExternalID)
+			}
+		})
+		return route53.New(session, &aws.Config{Credentials: creds})
+	}
 
 	provider := &AWSProvider{
-		client:         route53.New(session),
-		domainFilter:   domainFilter,
-		zoneIDFilter:   zoneIDFilter,
-		zoneTypeFilter: zoneTypeFilter,
-		dryRun:         dryRun,
+		client:         newClient(awsConfig.AssumeRole),
+		domainFilter:   awsConfig.DomainFilter,
+		zoneIDFilter:   awsConfig.ZoneIDFilter,
+		zoneTypeFilter: awsConfig.ZoneTypeFilter,
+		dryRun:         awsConfig.DryRun,
+		zoneRoles:      map[string]string{},
+		roleClients:    map[string]Route53API{},
+	}
+
+	for zoneID, role := range awsConfig.ZoneRoles {
+		provider.zoneRoles[strings.TrimPrefix(zoneID, hostedZonePrefix)] = role
+		if _, ok := provider.roleClients[role]; !ok {
+			provider.roleClients[role] = newClient(role)
+		}
 	}
 
 	return provider, nil
 }
 
-// Zones returns the list of hosted zones.
+// Zones returns the list of hosted zones of all accounts.
 func (p *AWSProvider) Zones() (map[string]*route53.HostedZone, error) {
 	zones := make(map[string]*route53.HostedZone)
 
-	f := func(resp *route53.ListHostedZonesOutput, lastPage bool) (shouldContinue bool) {
-		for _, zone := range resp.HostedZones {
-			if !p.zoneIDFilter.Match(aws.StringValue(zone.Id)) {
-				continue
-			}
-
-			if !p.zoneTypeFilter.Match(zone) {
-				continue
-			}
-
-			if !p.domainFilter.Match(aws.StringValue(zone.Name)) {
-				continue
+	// only accept zones from the account that is configured for them
+	f := func(role string) func(resp *route53.ListHostedZonesOutput, lastPage bool) (shouldContinue bool) {
+		return func(resp *route53.ListHostedZonesOutput, lastPage bool) (shouldContinue bool) {
+			for _, zone := range resp.HostedZones {
+				if p.zoneRoles[strings.TrimPrefix(aws.StringValue(zone.Id), hostedZonePrefix)] != role {
+					continue
+				}
+				p.addZone(zones, zone)
 			}
-
-			zones[aws.StringValue(zone.Id)] = zone
+			return true
 		}
-
-		return true
 	}
 
-	err := p.client.ListHostedZonesPages(&route53.ListHostedZonesInput{}, f)
+	err := p.client.ListHostedZonesPages(&route53.ListHostedZonesInput{}, f(""))
 	if err != nil {
 		return nil, err
 	}
 
+	for role, client := range p.roleClients {
+		if err := client.ListHostedZonesPages(&route53.ListHostedZonesInput{}, f(role)); err != nil {
+			return nil, err
+		}
+	}
+
 	for _, zone := range zones {
 		log.Debugf("Considering zone: %s (domain: %s)", aws.StringValue(zone.Id), aws.StringValue(zone.Name))
 	}
@@ -146,6 +216,56 @@ func (p *AWSProvider) Zones() (map[string]*route53.HostedZone, error) {
 	return zones, nil
 }
 
+// addZone adds the zone to the given zones if it passes all filters.
+func (p *AWSProvider) addZone(zones map[string]*route53.HostedZone, zone *route53.HostedZone) {
+	if !p.zoneIDFilter.Match(aws.StringValue(zone.Id)) {
+		return
+	}
+
+	if !p.zoneTypeFilter.Match(zone) {
+		return
+	}
+
+	if !p.domainFilter.Match(aws.StringValue(zone.Name)) {
+		return
+	}
+
+	zones[aws.StringValue(zone.Id)] = zone
+}
+
+// clientFor returns the client to manage the given hosted zone with.
+func (p *AWSProvider) clientFor(zoneID string) Route53API {
+	if role, ok := p.zoneRoles[strings.TrimPrefix(zoneID, hostedZonePrefix)]; ok {
+		return p.roleClients[role]
+	}
+	return p.client
+}
+
+// IsTransientError returns true for errors caused by Route53 API throttling. Failed changes are only transient if
+// the changes of all zones failed transiently, since a retry would apply the changes of the other zones again.
+func (p *AWSProvider) IsTransientError(err error) bool {
+	if failed, ok := err.(*awsChangeErrors); ok {
+		if failed.applied > 0 {
+			return false
+		}
+		for _, err := range failed.errs {
+			if !p.IsTransientError(err) {
+				return false
+			}
+		}
+		return true
+	}
+	awsErr, ok := err.(awserr.Error)
+	if !ok {
+		return false
+	}
+	switch awsErr.Code() {
+	case "Throttling", "ThrottlingException", "PriorRequestNotComplete", "RequestLimitExceeded":
+		return true
+	}
+	return false
+}
+
 // wildcardUnescape converts \\052.abc back to *.abc
 // Route53 stores wildcards escaped: http://docs.aws.amazon.com/Route53/latest/DeveloperGuide/DomainNameFormat.html?shortFooter=true#domain-name-format-asterisk
 func wildcardUnescape(s string) string {
@@ -162,73 +282,184 @@ func (p *AWSProvider) Records() (endpoints []*endpoint.Endpoint, _ error) {
 		return nil, err
 	}
 
-	f := func(resp *route53.ListResourceRecordSetsOutput, lastPage bool) (shouldContinue bool) {
-		for _, r := range resp.ResourceRecordSets {
-			// TODO(linki, ownership): Remove once ownership system is in place.
-			// See: https://github.com/kubernetes-incubator/external-dns/pull/122/files/74e2c3d3e237411e619aefc5aab694742001cdec#r109863370
+	// health checks are listed once per account and only if records refer to them
+	healthChecksByClient := map[Route53API]map[string]*route53.HealthCheck{}
 
-			if !supportedRecordType(aws.StringValue(r.Type)) {
-				continue
+	for _, z := range zones {
+		client := p.clientFor(aws.StringValue(z.Id))
+
+		var recordSets []*route53.ResourceRecordSet
+		f := func(resp *route53.ListResourceRecordSetsOutput, lastPage bool) (shouldContinue bool) {
+			for _, r := range resp.ResourceRecordSets {
+				// TODO(linki, ownership): Remove once ownership system is in place.
+				// See: https://github.com/kubernetes-incubator/external-dns/pull/122/files/74e2c3d3e237411e619aefc5aab694742001cdec#r109863370
+
+				if !supportedRecordType(aws.StringValue(r.Type)) {
+					continue
+				}
+
+				recordSets = append(recordSets, r)
 			}
 
+			return true
+		}
+
+		params := &route53.ListResourceRecordSetsInput{
+			HostedZoneId: z.Id,
+		}
+
+		if err := client.ListResourceRecordSetsPages(params, f); err != nil {
+			return nil, err
+		}
+
+		for _, r := range recordSets {
 			var ttl endpoint.TTL
 			if r.TTL != nil {
 				ttl = endpoint.TTL(*r.TTL)
 			}
 
+			var recordEndpoints []*endpoint.Endpoint
 			for _, rr := range r.ResourceRecords {
-				endpoints = append(endpoints, endpoint.NewEndpointWithTTL(wildcardUnescape(aws.StringValue(r.Name)), aws.StringValue(rr.Value), aws.StringValue(r.Type), ttl))
+				recordEndpoints = append(recordEndpoints, endpoint.NewEndpointWithTTL(wildcardUnescape(aws.StringValue(r.Name)), aws.StringValue(rr.Value), aws.StringValue(r.Type), ttl))
 			}
 
 			if r.AliasTarget != nil {
-				endpoints = append(endpoints, endpoint.NewEndpointWithTTL(wildcardUnescape(aws.StringValue(r.Name)), aws.StringValue(r.AliasTarget.DNSName), endpoint.RecordTypeCNAME, ttl))
+				recordEndpoints = append(recordEndpoints, endpoint.NewEndpointWithTTL(wildcardUnescape(aws.StringValue(r.Name)), aws.StringValue(r.AliasTarget.DNSName), endpoint.RecordTypeCNAME, ttl))
 			}
-		}
 
-		return true
-	}
+			var healthCheck *route53.HealthCheck
+			if r.HealthCheckId != nil {
+				healthChecks, ok := healthChecksByClient[client]
+				if !ok {
+					var err error
+					if healthChecks, err = listHealthChecks(client); err != nil {
+						return nil, err
+					}
+					healthChecksByClient[client] = healthChecks
+				}
+				healthCheck = healthChecks[aws.StringValue(r.HealthCheckId)]
+			}
 
-	for _, z := range zones {
-		params := &route53.ListResourceRecordSetsInput{
-			HostedZoneId: z.Id,
+			for _, ep := range recordEndpoints {
+				setRoutingPolicy(ep, r, healthCheck)
+			}
+			endpoints = append(endpoints, recordEndpoints...)
 		}
+	}
 
-		if err := p.client.ListResourceRecordSetsPages(params, f); err != nil {
-			return nil, err
+	return endpoints, nil
+}
+
+// setRoutingPolicy sets the set identifier, geo location, failover role and health check of the record set on the endpoint.
+// The config of the health check is only added for health checks managed by ExternalDNS.
+func setRoutingPolicy(ep *endpoint.Endpoint, r *route53.ResourceRecordSet, healthCheck *route53.HealthCheck) {
+	ep.SetIdentifier = aws.StringValue(r.SetIdentifier)
+
+	if r.GeoLocation != nil {
+		ep.GeoLocation = &endpoint.GeoLocation{
+			ContinentCode:   aws.StringValue(r.GeoLocation.ContinentCode),
+			CountryCode:     aws.StringValue(r.GeoLocation.CountryCode),
+			SubdivisionCode: aws.StringValue(r.GeoLocation.SubdivisionCode),
 		}
 	}
 
-	return endpoints, nil
+	if r.Failover != nil {
+		ep.WithProviderSpecific(providerSpecificFailover, aws.StringValue(r.Failover))
+	}
+
+	if r.HealthCheckId != nil {
+		ep.WithProviderSpecific(providerSpecificHealthCheckID, aws.StringValue(r.HealthCheckId))
+	}
+
+	if healthCheck != nil && isManagedHealthCheck(healthCheck) && healthCheck.HealthCheckConfig != nil {
+		config := healthCheck.HealthCheckConfig
+		ep.WithProviderSpecific(providerSpecificHealthCheckType, aws.StringValue(config.Type))
+		if config.Port != nil {
+			ep.WithProviderSpecific(providerSpecificHealthCheckPort, strconv.FormatInt(aws.Int64Value(config.Port), 10))
+		}
+		if config.ResourcePath != nil {
+			ep.WithProviderSpecific(providerSpecificHealthCheckPath, aws.StringValue(config.ResourcePath))
+		}
+	}
 }
 
 // CreateRecords creates a given set of DNS records in the given hosted zone.
 func (p *AWSProvider) CreateRecords(endpoints []*endpoint.Endpoint) error {
-	return p.submitChanges(newChanges(route53.ChangeActionCreate, endpoints))
+	return p.submitChanges(newChanges(route53.ChangeActionCreate, endpoints), nil)
 }
 
 // UpdateRecords updates a given set of old records to a new set of records in a given hosted zone.
 func (p *AWSProvider) UpdateRecords(endpoints, _ []*endpoint.Endpoint) error {
-	return p.submitChanges(newChanges(route53.ChangeActionUpsert, endpoints))
+	return p.submitChanges(newChanges(route53.ChangeActionUpsert, endpoints), nil)
 }
 
 // DeleteRecords deletes a given set of DNS records in a given zone.
 func (p *AWSProvider) DeleteRecords(endpoints []*endpoint.Endpoint) error {
-	return p.submitChanges(newChanges(route53.ChangeActionDelete, endpoints))
+	return p.submitChanges(newChanges(route53.ChangeActionDelete, endpoints), nil)
+}
+
+// SupportsGeoLocations returns true, the records of Route53 can be restricted to geo locations
+func (p *AWSProvider) SupportsGeoLocations() bool {
+	return true
+}
+
+// AdjustEndpoints normalizes the failover role and the health check of the desired endpoints the way Records reports them,
+// e.g. with the default port of the health check, so that they match the current records.
+// Invalid health checks are left as they are, they're reported when the records are changed.
+func (p *AWSProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	for _, ep := range endpoints {
+		if failover, ok := ep.GetProviderSpecificProperty(providerSpecificFailover); ok {
+			ep.WithProviderSpecific(providerSpecificFailover, strings.ToUpper(failover))
+		}
+		config, err := newHealthCheckConfig(ep)
+		if err != nil || config == nil {
+			continue
+		}
+		ep.WithProviderSpecific(providerSpecificHealthCheckType, aws.StringValue(config.Type))
+		ep.WithProviderSpecific(providerSpecificHealthCheckPort, strconv.FormatInt(aws.Int64Value(config.Port), 10))
+		if config.ResourcePath != nil {
+			ep.WithProviderSpecific(providerSpecificHealthCheckPath, aws.StringValue(config.ResourcePath))
+		}
+	}
+	return endpoints, nil
 }
 
 // ApplyChanges applies a given set of changes in a given zone.
+// Health checks requested by created or updated records are created along with them and
+// health checks of updated or deleted records are deleted once they are no longer used.
 func (p *AWSProvider) ApplyChanges(changes *plan.Changes) error {
-	combinedChanges := make([]*route53.Change, 0, len(changes.Create)+len(changes.UpdateNew)+len(changes.Delete))
+	healthChecks := newAWSHealthChecks()
+
+	creates := newChanges(route53.ChangeActionCreate, changes.Create)
+	for i, ep := range changes.Create {
+		healthChecks.add(creates[i], ep)
+	}
+
+	upserts := newChanges(route53.ChangeActionUpsert, changes.UpdateNew)
+	for i, ep := range changes.UpdateNew {
+		healthChecks.add(upserts[i], ep)
+		if i < len(changes.UpdateOld) {
+			healthChecks.replace(upserts[i], changes.UpdateOld[i])
+		}
+	}
+
+	deletes := newChanges(route53.ChangeActionDelete, changes.Delete)
+	for i, ep := range changes.Delete {
+		healthChecks.replace(deletes[i], ep)
+	}
+
+	combinedChanges := make([]*route53.Change, 0, len(creates)+len(upserts)+len(deletes))
 
-	combinedChanges = append(combinedChanges, newChanges(route53.ChangeActionCreate, changes.Create)...)
-	combinedChanges = append(combinedChanges, newChanges(route53.ChangeActionUpsert, changes.UpdateNew)...)
-	combinedChanges = append(combinedChanges, newChanges(route53.ChangeActionDelete, changes.Delete)...)
+	combinedChanges = append(combinedChanges, creates...)
+	combinedChanges = append(combinedChanges, upserts...)
+	combinedChanges = append(combinedChanges, deletes...)
 
-	return p.submitChanges(combinedChanges)
+	return p.submitChanges(combinedChanges, healthChecks)
 }
 
 // submitChanges takes a zone and a collection of Changes and sends them as a single transaction.
-func (p *AWSProvider) submitChanges(changes []*route53.Change) error {
+// The given health checks are managed along with the changes, they may be nil.
+func (p *AWSProvider) submitChanges(changes []*route53.Change, healthChecks *awsHealthChecks) error {
 	// return early if there is nothing to change
 	if len(changes) == 0 {
 		log.Info("All records are already up to date")
@@ -243,14 +474,30 @@ func (p *AWSProvider) submitChanges(changes []*route53.Change) error {
 	// separate into per-zone change sets to be passed to the API.
 	changesByZone := changesByZone(zones, changes)
 
+	failed := &awsChangeErrors{}
 	for z, cs := range changesByZone {
 		limCs := limitChangeSet(cs, maxChangeCount)
 
+		zoneLog := log.WithField("zone", aws.StringValue(zones[z].Name))
 		for _, c := range limCs {
-			log.Infof("Desired change: %s %s %s", *c.Action, *c.ResourceRecordSet.Name, *c.ResourceRecordSet.Type)
+			changeLog := zoneLog.WithFields(log.Fields{
+				"dnsName":    aws.StringValue(c.ResourceRecordSet.Name),
+				"recordType": aws.StringValue(c.ResourceRecordSet.Type),
+			})
+			if c.ResourceRecordSet.SetIdentifier != nil {
+				changeLog = changeLog.WithField("setIdentifier", aws.StringValue(c.ResourceRecordSet.SetIdentifier))
+			}
+			changeLog.Infof("Desired change: %s", aws.StringValue(c.Action))
+			if config := healthChecks.config(c); config != nil {
+				changeLog.Infof("Desired health check: %s", healthCheckString(config))
+			}
 		}
 
 		if !p.dryRun {
+			client := p.clientFor(z)
+
+			created := healthChecks.create(client, limCs)
+
 			params := &route53.ChangeResourceRecordSetsInput{
 				HostedZoneId: aws.String(z),
 				ChangeBatch: &route53.ChangeBatch{
@@ -258,17 +505,40 @@ func (p *AWSProvider) submitChanges(changes []*route53.Change) error {
 				},
 			}
 
-			if _, err := p.client.ChangeResourceRecordSets(params); err != nil {
-				log.Error(err) //TODO(ideahitme): consider changing the interface in cases when this error might be a concern for other components
+			if _, err := client.ChangeResourceRecordSets(params); err != nil {
+				zoneLog.Error(err)
+				healthChecks.rollback(client, created)
+				failed.errs = append(failed.errs, err)
 				continue
 			}
-			log.Infof("Record in zone %s were successfully updated", aws.StringValue(zones[z].Name))
+			zoneLog.Info("Records in zone were successfully updated")
+			failed.applied++
+
+			healthChecks.deleteObsolete(client, limCs)
 		}
 	}
 
+	if len(failed.errs) > 0 {
+		return failed
+	}
 	return nil
 }
 
+// awsChangeErrors are the errors of the change batches of the zones which failed
+type awsChangeErrors struct {
+	errs []error
+	// applied is the number of zones whose changes were applied
+	applied int
+}
+
+func (e *awsChangeErrors) Error() string {
+	msgs := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("failed to change the records of %d of %d zones: %s", len(e.errs), len(e.errs)+e.applied, strings.Join(msgs, "; "))
+}
+
 func limitChangeSet(cs []*route53.Change, limit int) []*route53.Change {
 	if len(cs) <= limit {
 		return cs
@@ -396,9 +666,257 @@ func newChange(action string, endpoint *endpoint.Endpoint) *route53.Change {
 		}
 	}
 
+	if endpoint.SetIdentifier != "" {
+		change.ResourceRecordSet.SetIdentifier = aws.String(endpoint.SetIdentifier)
+	}
+
+	if geo := endpoint.GeoLocation; geo != nil {
+		change.ResourceRecordSet.GeoLocation = &route53.GeoLocation{}
+		if geo.ContinentCode != "" {
+			change.ResourceRecordSet.GeoLocation.ContinentCode = aws.String(geo.ContinentCode)
+		}
+		if geo.CountryCode != "" {
+			change.ResourceRecordSet.GeoLocation.CountryCode = aws.String(geo.CountryCode)
+		}
+		if geo.SubdivisionCode != "" {
+			change.ResourceRecordSet.GeoLocation.SubdivisionCode = aws.String(geo.SubdivisionCode)
+		}
+	}
+
+	if failover, ok := endpoint.GetProviderSpecificProperty(providerSpecificFailover); ok {
+		change.ResourceRecordSet.Failover = aws.String(strings.ToUpper(failover))
+	}
+
+	if healthCheckID, ok := endpoint.GetProviderSpecificProperty(providerSpecificHealthCheckID); ok {
+		change.ResourceRecordSet.HealthCheckId = aws.String(healthCheckID)
+	}
+
 	return change
 }
 
+// awsHealthChecks tracks the health checks to manage along with a set of changes.
+// Health checks are created in the account of the hosted zone of their record.
+type awsHealthChecks struct {
+	// configs of the health checks to create and attach to changes
+	configs map[*route53.Change]*route53.HealthCheckConfig
+	// ids of the health checks that are no longer used once a change is applied
+	obsolete map[*route53.Change]string
+}
+
+func newAWSHealthChecks() *awsHealthChecks {
+	return &awsHealthChecks{
+		configs:  map[*route53.Change]*route53.HealthCheckConfig{},
+		obsolete: map[*route53.Change]string{},
+	}
+}
+
+// add requests the health check configured for the endpoint for its change
+func (h *awsHealthChecks) add(change *route53.Change, ep *endpoint.Endpoint) {
+	config, err := newHealthCheckConfig(ep)
+	if err != nil {
+		log.Errorf("Not creating health check: %v", err)
+		return
+	}
+	if config == nil {
+		return
+	}
+	if change.ResourceRecordSet.HealthCheckId != nil {
+		log.Warnf("Not creating health check for %s, it already refers to health check %s", ep.DNSName, aws.StringValue(change.ResourceRecordSet.HealthCheckId))
+		return
+	}
+	h.configs[change] = config
+}
+
+// replace marks the health check of the current endpoint as obsolete once the change is applied.
+// The health check is kept if the change requests an identical one.
+func (h *awsHealthChecks) replace(change *route53.Change, current *endpoint.Endpoint) {
+	id, ok := current.GetProviderSpecificProperty(providerSpecificHealthCheckID)
+	if !ok {
+		return
+	}
+	if aws.StringValue(change.Action) != route53.ChangeActionDelete {
+		if aws.StringValue(change.ResourceRecordSet.HealthCheckId) == id {
+			return
+		}
+		if config, ok := h.configs[change]; ok {
+			currentConfig, err := newHealthCheckConfig(current)
+			if err == nil && currentConfig != nil && healthCheckString(currentConfig) == healthCheckString(config) {
+				delete(h.configs, change)
+				change.ResourceRecordSet.HealthCheckId = aws.String(id)
+				return
+			}
+		}
+	}
+	h.obsolete[change] = id
+}
+
+// config returns the config of the health check to create for the change, nil if there is none
+func (h *awsHealthChecks) config(change *route53.Change) *route53.HealthCheckConfig {
+	if h == nil {
+		return nil
+	}
+	return h.configs[change]
+}
+
+// create creates the health checks requested for the changes and attaches them, it returns the created health checks
+func (h *awsHealthChecks) create(client Route53API, changes []*route53.Change) map[*route53.Change]string {
+	created := map[*route53.Change]string{}
+	if h == nil {
+		return created
+	}
+	for _, c := range changes {
+		config, ok := h.configs[c]
+		if !ok || c.ResourceRecordSet.HealthCheckId != nil {
+			continue
+		}
+		resp, err := client.CreateHealthCheck(&route53.CreateHealthCheckInput{
+			CallerReference:   aws.String(newHealthCheckCallerReference()),
+			HealthCheckConfig: config,
+		})
+		if err != nil {
+			log.Errorf("Failed to create health check for %s: %v", aws.StringValue(c.ResourceRecordSet.Name), err)
+			continue
+		}
+		id := aws.StringValue(resp.HealthCheck.Id)
+		log.Infof("Created health check %s for %s", id, aws.StringValue(c.ResourceRecordSet.Name))
+		c.ResourceRecordSet.HealthCheckId = aws.String(id)
+		created[c] = id
+	}
+	return created
+}
+
+// rollback deletes health checks created for changes that failed
+func (h *awsHealthChecks) rollback(client Route53API, created map[*route53.Change]string) {
+	for c, id := range created {
+		c.ResourceRecordSet.HealthCheckId = nil
+		deleteHealthCheck(client, id)
+	}
+}
+
+// deleteObsolete deletes the health checks managed by ExternalDNS which are no longer used by the applied changes
+func (h *awsHealthChecks) deleteObsolete(client Route53API, changes []*route53.Change) {
+	if h == nil {
+		return
+	}
+	var healthChecks map[string]*route53.HealthCheck
+	for _, c := range changes {
+		id, ok := h.obsolete[c]
+		if !ok {
+			continue
+		}
+		if healthChecks == nil {
+			var err error
+			if healthChecks, err = listHealthChecks(client); err != nil {
+				log.Errorf("Failed to list health checks: %v", err)
+				return
+			}
+		}
+		if healthCheck, ok := healthChecks[id]; ok && isManagedHealthCheck(healthCheck) {
+			deleteHealthCheck(client, id)
+		}
+	}
+}
+
+// newHealthCheckConfig returns the config of the health check requested by the endpoint, nil if there is none.
+// The health check probes the first target of the endpoint.
+func newHealthCheckConfig(ep *endpoint.Endpoint) (*route53.HealthCheckConfig, error) {
+	checkType, ok := ep.GetProviderSpecificProperty(providerSpecificHealthCheckType)
+	if !ok {
+		return nil, nil
+	}
+	if ep.SetIdentifier == "" {
+		return nil, fmt.Errorf("health check of %s requires a failover or geo routing policy", ep.DNSName)
+	}
+	if len(ep.Targets) == 0 {
+		return nil, fmt.Errorf("health check of %s requires a target", ep.DNSName)
+	}
+
+	config := &route53.HealthCheckConfig{
+		Type: aws.String(strings.ToUpper(checkType)),
+	}
+
+	var defaultPort int64
+	switch aws.StringValue(config.Type) {
+	case route53.HealthCheckTypeHttp:
+		defaultPort = 80
+	case route53.HealthCheckTypeHttps:
+		defaultPort = 443
+	case route53.HealthCheckTypeTcp:
+	default:
+		return nil, fmt.Errorf("unsupported health check type %q of %s, expected HTTP, HTTPS or TCP", checkType, ep.DNSName)
+	}
+
+	if port, ok := ep.GetProviderSpecificProperty(providerSpecificHealthCheckPort); ok {
+		value, err := strconv.ParseInt(port, 10, 64)
+		if err != nil || value < 1 || value > 65535 {
+			return nil, fmt.Errorf("invalid health check port %q of %s", port, ep.DNSName)
+		}
+		config.Port = aws.Int64(value)
+	} else if defaultPort != 0 {
+		config.Port = aws.Int64(defaultPort)
+	} else {
+		return nil, fmt.Errorf("%s health check of %s requires a port", aws.StringValue(config.Type), ep.DNSName)
+	}
+
+	if aws.StringValue(config.Type) != route53.HealthCheckTypeTcp {
+		path, ok := ep.GetProviderSpecificProperty(providerSpecificHealthCheckPath)
+		if !ok {
+			path = "/"
+		}
+		config.ResourcePath = aws.String(path)
+	}
+
+	if net.ParseIP(ep.Targets[0]) != nil {
+		config.IPAddress = aws.String(ep.Targets[0])
+	} else {
+		config.FullyQualifiedDomainName = aws.String(ep.Targets[0])
+	}
+
+	return config, nil
+}
+
+// healthCheckString returns a short description of a health check config, e.g. HTTP 1.2.3.4:80/healthz
+func healthCheckString(config *route53.HealthCheckConfig) string {
+	target := aws.StringValue(config.IPAddress)
+	if target == "" {
+		target = aws.StringValue(config.FullyQualifiedDomainName)
+	}
+	return fmt.Sprintf("%s %s:%d%s", aws.StringValue(config.Type), target, aws.Int64Value(config.Port), aws.StringValue(config.ResourcePath))
+}
+
+// newHealthCheckCallerReference returns a unique caller reference marking the health check as managed by ExternalDNS
+func newHealthCheckCallerReference() string {
+	return healthCheckCallerReferencePrefix + strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatInt(rand.Int63(), 36)
+}
+
+// isManagedHealthCheck returns true if the health check was created by ExternalDNS
+func isManagedHealthCheck(healthCheck *route53.HealthCheck) bool {
+	return strings.HasPrefix(aws.StringValue(healthCheck.CallerReference), healthCheckCallerReferencePrefix)
+}
+
+// listHealthChecks returns all health checks of the account of the client by their id
+func listHealthChecks(client Route53API) (map[string]*route53.HealthCheck, error) {
+	healthChecks := map[string]*route53.HealthCheck{}
+	f := func(resp *route53.ListHealthChecksOutput, lastPage bool) (shouldContinue bool) {
+		for _, healthCheck := range resp.HealthChecks {
+			healthChecks[aws.StringValue(healthCheck.Id)] = healthCheck
+		}
+		return true
+	}
+	if err := client.ListHealthChecksPages(&route53.ListHealthChecksInput{}, f); err != nil {
+		return nil, err
+	}
+	return healthChecks, nil
+}
+
+func deleteHealthCheck(client Route53API, id string) {
+	if _, err := client.DeleteHealthCheck(&route53.DeleteHealthCheckInput{HealthCheckId: aws.String(id)}); err != nil {
+		log.Errorf("Failed to delete health check %s: %v", id, err)
+		return
+	}
+	log.Infof("Deleted health check %s", id)
+}
+
 // suitableZones returns all suitable private zones and the most suitable public zone
 //   for a given hostname and a set of zones.
 func suitableZones(hostname string, zones map[string]*route53.HostedZone) []*route53.HostedZone {
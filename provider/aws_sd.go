@@ -0,0 +1,538 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	sd "github.com/aws/aws-sdk-go/service/servicediscovery"
+	"github.com/linki/instrumented_http"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+const (
+	sdDefaultRecordTTL = 300
+
+	// sdInstanceAttrIPV4 is the instance attribute of the address of an A record
+	sdInstanceAttrIPV4 = "AWS_INSTANCE_IPV4"
+	// sdInstanceAttrCname is the instance attribute of the target of a CNAME record
+	sdInstanceAttrCname = "AWS_INSTANCE_CNAME"
+	// sdInstanceAttrAlias is the instance attribute of the load balancer an alias record points to
+	sdInstanceAttrAlias = "AWS_ALIAS_DNS_NAME"
+
+	// sdMaxInstanceIDLength is the maximum length of the instance id, longer targets are hashed
+	sdMaxInstanceIDLength = 64
+)
+
+// AWSSDClient is the subset of the AWS Cloud Map (Service Discovery) API that we actually use. Add methods as required. Signatures must match exactly.
+type AWSSDClient interface {
+	CreateService(*sd.CreateServiceInput) (*sd.CreateServiceOutput, error)
+	DeregisterInstance(*sd.DeregisterInstanceInput) (*sd.DeregisterInstanceOutput, error)
+	GetService(*sd.GetServiceInput) (*sd.GetServiceOutput, error)
+	ListInstancesPages(*sd.ListInstancesInput, func(*sd.ListInstancesOutput, bool) bool) error
+	ListNamespacesPages(*sd.ListNamespacesInput, func(*sd.ListNamespacesOutput, bool) bool) error
+	ListServicesPages(*sd.ListServicesInput, func(*sd.ListServicesOutput, bool) bool) error
+	RegisterInstance(*sd.RegisterInstanceInput) (*sd.RegisterInstanceOutput, error)
+	UpdateService(*sd.UpdateServiceInput) (*sd.UpdateServiceOutput, error)
+}
+
+// AWSSDConfig contains configuration to create a new AWS Cloud Map provider.
+type AWSSDConfig struct {
+	DomainFilter         DomainFilter
+	NamespaceType        string
+	AssumeRole           string
+	AssumeRoleExternalID string
+	// RateLimiter limits the rate of the API requests, unlimited if nil
+	RateLimiter *RateLimiter
+	DryRun      bool
+}
+
+// AWSSDProvider is an implementation of Provider for AWS Cloud Map.
+// Every DNS name is a service of the namespace managing its domain, and every target an instance of the service;
+// Cloud Map creates the records in the hosted zone of the namespace. The labels of a record are kept in the
+// description of its service, which the aws-sd registry uses to track the ownership without TXT records.
+type AWSSDProvider struct {
+	client AWSSDClient
+	dryRun bool
+	// only consider namespaces managing domains ending in this suffix
+	namespaceFilter DomainFilter
+	// filter namespaces by type, e.g. public or private
+	namespaceType string
+}
+
+// NewAWSSDProvider initializes a new AWS Cloud Map based Provider.
+func NewAWSSDProvider(config AWSSDConfig) (*AWSSDProvider, error) {
+	awsConfig := aws.NewConfig()
+
+	awsConfig = awsConfig.WithHTTPClient(
+		instrumented_http.NewClient(awsConfig.HTTPClient, &instrumented_http.Callbacks{
+			PathProcessor: func(path string) string {
+				parts := strings.Split(path, "/")
+				return parts[len(parts)-1]
+			},
+		}),
+	)
+
+	session, err := session.NewSessionWithOptions(session.Options{
+		Config:            *awsConfig,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if config.RateLimiter != nil {
+		session.Handlers.Send.PushFrontNamed(config.RateLimiter.awsHandler())
+	}
+
+	client := sd.New(session)
+	if config.AssumeRole != "" {
+		log.Infof("Assuming role %s", config.AssumeRole)
+		creds := stscreds.NewCredentials(session, config.AssumeRole, func(p *stscreds.AssumeRoleProvider) {
+			if config.AssumeRoleExternalID != "" {
+				p.ExternalID = aws.String(config.AssumeRoleExternalID)
+			}
+		})
+		client = sd.New(session, &aws.Config{Credentials: creds})
+	}
+
+	return newAWSSDProvider(client, config), nil
+}
+
+func newAWSSDProvider(client AWSSDClient, config AWSSDConfig) *AWSSDProvider {
+	return &AWSSDProvider{
+		client:          client,
+		dryRun:          config.DryRun,
+		namespaceFilter: config.DomainFilter,
+		namespaceType:   config.NamespaceType,
+	}
+}
+
+// Records returns an endpoint per service with registered instances.
+func (p *AWSSDProvider) Records() ([]*endpoint.Endpoint, error) {
+	namespaces, err := p.namespaces()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := []*endpoint.Endpoint{}
+	for _, ns := range namespaces {
+		services, err := p.services(aws.StringValue(ns.Id))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, srv := range services {
+			instances, err := p.instances(aws.StringValue(srv.Id))
+			if err != nil {
+				return nil, err
+			}
+			if len(instances) == 0 {
+				continue
+			}
+			endpoints = append(endpoints, p.instancesToEndpoint(ns, srv, instances))
+		}
+	}
+
+	return endpoints, nil
+}
+
+// instancesToEndpoint converts the instances of a service into an endpoint.
+// Alias records are returned as CNAME records like the AWS provider does.
+func (p *AWSSDProvider) instancesToEndpoint(ns *sd.NamespaceSummary, srv *sd.Service, instances []*sd.InstanceSummary) *endpoint.Endpoint {
+	ep := &endpoint.Endpoint{
+		DNSName: aws.StringValue(srv.Name) + "." + aws.StringValue(ns.Name),
+		Targets: endpoint.Targets{},
+		Labels:  endpoint.NewLabels(),
+	}
+	if srv.DnsConfig != nil && len(srv.DnsConfig.DnsRecords) > 0 {
+		ep.RecordType = aws.StringValue(srv.DnsConfig.DnsRecords[0].Type)
+		ep.RecordTTL = endpoint.TTL(aws.Int64Value(srv.DnsConfig.DnsRecords[0].TTL))
+	}
+	if description := aws.StringValue(srv.Description); description != "" {
+		ep.Labels[endpoint.AWSSDDescriptionLabel] = description
+	}
+
+	for _, instance := range instances {
+		if alias, ok := instance.Attributes[sdInstanceAttrAlias]; ok {
+			ep.RecordType = endpoint.RecordTypeCNAME
+			ep.Targets = append(ep.Targets, aws.StringValue(alias))
+		} else if ip, ok := instance.Attributes[sdInstanceAttrIPV4]; ok {
+			ep.Targets = append(ep.Targets, aws.StringValue(ip))
+		} else if cname, ok := instance.Attributes[sdInstanceAttrCname]; ok {
+			ep.Targets = append(ep.Targets, aws.StringValue(cname))
+		}
+	}
+	sort.Strings(ep.Targets)
+
+	return ep
+}
+
+// ApplyChanges registers the instances of created and updated endpoints, creating their services if necessary,
+// and deregisters the instances of deleted endpoints and the targets removed by updates.
+func (p *AWSSDProvider) ApplyChanges(changes *plan.Changes) error {
+	namespaces, err := p.namespaces()
+	if err != nil {
+		return err
+	}
+
+	creates := make([]*endpoint.Endpoint, 0, len(changes.Create)+len(changes.UpdateNew))
+	creates = append(creates, changes.Create...)
+	creates = append(creates, changes.UpdateNew...)
+
+	// targets of updated endpoints which are kept must not be deregistered
+	kept := map[string]map[string]bool{}
+	for _, ep := range changes.UpdateNew {
+		kept[ep.DNSName] = map[string]bool{}
+		for _, target := range ep.Targets {
+			kept[ep.DNSName][target] = true
+		}
+	}
+	deletes := make([]*endpoint.Endpoint, 0, len(changes.Delete)+len(changes.UpdateOld))
+	deletes = append(deletes, changes.Delete...)
+	for _, old := range changes.UpdateOld {
+		ep := *old
+		ep.Targets = endpoint.Targets{}
+		for _, target := range old.Targets {
+			if !kept[old.DNSName][target] {
+				ep.Targets = append(ep.Targets, target)
+			}
+		}
+		deletes = append(deletes, &ep)
+	}
+
+	if err := p.submitCreates(namespaces, creates); err != nil {
+		return err
+	}
+	return p.submitDeletes(namespaces, deletes)
+}
+
+func (p *AWSSDProvider) submitCreates(namespaces []*sd.NamespaceSummary, endpoints []*endpoint.Endpoint) error {
+	servicesByNamespace := map[string]map[string]*sd.Service{}
+
+	for _, ep := range endpoints {
+		if ep.RecordType != endpoint.RecordTypeA && ep.RecordType != endpoint.RecordTypeCNAME {
+			log.Warnf("Skipping %s, record type %s is not supported by AWS Cloud Map", ep.DNSName, ep.RecordType)
+			continue
+		}
+		ns, serviceName := p.matchingNamespace(ep.DNSName, namespaces)
+		if ns == nil {
+			log.Warnf("Skipping %s, no matching namespace found", ep.DNSName)
+			continue
+		}
+
+		services, ok := servicesByNamespace[aws.StringValue(ns.Id)]
+		if !ok {
+			var err error
+			if services, err = p.services(aws.StringValue(ns.Id)); err != nil {
+				return err
+			}
+			servicesByNamespace[aws.StringValue(ns.Id)] = services
+		}
+
+		srv := services[serviceName]
+		if srv == nil {
+			var err error
+			if srv, err = p.createService(ns, serviceName, ep); err != nil {
+				return err
+			}
+			services[serviceName] = srv
+		} else if err := p.updateService(srv, ep); err != nil {
+			return err
+		}
+
+		for _, target := range ep.Targets {
+			if err := p.registerInstance(srv, ep, target); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *AWSSDProvider) submitDeletes(namespaces []*sd.NamespaceSummary, endpoints []*endpoint.Endpoint) error {
+	servicesByNamespace := map[string]map[string]*sd.Service{}
+
+	for _, ep := range endpoints {
+		if len(ep.Targets) == 0 {
+			continue
+		}
+		ns, serviceName := p.matchingNamespace(ep.DNSName, namespaces)
+		if ns == nil {
+			log.Warnf("Skipping %s, no matching namespace found", ep.DNSName)
+			continue
+		}
+
+		services, ok := servicesByNamespace[aws.StringValue(ns.Id)]
+		if !ok {
+			var err error
+			if services, err = p.services(aws.StringValue(ns.Id)); err != nil {
+				return err
+			}
+			servicesByNamespace[aws.StringValue(ns.Id)] = services
+		}
+
+		srv := services[serviceName]
+		if srv == nil {
+			log.Warnf("Skipping %s, service %s not found", ep.DNSName, serviceName)
+			continue
+		}
+
+		for _, target := range ep.Targets {
+			log.Infof("Deregistering instance %s of service %s", target, aws.StringValue(srv.Name))
+			if p.dryRun {
+				continue
+			}
+			_, err := p.client.DeregisterInstance(&sd.DeregisterInstanceInput{
+				ServiceId:  srv.Id,
+				InstanceId: aws.String(sdInstanceID(target)),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// namespaces returns the namespaces matching the domain and type filters.
+func (p *AWSSDProvider) namespaces() ([]*sd.NamespaceSummary, error) {
+	namespaces := []*sd.NamespaceSummary{}
+	err := p.client.ListNamespacesPages(&sd.ListNamespacesInput{}, func(resp *sd.ListNamespacesOutput, lastPage bool) bool {
+		for _, ns := range resp.Namespaces {
+			if !p.namespaceFilter.Match(aws.StringValue(ns.Name)) {
+				continue
+			}
+			if !p.namespaceTypeMatch(aws.StringValue(ns.Type)) {
+				continue
+			}
+			namespaces = append(namespaces, ns)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return namespaces, nil
+}
+
+func (p *AWSSDProvider) namespaceTypeMatch(namespaceType string) bool {
+	switch p.namespaceType {
+	case zoneTypePublic:
+		return namespaceType == sd.NamespaceTypeDnsPublic
+	case zoneTypePrivate:
+		return namespaceType == sd.NamespaceTypeDnsPrivate
+	}
+	return true
+}
+
+// services returns the services of the namespace by name, including their DNS configuration.
+func (p *AWSSDProvider) services(namespaceID string) (map[string]*sd.Service, error) {
+	ids := []*string{}
+	err := p.client.ListServicesPages(&sd.ListServicesInput{
+		Filters: []*sd.ServiceFilter{{
+			Name:      aws.String(sd.ServiceFilterNameNamespaceId),
+			Values:    []*string{aws.String(namespaceID)},
+			Condition: aws.String(sd.FilterConditionEq),
+		}},
+	}, func(resp *sd.ListServicesOutput, lastPage bool) bool {
+		for _, srv := range resp.Services {
+			ids = append(ids, srv.Id)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	services := map[string]*sd.Service{}
+	for _, id := range ids {
+		out, err := p.client.GetService(&sd.GetServiceInput{Id: id})
+		if err != nil {
+			return nil, err
+		}
+		services[aws.StringValue(out.Service.Name)] = out.Service
+	}
+	return services, nil
+}
+
+// instances returns the registered instances of the service.
+func (p *AWSSDProvider) instances(serviceID string) ([]*sd.InstanceSummary, error) {
+	instances := []*sd.InstanceSummary{}
+	err := p.client.ListInstancesPages(&sd.ListInstancesInput{ServiceId: aws.String(serviceID)}, func(resp *sd.ListInstancesOutput, lastPage bool) bool {
+		instances = append(instances, resp.Instances...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return instances, nil
+}
+
+func (p *AWSSDProvider) createService(ns *sd.NamespaceSummary, serviceName string, ep *endpoint.Endpoint) (*sd.Service, error) {
+	recordType, routingPolicy := sdRecordType(ep)
+	srv := &sd.Service{
+		Name:        aws.String(serviceName),
+		Description: aws.String(ep.Labels[endpoint.AWSSDDescriptionLabel]),
+		DnsConfig: &sd.DnsConfig{
+			NamespaceId:   ns.Id,
+			RoutingPolicy: aws.String(routingPolicy),
+			DnsRecords: []*sd.DnsRecord{{
+				Type: aws.String(recordType),
+				TTL:  aws.Int64(sdRecordTTL(ep)),
+			}},
+		},
+	}
+
+	log.Infof("Creating service %s in namespace %s", serviceName, aws.StringValue(ns.Name))
+	if p.dryRun {
+		srv.Id = aws.String("dry-run-" + serviceName)
+		return srv, nil
+	}
+
+	out, err := p.client.CreateService(&sd.CreateServiceInput{
+		Name:        srv.Name,
+		Description: srv.Description,
+		DnsConfig:   srv.DnsConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Service, nil
+}
+
+// updateService updates the description and TTL of the service if they changed.
+// The type of the records can't be changed.
+func (p *AWSSDProvider) updateService(srv *sd.Service, ep *endpoint.Endpoint) error {
+	recordType, _ := sdRecordType(ep)
+	current := srv.DnsConfig.DnsRecords[0]
+	if aws.StringValue(current.Type) != recordType {
+		return fmt.Errorf("can't change the type of service %s from %s to %s", aws.StringValue(srv.Name), aws.StringValue(current.Type), recordType)
+	}
+
+	description := ep.Labels[endpoint.AWSSDDescriptionLabel]
+	ttl := sdRecordTTL(ep)
+	if aws.StringValue(srv.Description) == description && aws.Int64Value(current.TTL) == ttl {
+		return nil
+	}
+
+	log.Infof("Updating service %s", aws.StringValue(srv.Name))
+	if p.dryRun {
+		return nil
+	}
+	_, err := p.client.UpdateService(&sd.UpdateServiceInput{
+		Id: srv.Id,
+		Service: &sd.ServiceChange{
+			Description: aws.String(description),
+			DnsConfig: &sd.DnsConfigChange{
+				DnsRecords: []*sd.DnsRecord{{
+					Type: current.Type,
+					TTL:  aws.Int64(ttl),
+				}},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	srv.Description = aws.String(description)
+	current.TTL = aws.Int64(ttl)
+	return nil
+}
+
+func (p *AWSSDProvider) registerInstance(srv *sd.Service, ep *endpoint.Endpoint, target string) error {
+	attributes := map[string]*string{}
+	switch {
+	case isAWSLoadBalancer(ep):
+		attributes[sdInstanceAttrAlias] = aws.String(target)
+	case ep.RecordType == endpoint.RecordTypeCNAME:
+		attributes[sdInstanceAttrCname] = aws.String(target)
+	default:
+		attributes[sdInstanceAttrIPV4] = aws.String(target)
+	}
+
+	log.Infof("Registering instance %s of service %s", target, aws.StringValue(srv.Name))
+	if p.dryRun {
+		return nil
+	}
+	_, err := p.client.RegisterInstance(&sd.RegisterInstanceInput{
+		ServiceId:  srv.Id,
+		InstanceId: aws.String(sdInstanceID(target)),
+		Attributes: attributes,
+	})
+	return err
+}
+
+// matchingNamespace returns the namespace with the longest name the DNS name belongs to,
+// and the name of the service within it.
+func (p *AWSSDProvider) matchingNamespace(dnsName string, namespaces []*sd.NamespaceSummary) (*sd.NamespaceSummary, string) {
+	var (
+		match       *sd.NamespaceSummary
+		serviceName string
+	)
+	for _, ns := range namespaces {
+		suffix := "." + aws.StringValue(ns.Name)
+		if !strings.HasSuffix(dnsName, suffix) || len(dnsName) == len(suffix) {
+			continue
+		}
+		if match == nil || len(aws.StringValue(ns.Name)) > len(aws.StringValue(match.Name)) {
+			match = ns
+			serviceName = strings.TrimSuffix(dnsName, suffix)
+		}
+	}
+	return match, serviceName
+}
+
+// sdRecordType returns the record type and routing policy of the service of the endpoint.
+// Load balancers are registered as alias records, which require an A record with weighted routing.
+func sdRecordType(ep *endpoint.Endpoint) (string, string) {
+	if isAWSLoadBalancer(ep) {
+		return sd.RecordTypeA, sd.RoutingPolicyWeighted
+	}
+	if ep.RecordType == endpoint.RecordTypeCNAME {
+		return sd.RecordTypeCname, sd.RoutingPolicyWeighted
+	}
+	return sd.RecordTypeA, sd.RoutingPolicyMultivalue
+}
+
+func sdRecordTTL(ep *endpoint.Endpoint) int64 {
+	if ep.RecordTTL.IsConfigured() {
+		return int64(ep.RecordTTL)
+	}
+	return sdDefaultRecordTTL
+}
+
+// sdInstanceID returns the id of the instance of the target, hashing targets exceeding the maximum length.
+func sdInstanceID(target string) string {
+	target = strings.ToLower(target)
+	if len(target) <= sdMaxInstanceIDLength {
+		return target
+	}
+	hash := md5.Sum([]byte(target))
+	return hex.EncodeToString(hash[:])
+}
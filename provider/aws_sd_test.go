@@ -0,0 +1,335 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	sd "github.com/aws/aws-sdk-go/service/servicediscovery"
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/internal/testutils"
+	"github.com/kubernetes-incubator/external-dns/plan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Compile time checks for interface conformance
+var _ AWSSDClient = &AWSSDClientStub{}
+var _ AWSSDClient = &sd.ServiceDiscovery{}
+
+// AWSSDClientStub is a minimal in-memory implementation of AWSSDClient, used for unit testing.
+type AWSSDClientStub struct {
+	namespaces []*sd.NamespaceSummary
+	// services by namespace id
+	services map[string][]*sd.Service
+	// instances by service id
+	instances map[string]map[string]*sd.InstanceSummary
+}
+
+func newAWSSDClientStub() *AWSSDClientStub {
+	return &AWSSDClientStub{
+		namespaces: []*sd.NamespaceSummary{
+			{Id: aws.String("ns-public"), Name: aws.String("public.com"), Type: aws.String(sd.NamespaceTypeDnsPublic)},
+			{Id: aws.String("ns-private"), Name: aws.String("private.com"), Type: aws.String(sd.NamespaceTypeDnsPrivate)},
+			{Id: aws.String("ns-sub"), Name: aws.String("sub.public.com"), Type: aws.String(sd.NamespaceTypeDnsPublic)},
+		},
+		services:  map[string][]*sd.Service{},
+		instances: map[string]map[string]*sd.InstanceSummary{},
+	}
+}
+
+func (s *AWSSDClientStub) CreateService(input *sd.CreateServiceInput) (*sd.CreateServiceOutput, error) {
+	namespaceID := aws.StringValue(input.DnsConfig.NamespaceId)
+	for _, srv := range s.services[namespaceID] {
+		if aws.StringValue(srv.Name) == aws.StringValue(input.Name) {
+			return nil, awserr.New(sd.ErrCodeServiceAlreadyExists, "service already exists", nil)
+		}
+	}
+	srv := &sd.Service{
+		Id:          aws.String(fmt.Sprintf("srv-%s-%s", namespaceID, aws.StringValue(input.Name))),
+		Name:        input.Name,
+		Description: input.Description,
+		DnsConfig:   input.DnsConfig,
+	}
+	s.services[namespaceID] = append(s.services[namespaceID], srv)
+	s.instances[aws.StringValue(srv.Id)] = map[string]*sd.InstanceSummary{}
+	return &sd.CreateServiceOutput{Service: srv}, nil
+}
+
+func (s *AWSSDClientStub) DeregisterInstance(input *sd.DeregisterInstanceInput) (*sd.DeregisterInstanceOutput, error) {
+	instances := s.instances[aws.StringValue(input.ServiceId)]
+	if _, ok := instances[aws.StringValue(input.InstanceId)]; !ok {
+		return nil, awserr.New(sd.ErrCodeInstanceNotFound, "instance not found", nil)
+	}
+	delete(instances, aws.StringValue(input.InstanceId))
+	return &sd.DeregisterInstanceOutput{}, nil
+}
+
+func (s *AWSSDClientStub) GetService(input *sd.GetServiceInput) (*sd.GetServiceOutput, error) {
+	for _, services := range s.services {
+		for _, srv := range services {
+			if aws.StringValue(srv.Id) == aws.StringValue(input.Id) {
+				return &sd.GetServiceOutput{Service: srv}, nil
+			}
+		}
+	}
+	return nil, awserr.New(sd.ErrCodeServiceNotFound, "service not found", nil)
+}
+
+func (s *AWSSDClientStub) ListInstancesPages(input *sd.ListInstancesInput, fn func(*sd.ListInstancesOutput, bool) bool) error {
+	instances := []*sd.InstanceSummary{}
+	for _, instance := range s.instances[aws.StringValue(input.ServiceId)] {
+		instances = append(instances, instance)
+	}
+	fn(&sd.ListInstancesOutput{Instances: instances}, true)
+	return nil
+}
+
+func (s *AWSSDClientStub) ListNamespacesPages(input *sd.ListNamespacesInput, fn func(*sd.ListNamespacesOutput, bool) bool) error {
+	fn(&sd.ListNamespacesOutput{Namespaces: s.namespaces}, true)
+	return nil
+}
+
+func (s *AWSSDClientStub) ListServicesPages(input *sd.ListServicesInput, fn func(*sd.ListServicesOutput, bool) bool) error {
+	summaries := []*sd.ServiceSummary{}
+	for _, srv := range s.services[aws.StringValue(input.Filters[0].Values[0])] {
+		summaries = append(summaries, &sd.ServiceSummary{Id: srv.Id, Name: srv.Name, Description: srv.Description})
+	}
+	fn(&sd.ListServicesOutput{Services: summaries}, true)
+	return nil
+}
+
+func (s *AWSSDClientStub) RegisterInstance(input *sd.RegisterInstanceInput) (*sd.RegisterInstanceOutput, error) {
+	instances, ok := s.instances[aws.StringValue(input.ServiceId)]
+	if !ok {
+		return nil, awserr.New(sd.ErrCodeServiceNotFound, "service not found", nil)
+	}
+	instances[aws.StringValue(input.InstanceId)] = &sd.InstanceSummary{Id: input.InstanceId, Attributes: input.Attributes}
+	return &sd.RegisterInstanceOutput{}, nil
+}
+
+func (s *AWSSDClientStub) UpdateService(input *sd.UpdateServiceInput) (*sd.UpdateServiceOutput, error) {
+	out, err := s.GetService(&sd.GetServiceInput{Id: input.Id})
+	if err != nil {
+		return nil, err
+	}
+	out.Service.Description = input.Service.Description
+	out.Service.DnsConfig.DnsRecords = input.Service.DnsConfig.DnsRecords
+	return &sd.UpdateServiceOutput{}, nil
+}
+
+func (s *AWSSDClientStub) service(namespaceID, name string) *sd.Service {
+	for _, srv := range s.services[namespaceID] {
+		if aws.StringValue(srv.Name) == name {
+			return srv
+		}
+	}
+	return nil
+}
+
+func newTestAWSSDProvider(client AWSSDClient, domainFilter DomainFilter, namespaceType string, dryRun bool) *AWSSDProvider {
+	return newAWSSDProvider(client, AWSSDConfig{
+		DomainFilter:  domainFilter,
+		NamespaceType: namespaceType,
+		DryRun:        dryRun,
+	})
+}
+
+func TestAWSSDProviderNamespaces(t *testing.T) {
+	client := newAWSSDClientStub()
+
+	for _, tc := range []struct {
+		title         string
+		domainFilter  DomainFilter
+		namespaceType string
+		expected      []string
+	}{
+		{"all", NewDomainFilter([]string{}), "", []string{"public.com", "private.com", "sub.public.com"}},
+		{"domain filter", NewDomainFilter([]string{"private.com"}), "", []string{"private.com"}},
+		{"public", NewDomainFilter([]string{}), "public", []string{"public.com", "sub.public.com"}},
+		{"private", NewDomainFilter([]string{}), "private", []string{"private.com"}},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			namespaces, err := newTestAWSSDProvider(client, tc.domainFilter, tc.namespaceType, false).namespaces()
+			require.NoError(t, err)
+			names := []string{}
+			for _, ns := range namespaces {
+				names = append(names, aws.StringValue(ns.Name))
+			}
+			assert.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+func TestAWSSDProviderRecords(t *testing.T) {
+	client := newAWSSDClientStub()
+	client.services["ns-public"] = []*sd.Service{
+		{
+			Id:          aws.String("srv-a"),
+			Name:        aws.String("a"),
+			Description: aws.String("heritage=external-dns,external-dns/owner=owner"),
+			DnsConfig: &sd.DnsConfig{
+				NamespaceId: aws.String("ns-public"),
+				DnsRecords:  []*sd.DnsRecord{{Type: aws.String(sd.RecordTypeA), TTL: aws.Int64(100)}},
+			},
+		},
+		{
+			Id:   aws.String("srv-alias"),
+			Name: aws.String("alias"),
+			DnsConfig: &sd.DnsConfig{
+				NamespaceId: aws.String("ns-public"),
+				DnsRecords:  []*sd.DnsRecord{{Type: aws.String(sd.RecordTypeA), TTL: aws.Int64(100)}},
+			},
+		},
+		{
+			Id:   aws.String("srv-cname"),
+			Name: aws.String("cname"),
+			DnsConfig: &sd.DnsConfig{
+				NamespaceId: aws.String("ns-public"),
+				DnsRecords:  []*sd.DnsRecord{{Type: aws.String(sd.RecordTypeCname), TTL: aws.Int64(80)}},
+			},
+		},
+		{
+			Id:   aws.String("srv-empty"),
+			Name: aws.String("empty"),
+			DnsConfig: &sd.DnsConfig{
+				NamespaceId: aws.String("ns-public"),
+				DnsRecords:  []*sd.DnsRecord{{Type: aws.String(sd.RecordTypeA), TTL: aws.Int64(100)}},
+			},
+		},
+	}
+	client.instances = map[string]map[string]*sd.InstanceSummary{
+		"srv-a": {
+			"1.2.3.4": {Id: aws.String("1.2.3.4"), Attributes: map[string]*string{sdInstanceAttrIPV4: aws.String("1.2.3.4")}},
+			"1.2.3.5": {Id: aws.String("1.2.3.5"), Attributes: map[string]*string{sdInstanceAttrIPV4: aws.String("1.2.3.5")}},
+		},
+		"srv-alias": {
+			"load-balancer.us-east-1.elb.amazonaws.com": {
+				Id:         aws.String("load-balancer.us-east-1.elb.amazonaws.com"),
+				Attributes: map[string]*string{sdInstanceAttrAlias: aws.String("load-balancer.us-east-1.elb.amazonaws.com")},
+			},
+		},
+		"srv-cname": {
+			"cname.example.com": {Id: aws.String("cname.example.com"), Attributes: map[string]*string{sdInstanceAttrCname: aws.String("cname.example.com")}},
+		},
+		"srv-empty": {},
+	}
+
+	endpoints, err := newTestAWSSDProvider(client, NewDomainFilter([]string{}), "", false).Records()
+	require.NoError(t, err)
+
+	expected := []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("a.public.com", "1.2.3.4", endpoint.RecordTypeA, 100),
+		endpoint.NewEndpointWithTTL("alias.public.com", "load-balancer.us-east-1.elb.amazonaws.com", endpoint.RecordTypeCNAME, 100),
+		endpoint.NewEndpointWithTTL("cname.public.com", "cname.example.com", endpoint.RecordTypeCNAME, 80),
+	}
+	expected[0].Targets = endpoint.Targets{"1.2.3.4", "1.2.3.5"}
+	assert.True(t, testutils.SameEndpoints(expected, endpoints), "expected and actual endpoints don't match. %s:%s", expected, endpoints)
+
+	for _, ep := range endpoints {
+		if ep.DNSName == "a.public.com" {
+			assert.Equal(t, "heritage=external-dns,external-dns/owner=owner", ep.Labels[endpoint.AWSSDDescriptionLabel])
+		}
+	}
+}
+
+func TestAWSSDProviderApplyChanges(t *testing.T) {
+	client := newAWSSDClientStub()
+	provider := newTestAWSSDProvider(client, NewDomainFilter([]string{}), "", false)
+
+	service := endpoint.NewEndpointWithTTL("service.public.com", "1.2.3.4", endpoint.RecordTypeA, 60)
+	service.Targets = endpoint.Targets{"1.2.3.4", "1.2.3.5"}
+	service.Labels[endpoint.AWSSDDescriptionLabel] = "heritage=external-dns,external-dns/owner=owner"
+	sub := endpoint.NewEndpoint("service.sub.public.com", "cname.example.com", endpoint.RecordTypeCNAME)
+	lb := endpoint.NewEndpoint("lb.private.com", "load-balancer.us-east-1.elb.amazonaws.com", endpoint.RecordTypeCNAME)
+	txt := endpoint.NewEndpoint("txt.public.com", "text", endpoint.RecordTypeTXT)
+	unknown := endpoint.NewEndpoint("service.unknown.com", "1.2.3.4", endpoint.RecordTypeA)
+
+	require.NoError(t, provider.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{service, sub, lb, txt, unknown},
+	}))
+
+	srv := client.service("ns-public", "service")
+	require.NotNil(t, srv)
+	assert.Equal(t, "heritage=external-dns,external-dns/owner=owner", aws.StringValue(srv.Description))
+	assert.Equal(t, sd.RoutingPolicyMultivalue, aws.StringValue(srv.DnsConfig.RoutingPolicy))
+	assert.Equal(t, int64(60), aws.Int64Value(srv.DnsConfig.DnsRecords[0].TTL))
+	assert.Len(t, client.instances[aws.StringValue(srv.Id)], 2)
+
+	subSrv := client.service("ns-sub", "service")
+	require.NotNil(t, subSrv, "should use the namespace with the longest matching name")
+	assert.Equal(t, sd.RecordTypeCname, aws.StringValue(subSrv.DnsConfig.DnsRecords[0].Type))
+	assert.Equal(t, int64(sdDefaultRecordTTL), aws.Int64Value(subSrv.DnsConfig.DnsRecords[0].TTL))
+
+	lbSrv := client.service("ns-private", "lb")
+	require.NotNil(t, lbSrv)
+	assert.Equal(t, sd.RecordTypeA, aws.StringValue(lbSrv.DnsConfig.DnsRecords[0].Type))
+	assert.Equal(t, sd.RoutingPolicyWeighted, aws.StringValue(lbSrv.DnsConfig.RoutingPolicy))
+	assert.Contains(t, client.instances[aws.StringValue(lbSrv.Id)]["load-balancer.us-east-1.elb.amazonaws.com"].Attributes, sdInstanceAttrAlias)
+
+	assert.Nil(t, client.service("ns-public", "txt"), "should skip unsupported record types")
+
+	records, err := provider.Records()
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	updated := endpoint.NewEndpointWithTTL("service.public.com", "1.2.3.5", endpoint.RecordTypeA, 120)
+	updated.Targets = endpoint.Targets{"1.2.3.5", "1.2.3.6"}
+	updated.Labels[endpoint.AWSSDDescriptionLabel] = "heritage=external-dns,external-dns/owner=owner,external-dns/resource=service/default/service"
+	require.NoError(t, provider.ApplyChanges(&plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{service},
+		UpdateNew: []*endpoint.Endpoint{updated},
+		Delete:    []*endpoint.Endpoint{sub},
+	}))
+
+	assert.Equal(t, "heritage=external-dns,external-dns/owner=owner,external-dns/resource=service/default/service", aws.StringValue(srv.Description))
+	assert.Equal(t, int64(120), aws.Int64Value(srv.DnsConfig.DnsRecords[0].TTL))
+	instances := client.instances[aws.StringValue(srv.Id)]
+	assert.Len(t, instances, 2)
+	assert.Contains(t, instances, "1.2.3.5")
+	assert.Contains(t, instances, "1.2.3.6")
+	assert.Empty(t, client.instances[aws.StringValue(subSrv.Id)])
+
+	// the type of a service can't be changed
+	assert.Error(t, provider.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("service.public.com", "cname.example.com", endpoint.RecordTypeCNAME)},
+	}))
+}
+
+func TestAWSSDProviderApplyChangesDryRun(t *testing.T) {
+	client := newAWSSDClientStub()
+	provider := newTestAWSSDProvider(client, NewDomainFilter([]string{}), "", true)
+
+	require.NoError(t, provider.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("service.public.com", "1.2.3.4", endpoint.RecordTypeA)},
+	}))
+	assert.Empty(t, client.services)
+}
+
+func TestAWSSDInstanceID(t *testing.T) {
+	assert.Equal(t, "1.2.3.4", sdInstanceID("1.2.3.4"))
+	assert.Equal(t, "load-balancer.example.com", sdInstanceID("Load-Balancer.example.com"))
+
+	long := strings.Repeat("a", sdMaxInstanceIDLength) + ".example.com"
+	id := sdInstanceID(long)
+	assert.Len(t, id, 32)
+	assert.Equal(t, id, sdInstanceID(strings.ToUpper(long)))
+}
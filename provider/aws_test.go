@@ -22,8 +22,10 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/kubernetes-incubator/external-dns/endpoint"
 	"github.com/kubernetes-incubator/external-dns/internal/testutils"
@@ -40,15 +42,19 @@ var _ Route53API = &Route53APIStub{}
 // of all of its methods.
 // mostly taken from: https://github.com/kubernetes/kubernetes/blob/853167624edb6bc0cfdcdfb88e746e178f5db36c/federation/pkg/dnsprovider/providers/aws/route53/stubs/route53api.go
 type Route53APIStub struct {
-	zones      map[string]*route53.HostedZone
-	recordSets map[string]map[string][]*route53.ResourceRecordSet
+	zones        map[string]*route53.HostedZone
+	recordSets   map[string]map[string][]*route53.ResourceRecordSet
+	healthChecks map[string]*route53.HealthCheck
+	// changeErrors are returned by the next calls of ChangeResourceRecordSets
+	changeErrors []error
 }
 
 // NewRoute53APIStub returns an initialized Route53APIStub
 func NewRoute53APIStub() *Route53APIStub {
 	return &Route53APIStub{
-		zones:      make(map[string]*route53.HostedZone),
-		recordSets: make(map[string]map[string][]*route53.ResourceRecordSet),
+		zones:        make(map[string]*route53.HostedZone),
+		recordSets:   make(map[string]map[string][]*route53.ResourceRecordSet),
+		healthChecks: make(map[string]*route53.HealthCheck),
 	}
 }
 
@@ -79,6 +85,12 @@ func wildcardEscape(s string) string {
 }
 
 func (r *Route53APIStub) ChangeResourceRecordSets(input *route53.ChangeResourceRecordSetsInput) (*route53.ChangeResourceRecordSetsOutput, error) {
+	if len(r.changeErrors) > 0 {
+		err := r.changeErrors[0]
+		r.changeErrors = r.changeErrors[1:]
+		return nil, err
+	}
+
 	_, ok := r.zones[aws.StringValue(input.HostedZoneId)]
 	if !ok {
 		return nil, fmt.Errorf("Hosted zone doesn't exist: %s", aws.StringValue(input.HostedZoneId))
@@ -109,7 +121,13 @@ func (r *Route53APIStub) ChangeResourceRecordSets(input *route53.ChangeResourceR
 			change.ResourceRecordSet.AliasTarget.DNSName = aws.String(wildcardEscape(ensureTrailingDot(aws.StringValue(change.ResourceRecordSet.AliasTarget.DNSName))))
 		}
 
-		key := aws.StringValue(change.ResourceRecordSet.Name) + "::" + aws.StringValue(change.ResourceRecordSet.Type)
+		if id := change.ResourceRecordSet.HealthCheckId; id != nil {
+			if _, found := r.healthChecks[aws.StringValue(id)]; !found {
+				return nil, fmt.Errorf("Health check doesn't exist: %s", aws.StringValue(id))
+			}
+		}
+
+		key := aws.StringValue(change.ResourceRecordSet.Name) + "::" + aws.StringValue(change.ResourceRecordSet.Type) + "::" + aws.StringValue(change.ResourceRecordSet.SetIdentifier)
 		switch aws.StringValue(change.Action) {
 		case route53.ChangeActionCreate:
 			if _, found := recordSets[key]; found {
@@ -139,6 +157,49 @@ func (r *Route53APIStub) ListHostedZonesPages(input *route53.ListHostedZonesInpu
 	return nil
 }
 
+func (r *Route53APIStub) CreateHealthCheck(input *route53.CreateHealthCheckInput) (*route53.CreateHealthCheckOutput, error) {
+	id := fmt.Sprintf("health-check-%d", len(r.healthChecks)+1)
+	for _, healthCheck := range r.healthChecks {
+		if aws.StringValue(healthCheck.CallerReference) == aws.StringValue(input.CallerReference) {
+			return nil, fmt.Errorf("Health check with caller reference %s already exists", aws.StringValue(input.CallerReference))
+		}
+	}
+	r.healthChecks[id] = &route53.HealthCheck{
+		Id:                aws.String(id),
+		CallerReference:   input.CallerReference,
+		HealthCheckConfig: input.HealthCheckConfig,
+	}
+	return &route53.CreateHealthCheckOutput{HealthCheck: r.healthChecks[id]}, nil
+}
+
+func (r *Route53APIStub) DeleteHealthCheck(input *route53.DeleteHealthCheckInput) (*route53.DeleteHealthCheckOutput, error) {
+	id := aws.StringValue(input.HealthCheckId)
+	if _, ok := r.healthChecks[id]; !ok {
+		return nil, fmt.Errorf("Health check doesn't exist: %s", id)
+	}
+	for _, recordSets := range r.recordSets {
+		for _, rrsets := range recordSets {
+			for _, rrset := range rrsets {
+				if aws.StringValue(rrset.HealthCheckId) == id {
+					return nil, fmt.Errorf("Health check %s is still referenced", id)
+				}
+			}
+		}
+	}
+	delete(r.healthChecks, id)
+	return &route53.DeleteHealthCheckOutput{}, nil
+}
+
+func (r *Route53APIStub) ListHealthChecksPages(input *route53.ListHealthChecksInput, fn func(p *route53.ListHealthChecksOutput, lastPage bool) (shouldContinue bool)) error {
+	output := &route53.ListHealthChecksOutput{}
+	for _, healthCheck := range r.healthChecks {
+		output.HealthChecks = append(output.HealthChecks, healthCheck)
+	}
+	lastPage := true
+	fn(output, lastPage)
+	return nil
+}
+
 func (r *Route53APIStub) CreateHostedZone(input *route53.CreateHostedZoneInput) (*route53.CreateHostedZoneOutput, error) {
 	name := aws.StringValue(input.Name)
 	id := "/hostedzone/" + name
@@ -203,6 +264,62 @@ func TestAWSZones(t *testing.T) {
 	}
 }
 
+func TestAWSZonesAndRecordsInMultipleAccounts(t *testing.T) {
+	role := "arn:aws:iam::123456789012:role/external-dns"
+	defaultClient := NewRoute53APIStub()
+	roleClient := NewRoute53APIStub()
+
+	provider := &AWSProvider{
+		client:         defaultClient,
+		domainFilter:   NewDomainFilter([]string{"ext-dns-test-2.teapot.zalan.do."}),
+		zoneIDFilter:   NewZoneIDFilter([]string{}),
+		zoneTypeFilter: NewZoneTypeFilter(""),
+		zoneRoles:      map[string]string{"zone-2.ext-dns-test-2.teapot.zalan.do.": role},
+		roleClients:    map[string]Route53API{role: roleClient},
+	}
+
+	for _, client := range []*Route53APIStub{defaultClient, roleClient} {
+		for _, name := range []string{"zone-1.ext-dns-test-2.teapot.zalan.do.", "zone-2.ext-dns-test-2.teapot.zalan.do."} {
+			_, err := client.CreateHostedZone(&route53.CreateHostedZoneInput{
+				CallerReference: aws.String("external-dns.alpha.kubernetes.io/test-zone"),
+				Name:            aws.String(name),
+			})
+			require.NoError(t, err)
+		}
+	}
+
+	// zone-2 is only taken from the account of the role, zone-1 only from the default account
+	zones, err := provider.Zones()
+	require.NoError(t, err)
+	validateAWSZones(t, zones, map[string]*route53.HostedZone{
+		"/hostedzone/zone-1.ext-dns-test-2.teapot.zalan.do.": {
+			Id:   aws.String("/hostedzone/zone-1.ext-dns-test-2.teapot.zalan.do."),
+			Name: aws.String("zone-1.ext-dns-test-2.teapot.zalan.do."),
+		},
+		"/hostedzone/zone-2.ext-dns-test-2.teapot.zalan.do.": {
+			Id:   aws.String("/hostedzone/zone-2.ext-dns-test-2.teapot.zalan.do."),
+			Name: aws.String("zone-2.ext-dns-test-2.teapot.zalan.do."),
+		},
+	})
+
+	require.NoError(t, provider.CreateRecords([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("create-test.zone-1.ext-dns-test-2.teapot.zalan.do", "1.2.3.4", endpoint.RecordTypeA),
+		endpoint.NewEndpoint("create-test.zone-2.ext-dns-test-2.teapot.zalan.do", "8.8.8.8", endpoint.RecordTypeA),
+	}))
+
+	assert.Len(t, listAWSRecords(t, defaultClient, "/hostedzone/zone-1.ext-dns-test-2.teapot.zalan.do."), 1)
+	assert.Len(t, listAWSRecords(t, defaultClient, "/hostedzone/zone-2.ext-dns-test-2.teapot.zalan.do."), 0)
+	assert.Len(t, listAWSRecords(t, roleClient, "/hostedzone/zone-1.ext-dns-test-2.teapot.zalan.do."), 0)
+	assert.Len(t, listAWSRecords(t, roleClient, "/hostedzone/zone-2.ext-dns-test-2.teapot.zalan.do."), 1)
+
+	records, err := provider.Records()
+	require.NoError(t, err)
+	validateEndpoints(t, records, []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("create-test.zone-1.ext-dns-test-2.teapot.zalan.do", "1.2.3.4", endpoint.RecordTypeA, endpoint.TTL(recordTTL)),
+		endpoint.NewEndpointWithTTL("create-test.zone-2.ext-dns-test-2.teapot.zalan.do", "8.8.8.8", endpoint.RecordTypeA, endpoint.TTL(recordTTL)),
+	})
+}
+
 func TestAWSRecords(t *testing.T) {
 	provider := newAWSProvider(t, NewDomainFilter([]string{"ext-dns-test-2.teapot.zalan.do."}), NewZoneIDFilter([]string{}), NewZoneTypeFilter(""), false, []*endpoint.Endpoint{
 		endpoint.NewEndpointWithTTL("list-test.zone-1.ext-dns-test-2.teapot.zalan.do", "1.2.3.4", endpoint.RecordTypeA, endpoint.TTL(recordTTL)),
@@ -417,6 +534,232 @@ func TestAWSApplyChangesDryRun(t *testing.T) {
 	validateEndpoints(t, records, originalEndpoints)
 }
 
+func TestAWSRoutingPolicies(t *testing.T) {
+	provider := newAWSProvider(t, NewDomainFilter([]string{"ext-dns-test-2.teapot.zalan.do."}), NewZoneIDFilter([]string{}), NewZoneTypeFilter(""), false, []*endpoint.Endpoint{})
+
+	routed := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("geo-test.zone-1.ext-dns-test-2.teapot.zalan.do", "1.2.3.4", endpoint.RecordTypeA).
+			WithSetIdentifier("europe").WithGeoLocation(&endpoint.GeoLocation{ContinentCode: "EU"}),
+		endpoint.NewEndpoint("geo-test.zone-1.ext-dns-test-2.teapot.zalan.do", "5.6.7.8", endpoint.RecordTypeA).
+			WithSetIdentifier("california").WithGeoLocation(&endpoint.GeoLocation{CountryCode: "US", SubdivisionCode: "CA"}),
+		endpoint.NewEndpoint("geo-test.zone-1.ext-dns-test-2.teapot.zalan.do", "8.8.8.8", endpoint.RecordTypeA).
+			WithSetIdentifier("default").WithGeoLocation(&endpoint.GeoLocation{CountryCode: "*"}),
+		endpoint.NewEndpoint("failover-test.zone-1.ext-dns-test-2.teapot.zalan.do", "1.2.3.4", endpoint.RecordTypeA).
+			WithSetIdentifier("primary").WithProviderSpecific("aws/failover", "primary"),
+		endpoint.NewEndpoint("failover-test.zone-1.ext-dns-test-2.teapot.zalan.do", "4.3.2.1", endpoint.RecordTypeA).
+			WithSetIdentifier("secondary").WithProviderSpecific("aws/failover", "SECONDARY"),
+	}
+	require.NoError(t, provider.ApplyChanges(&plan.Changes{Create: routed}))
+
+	records, err := provider.Records()
+	require.NoError(t, err)
+
+	validateEndpoints(t, records, []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("geo-test.zone-1.ext-dns-test-2.teapot.zalan.do", "1.2.3.4", endpoint.RecordTypeA, endpoint.TTL(recordTTL)).
+			WithSetIdentifier("europe").WithGeoLocation(&endpoint.GeoLocation{ContinentCode: "EU"}),
+		endpoint.NewEndpointWithTTL("geo-test.zone-1.ext-dns-test-2.teapot.zalan.do", "5.6.7.8", endpoint.RecordTypeA, endpoint.TTL(recordTTL)).
+			WithSetIdentifier("california").WithGeoLocation(&endpoint.GeoLocation{CountryCode: "US", SubdivisionCode: "CA"}),
+		endpoint.NewEndpointWithTTL("geo-test.zone-1.ext-dns-test-2.teapot.zalan.do", "8.8.8.8", endpoint.RecordTypeA, endpoint.TTL(recordTTL)).
+			WithSetIdentifier("default").WithGeoLocation(&endpoint.GeoLocation{CountryCode: "*"}),
+		endpoint.NewEndpointWithTTL("failover-test.zone-1.ext-dns-test-2.teapot.zalan.do", "1.2.3.4", endpoint.RecordTypeA, endpoint.TTL(recordTTL)).
+			WithSetIdentifier("primary").WithProviderSpecific("aws/failover", "PRIMARY"),
+		endpoint.NewEndpointWithTTL("failover-test.zone-1.ext-dns-test-2.teapot.zalan.do", "4.3.2.1", endpoint.RecordTypeA, endpoint.TTL(recordTTL)).
+			WithSetIdentifier("secondary").WithProviderSpecific("aws/failover", "SECONDARY"),
+	})
+
+	// records with a set identifier are deleted individually
+	require.NoError(t, provider.ApplyChanges(&plan.Changes{Delete: records[:1]}))
+	records, err = provider.Records()
+	require.NoError(t, err)
+	assert.Len(t, records, 4)
+}
+
+func TestAWSHealthChecks(t *testing.T) {
+	provider := newAWSProvider(t, NewDomainFilter([]string{"ext-dns-test-2.teapot.zalan.do."}), NewZoneIDFilter([]string{}), NewZoneTypeFilter(""), false, []*endpoint.Endpoint{})
+	stub := provider.client.(*Route53APIStub)
+
+	primary := func(target string, ttl endpoint.TTL) *endpoint.Endpoint {
+		return endpoint.NewEndpointWithTTL("failover-test.zone-1.ext-dns-test-2.teapot.zalan.do", target, endpoint.RecordTypeA, ttl).
+			WithSetIdentifier("primary").
+			WithProviderSpecific("aws/failover", "PRIMARY").
+			WithProviderSpecific("aws/health-check-type", "http").
+			WithProviderSpecific("aws/health-check-path", "/healthz")
+	}
+
+	// create a record with a health check
+	require.NoError(t, provider.ApplyChanges(&plan.Changes{Create: []*endpoint.Endpoint{primary("1.2.3.4", 0)}}))
+	require.Len(t, stub.healthChecks, 1)
+	for _, healthCheck := range stub.healthChecks {
+		assert.True(t, strings.HasPrefix(aws.StringValue(healthCheck.CallerReference), healthCheckCallerReferencePrefix))
+		assert.Equal(t, "HTTP 1.2.3.4:80/healthz", healthCheckString(healthCheck.HealthCheckConfig))
+	}
+
+	records, err := provider.Records()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	healthCheckID, ok := records[0].GetProviderSpecificProperty("aws/health-check-id")
+	require.True(t, ok)
+	assert.True(t, records[0].ProviderSpecific.Same(endpoint.ProviderSpecific{
+		{Name: "aws/failover", Value: "PRIMARY"},
+		{Name: "aws/health-check-id", Value: healthCheckID},
+		{Name: "aws/health-check-type", Value: "HTTP"},
+		{Name: "aws/health-check-port", Value: "80"},
+		{Name: "aws/health-check-path", Value: "/healthz"},
+	}), "unexpected provider specific properties %v", records[0].ProviderSpecific)
+
+	// the health check is kept if only the TTL changes
+	require.NoError(t, provider.ApplyChanges(&plan.Changes{
+		UpdateOld: records,
+		UpdateNew: []*endpoint.Endpoint{primary("1.2.3.4", 60)},
+	}))
+	require.Len(t, stub.healthChecks, 1)
+	assert.Contains(t, stub.healthChecks, healthCheckID)
+
+	// the health check is replaced if the target changes
+	records, err = provider.Records()
+	require.NoError(t, err)
+	require.NoError(t, provider.ApplyChanges(&plan.Changes{
+		UpdateOld: records,
+		UpdateNew: []*endpoint.Endpoint{primary("4.3.2.1", 60)},
+	}))
+	require.Len(t, stub.healthChecks, 1)
+	assert.NotContains(t, stub.healthChecks, healthCheckID)
+	for _, healthCheck := range stub.healthChecks {
+		assert.Equal(t, "HTTP 4.3.2.1:80/healthz", healthCheckString(healthCheck.HealthCheckConfig))
+	}
+
+	// the health check is deleted along with the record
+	records, err = provider.Records()
+	require.NoError(t, err)
+	require.NoError(t, provider.ApplyChanges(&plan.Changes{Delete: records}))
+	assert.Empty(t, stub.healthChecks)
+
+	// health checks not created by ExternalDNS are attached but never deleted
+	_, err = stub.CreateHealthCheck(&route53.CreateHealthCheckInput{
+		CallerReference:   aws.String("manual"),
+		HealthCheckConfig: &route53.HealthCheckConfig{Type: aws.String(route53.HealthCheckTypeTcp)},
+	})
+	require.NoError(t, err)
+	require.NoError(t, provider.ApplyChanges(&plan.Changes{Create: []*endpoint.Endpoint{
+		endpoint.NewEndpoint("failover-test.zone-1.ext-dns-test-2.teapot.zalan.do", "1.2.3.4", endpoint.RecordTypeA).
+			WithSetIdentifier("primary").
+			WithProviderSpecific("aws/failover", "PRIMARY").
+			WithProviderSpecific("aws/health-check-id", "health-check-1"),
+	}}))
+	records, err = provider.Records()
+	require.NoError(t, err)
+	assert.True(t, records[0].ProviderSpecific.Same(endpoint.ProviderSpecific{
+		{Name: "aws/failover", Value: "PRIMARY"},
+		{Name: "aws/health-check-id", Value: "health-check-1"},
+	}), "unexpected provider specific properties %v", records[0].ProviderSpecific)
+	require.NoError(t, provider.ApplyChanges(&plan.Changes{Delete: records}))
+	assert.Len(t, stub.healthChecks, 1)
+}
+
+func TestAWSAdjustEndpointsMatchesRecords(t *testing.T) {
+	provider := newAWSProvider(t, NewDomainFilter([]string{"ext-dns-test-2.teapot.zalan.do."}), NewZoneIDFilter([]string{}), NewZoneTypeFilter(""), false, []*endpoint.Endpoint{})
+	desired := func() []*endpoint.Endpoint {
+		return []*endpoint.Endpoint{
+			endpoint.NewEndpoint("failover-test.zone-1.ext-dns-test-2.teapot.zalan.do", "1.2.3.4", endpoint.RecordTypeA).
+				WithSetIdentifier("primary").
+				WithProviderSpecific("aws/failover", "primary").
+				WithProviderSpecific("aws/health-check-type", "http"),
+		}
+	}
+	require.NoError(t, provider.ApplyChanges(&plan.Changes{Create: desired()}))
+
+	adjusted, err := provider.AdjustEndpoints(desired())
+	require.NoError(t, err)
+	assert.Equal(t, endpoint.ProviderSpecific{
+		{Name: "aws/failover", Value: "PRIMARY"},
+		{Name: "aws/health-check-type", Value: "HTTP"},
+		{Name: "aws/health-check-port", Value: "80"},
+		{Name: "aws/health-check-path", Value: "/"},
+	}, adjusted[0].ProviderSpecific)
+
+	// the record with the health check created by the provider is up to date
+	records, err := provider.Records()
+	require.NoError(t, err)
+	changes := (&plan.Plan{
+		Policies:                  []plan.Policy{&plan.SyncPolicy{}},
+		Current:                   records,
+		Desired:                   adjusted,
+		ProviderManagedProperties: ProviderManagedProperties,
+	}).Calculate().Changes
+	assert.False(t, changes.HasChanges())
+}
+
+func TestAWSHealthChecksRollback(t *testing.T) {
+	provider := newAWSProvider(t, NewDomainFilter([]string{"ext-dns-test-2.teapot.zalan.do."}), NewZoneIDFilter([]string{}), NewZoneTypeFilter(""), false, []*endpoint.Endpoint{})
+	stub := provider.client.(*Route53APIStub)
+
+	// the change batch is rejected because of the second record, the created health check is deleted again
+	assert.EqualError(t, provider.ApplyChanges(&plan.Changes{Create: []*endpoint.Endpoint{
+		endpoint.NewEndpoint("failover-test.zone-1.ext-dns-test-2.teapot.zalan.do", "1.2.3.4", endpoint.RecordTypeA).
+			WithSetIdentifier("primary").
+			WithProviderSpecific("aws/failover", "PRIMARY").
+			WithProviderSpecific("aws/health-check-type", "TCP").
+			WithProviderSpecific("aws/health-check-port", "443"),
+		endpoint.NewEndpoint("invalid-test.zone-1.ext-dns-test-2.teapot.zalan.do", "not-an-ip", endpoint.RecordTypeA),
+	}}), "failed to change the records of 1 of 1 zones: A records must point to IPs")
+	assert.Empty(t, stub.healthChecks)
+}
+
+func TestAWSApplyChangesDryRunWithHealthChecks(t *testing.T) {
+	provider := newAWSProvider(t, NewDomainFilter([]string{"ext-dns-test-2.teapot.zalan.do."}), NewZoneIDFilter([]string{}), NewZoneTypeFilter(""), true, []*endpoint.Endpoint{})
+	stub := provider.client.(*Route53APIStub)
+
+	require.NoError(t, provider.ApplyChanges(&plan.Changes{Create: []*endpoint.Endpoint{
+		endpoint.NewEndpoint("failover-test.zone-1.ext-dns-test-2.teapot.zalan.do", "1.2.3.4", endpoint.RecordTypeA).
+			WithSetIdentifier("primary").
+			WithProviderSpecific("aws/failover", "PRIMARY").
+			WithProviderSpecific("aws/health-check-type", "HTTPS"),
+	}}))
+	assert.Empty(t, stub.healthChecks)
+}
+
+func TestAWSNewHealthCheckConfig(t *testing.T) {
+	newEndpoint := func(target string, properties ...string) *endpoint.Endpoint {
+		ep := endpoint.NewEndpoint("failover-test.example.org", target, endpoint.RecordTypeA).WithSetIdentifier("primary")
+		for i := 0; i < len(properties); i += 2 {
+			ep.WithProviderSpecific(properties[i], properties[i+1])
+		}
+		return ep
+	}
+
+	for _, tc := range []struct {
+		title     string
+		endpoint  *endpoint.Endpoint
+		expected  string
+		expectErr bool
+	}{
+		{"no health check", newEndpoint("1.2.3.4"), "", false},
+		{"HTTP defaults", newEndpoint("1.2.3.4", "aws/health-check-type", "HTTP"), "HTTP 1.2.3.4:80/", false},
+		{"HTTPS defaults", newEndpoint("lb.example.com", "aws/health-check-type", "https"), "HTTPS lb.example.com:443/", false},
+		{"HTTP with port and path", newEndpoint("1.2.3.4", "aws/health-check-type", "HTTP", "aws/health-check-port", "8080", "aws/health-check-path", "/healthz"), "HTTP 1.2.3.4:8080/healthz", false},
+		{"TCP", newEndpoint("1.2.3.4", "aws/health-check-type", "TCP", "aws/health-check-port", "5432", "aws/health-check-path", "/ignored"), "TCP 1.2.3.4:5432", false},
+		{"TCP without port", newEndpoint("1.2.3.4", "aws/health-check-type", "TCP"), "", true},
+		{"invalid port", newEndpoint("1.2.3.4", "aws/health-check-type", "HTTP", "aws/health-check-port", "http"), "", true},
+		{"port out of range", newEndpoint("1.2.3.4", "aws/health-check-type", "HTTP", "aws/health-check-port", "65536"), "", true},
+		{"unsupported type", newEndpoint("1.2.3.4", "aws/health-check-type", "ICMP"), "", true},
+		{"without routing policy", newEndpoint("1.2.3.4", "aws/health-check-type", "HTTP").WithSetIdentifier(""), "", true},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			config, err := newHealthCheckConfig(tc.endpoint)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tc.expected == "" {
+				assert.Nil(t, config)
+				return
+			}
+			assert.Equal(t, tc.expected, healthCheckString(config))
+		})
+	}
+}
+
 func TestAWSChangesByZones(t *testing.T) {
 	changes := []*route53.Change{
 		{
@@ -532,7 +875,7 @@ func TestAWSsubmitChanges(t *testing.T) {
 	cs := make([]*route53.Change, 0, len(endpoints))
 	cs = append(cs, newChanges(route53.ChangeActionCreate, endpoints)...)
 
-	require.NoError(t, provider.submitChanges(cs))
+	require.NoError(t, provider.submitChanges(cs, nil))
 
 	records, err := provider.Records()
 	require.NoError(t, err)
@@ -863,3 +1206,53 @@ func newAWSProvider(t *testing.T, domainFilter DomainFilter, zoneIDFilter ZoneID
 func validateRecords(t *testing.T, records []*route53.ResourceRecordSet, expected []*route53.ResourceRecordSet) {
 	assert.Equal(t, expected, records)
 }
+
+func TestAWSApplyChangesRetriesThrottling(t *testing.T) {
+	provider := newAWSProvider(t, NewDomainFilter([]string{"ext-dns-test-2.teapot.zalan.do."}), NewZoneIDFilter([]string{}), NewZoneTypeFilter(""), false, []*endpoint.Endpoint{})
+	stub := provider.client.(*Route53APIStub)
+	stub.changeErrors = []error{awserr.New("Throttling", "Rate exceeded", nil)}
+
+	retrying := NewRetryProvider(provider, 2, time.Second)
+	retrying.sleep = func(time.Duration) {}
+	require.NoError(t, retrying.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("create-test.zone-1.ext-dns-test-2.teapot.zalan.do", "8.8.8.8", endpoint.RecordTypeA)},
+	}))
+
+	records, err := provider.Records()
+	require.NoError(t, err)
+	validateEndpoints(t, records, []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("create-test.zone-1.ext-dns-test-2.teapot.zalan.do", "8.8.8.8", endpoint.RecordTypeA, endpoint.TTL(recordTTL)),
+	})
+}
+
+func TestAWSApplyChangesReturnsErrors(t *testing.T) {
+	provider := newAWSProvider(t, NewDomainFilter([]string{"ext-dns-test-2.teapot.zalan.do."}), NewZoneIDFilter([]string{}), NewZoneTypeFilter(""), false, []*endpoint.Endpoint{})
+	stub := provider.client.(*Route53APIStub)
+	changes := &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("create-test.zone-1.ext-dns-test-2.teapot.zalan.do", "8.8.8.8", endpoint.RecordTypeA),
+			endpoint.NewEndpoint("create-test.zone-2.ext-dns-test-2.teapot.zalan.do", "8.8.4.4", endpoint.RecordTypeA),
+		},
+	}
+
+	// the changes of all zones failed transiently
+	stub.changeErrors = []error{awserr.New("Throttling", "Rate exceeded", nil), awserr.New("Throttling", "Rate exceeded", nil)}
+	err := provider.ApplyChanges(changes)
+	require.Error(t, err)
+	assert.True(t, provider.IsTransientError(err))
+
+	// a retry would create the records of the other zone again
+	stub.changeErrors = []error{awserr.New("Throttling", "Rate exceeded", nil)}
+	err = provider.ApplyChanges(changes)
+	require.Error(t, err)
+	assert.False(t, provider.IsTransientError(err))
+}
+
+func TestAWSIsTransientError(t *testing.T) {
+	provider := &AWSProvider{}
+
+	assert.True(t, provider.IsTransientError(awserr.New("Throttling", "Rate exceeded", nil)))
+	assert.True(t, provider.IsTransientError(awserr.New("PriorRequestNotComplete", "The request was rejected because Route 53 was still processing a prior request.", nil)))
+	assert.False(t, provider.IsTransientError(awserr.New("InvalidChangeBatch", "Tried to create resource record set but it already exists", nil)))
+	assert.False(t, provider.IsTransientError(fmt.Errorf("some error")))
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+// CachedProvider wraps a Provider and serves its records from a cache for RefreshDelay.
+// This avoids listing large zones in every synchronization. The cache is dropped after
+// every call to ApplyChanges, so that changes are always planned against fresh records.
+type CachedProvider struct {
+	Provider
+	RefreshDelay time.Duration
+
+	cache    []*endpoint.Endpoint
+	lastRead time.Time
+	// now is used to determine the age of the cache, it can be replaced in tests
+	now func() time.Time
+}
+
+// NewCachedProvider returns a CachedProvider caching the records of the given provider for refreshDelay
+func NewCachedProvider(provider Provider, refreshDelay time.Duration) *CachedProvider {
+	return &CachedProvider{
+		Provider:     provider,
+		RefreshDelay: refreshDelay,
+		now:          time.Now,
+	}
+}
+
+// Records returns the cached records if they are younger than RefreshDelay and lists them otherwise. It returns copies
+// of the records, since callers modify them, e.g. the registries set their labels.
+func (c *CachedProvider) Records() ([]*endpoint.Endpoint, error) {
+	if c.needRefresh() {
+		log.Debug("Records cache provider: refreshing records")
+		records, err := c.Provider.Records()
+		if err != nil {
+			c.Reset()
+			return nil, err
+		}
+		c.cache = records
+		c.lastRead = c.now()
+	} else {
+		log.Debug("Records cache provider: using records from cache")
+	}
+	return copyEndpoints(c.cache), nil
+}
+
+// ApplyChanges applies the changes with the wrapped provider and invalidates the cache
+func (c *CachedProvider) ApplyChanges(changes *plan.Changes) error {
+	if changes.HasChanges() {
+		c.Reset()
+	}
+	return c.Provider.ApplyChanges(changes)
+}
+
+// AdjustEndpoints forwards to the wrapped provider if it adjusts endpoints
+func (c *CachedProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	if adjuster, ok := c.Provider.(EndpointsAdjuster); ok {
+		return adjuster.AdjustEndpoints(endpoints)
+	}
+	return endpoints, nil
+}
+
+// SupportsGeoLocations forwards to the wrapped provider
+func (c *CachedProvider) SupportsGeoLocations() bool {
+	return SupportsGeoLocations(c.Provider)
+}
+
+// Reset drops the cached records
+func (c *CachedProvider) Reset() {
+	c.cache = nil
+	c.lastRead = time.Time{}
+}
+
+func (c *CachedProvider) needRefresh() bool {
+	if c.lastRead.IsZero() {
+		return true
+	}
+	return c.now().Sub(c.lastRead) > c.RefreshDelay
+}
+
+// copyEndpoints returns deep copies of the endpoints, so that the cache doesn't share memory with the endpoints of callers
+func copyEndpoints(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	copies := make([]*endpoint.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		copies = append(copies, ep.DeepCopy())
+	}
+	return copies
+}
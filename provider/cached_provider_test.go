@@ -0,0 +1,119 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+var _ Provider = &CachedProvider{}
+var _ EndpointsAdjuster = &CachedProvider{}
+
+func newTestCachedProvider() (*CachedProvider, *int, *time.Time) {
+	calls := 0
+	backend := NewInMemoryProvider(InMemoryInitZones([]string{"example.org"}))
+	backend.OnRecords = func() { calls++ }
+
+	now := time.Date(2017, 11, 1, 0, 0, 0, 0, time.UTC)
+	cached := NewCachedProvider(backend, time.Minute)
+	cached.now = func() time.Time { return now }
+
+	return cached, &calls, &now
+}
+
+func TestCachedProviderRecords(t *testing.T) {
+	cached, calls, now := newTestCachedProvider()
+
+	_, err := cached.Records()
+	require.NoError(t, err)
+	_, err = cached.Records()
+	require.NoError(t, err)
+	assert.Equal(t, 1, *calls)
+
+	*now = now.Add(59 * time.Second)
+	_, err = cached.Records()
+	require.NoError(t, err)
+	assert.Equal(t, 1, *calls)
+
+	*now = now.Add(2 * time.Second)
+	_, err = cached.Records()
+	require.NoError(t, err)
+	assert.Equal(t, 2, *calls)
+}
+
+func TestCachedProviderApplyChangesInvalidatesCache(t *testing.T) {
+	cached, calls, _ := newTestCachedProvider()
+
+	records, err := cached.Records()
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	// empty changes keep the cache
+	require.NoError(t, cached.ApplyChanges(&plan.Changes{}))
+	_, err = cached.Records()
+	require.NoError(t, err)
+	assert.Equal(t, 1, *calls)
+
+	require.NoError(t, cached.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", "1.2.3.4", endpoint.RecordTypeA)},
+	}))
+	records, err = cached.Records()
+	require.NoError(t, err)
+	assert.Equal(t, 2, *calls)
+	assert.Len(t, records, 1)
+}
+
+func TestCachedProviderErrorsAreNotCached(t *testing.T) {
+	cached, calls, _ := newTestCachedProvider()
+	InMemoryWithThrottling(1)(cached.Provider.(*InMemoryProvider))
+
+	_, err := cached.Records()
+	assert.EqualError(t, err, ErrThrottled.Error())
+
+	_, err = cached.Records()
+	require.NoError(t, err)
+	_, err = cached.Records()
+	require.NoError(t, err)
+	assert.Equal(t, 2, *calls)
+}
+
+func TestCachedProviderReturnsCopies(t *testing.T) {
+	cached, _, _ := newTestCachedProvider()
+	require.NoError(t, cached.Provider.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", "1.2.3.4", endpoint.RecordTypeA)},
+	}))
+
+	records, err := cached.Records()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	// callers like the registries modify the records
+	records[0].Labels = map[string]string{endpoint.OwnerLabelKey: "owner"}
+	records[0].Targets[0] = "5.6.7.8"
+
+	records, err = cached.Records()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Empty(t, records[0].Labels[endpoint.OwnerLabelKey])
+	assert.Equal(t, endpoint.Targets{"1.2.3.4"}, records[0].Targets)
+}
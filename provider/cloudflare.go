@@ -18,6 +18,7 @@ package provider
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 
@@ -104,9 +105,9 @@ type cloudFlareChange struct {
 }
 
 // NewCloudFlareProvider initializes a new CloudFlare DNS based Provider.
-func NewCloudFlareProvider(domainFilter DomainFilter, zoneIDFilter ZoneIDFilter, proxied bool, dryRun bool) (*CloudFlareProvider, error) {
+func NewCloudFlareProvider(domainFilter DomainFilter, zoneIDFilter ZoneIDFilter, proxied bool, rateLimiter *RateLimiter, dryRun bool) (*CloudFlareProvider, error) {
 	// initialize via API email and API key and returns new API object
-	config, err := cloudflare.New(os.Getenv("CF_API_KEY"), os.Getenv("CF_API_EMAIL"))
+	config, err := cloudflare.New(os.Getenv("CF_API_KEY"), os.Getenv("CF_API_EMAIL"), cloudflare.HTTPClient(rateLimiter.Client(http.DefaultClient)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize cloudflare provider: %v", err)
 	}
@@ -55,7 +55,7 @@ type DigitalOceanChange struct {
 }
 
 // NewDigitalOceanProvider initializes a new DigitalOcean DNS based Provider.
-func NewDigitalOceanProvider(domainFilter DomainFilter, dryRun bool) (*DigitalOceanProvider, error) {
+func NewDigitalOceanProvider(domainFilter DomainFilter, rateLimiter *RateLimiter, dryRun bool) (*DigitalOceanProvider, error) {
 	token, ok := os.LookupEnv("DO_TOKEN")
 	if !ok {
 		return nil, fmt.Errorf("No token found")
@@ -63,7 +63,7 @@ func NewDigitalOceanProvider(domainFilter DomainFilter, dryRun bool) (*DigitalOc
 	oauthClient := oauth2.NewClient(oauth2.NoContext, oauth2.StaticTokenSource(&oauth2.Token{
 		AccessToken: token,
 	}))
-	client := godo.NewClient(oauthClient)
+	client := godo.NewClient(rateLimiter.Client(oauthClient))
 
 	provider := &DigitalOceanProvider{
 		Client:       client.Domains,
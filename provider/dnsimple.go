@@ -28,7 +28,13 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-const dnsimpleRecordTTL = 3600 // Default TTL of 1 hour if not set (DNSimple's default)
+const (
+	dnsimpleRecordTTL = 3600 // Default TTL of 1 hour if not set (DNSimple's default)
+
+	// dnsimpleSandboxBaseURL is the API endpoint of DNSimple's sandbox environment
+	// which can be used to test against without touching production zones.
+	dnsimpleSandboxBaseURL = "https://api.sandbox.dnsimple.com"
+)
 
 type identityService struct {
 	service *dnsimple.IdentityService
@@ -101,13 +107,14 @@ const (
 	dnsimpleUpdate = "UPDATE"
 )
 
-// NewDnsimpleProvider initializes a new Dnsimple based provider
-func NewDnsimpleProvider(domainFilter DomainFilter, zoneIDFilter ZoneIDFilter, dryRun bool) (Provider, error) {
+// NewDnsimpleProvider initializes a new Dnsimple based provider.
+// If sandbox is true, the provider talks to DNSimple's sandbox environment instead of production.
+func NewDnsimpleProvider(domainFilter DomainFilter, zoneIDFilter ZoneIDFilter, sandbox bool, dryRun bool) (Provider, error) {
 	oauthToken := os.Getenv("DNSIMPLE_OAUTH")
 	if len(oauthToken) == 0 {
 		return nil, fmt.Errorf("No dnsimple oauth token provided")
 	}
-	client := dnsimple.NewClient(dnsimple.NewOauthTokenCredentials(oauthToken))
+	client := newDnsimpleClient(oauthToken, sandbox)
 	provider := &dnsimpleProvider{
 		client:       dnsimpleZoneService{service: client.Zones},
 		identity:     identityService{service: client.Identity},
@@ -123,6 +130,15 @@ func NewDnsimpleProvider(domainFilter DomainFilter, zoneIDFilter ZoneIDFilter, d
 	return provider, nil
 }
 
+// newDnsimpleClient returns a client of the DNSimple API, of the sandbox environment if sandbox is true
+func newDnsimpleClient(oauthToken string, sandbox bool) *dnsimple.Client {
+	client := dnsimple.NewClient(dnsimple.NewOauthTokenCredentials(oauthToken))
+	if sandbox {
+		client.BaseURL = dnsimpleSandboxBaseURL
+	}
+	return client
+}
+
 // Returns a list of filtered Zones
 func (p *dnsimpleProvider) Zones() (map[string]dnsimple.Zone, error) {
 	zones := make(map[string]dnsimple.Zone)
@@ -176,7 +192,11 @@ func (p *dnsimpleProvider) Records() (endpoints []*endpoint.Endpoint, _ error) {
 				default:
 					continue
 				}
-				endpoints = append(endpoints, endpoint.NewEndpointWithTTL(record.Name+"."+record.ZoneID, record.Content, record.Type, endpoint.TTL(record.TTL)))
+				dnsName := record.ZoneID
+				if record.Name != "" {
+					dnsName = record.Name + "." + record.ZoneID
+				}
+				endpoints = append(endpoints, endpoint.NewEndpointWithTTL(dnsName, record.Content, record.Type, endpoint.TTL(record.TTL)))
 			}
 			page++
 			if page > records.Pagination.TotalPages {
@@ -232,7 +252,12 @@ func (p *dnsimpleProvider) submitChanges(changes []*dnsimpleChange) error {
 		}
 		log.Infof("Changing records: %s %v in zone: %s", change.Action, change.ResourceRecordSet, zone.Name)
 
-		change.ResourceRecordSet.Name = strings.TrimSuffix(change.ResourceRecordSet.Name, "."+zone.Name)
+		// Records at the apex of the zone have an empty name in DNSimple
+		if change.ResourceRecordSet.Name == zone.Name {
+			change.ResourceRecordSet.Name = ""
+		} else {
+			change.ResourceRecordSet.Name = strings.TrimSuffix(change.ResourceRecordSet.Name, "."+zone.Name)
+		}
 		if !p.dryRun {
 			switch change.Action {
 			case dnsimpleCreate:
@@ -241,7 +266,7 @@ func (p *dnsimpleProvider) submitChanges(changes []*dnsimpleChange) error {
 					return err
 				}
 			case dnsimpleDelete:
-				recordID, err := p.GetRecordID(zone.Name, change.ResourceRecordSet.Name)
+				recordID, err := p.GetRecordID(zone.Name, change.ResourceRecordSet.Name, change.ResourceRecordSet.Type)
 				if err != nil {
 					return err
 				}
@@ -250,7 +275,7 @@ func (p *dnsimpleProvider) submitChanges(changes []*dnsimpleChange) error {
 					return err
 				}
 			case dnsimpleUpdate:
-				recordID, err := p.GetRecordID(zone.Name, change.ResourceRecordSet.Name)
+				recordID, err := p.GetRecordID(zone.Name, change.ResourceRecordSet.Name, change.ResourceRecordSet.Type)
 				if err != nil {
 					return err
 				}
@@ -264,8 +289,9 @@ func (p *dnsimpleProvider) submitChanges(changes []*dnsimpleChange) error {
 	return nil
 }
 
-// Returns the record ID for a given record name and zone
-func (p *dnsimpleProvider) GetRecordID(zone string, recordName string) (recordID int, err error) {
+// Returns the record ID for a given record name, type and zone.
+// The type has to be taken into account since e.g. an A record and its ownership TXT record can share a name.
+func (p *dnsimpleProvider) GetRecordID(zone string, recordName string, recordType string) (recordID int, err error) {
 	page := 1
 	listOptions := &dnsimple.ZoneRecordListOptions{Name: recordName}
 	for {
@@ -276,7 +302,7 @@ func (p *dnsimpleProvider) GetRecordID(zone string, recordName string) (recordID
 		}
 
 		for _, record := range records.Data {
-			if record.Name == recordName {
+			if record.Name == recordName && record.Type == recordType {
 				return record.ID, nil
 			}
 		}
@@ -84,8 +84,18 @@ func TestDnsimpleServices(t *testing.T) {
 		Priority: 0,
 		Type:     "CNAME",
 	}
+	apexRecord := dnsimple.ZoneRecord{
+		ID:       4,
+		ZoneID:   "example.com",
+		ParentID: 0,
+		Name:     "",
+		Content:  "127.0.0.2",
+		TTL:      3600,
+		Priority: 0,
+		Type:     "A",
+	}
 
-	records := []dnsimple.ZoneRecord{firstRecord, secondRecord, thirdRecord}
+	records := []dnsimple.ZoneRecord{firstRecord, secondRecord, thirdRecord, apexRecord}
 	dnsimpleListRecordsResponse = dnsimple.ZoneRecordsResponse{
 		Response: dnsimple.Response{Pagination: &dnsimple.Pagination{}},
 		Data:     records,
@@ -145,6 +155,13 @@ func testDnsimpleProviderRecords(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, len(dnsimpleListRecordsResponse.Data), len(result))
 
+	dnsNames := []string{}
+	for _, ep := range result {
+		dnsNames = append(dnsNames, ep.DNSName)
+	}
+	assert.Contains(t, dnsNames, "example.com")
+	assert.Contains(t, dnsNames, "example-beta.example.com")
+
 	mockProvider.accountID = "2"
 	result, err = mockProvider.Records()
 	assert.NotNil(t, err)
@@ -156,7 +173,10 @@ func testDnsimpleProviderApplyChanges(t *testing.T) {
 		{DNSName: "custom-ttl.example.com", RecordTTL: 60, Targets: endpoint.Targets{"target"}, RecordType: endpoint.RecordTypeCNAME},
 	}
 	changes.Delete = []*endpoint.Endpoint{{DNSName: "example-beta.example.com", Targets: endpoint.Targets{"127.0.0.1"}, RecordType: endpoint.RecordTypeA}}
-	changes.UpdateNew = []*endpoint.Endpoint{{DNSName: "example.example.com", Targets: endpoint.Targets{"target"}, RecordType: endpoint.RecordTypeCNAME}}
+	changes.UpdateNew = []*endpoint.Endpoint{
+		{DNSName: "example.example.com", Targets: endpoint.Targets{"target"}, RecordType: endpoint.RecordTypeCNAME},
+		{DNSName: "example.com", Targets: endpoint.Targets{"127.0.0.2"}, RecordType: endpoint.RecordTypeA},
+	}
 
 	mockProvider.accountID = "1"
 	err := mockProvider.ApplyChanges(changes)
@@ -176,7 +196,7 @@ func testDnsimpleSuitableZone(t *testing.T) {
 
 func TestNewDnsimpleProvider(t *testing.T) {
 	os.Setenv("DNSIMPLE_OAUTH", "xxxxxxxxxxxxxxxxxxxxxxxxxx")
-	_, err := NewDnsimpleProvider(NewDomainFilter([]string{"example.com"}), NewZoneIDFilter([]string{""}), true)
+	_, err := NewDnsimpleProvider(NewDomainFilter([]string{"example.com"}), NewZoneIDFilter([]string{""}), false, true)
 	if err == nil {
 		t.Errorf("Expected to fail new provider on bad token")
 	}
@@ -186,15 +206,27 @@ func TestNewDnsimpleProvider(t *testing.T) {
 	}
 }
 
+func TestNewDnsimpleClient(t *testing.T) {
+	assert.Equal(t, "https://api.sandbox.dnsimple.com", newDnsimpleClient("xxxxxxxxxxxxxxxxxxxxxxxxxx", true).BaseURL)
+	assert.NotEqual(t, dnsimpleSandboxBaseURL, newDnsimpleClient("xxxxxxxxxxxxxxxxxxxxxxxxxx", false).BaseURL)
+}
+
 func testDnsimpleGetRecordID(t *testing.T) {
 	mockProvider.accountID = "1"
-	result, err := mockProvider.GetRecordID("example.com", "example")
+	result, err := mockProvider.GetRecordID("example.com", "example", "CNAME")
 	assert.Nil(t, err)
 	assert.Equal(t, 2, result)
 
-	result, err = mockProvider.GetRecordID("example.com", "example-beta")
+	result, err = mockProvider.GetRecordID("example.com", "example-beta", "A")
 	assert.Nil(t, err)
 	assert.Equal(t, 1, result)
+
+	result, err = mockProvider.GetRecordID("example.com", "", "A")
+	assert.Nil(t, err)
+	assert.Equal(t, 4, result)
+
+	_, err = mockProvider.GetRecordID("example.com", "example-beta", "TXT")
+	assert.NotNil(t, err)
 }
 
 func validateDnsimpleZones(t *testing.T, zones map[string]dnsimple.Zone, expected []dnsimple.Zone) {
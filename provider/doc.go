@@ -0,0 +1,22 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package provider implements the DNS providers applying changes to the records of a DNS service.
+//
+// The Provider interface and the optional interfaces like EndpointsAdjuster and TransientErrorClassifier are a stable
+// API following semantic versioning, new capabilities are added as new optional interfaces. The configs of the
+// individual providers follow their DNS services and may gain fields.
+package provider
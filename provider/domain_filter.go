@@ -17,12 +17,17 @@ limitations under the License.
 package provider
 
 import (
+	"regexp"
 	"strings"
 )
 
-// DomainFilter holds a lists of valid domain names
+// DomainFilter holds a lists of valid domain names, excluded sub-domains
+// and optionally regular expressions which domains must match or must not match
 type DomainFilter struct {
-	filters []string
+	filters        []string
+	exclusions     []string
+	regex          *regexp.Regexp
+	regexExclusion *regexp.Regexp
 }
 
 // NewDomainFilter returns a new DomainFilter given a comma separated list of domains
@@ -35,11 +40,49 @@ func NewDomainFilter(domainFilters []string) DomainFilter {
 		filters[i] = strings.TrimSuffix(strings.TrimSpace(domain), ".")
 	}
 
-	return DomainFilter{filters}
+	return DomainFilter{filters: filters}
+}
+
+// NewDomainFilterWithExclusions returns a new DomainFilter which doesn't match the excluded domains and their
+// sub-domains, e.g. to manage example.com but never corp.example.com
+func NewDomainFilterWithExclusions(domainFilters []string, excludeDomains []string) DomainFilter {
+	df := NewDomainFilter(domainFilters)
+	for _, domain := range excludeDomains {
+		if domain = strings.TrimSuffix(strings.TrimSpace(domain), "."); domain != "" {
+			df.exclusions = append(df.exclusions, domain)
+		}
+	}
+	return df
+}
+
+// NewRegexDomainFilter returns a new DomainFilter which additionally requires domains to match regexDomainFilter
+// and not to match regexDomainExclusion, e.g. to express "all prod zones except sandbox". Either may be nil.
+func NewRegexDomainFilter(domainFilters, excludeDomains []string, regexDomainFilter, regexDomainExclusion *regexp.Regexp) DomainFilter {
+	df := NewDomainFilterWithExclusions(domainFilters, excludeDomains)
+	df.regex = regexDomainFilter
+	df.regexExclusion = regexDomainExclusion
+	return df
 }
 
 // Match checks whether a domain can be found in the DomainFilter.
+// It must match the suffixes and the regular expression, if configured, and must not match the exclusions.
 func (df DomainFilter) Match(domain string) bool {
+	name := strings.TrimSuffix(domain, ".")
+	for _, exclusion := range df.exclusions {
+		if name == exclusion || strings.HasSuffix(name, "."+exclusion) {
+			return false
+		}
+	}
+	if df.regex != nil && !df.regex.MatchString(name) {
+		return false
+	}
+	if df.regexExclusion != nil && df.regexExclusion.MatchString(name) {
+		return false
+	}
+	return df.matchSuffix(name)
+}
+
+func (df DomainFilter) matchSuffix(domain string) bool {
 	// return always true, if not filter is specified
 	if len(df.filters) == 0 {
 		return true
@@ -55,8 +98,27 @@ func (df DomainFilter) Match(domain string) bool {
 	return false
 }
 
+// matchLength returns the length of the longest domain suffix of the filter matching the domain, 0 if the filter
+// has no suffixes and -1 if the domain doesn't match at all
+func (df DomainFilter) matchLength(domain string) int {
+	if !df.Match(domain) {
+		return -1
+	}
+	name := strings.TrimSuffix(domain, ".")
+	length := 0
+	for _, filter := range df.filters {
+		if strings.HasSuffix(name, filter) && len(filter) > length {
+			length = len(filter)
+		}
+	}
+	return length
+}
+
 // IsConfigured returns true if DomainFilter is configured, false otherwise
 func (df DomainFilter) IsConfigured() bool {
+	if len(df.exclusions) > 0 || df.regex != nil || df.regexExclusion != nil {
+		return true
+	}
 	if len(df.filters) == 1 {
 		return df.filters[0] != ""
 	}
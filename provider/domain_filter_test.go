@@ -17,6 +17,7 @@ limitations under the License.
 package provider
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -120,3 +121,155 @@ func TestDomainFilterMatchWithEmptyFilter(t *testing.T) {
 		}
 	}
 }
+
+var domainExclusionTests = []struct {
+	domainFilter  []string
+	excludeDomain []string
+	domains       []string
+	expected      bool
+}{
+	{
+		[]string{"example.com"},
+		[]string{"corp.example.com"},
+		[]string{"example.com", "foo.example.com", "foocorp.example.com"},
+		true,
+	},
+	{
+		[]string{"example.com"},
+		[]string{"corp.example.com."},
+		[]string{"corp.example.com", "foo.corp.example.com"},
+		false,
+	},
+	{
+		[]string{},
+		[]string{"corp.example.com"},
+		[]string{"example.org", "foo.example.com"},
+		true,
+	},
+	{
+		[]string{},
+		[]string{"corp.example.com", " sandbox.example.org "},
+		[]string{"foo.corp.example.com", "sandbox.example.org"},
+		false,
+	},
+	{
+		[]string{""},
+		[]string{""},
+		[]string{"example.org"},
+		true,
+	},
+}
+
+func TestDomainFilterMatchWithExclusions(t *testing.T) {
+	for i, tt := range domainExclusionTests {
+		domainFilter := NewDomainFilterWithExclusions(tt.domainFilter, tt.excludeDomain)
+		for _, domain := range tt.domains {
+			assert.Equal(t, tt.expected, domainFilter.Match(domain), "should not fail: %v in test-case #%v", domain, i)
+			assert.Equal(t, tt.expected, domainFilter.Match(domain+"."), "should not fail: %v in test-case #%v", domain+".", i)
+		}
+	}
+}
+
+func TestDomainFilterWithExclusionsIsConfigured(t *testing.T) {
+	assert.False(t, NewDomainFilterWithExclusions([]string{""}, []string{""}).IsConfigured())
+	assert.True(t, NewDomainFilterWithExclusions([]string{""}, []string{"corp.example.com"}).IsConfigured())
+}
+
+type regexDomainFilterTest struct {
+	domainFilter         []string
+	regexDomainFilter    string
+	regexDomainExclusion string
+	domains              []string
+	expected             bool
+}
+
+var regexDomainFilterTests = []regexDomainFilterTest{
+	{
+		[]string{},
+		`\.prod\.`,
+		"",
+		[]string{"foo.prod.example.org", "prod.prod.example.org"},
+		true,
+	},
+	{
+		[]string{},
+		`\.prod\.`,
+		"",
+		[]string{"foo.staging.example.org", "prod.example.org"},
+		false,
+	},
+	{
+		[]string{},
+		`\.prod\.`,
+		`^sandbox\.`,
+		[]string{"sandbox.prod.example.org"},
+		false,
+	},
+	{
+		[]string{},
+		"",
+		`^sandbox\.`,
+		[]string{"sandbox.example.org"},
+		false,
+	},
+	{
+		[]string{},
+		"",
+		`^sandbox\.`,
+		[]string{"foo.example.org", "foo.sandbox.example.org"},
+		true,
+	},
+	{
+		[]string{"example.org"},
+		`\.prod\.`,
+		"",
+		[]string{"foo.prod.example.org"},
+		true,
+	},
+	{
+		[]string{"example.org"},
+		`\.prod\.`,
+		"",
+		[]string{"foo.prod.example.com"},
+		false,
+	},
+	{
+		[]string{},
+		`org$`,
+		"",
+		[]string{"example.org"},
+		true,
+	},
+}
+
+func TestRegexDomainFilterMatch(t *testing.T) {
+	for i, tt := range regexDomainFilterTests {
+		domainFilter := NewRegexDomainFilter(tt.domainFilter, nil, compileOptional(tt.regexDomainFilter), compileOptional(tt.regexDomainExclusion))
+		for _, domain := range tt.domains {
+			assert.Equal(t, tt.expected, domainFilter.Match(domain), "should not fail: %v in test-case #%v", domain, i)
+			assert.Equal(t, tt.expected, domainFilter.Match(domain+"."), "should not fail: %v in test-case #%v", domain+".", i)
+		}
+	}
+}
+
+func TestRegexDomainFilterIsConfigured(t *testing.T) {
+	assert.False(t, NewRegexDomainFilter([]string{""}, nil, nil, nil).IsConfigured())
+	assert.True(t, NewRegexDomainFilter([]string{""}, nil, regexp.MustCompile(`\.prod\.`), nil).IsConfigured())
+	assert.True(t, NewRegexDomainFilter([]string{""}, nil, nil, regexp.MustCompile(`^sandbox\.`)).IsConfigured())
+}
+
+func compileOptional(expr string) *regexp.Regexp {
+	if expr == "" {
+		return nil
+	}
+	return regexp.MustCompile(expr)
+}
+
+func TestDomainFilterMatchLength(t *testing.T) {
+	filter := NewDomainFilterWithExclusions([]string{"example.org", "internal.example.org"}, []string{"corp.example.org"})
+	assert.Equal(t, len("internal.example.org"), filter.matchLength("db.internal.example.org."))
+	assert.Equal(t, len("example.org"), filter.matchLength("www.example.org"))
+	assert.Equal(t, -1, filter.matchLength("www.corp.example.org"))
+	assert.Equal(t, -1, filter.matchLength("www.example.com"))
+	assert.Equal(t, 0, NewDomainFilter(nil).matchLength("www.example.com"))
+}
@@ -0,0 +1,327 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/exoscale/egoscale"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+const (
+	// exoscaleDefaultTTL is used for records which don't define a TTL (Exoscale's default)
+	exoscaleDefaultTTL = 3600
+)
+
+// exoscaleDNSClient is the subset of the Exoscale DNS API that we actually use.
+type exoscaleDNSClient interface {
+	GetDomains() ([]egoscale.DNSDomain, error)
+	GetRecords(domain string) ([]egoscale.DNSRecord, error)
+	CreateRecord(domain string, record egoscale.DNSRecord) (*egoscale.DNSRecord, error)
+	UpdateRecord(domain string, record egoscale.UpdateDNSRecord) (*egoscale.DNSRecord, error)
+	DeleteRecord(domain string, recordID int64) error
+}
+
+// ExoscaleProvider is an implementation of Provider for Exoscale DNS.
+type ExoscaleProvider struct {
+	client exoscaleDNSClient
+	// only consider hosted zones managing domains ending in this suffix
+	domainFilter DomainFilter
+	// filter domains by their numeric ID
+	zoneIDFilter ZoneIDFilter
+	dryRun       bool
+}
+
+// NewExoscaleProvider initializes a new Exoscale DNS based Provider authenticating with the given API key and secret.
+func NewExoscaleProvider(apiEndpoint, apiKey, apiSecret string, domainFilter DomainFilter, zoneIDFilter ZoneIDFilter, dryRun bool) (*ExoscaleProvider, error) {
+	if apiKey == "" || apiSecret == "" {
+		return nil, fmt.Errorf("no Exoscale API key and secret specified")
+	}
+
+	provider := &ExoscaleProvider{
+		client:       egoscale.NewClient(apiEndpoint, apiKey, apiSecret),
+		domainFilter: domainFilter,
+		zoneIDFilter: zoneIDFilter,
+		dryRun:       dryRun,
+	}
+	return provider, nil
+}
+
+// zones returns the domains which pass the filters, keyed by their name.
+func (p *ExoscaleProvider) zones() (map[string]egoscale.DNSDomain, error) {
+	domains, err := p.client.GetDomains()
+	if err != nil {
+		return nil, err
+	}
+
+	zones := map[string]egoscale.DNSDomain{}
+	for _, domain := range domains {
+		if !p.domainFilter.Match(domain.Name) || !p.zoneIDFilter.Match(strconv.FormatInt(domain.ID, 10)) {
+			continue
+		}
+		zones[domain.Name] = domain
+	}
+	return zones, nil
+}
+
+// Records returns the list of records in all relevant zones.
+func (p *ExoscaleProvider) Records() ([]*endpoint.Endpoint, error) {
+	zones, err := p.zones()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := []*endpoint.Endpoint{}
+	for _, zone := range zones {
+		records, err := p.client.GetRecords(zone.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		// Exoscale returns one record per content, records of the same name and type are merged into one endpoint
+		byNameAndType := map[string]*endpoint.Endpoint{}
+		for _, record := range records {
+			if !supportedRecordType(record.RecordType) {
+				continue
+			}
+
+			name := zone.Name
+			if record.Name != "" {
+				name = record.Name + "." + zone.Name
+			}
+
+			key := name + "/" + record.RecordType
+			if ep, ok := byNameAndType[key]; ok {
+				ep.Targets = append(ep.Targets, record.Content)
+				continue
+			}
+
+			ep := endpoint.NewEndpointWithTTL(name, record.Content, record.RecordType, endpoint.TTL(record.TTL))
+			byNameAndType[key] = ep
+			endpoints = append(endpoints, ep)
+		}
+	}
+
+	return endpoints, nil
+}
+
+// ApplyChanges applies a given set of changes in all relevant zones.
+// Obsolete records are deleted first so that their names can be reused by the records created afterwards.
+func (p *ExoscaleProvider) ApplyChanges(changes *plan.Changes) error {
+	if len(changes.Create) == 0 && len(changes.UpdateNew) == 0 && len(changes.Delete) == 0 {
+		log.Info("All records are already up to date")
+		return nil
+	}
+
+	zones, err := p.zones()
+	if err != nil {
+		return err
+	}
+	zoneNameMapper := zoneIDName{}
+	for name := range zones {
+		zoneNameMapper.Add(name, name)
+	}
+
+	for _, ep := range changes.Delete {
+		zone, name := exoscaleRecordName(zoneNameMapper, ep)
+		if zone == "" {
+			continue
+		}
+		if err := p.deleteRecords(zone, name, ep); err != nil {
+			return err
+		}
+	}
+
+	for _, ep := range changes.UpdateNew {
+		zone, name := exoscaleRecordName(zoneNameMapper, ep)
+		if zone == "" {
+			continue
+		}
+		if err := p.updateRecords(zone, name, ep); err != nil {
+			return err
+		}
+	}
+
+	for _, ep := range changes.Create {
+		zone, name := exoscaleRecordName(zoneNameMapper, ep)
+		if zone == "" {
+			continue
+		}
+		for _, target := range ep.Targets {
+			if err := p.createRecord(zone, name, ep, target); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// deleteRecords deletes the records of the zone holding one of the endpoint's targets.
+func (p *ExoscaleProvider) deleteRecords(zone, name string, ep *endpoint.Endpoint) error {
+	records, err := p.recordsByNameAndType(zone, name, ep.RecordType)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if !exoscaleHasTarget(ep.Targets, record.Content) {
+			continue
+		}
+		if err := p.deleteRecord(zone, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateRecords brings the records of the zone in line with the desired endpoint: records of targets which are still
+// desired are updated in place, the remaining ones are deleted and records of new targets are created.
+func (p *ExoscaleProvider) updateRecords(zone, name string, desired *endpoint.Endpoint) error {
+	records, err := p.recordsByNameAndType(zone, name, desired.RecordType)
+	if err != nil {
+		return err
+	}
+
+	ttl := exoscaleTTL(desired)
+	existing := map[string]bool{}
+	for _, record := range records {
+		if !exoscaleHasTarget(desired.Targets, record.Content) || existing[record.Content] {
+			if err := p.deleteRecord(zone, record); err != nil {
+				return err
+			}
+			continue
+		}
+
+		existing[record.Content] = true
+		if record.TTL == ttl {
+			continue
+		}
+		if err := p.updateRecord(zone, record, ttl); err != nil {
+			return err
+		}
+	}
+
+	for _, target := range desired.Targets {
+		if existing[target] {
+			continue
+		}
+		if err := p.createRecord(zone, name, desired, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordsByNameAndType returns the records of the zone with the given relative name and type.
+func (p *ExoscaleProvider) recordsByNameAndType(zone, name, recordType string) ([]egoscale.DNSRecord, error) {
+	records, err := p.client.GetRecords(zone)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := []egoscale.DNSRecord{}
+	for _, record := range records {
+		if record.Name == name && record.RecordType == recordType {
+			matching = append(matching, record)
+		}
+	}
+	return matching, nil
+}
+
+func (p *ExoscaleProvider) createRecord(zone, name string, ep *endpoint.Endpoint, target string) error {
+	log.Infof("Creating record %s %s %s in zone %s", ep.DNSName, ep.RecordType, target, zone)
+	if p.dryRun {
+		return nil
+	}
+
+	_, err := p.client.CreateRecord(zone, egoscale.DNSRecord{
+		Name:       name,
+		RecordType: ep.RecordType,
+		Content:    target,
+		TTL:        exoscaleTTL(ep),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create record %s %s %s: %v", ep.DNSName, ep.RecordType, target, err)
+	}
+	return nil
+}
+
+func (p *ExoscaleProvider) updateRecord(zone string, record egoscale.DNSRecord, ttl int) error {
+	log.Infof("Updating TTL of record %s %s %s in zone %s to %d", record.Name, record.RecordType, record.Content, zone, ttl)
+	if p.dryRun {
+		return nil
+	}
+
+	_, err := p.client.UpdateRecord(zone, egoscale.UpdateDNSRecord{
+		ID:         record.ID,
+		DomainID:   record.DomainID,
+		Name:       record.Name,
+		RecordType: record.RecordType,
+		Content:    record.Content,
+		TTL:        ttl,
+		Prio:       record.Prio,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update record %d in zone %s: %v", record.ID, zone, err)
+	}
+	return nil
+}
+
+func (p *ExoscaleProvider) deleteRecord(zone string, record egoscale.DNSRecord) error {
+	log.Infof("Deleting record %s %s %s in zone %s", record.Name, record.RecordType, record.Content, zone)
+	if p.dryRun {
+		return nil
+	}
+
+	if err := p.client.DeleteRecord(zone, record.ID); err != nil {
+		return fmt.Errorf("failed to delete record %d in zone %s: %v", record.ID, zone, err)
+	}
+	return nil
+}
+
+// exoscaleRecordName returns the zone of the endpoint and its name relative to the zone, which is empty for the apex.
+func exoscaleRecordName(zoneNameMapper zoneIDName, ep *endpoint.Endpoint) (string, string) {
+	zone, _ := zoneNameMapper.FindZone(ep.DNSName)
+	if zone == "" {
+		log.Debugf("Skipping record %s because no hosted zone matching record DNS Name was detected", ep.DNSName)
+		return "", ""
+	}
+	return zone, strings.TrimSuffix(strings.TrimSuffix(ep.DNSName, zone), ".")
+}
+
+func exoscaleTTL(ep *endpoint.Endpoint) int {
+	if ep.RecordTTL.IsConfigured() {
+		return int(ep.RecordTTL)
+	}
+	return exoscaleDefaultTTL
+}
+
+func exoscaleHasTarget(targets endpoint.Targets, target string) bool {
+	for _, t := range targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/exoscale/egoscale"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+// exoscaleMockClient serves domains and records from memory and counts the calls modifying records.
+type exoscaleMockClient struct {
+	domains []egoscale.DNSDomain
+	records map[string][]egoscale.DNSRecord
+	nextID  int64
+
+	created, updated, deleted int
+}
+
+func newExoscaleMockClient() *exoscaleMockClient {
+	return &exoscaleMockClient{
+		domains: []egoscale.DNSDomain{
+			{ID: 1, Name: "foo.com"},
+			{ID: 2, Name: "bar.com"},
+		},
+		records: map[string][]egoscale.DNSRecord{
+			"foo.com": {
+				{ID: 10, DomainID: 1, Name: "", RecordType: "NS", Content: "ns1.exoscale.ch", TTL: 3600},
+				{ID: 11, DomainID: 1, Name: "www", RecordType: endpoint.RecordTypeA, Content: "1.2.3.4", TTL: 300},
+				{ID: 12, DomainID: 1, Name: "www", RecordType: endpoint.RecordTypeA, Content: "5.6.7.8", TTL: 300},
+				{ID: 13, DomainID: 1, Name: "www", RecordType: endpoint.RecordTypeTXT, Content: "heritage=external-dns,external-dns/owner=default", TTL: 300},
+			},
+			"bar.com": {
+				{ID: 20, DomainID: 2, Name: "", RecordType: endpoint.RecordTypeA, Content: "8.8.8.8", TTL: 3600},
+				{ID: 21, DomainID: 2, Name: "api", RecordType: endpoint.RecordTypeCNAME, Content: "lb.example.com", TTL: 60},
+			},
+		},
+		nextID: 100,
+	}
+}
+
+func (c *exoscaleMockClient) GetDomains() ([]egoscale.DNSDomain, error) {
+	return c.domains, nil
+}
+
+func (c *exoscaleMockClient) GetRecords(domain string) ([]egoscale.DNSRecord, error) {
+	records, ok := c.records[domain]
+	if !ok {
+		return nil, fmt.Errorf("domain %s not found", domain)
+	}
+	return records, nil
+}
+
+func (c *exoscaleMockClient) CreateRecord(domain string, record egoscale.DNSRecord) (*egoscale.DNSRecord, error) {
+	c.created++
+	record.ID = c.nextID
+	c.nextID++
+	c.records[domain] = append(c.records[domain], record)
+	return &record, nil
+}
+
+func (c *exoscaleMockClient) UpdateRecord(domain string, update egoscale.UpdateDNSRecord) (*egoscale.DNSRecord, error) {
+	c.updated++
+	for i, record := range c.records[domain] {
+		if record.ID == update.ID {
+			record.TTL = update.TTL
+			record.Content = update.Content
+			c.records[domain][i] = record
+			return &record, nil
+		}
+	}
+	return nil, fmt.Errorf("record %d not found", update.ID)
+}
+
+func (c *exoscaleMockClient) DeleteRecord(domain string, recordID int64) error {
+	c.deleted++
+	records := c.records[domain]
+	for i, record := range records {
+		if record.ID == recordID {
+			c.records[domain] = append(records[:i], records[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("record %d not found", recordID)
+}
+
+func newExoscaleTestProvider(client exoscaleDNSClient, domainFilter DomainFilter, zoneIDFilter ZoneIDFilter, dryRun bool) *ExoscaleProvider {
+	return &ExoscaleProvider{
+		client:       client,
+		domainFilter: domainFilter,
+		zoneIDFilter: zoneIDFilter,
+		dryRun:       dryRun,
+	}
+}
+
+func TestNewExoscaleProviderRequiresCredentials(t *testing.T) {
+	_, err := NewExoscaleProvider("https://api.exoscale.ch/dns", "", "", NewDomainFilter(nil), NewZoneIDFilter(nil), false)
+	assert.Error(t, err)
+
+	_, err = NewExoscaleProvider("https://api.exoscale.ch/dns", "key", "secret", NewDomainFilter(nil), NewZoneIDFilter(nil), false)
+	assert.NoError(t, err)
+}
+
+func TestExoscaleRecords(t *testing.T) {
+	for _, tc := range []struct {
+		title        string
+		domainFilter DomainFilter
+		zoneIDFilter ZoneIDFilter
+		expected     []*endpoint.Endpoint
+	}{
+		{
+			"all domains",
+			NewDomainFilter(nil),
+			NewZoneIDFilter(nil),
+			[]*endpoint.Endpoint{
+				{DNSName: "www.foo.com", Targets: endpoint.Targets{"1.2.3.4", "5.6.7.8"}, RecordType: endpoint.RecordTypeA, RecordTTL: 300},
+				endpoint.NewEndpointWithTTL("www.foo.com", "heritage=external-dns,external-dns/owner=default", endpoint.RecordTypeTXT, 300),
+				endpoint.NewEndpointWithTTL("bar.com", "8.8.8.8", endpoint.RecordTypeA, 3600),
+				endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 60),
+			},
+		},
+		{
+			"domain filter",
+			NewDomainFilter([]string{"bar.com"}),
+			NewZoneIDFilter(nil),
+			[]*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("bar.com", "8.8.8.8", endpoint.RecordTypeA, 3600),
+				endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 60),
+			},
+		},
+		{
+			"zone id filter",
+			NewDomainFilter(nil),
+			NewZoneIDFilter([]string{"2"}),
+			[]*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("bar.com", "8.8.8.8", endpoint.RecordTypeA, 3600),
+				endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 60),
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			provider := newExoscaleTestProvider(newExoscaleMockClient(), tc.domainFilter, tc.zoneIDFilter, false)
+
+			records, err := provider.Records()
+			require.NoError(t, err)
+
+			validateEndpoints(t, records, tc.expected)
+		})
+	}
+}
+
+func TestExoscaleApplyChanges(t *testing.T) {
+	client := newExoscaleMockClient()
+	provider := newExoscaleTestProvider(client, NewDomainFilter(nil), NewZoneIDFilter(nil), false)
+
+	err := provider.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("new.foo.com", "8.8.8.8", endpoint.RecordTypeA),
+			endpoint.NewEndpoint("new.unknown.com", "8.8.8.8", endpoint.RecordTypeA),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			{DNSName: "www.foo.com", Targets: endpoint.Targets{"1.2.3.4", "5.6.7.8"}, RecordType: endpoint.RecordTypeA, RecordTTL: 300},
+			endpoint.NewEndpointWithTTL("bar.com", "8.8.8.8", endpoint.RecordTypeA, 3600),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			{DNSName: "www.foo.com", Targets: endpoint.Targets{"1.2.3.4", "9.9.9.9"}, RecordType: endpoint.RecordTypeA, RecordTTL: 300},
+			endpoint.NewEndpointWithTTL("bar.com", "8.8.8.8", endpoint.RecordTypeA, 60),
+		},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 60),
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, client.created)
+	assert.Equal(t, 1, client.updated)
+	assert.Equal(t, 2, client.deleted)
+
+	records, err := provider.Records()
+	require.NoError(t, err)
+
+	validateEndpoints(t, records, []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("new.foo.com", "8.8.8.8", endpoint.RecordTypeA, exoscaleDefaultTTL),
+		{DNSName: "www.foo.com", Targets: endpoint.Targets{"1.2.3.4", "9.9.9.9"}, RecordType: endpoint.RecordTypeA, RecordTTL: 300},
+		endpoint.NewEndpointWithTTL("www.foo.com", "heritage=external-dns,external-dns/owner=default", endpoint.RecordTypeTXT, 300),
+		endpoint.NewEndpointWithTTL("bar.com", "8.8.8.8", endpoint.RecordTypeA, 60),
+	})
+}
+
+func TestExoscaleApplyChangesDryRun(t *testing.T) {
+	client := newExoscaleMockClient()
+	provider := newExoscaleTestProvider(client, NewDomainFilter(nil), NewZoneIDFilter(nil), true)
+
+	err := provider.ApplyChanges(&plan.Changes{
+		Create:    []*endpoint.Endpoint{endpoint.NewEndpoint("new.foo.com", "8.8.8.8", endpoint.RecordTypeA)},
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("bar.com", "8.8.8.8", endpoint.RecordTypeA, 3600)},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("bar.com", "8.8.8.8", endpoint.RecordTypeA, 60)},
+		Delete:    []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 60)},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, client.created)
+	assert.Equal(t, 0, client.updated)
+	assert.Equal(t, 0, client.deleted)
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+const (
+	gandiLiveDNSURL = "https://api.gandi.net/v5/livedns"
+
+	// gandiDefaultTTL is used for records which don't define a TTL (Gandi's default)
+	gandiDefaultTTL = 10800
+	// gandiMinTTL is the lowest TTL accepted by Gandi
+	gandiMinTTL = 300
+
+	// gandiApex is the name of records at the apex of a domain
+	gandiApex = "@"
+)
+
+// GandiProvider is an implementation of Provider for Gandi LiveDNS.
+// LiveDNS manages records as rrsets, which map to endpoints one to one.
+type GandiProvider struct {
+	apiURL string
+	token  string
+	client *http.Client
+	// only consider hosted zones managing domains ending in this suffix
+	domainFilter DomainFilter
+	dryRun       bool
+}
+
+type gandiDomain struct {
+	FQDN string `json:"fqdn"`
+}
+
+type gandiRRSet struct {
+	Name   string   `json:"rrset_name,omitempty"`
+	Type   string   `json:"rrset_type,omitempty"`
+	TTL    int      `json:"rrset_ttl,omitempty"`
+	Values []string `json:"rrset_values"`
+}
+
+type gandiError struct {
+	Message string `json:"message"`
+}
+
+// NewGandiProvider initializes a new Gandi LiveDNS based Provider authenticating with a personal access token.
+func NewGandiProvider(token string, domainFilter DomainFilter, rateLimiter *RateLimiter, dryRun bool) (*GandiProvider, error) {
+	if token == "" {
+		return nil, fmt.Errorf("no Gandi personal access token specified")
+	}
+
+	p := &GandiProvider{
+		apiURL:       gandiLiveDNSURL,
+		token:        token,
+		client:       rateLimiter.Client(&http.Client{Timeout: 30 * time.Second}),
+		domainFilter: domainFilter,
+		dryRun:       dryRun,
+	}
+	return p, nil
+}
+
+// zones returns the names of the LiveDNS domains which pass the domain filter.
+func (p *GandiProvider) zones() ([]string, error) {
+	domains := []gandiDomain{}
+	if err := p.do(http.MethodGet, "/domains", nil, &domains); err != nil {
+		return nil, err
+	}
+
+	zones := []string{}
+	for _, domain := range domains {
+		if p.domainFilter.Match(domain.FQDN) {
+			zones = append(zones, domain.FQDN)
+		}
+	}
+	return zones, nil
+}
+
+// Records returns the list of records in all relevant zones.
+func (p *GandiProvider) Records() ([]*endpoint.Endpoint, error) {
+	zones, err := p.zones()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := []*endpoint.Endpoint{}
+	for _, zone := range zones {
+		rrsets := []gandiRRSet{}
+		if err := p.do(http.MethodGet, "/domains/"+url.PathEscape(zone)+"/records", nil, &rrsets); err != nil {
+			return nil, err
+		}
+
+		for _, rrset := range rrsets {
+			if !supportedRecordType(rrset.Type) || len(rrset.Values) == 0 {
+				continue
+			}
+
+			name := zone
+			if rrset.Name != gandiApex {
+				name = rrset.Name + "." + zone
+			}
+
+			ep := endpoint.NewEndpointWithTTL(name, "", rrset.Type, endpoint.TTL(rrset.TTL))
+			ep.Targets = make(endpoint.Targets, 0, len(rrset.Values))
+			for _, value := range rrset.Values {
+				if rrset.Type == endpoint.RecordTypeCNAME {
+					value = strings.TrimSuffix(value, ".")
+				}
+				ep.Targets = append(ep.Targets, value)
+			}
+			endpoints = append(endpoints, ep)
+		}
+	}
+
+	return endpoints, nil
+}
+
+// AdjustEndpoints raises TTLs below Gandi's minimum, which would otherwise never match the current records.
+func (p *GandiProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	for _, ep := range endpoints {
+		if ep.RecordTTL.IsConfigured() && ep.RecordTTL < gandiMinTTL {
+			ep.RecordTTL = gandiMinTTL
+		}
+	}
+	return endpoints, nil
+}
+
+// ApplyChanges applies a given set of changes in all relevant zones.
+// Every change replaces or deletes a whole rrset, the records of UpdateOld are therefore not needed.
+func (p *GandiProvider) ApplyChanges(changes *plan.Changes) error {
+	if len(changes.Create) == 0 && len(changes.UpdateNew) == 0 && len(changes.Delete) == 0 {
+		log.Info("All records are already up to date")
+		return nil
+	}
+
+	zones, err := p.zones()
+	if err != nil {
+		return err
+	}
+	zoneNameMapper := zoneIDName{}
+	for _, zone := range zones {
+		zoneNameMapper.Add(zone, zone)
+	}
+
+	for _, ep := range changes.Delete {
+		if err := p.submitChange(zoneNameMapper, http.MethodDelete, ep); err != nil {
+			return err
+		}
+	}
+	for _, ep := range changes.UpdateNew {
+		if err := p.submitChange(zoneNameMapper, http.MethodPut, ep); err != nil {
+			return err
+		}
+	}
+	for _, ep := range changes.Create {
+		if err := p.submitChange(zoneNameMapper, http.MethodPost, ep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// submitChange creates (POST), replaces (PUT) or deletes (DELETE) the rrset of the endpoint.
+func (p *GandiProvider) submitChange(zoneNameMapper zoneIDName, method string, ep *endpoint.Endpoint) error {
+	zone, _ := zoneNameMapper.FindZone(ep.DNSName)
+	if zone == "" {
+		log.Debugf("Skipping record %s because no hosted zone matching record DNS Name was detected", ep.DNSName)
+		return nil
+	}
+
+	name := strings.TrimSuffix(strings.TrimSuffix(ep.DNSName, zone), ".")
+	if name == "" {
+		name = gandiApex
+	}
+	path := "/domains/" + url.PathEscape(zone) + "/records/" + url.PathEscape(name) + "/" + ep.RecordType
+
+	log.Infof("Changing record: %s %s %s %v in zone %s", method, ep.DNSName, ep.RecordType, ep.Targets, zone)
+	if p.dryRun {
+		return nil
+	}
+
+	var in interface{}
+	if method != http.MethodDelete {
+		rrset := &gandiRRSet{TTL: gandiDefaultTTL}
+		if ep.RecordTTL.IsConfigured() {
+			rrset.TTL = int(ep.RecordTTL)
+		}
+		for _, target := range ep.Targets {
+			if ep.RecordType == endpoint.RecordTypeCNAME {
+				target = ensureTrailingDot(target)
+			}
+			rrset.Values = append(rrset.Values, target)
+		}
+		in = rrset
+	}
+
+	if err := p.do(method, path, in, nil); err != nil {
+		return fmt.Errorf("failed to change record %s %s: %v", ep.DNSName, ep.RecordType, err)
+	}
+	return nil
+}
+
+// do sends a request to the LiveDNS API.
+func (p *GandiProvider) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, p.apiURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := gandiError{}
+		if err := json.Unmarshal(data, &apiErr); err != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return fmt.Errorf("Gandi LiveDNS %s %s failed with status %d: %s", method, path, resp.StatusCode, apiErr.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+var _ Provider = &GandiProvider{}
+var _ EndpointsAdjuster = &GandiProvider{}
+
+// gandiServer emulates the LiveDNS API, keeping the rrsets of every domain.
+type gandiServer struct {
+	rrsets   map[string][]gandiRRSet
+	requests []string
+}
+
+func newGandiServer() *gandiServer {
+	return &gandiServer{
+		rrsets: map[string][]gandiRRSet{
+			"foo.com": {
+				{Name: "@", Type: "NS", TTL: 10800, Values: []string{"ns1.gandi.net."}},
+				{Name: "www", Type: endpoint.RecordTypeA, TTL: 300, Values: []string{"1.2.3.4", "5.6.7.8"}},
+				{Name: "www", Type: endpoint.RecordTypeTXT, TTL: 300, Values: []string{"\"heritage=external-dns,external-dns/owner=default\""}},
+			},
+			"bar.com": {
+				{Name: "api", Type: endpoint.RecordTypeCNAME, TTL: 10800, Values: []string{"lb.example.com."}},
+			},
+		},
+	}
+}
+
+func (s *gandiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(gandiError{Message: "Access was denied to this resource."})
+		return
+	}
+
+	if r.URL.Path == "/domains" {
+		json.NewEncoder(w).Encode([]gandiDomain{{FQDN: "foo.com"}, {FQDN: "bar.com"}})
+		return
+	}
+
+	// /domains/{fqdn}/records[/{name}/{type}]
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/domains/"), "/")
+	domain := parts[0]
+	if len(parts) == 2 && r.Method == http.MethodGet {
+		json.NewEncoder(w).Encode(s.rrsets[domain])
+		return
+	}
+	if len(parts) != 4 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+
+	index := -1
+	for i, rrset := range s.rrsets[domain] {
+		if rrset.Name == parts[2] && rrset.Type == parts[3] {
+			index = i
+		}
+	}
+
+	rrset := gandiRRSet{}
+	if r.Method != http.MethodDelete {
+		if err := json.NewDecoder(r.Body).Decode(&rrset); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		rrset.Name, rrset.Type = parts[2], parts[3]
+	}
+
+	switch {
+	case r.Method == http.MethodPost && index < 0:
+		s.rrsets[domain] = append(s.rrsets[domain], rrset)
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && index >= 0:
+		s.rrsets[domain][index] = rrset
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodDelete && index >= 0:
+		s.rrsets[domain] = append(s.rrsets[domain][:index], s.rrsets[domain][index+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	case index >= 0:
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(gandiError{Message: "A DNS Record already exists with same value"})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(gandiError{Message: "The resource could not be found."})
+	}
+}
+
+func newGandiTestProvider(t *testing.T, s *gandiServer, domainFilter DomainFilter, dryRun bool) (*httptest.Server, *GandiProvider) {
+	server := httptest.NewServer(s)
+	p, err := NewGandiProvider("secret", domainFilter, nil, dryRun)
+	require.NoError(t, err)
+	p.apiURL = server.URL
+	return server, p
+}
+
+func TestNewGandiProviderRequiresToken(t *testing.T) {
+	_, err := NewGandiProvider("", NewDomainFilter(nil), nil, false)
+	assert.Error(t, err)
+}
+
+func TestGandiRecords(t *testing.T) {
+	server, p := newGandiTestProvider(t, newGandiServer(), NewDomainFilter(nil), false)
+	defer server.Close()
+
+	records, err := p.Records()
+	require.NoError(t, err)
+
+	validateEndpoints(t, records, []*endpoint.Endpoint{
+		{DNSName: "www.foo.com", Targets: endpoint.Targets{"1.2.3.4", "5.6.7.8"}, RecordType: endpoint.RecordTypeA, RecordTTL: 300},
+		endpoint.NewEndpointWithTTL("www.foo.com", "\"heritage=external-dns,external-dns/owner=default\"", endpoint.RecordTypeTXT, 300),
+		endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 10800),
+	})
+}
+
+func TestGandiRecordsDomainFilter(t *testing.T) {
+	server, p := newGandiTestProvider(t, newGandiServer(), NewDomainFilter([]string{"bar.com"}), false)
+	defer server.Close()
+
+	records, err := p.Records()
+	require.NoError(t, err)
+
+	validateEndpoints(t, records, []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 10800),
+	})
+}
+
+func TestGandiRecordsInvalidToken(t *testing.T) {
+	server, p := newGandiTestProvider(t, newGandiServer(), NewDomainFilter(nil), false)
+	defer server.Close()
+	p.token = "wrong"
+
+	_, err := p.Records()
+	assert.Error(t, err)
+}
+
+func TestGandiApplyChanges(t *testing.T) {
+	s := newGandiServer()
+	server, p := newGandiTestProvider(t, s, NewDomainFilter(nil), false)
+	defer server.Close()
+
+	err := p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("foo.com", "9.9.9.9", endpoint.RecordTypeA),
+			endpoint.NewEndpoint("new.bar.com", "www.foo.com", endpoint.RecordTypeCNAME),
+			endpoint.NewEndpoint("new.unknown.com", "9.9.9.9", endpoint.RecordTypeA),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			{DNSName: "www.foo.com", Targets: endpoint.Targets{"1.2.3.4", "5.6.7.8"}, RecordType: endpoint.RecordTypeA, RecordTTL: 300},
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			{DNSName: "www.foo.com", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA, RecordTTL: 600},
+		},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 10800),
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"DELETE /domains/bar.com/records/api/CNAME",
+		"PUT /domains/foo.com/records/www/A",
+		"POST /domains/foo.com/records/@/A",
+		"POST /domains/bar.com/records/new/CNAME",
+	}, s.requests)
+	assert.Equal(t, []string{"www.foo.com."}, s.rrsets["bar.com"][0].Values)
+
+	records, err := p.Records()
+	require.NoError(t, err)
+
+	validateEndpoints(t, records, []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("foo.com", "9.9.9.9", endpoint.RecordTypeA, gandiDefaultTTL),
+		endpoint.NewEndpointWithTTL("www.foo.com", "1.2.3.4", endpoint.RecordTypeA, 600),
+		endpoint.NewEndpointWithTTL("www.foo.com", "\"heritage=external-dns,external-dns/owner=default\"", endpoint.RecordTypeTXT, 300),
+		endpoint.NewEndpointWithTTL("new.bar.com", "www.foo.com", endpoint.RecordTypeCNAME, gandiDefaultTTL),
+	})
+}
+
+func TestGandiApplyChangesFailure(t *testing.T) {
+	server, p := newGandiTestProvider(t, newGandiServer(), NewDomainFilter(nil), false)
+	defer server.Close()
+
+	err := p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME)},
+	})
+	assert.Error(t, err)
+}
+
+func TestGandiApplyChangesDryRun(t *testing.T) {
+	s := newGandiServer()
+	server, p := newGandiTestProvider(t, s, NewDomainFilter(nil), true)
+	defer server.Close()
+
+	err := p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("new.foo.com", "9.9.9.9", endpoint.RecordTypeA)},
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 10800)},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, s.requests)
+}
+
+func TestGandiAdjustEndpoints(t *testing.T) {
+	p, err := NewGandiProvider("secret", NewDomainFilter(nil), nil, false)
+	require.NoError(t, err)
+
+	endpoints, err := p.AdjustEndpoints([]*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("low.foo.com", "1.2.3.4", endpoint.RecordTypeA, 60),
+		endpoint.NewEndpoint("unset.foo.com", "1.2.3.4", endpoint.RecordTypeA),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, endpoint.TTL(gandiMinTTL), endpoints[0].RecordTTL)
+	assert.False(t, endpoints[1].RecordTTL.IsConfigured())
+}
@@ -0,0 +1,400 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+const (
+	godaddyAPIURL    = "https://api.godaddy.com"
+	godaddyOTEAPIURL = "https://api.ote-godaddy.com"
+
+	// godaddyMinTTL is the lowest TTL accepted by GoDaddy, it's also used for records which don't define a TTL
+	godaddyMinTTL = 600
+
+	// godaddyMaxRetries is the number of times a request is retried after being rate limited
+	godaddyMaxRetries = 3
+	// godaddyMaxRetryAfter caps the time to wait for a rate limited request
+	godaddyMaxRetryAfter = time.Minute
+
+	// godaddyApex is the name of records at the apex of a domain
+	godaddyApex = "@"
+)
+
+// GoDaddyProvider is an implementation of Provider for GoDaddy DNS using the v1 domains API.
+//
+// GoDaddy allows only 60 requests per minute, changes are therefore batched per domain: the records of
+// a domain are fetched once and every changed record type is replaced with a single request.
+type GoDaddyProvider struct {
+	apiURL    string
+	apiKey    string
+	apiSecret string
+	client    *http.Client
+	// only consider hosted zones managing domains ending in this suffix
+	domainFilter DomainFilter
+	// filter domains by their numeric ID
+	zoneIDFilter ZoneIDFilter
+	dryRun       bool
+}
+
+type godaddyDomain struct {
+	Domain   string `json:"domain"`
+	DomainID int64  `json:"domainId"`
+	Status   string `json:"status"`
+}
+
+type godaddyRecord struct {
+	Type string `json:"type,omitempty"`
+	Name string `json:"name"`
+	Data string `json:"data"`
+	TTL  int    `json:"ttl,omitempty"`
+}
+
+type godaddyError struct {
+	Code          string `json:"code"`
+	Message       string `json:"message"`
+	RetryAfterSec int    `json:"retryAfterSec"`
+}
+
+// NewGoDaddyProvider initializes a new GoDaddy DNS based Provider.
+// If ote is true, the provider talks to GoDaddy's test environment (OTE) instead of production.
+func NewGoDaddyProvider(apiKey, apiSecret string, ote bool, domainFilter DomainFilter, zoneIDFilter ZoneIDFilter, rateLimiter *RateLimiter, dryRun bool) (*GoDaddyProvider, error) {
+	if apiKey == "" || apiSecret == "" {
+		return nil, fmt.Errorf("no GoDaddy API key and secret specified")
+	}
+
+	apiURL := godaddyAPIURL
+	if ote {
+		apiURL = godaddyOTEAPIURL
+	}
+
+	p := &GoDaddyProvider{
+		apiURL:       apiURL,
+		apiKey:       apiKey,
+		apiSecret:    apiSecret,
+		client:       rateLimiter.Client(&http.Client{Timeout: 30 * time.Second}),
+		domainFilter: domainFilter,
+		zoneIDFilter: zoneIDFilter,
+		dryRun:       dryRun,
+	}
+	return p, nil
+}
+
+// zones returns the active domains which pass the filters, keyed by their name.
+func (p *GoDaddyProvider) zones() (map[string]godaddyDomain, error) {
+	domains := []godaddyDomain{}
+	if err := p.do(http.MethodGet, "/v1/domains?statuses=ACTIVE", nil, &domains); err != nil {
+		return nil, err
+	}
+
+	zones := map[string]godaddyDomain{}
+	for _, domain := range domains {
+		if !p.domainFilter.Match(domain.Domain) || !p.zoneIDFilter.Match(strconv.FormatInt(domain.DomainID, 10)) {
+			continue
+		}
+		zones[domain.Domain] = domain
+	}
+	return zones, nil
+}
+
+// zoneRecords returns all records of the domain.
+func (p *GoDaddyProvider) zoneRecords(domain string) ([]godaddyRecord, error) {
+	records := []godaddyRecord{}
+	if err := p.do(http.MethodGet, "/v1/domains/"+url.PathEscape(domain)+"/records", nil, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Records returns the list of records in all relevant zones.
+func (p *GoDaddyProvider) Records() ([]*endpoint.Endpoint, error) {
+	zones, err := p.zones()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := []*endpoint.Endpoint{}
+	for _, zone := range zones {
+		records, err := p.zoneRecords(zone.Domain)
+		if err != nil {
+			return nil, err
+		}
+
+		// GoDaddy returns one record per data, records of the same name and type are merged into one endpoint
+		byNameAndType := map[string]*endpoint.Endpoint{}
+		for _, record := range records {
+			if !supportedRecordType(record.Type) {
+				continue
+			}
+
+			name := zone.Domain
+			if record.Name != godaddyApex {
+				name = record.Name + "." + zone.Domain
+			}
+
+			key := name + "/" + record.Type
+			if ep, ok := byNameAndType[key]; ok {
+				ep.Targets = append(ep.Targets, record.Data)
+				continue
+			}
+
+			ep := endpoint.NewEndpointWithTTL(name, record.Data, record.Type, endpoint.TTL(record.TTL))
+			byNameAndType[key] = ep
+			endpoints = append(endpoints, ep)
+		}
+	}
+
+	return endpoints, nil
+}
+
+// AdjustEndpoints raises TTLs below GoDaddy's minimum, which would otherwise never match the current records.
+func (p *GoDaddyProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	for _, ep := range endpoints {
+		if ep.RecordTTL.IsConfigured() && ep.RecordTTL < godaddyMinTTL {
+			ep.RecordTTL = godaddyMinTTL
+		}
+	}
+	return endpoints, nil
+}
+
+// godaddyChanges collects the records to remove and to add in a domain.
+type godaddyChanges struct {
+	remove []godaddyRecord
+	add    []godaddyRecord
+}
+
+// ApplyChanges applies a given set of changes in all relevant zones.
+func (p *GoDaddyProvider) ApplyChanges(changes *plan.Changes) error {
+	if len(changes.Create) == 0 && len(changes.UpdateNew) == 0 && len(changes.Delete) == 0 {
+		log.Info("All records are already up to date")
+		return nil
+	}
+
+	zones, err := p.zones()
+	if err != nil {
+		return err
+	}
+	zoneNameMapper := zoneIDName{}
+	for name := range zones {
+		zoneNameMapper.Add(name, name)
+	}
+
+	changesByZone := map[string]*godaddyChanges{}
+	collect := func(endpoints []*endpoint.Endpoint, remove bool) {
+		for _, ep := range endpoints {
+			zone, _ := zoneNameMapper.FindZone(ep.DNSName)
+			if zone == "" {
+				log.Debugf("Skipping record %s because no hosted zone matching record DNS Name was detected", ep.DNSName)
+				continue
+			}
+			if changesByZone[zone] == nil {
+				changesByZone[zone] = &godaddyChanges{}
+			}
+			records := newGoDaddyRecords(zone, ep)
+			if remove {
+				changesByZone[zone].remove = append(changesByZone[zone].remove, records...)
+			} else {
+				changesByZone[zone].add = append(changesByZone[zone].add, records...)
+			}
+		}
+	}
+	collect(changes.Delete, true)
+	collect(changes.UpdateOld, true)
+	collect(changes.UpdateNew, false)
+	collect(changes.Create, false)
+
+	for zone, zoneChanges := range changesByZone {
+		if err := p.applyZoneChanges(zone, zoneChanges); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyZoneChanges applies the changes of a domain by replacing the records of every changed type at once.
+func (p *GoDaddyProvider) applyZoneChanges(zone string, changes *godaddyChanges) error {
+	records, err := p.zoneRecords(zone)
+	if err != nil {
+		return err
+	}
+
+	byType := map[string][]godaddyRecord{}
+	for _, record := range records {
+		byType[record.Type] = append(byType[record.Type], record)
+	}
+	changedTypes := map[string][]string{}
+
+	for _, remove := range changes.remove {
+		current := byType[remove.Type]
+		for i, record := range current {
+			if record.Name == remove.Name && record.Data == remove.Data {
+				byType[remove.Type] = append(current[:i:i], current[i+1:]...)
+				changedTypes[remove.Type] = append(changedTypes[remove.Type], remove.Name)
+				log.Infof("Deleting record %s %s %s in domain %s", remove.Name, remove.Type, remove.Data, zone)
+				break
+			}
+		}
+	}
+
+	for _, add := range changes.add {
+		byType[add.Type] = append(byType[add.Type], add)
+		changedTypes[add.Type] = append(changedTypes[add.Type], add.Name)
+		log.Infof("Creating record %s %s %s in domain %s", add.Name, add.Type, add.Data, zone)
+	}
+
+	types := make([]string, 0, len(changedTypes))
+	for recordType := range changedTypes {
+		types = append(types, recordType)
+	}
+	sort.Strings(types)
+
+	for _, recordType := range types {
+		if p.dryRun {
+			continue
+		}
+		if err := p.replaceRecords(zone, recordType, byType[recordType], changedTypes[recordType]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceRecords replaces all records of the given type in the domain. Since GoDaddy refuses to replace them with
+// an empty list, the names which were changed are deleted one by one if no record of the type is left.
+func (p *GoDaddyProvider) replaceRecords(zone, recordType string, records []godaddyRecord, changedNames []string) error {
+	path := "/v1/domains/" + url.PathEscape(zone) + "/records/" + recordType
+
+	if len(records) > 0 {
+		body := make([]godaddyRecord, 0, len(records))
+		for _, record := range records {
+			record.Type = ""
+			body = append(body, record)
+		}
+		if err := p.do(http.MethodPut, path, body, nil); err != nil {
+			return fmt.Errorf("failed to replace %s records of domain %s: %v", recordType, zone, err)
+		}
+		return nil
+	}
+
+	deleted := map[string]bool{}
+	for _, name := range changedNames {
+		if deleted[name] {
+			continue
+		}
+		deleted[name] = true
+		if err := p.do(http.MethodDelete, path+"/"+url.PathEscape(name), nil, nil); err != nil {
+			return fmt.Errorf("failed to delete %s records %s of domain %s: %v", recordType, name, zone, err)
+		}
+	}
+	return nil
+}
+
+// newGoDaddyRecords returns one record for every target of the endpoint, named relative to the zone.
+func newGoDaddyRecords(zone string, ep *endpoint.Endpoint) []godaddyRecord {
+	name := strings.TrimSuffix(strings.TrimSuffix(ep.DNSName, zone), ".")
+	if name == "" {
+		name = godaddyApex
+	}
+
+	ttl := godaddyMinTTL
+	if ep.RecordTTL.IsConfigured() && int(ep.RecordTTL) > godaddyMinTTL {
+		ttl = int(ep.RecordTTL)
+	}
+
+	records := make([]godaddyRecord, 0, len(ep.Targets))
+	for _, target := range ep.Targets {
+		records = append(records, godaddyRecord{Type: ep.RecordType, Name: name, Data: target, TTL: ttl})
+	}
+	return records
+}
+
+// do sends a request to the GoDaddy API, retrying it when being rate limited.
+func (p *GoDaddyProvider) do(method, path string, in, out interface{}) error {
+	var data []byte
+	if in != nil {
+		var err error
+		if data, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		var body io.Reader
+		if data != nil {
+			body = bytes.NewReader(data)
+		}
+		req, err := http.NewRequest(method, p.apiURL+path, body)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("sso-key %s:%s", p.apiKey, p.apiSecret))
+		req.Header.Set("Accept", "application/json")
+		if data != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return err
+		}
+		respData, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+			if out == nil || len(respData) == 0 {
+				return nil
+			}
+			return json.Unmarshal(respData, out)
+		}
+
+		apiErr := godaddyError{}
+		if err := json.Unmarshal(respData, &apiErr); err != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(respData))
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < godaddyMaxRetries {
+			retryAfter := time.Duration(apiErr.RetryAfterSec) * time.Second
+			if retryAfter > godaddyMaxRetryAfter {
+				retryAfter = godaddyMaxRetryAfter
+			}
+			log.Warnf("GoDaddy API rate limit exceeded, retrying %s %s in %s", method, path, retryAfter)
+			time.Sleep(retryAfter)
+			continue
+		}
+
+		return fmt.Errorf("GoDaddy API %s %s failed with status %d: %s", method, path, resp.StatusCode, apiErr.Message)
+	}
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+var _ Provider = &GoDaddyProvider{}
+var _ EndpointsAdjuster = &GoDaddyProvider{}
+
+// godaddyServer emulates the GoDaddy v1 domains API and records the modifying requests it receives.
+type godaddyServer struct {
+	domains     []godaddyDomain
+	records     map[string][]godaddyRecord
+	requests    []string
+	rateLimited int
+}
+
+func newGoDaddyServer() *godaddyServer {
+	return &godaddyServer{
+		domains: []godaddyDomain{
+			{Domain: "foo.com", DomainID: 1, Status: "ACTIVE"},
+			{Domain: "bar.com", DomainID: 2, Status: "ACTIVE"},
+		},
+		records: map[string][]godaddyRecord{
+			"foo.com": {
+				{Type: "NS", Name: "@", Data: "ns01.domaincontrol.com", TTL: 3600},
+				{Type: endpoint.RecordTypeA, Name: "@", Data: "8.8.8.8", TTL: 600},
+				{Type: endpoint.RecordTypeA, Name: "www", Data: "1.2.3.4", TTL: 600},
+				{Type: endpoint.RecordTypeA, Name: "www", Data: "5.6.7.8", TTL: 600},
+				{Type: endpoint.RecordTypeTXT, Name: "www", Data: "heritage=external-dns,external-dns/owner=default", TTL: 600},
+			},
+			"bar.com": {
+				{Type: endpoint.RecordTypeCNAME, Name: "api", Data: "lb.example.com", TTL: 3600},
+			},
+		},
+	}
+}
+
+func (s *godaddyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "sso-key key:secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(godaddyError{Code: "UNABLE_TO_AUTHENTICATE", Message: "Unauthorized"})
+		return
+	}
+	if s.rateLimited > 0 {
+		s.rateLimited--
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(godaddyError{Code: "TOO_MANY_REQUESTS", Message: "Too many requests", RetryAfterSec: 0})
+		return
+	}
+
+	if r.URL.Path == "/v1/domains" {
+		json.NewEncoder(w).Encode(s.domains)
+		return
+	}
+
+	// /v1/domains/{domain}/records[/{type}[/{name}]]
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/domains/"), "/")
+	domain := parts[0]
+	if r.Method != http.MethodGet {
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	}
+
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 2:
+		json.NewEncoder(w).Encode(s.records[domain])
+	case r.Method == http.MethodPut && len(parts) == 3:
+		body := []godaddyRecord{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		records := []godaddyRecord{}
+		for _, record := range s.records[domain] {
+			if record.Type != parts[2] {
+				records = append(records, record)
+			}
+		}
+		for _, record := range body {
+			record.Type = parts[2]
+			records = append(records, record)
+		}
+		s.records[domain] = records
+	case r.Method == http.MethodDelete && len(parts) == 4:
+		records := []godaddyRecord{}
+		for _, record := range s.records[domain] {
+			if record.Type != parts[2] || record.Name != parts[3] {
+				records = append(records, record)
+			}
+		}
+		s.records[domain] = records
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newGoDaddyTestProvider(t *testing.T, s *godaddyServer, domainFilter DomainFilter, zoneIDFilter ZoneIDFilter, dryRun bool) (*httptest.Server, *GoDaddyProvider) {
+	server := httptest.NewServer(s)
+	p, err := NewGoDaddyProvider("key", "secret", false, domainFilter, zoneIDFilter, nil, dryRun)
+	require.NoError(t, err)
+	p.apiURL = server.URL
+	return server, p
+}
+
+func TestNewGoDaddyProvider(t *testing.T) {
+	_, err := NewGoDaddyProvider("", "", false, NewDomainFilter(nil), NewZoneIDFilter(nil), nil, false)
+	assert.Error(t, err)
+
+	p, err := NewGoDaddyProvider("key", "secret", true, NewDomainFilter(nil), NewZoneIDFilter(nil), nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, godaddyOTEAPIURL, p.apiURL)
+}
+
+func TestGoDaddyRecords(t *testing.T) {
+	for _, tc := range []struct {
+		title        string
+		domainFilter DomainFilter
+		zoneIDFilter ZoneIDFilter
+		expected     []*endpoint.Endpoint
+	}{
+		{
+			"all domains",
+			NewDomainFilter(nil),
+			NewZoneIDFilter(nil),
+			[]*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("foo.com", "8.8.8.8", endpoint.RecordTypeA, 600),
+				{DNSName: "www.foo.com", Targets: endpoint.Targets{"1.2.3.4", "5.6.7.8"}, RecordType: endpoint.RecordTypeA, RecordTTL: 600},
+				endpoint.NewEndpointWithTTL("www.foo.com", "heritage=external-dns,external-dns/owner=default", endpoint.RecordTypeTXT, 600),
+				endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 3600),
+			},
+		},
+		{
+			"zone id filter",
+			NewDomainFilter(nil),
+			NewZoneIDFilter([]string{"2"}),
+			[]*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 3600),
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			server, p := newGoDaddyTestProvider(t, newGoDaddyServer(), tc.domainFilter, tc.zoneIDFilter, false)
+			defer server.Close()
+
+			records, err := p.Records()
+			require.NoError(t, err)
+
+			validateEndpoints(t, records, tc.expected)
+		})
+	}
+}
+
+func TestGoDaddyRecordsInvalidCredentials(t *testing.T) {
+	server, p := newGoDaddyTestProvider(t, newGoDaddyServer(), NewDomainFilter(nil), NewZoneIDFilter(nil), false)
+	defer server.Close()
+	p.apiSecret = "wrong"
+
+	_, err := p.Records()
+	assert.Error(t, err)
+}
+
+func TestGoDaddyRecordsRateLimited(t *testing.T) {
+	s := newGoDaddyServer()
+	server, p := newGoDaddyTestProvider(t, s, NewDomainFilter(nil), NewZoneIDFilter(nil), false)
+	defer server.Close()
+
+	s.rateLimited = godaddyMaxRetries
+	_, err := p.Records()
+	assert.NoError(t, err)
+
+	s.rateLimited = godaddyMaxRetries + 1
+	_, err = p.Records()
+	assert.Error(t, err)
+}
+
+func TestGoDaddyApplyChanges(t *testing.T) {
+	s := newGoDaddyServer()
+	server, p := newGoDaddyTestProvider(t, s, NewDomainFilter(nil), NewZoneIDFilter(nil), false)
+	defer server.Close()
+
+	err := p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("new.foo.com", "9.9.9.9", endpoint.RecordTypeA),
+			endpoint.NewEndpoint("new.foo.com", "heritage=external-dns,external-dns/owner=default", endpoint.RecordTypeTXT),
+			endpoint.NewEndpoint("new.unknown.com", "9.9.9.9", endpoint.RecordTypeA),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			{DNSName: "www.foo.com", Targets: endpoint.Targets{"1.2.3.4", "5.6.7.8"}, RecordType: endpoint.RecordTypeA, RecordTTL: 600},
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			{DNSName: "www.foo.com", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA, RecordTTL: 3600},
+		},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 3600),
+		},
+	})
+	require.NoError(t, err)
+
+	// one request per changed type and domain
+	sort.Strings(s.requests)
+	assert.Equal(t, []string{
+		"DELETE /v1/domains/bar.com/records/CNAME/api",
+		"PUT /v1/domains/foo.com/records/A",
+		"PUT /v1/domains/foo.com/records/TXT",
+	}, s.requests)
+
+	records, err := p.Records()
+	require.NoError(t, err)
+
+	validateEndpoints(t, records, []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("foo.com", "8.8.8.8", endpoint.RecordTypeA, 600),
+		endpoint.NewEndpointWithTTL("www.foo.com", "1.2.3.4", endpoint.RecordTypeA, 3600),
+		endpoint.NewEndpointWithTTL("www.foo.com", "heritage=external-dns,external-dns/owner=default", endpoint.RecordTypeTXT, 600),
+		endpoint.NewEndpointWithTTL("new.foo.com", "9.9.9.9", endpoint.RecordTypeA, godaddyMinTTL),
+		endpoint.NewEndpointWithTTL("new.foo.com", "heritage=external-dns,external-dns/owner=default", endpoint.RecordTypeTXT, godaddyMinTTL),
+	})
+}
+
+func TestGoDaddyApplyChangesDryRun(t *testing.T) {
+	s := newGoDaddyServer()
+	server, p := newGoDaddyTestProvider(t, s, NewDomainFilter(nil), NewZoneIDFilter(nil), true)
+	defer server.Close()
+
+	err := p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("new.foo.com", "9.9.9.9", endpoint.RecordTypeA)},
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 3600)},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, s.requests)
+}
+
+func TestGoDaddyAdjustEndpoints(t *testing.T) {
+	p, err := NewGoDaddyProvider("key", "secret", false, NewDomainFilter(nil), NewZoneIDFilter(nil), nil, false)
+	require.NoError(t, err)
+
+	endpoints, err := p.AdjustEndpoints([]*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("low.foo.com", "1.2.3.4", endpoint.RecordTypeA, 60),
+		endpoint.NewEndpointWithTTL("high.foo.com", "1.2.3.4", endpoint.RecordTypeA, 3600),
+		endpoint.NewEndpoint("unset.foo.com", "1.2.3.4", endpoint.RecordTypeA),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, endpoint.TTL(godaddyMinTTL), endpoints[0].RecordTTL)
+	assert.Equal(t, endpoint.TTL(3600), endpoints[1].RecordTTL)
+	assert.False(t, endpoints[2].RecordTTL.IsConfigured())
+}
@@ -18,6 +18,7 @@ package provider
 
 import (
 	"fmt"
+	"net/http"
 	"sort"
 	"strings"
 
@@ -116,7 +117,7 @@ type GoogleProvider struct {
 }
 
 // NewGoogleProvider initializes a new Google CloudDNS based Provider.
-func NewGoogleProvider(project string, domainFilter DomainFilter, zoneIDFilter ZoneIDFilter, dryRun bool) (*GoogleProvider, error) {
+func NewGoogleProvider(project string, domainFilter DomainFilter, zoneIDFilter ZoneIDFilter, rateLimiter *RateLimiter, dryRun bool) (*GoogleProvider, error) {
 	gcloud, err := google.DefaultClient(context.TODO(), dns.NdevClouddnsReadwriteScope)
 	if err != nil {
 		return nil, err
@@ -128,6 +129,7 @@ func NewGoogleProvider(project string, domainFilter DomainFilter, zoneIDFilter Z
 			return parts[len(parts)-1]
 		},
 	})
+	gcloud = rateLimiter.Client(gcloud)
 
 	dnsClient, err := dns.New(gcloud)
 	if err != nil {
@@ -161,7 +163,7 @@ func (p *GoogleProvider) Zones() (map[string]*dns.ManagedZone, error) {
 
 	f := func(resp *dns.ManagedZonesListResponse) error {
 		for _, zone := range resp.ManagedZones {
-			if p.domainFilter.Match(zone.DnsName) || p.zoneIDFilter.Match(fmt.Sprintf("%v", zone.Id)) {
+			if p.domainFilter.Match(zone.DnsName) && p.zoneIDFilter.Match(fmt.Sprintf("%v", zone.Id)) {
 				zones[zone.Name] = zone
 				log.Debugf("Matched %s (zone: %s)", zone.DnsName, zone.Name)
 			} else {
@@ -271,6 +273,24 @@ func (p *GoogleProvider) ApplyChanges(changes *plan.Changes) error {
 	return p.submitChange(change)
 }
 
+// IsTransientError returns true for errors caused by rate limiting or temporary unavailability of the Cloud DNS API
+func (p *GoogleProvider) IsTransientError(err error) bool {
+	apiErr, ok := err.(*googleapi.Error)
+	if !ok {
+		return false
+	}
+	if apiErr.Code == http.StatusTooManyRequests || apiErr.Code >= http.StatusInternalServerError {
+		return true
+	}
+	// quota errors are reported as forbidden
+	for _, item := range apiErr.Errors {
+		if item.Reason == "rateLimitExceeded" || item.Reason == "userRateLimitExceeded" {
+			return true
+		}
+	}
+	return false
+}
+
 // newFilteredRecords returns a collection of RecordSets based on the given endpoints and domainFilter.
 func (p *GoogleProvider) newFilteredRecords(endpoints []*endpoint.Endpoint) []*dns.ResourceRecordSet {
 	records := []*dns.ResourceRecordSet{}
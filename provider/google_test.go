@@ -647,3 +647,13 @@ func clearGoogleRecords(t *testing.T, provider *GoogleProvider, zone string) {
 		require.NoError(t, err)
 	}
 }
+
+func TestGoogleIsTransientError(t *testing.T) {
+	provider := &GoogleProvider{}
+
+	assert.True(t, provider.IsTransientError(&googleapi.Error{Code: http.StatusTooManyRequests}))
+	assert.True(t, provider.IsTransientError(&googleapi.Error{Code: http.StatusServiceUnavailable}))
+	assert.True(t, provider.IsTransientError(&googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}}}))
+	assert.False(t, provider.IsTransientError(&googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "forbidden"}}}))
+	assert.False(t, provider.IsTransientError(fmt.Errorf("some error")))
+}
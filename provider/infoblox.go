@@ -157,7 +157,8 @@ func (p *InfobloxProvider) Records() (endpoints []*endpoint.Endpoint, err error)
 		for _, res := range resT {
 			// The Infoblox API strips enclosing double quotes from TXT records lacking whitespace.
 			// Unhandled, the missing double quotes would break the extractOwnerID method of the registry package.
-			if _, err := strconv.Unquote(res.Text); err != nil {
+			// Values of multiple strings, e.g. "abc" "def", keep their quotes.
+			if !strings.HasPrefix(res.Text, "\"") {
 				res.Text = strconv.Quote(res.Text)
 			}
 			endpoints = append(endpoints, endpoint.NewEndpoint(res.Name, res.Text, endpoint.RecordTypeTXT))
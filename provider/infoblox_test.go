@@ -348,6 +348,7 @@ func TestInfobloxRecords(t *testing.T) {
 			createMockInfobloxObject("nginx.example.com", endpoint.RecordTypeTXT, "heritage=external-dns,external-dns/owner=default"),
 			createMockInfobloxObject("whitespace.example.com", endpoint.RecordTypeA, "123.123.123.124"),
 			createMockInfobloxObject("whitespace.example.com", endpoint.RecordTypeTXT, "heritage=external-dns,external-dns/owner=white space"),
+			createMockInfobloxObject("split.example.com", endpoint.RecordTypeTXT, "\"heritage=external-dns\" \",external-dns/owner=default\""),
 			createMockInfobloxObject("hack.example.com", endpoint.RecordTypeCNAME, "cerberus.infoblox.com"),
 		},
 	}
@@ -365,6 +366,7 @@ func TestInfobloxRecords(t *testing.T) {
 		endpoint.NewEndpoint("nginx.example.com", "\"heritage=external-dns,external-dns/owner=default\"", endpoint.RecordTypeTXT),
 		endpoint.NewEndpoint("whitespace.example.com", "123.123.123.124", endpoint.RecordTypeA),
 		endpoint.NewEndpoint("whitespace.example.com", "\"heritage=external-dns,external-dns/owner=white space\"", endpoint.RecordTypeTXT),
+		endpoint.NewEndpoint("split.example.com", "\"heritage=external-dns\" \",external-dns/owner=default\"", endpoint.RecordTypeTXT),
 		endpoint.NewEndpoint("hack.example.com", "cerberus.infoblox.com", endpoint.RecordTypeCNAME),
 	}
 	validateEndpoints(t, actual, expected)
@@ -18,6 +18,7 @@ package provider
 
 import (
 	"errors"
+	"sort"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -37,6 +38,10 @@ var (
 	ErrRecordNotFound = errors.New("record not found")
 	// ErrDuplicateRecordFound when record is repeated in create/update/delete
 	ErrDuplicateRecordFound = errors.New("invalid batch request")
+	// ErrThrottled when throttling is simulated via InMemoryWithThrottling
+	ErrThrottled = errors.New("rate exceeded")
+	// ErrPartialApply when only a part of the changes was applied, see InMemoryWithPartialApply
+	ErrPartialApply = errors.New("changes only partially applied")
 )
 
 // InMemoryProvider - dns provider only used for testing purposes
@@ -47,6 +52,10 @@ type InMemoryProvider struct {
 	filter         *filter
 	OnApplyChanges func(changes *plan.Changes)
 	OnRecords      func()
+	// number of upcoming calls failing with ErrThrottled
+	throttled int
+	// number of record changes applied by the next ApplyChanges call before failing, -1 applies all changes
+	partialApplyLimit int
 }
 
 // InMemoryOption allows to extend in-memory provider
@@ -79,6 +88,22 @@ func InMemoryWithDomain(domainFilter DomainFilter) InMemoryOption {
 	}
 }
 
+// InMemoryWithThrottling makes the next n calls to Records and ApplyChanges fail with ErrThrottled
+func InMemoryWithThrottling(n int) InMemoryOption {
+	return func(p *InMemoryProvider) {
+		p.throttled = n
+	}
+}
+
+// InMemoryWithPartialApply makes the next call to ApplyChanges apply only the first n record changes
+// and fail with ErrPartialApply afterwards, simulating a provider that stops in the middle of a batch.
+// Changes are applied zone by zone, in the order creates, updates and deletes.
+func InMemoryWithPartialApply(n int) InMemoryOption {
+	return func(p *InMemoryProvider) {
+		p.partialApplyLimit = n
+	}
+}
+
 // InMemoryInitZones pre-seeds the InMemoryProvider with given zones
 func InMemoryInitZones(zones []string) InMemoryOption {
 	return func(p *InMemoryProvider) {
@@ -93,11 +118,12 @@ func InMemoryInitZones(zones []string) InMemoryOption {
 // NewInMemoryProvider returns InMemoryProvider DNS provider interface implementation
 func NewInMemoryProvider(opts ...InMemoryOption) *InMemoryProvider {
 	im := &InMemoryProvider{
-		filter:         &filter{},
-		OnApplyChanges: func(changes *plan.Changes) {},
-		OnRecords:      func() {},
-		domain:         NewDomainFilter([]string{""}),
-		client:         newInMemoryClient(),
+		filter:            &filter{},
+		OnApplyChanges:    func(changes *plan.Changes) {},
+		OnRecords:         func() {},
+		domain:            NewDomainFilter([]string{""}),
+		client:            newInMemoryClient(),
+		partialApplyLimit: -1,
 	}
 
 	for _, opt := range opts {
@@ -121,6 +147,11 @@ func (im *InMemoryProvider) Zones() map[string]string {
 func (im *InMemoryProvider) Records() ([]*endpoint.Endpoint, error) {
 	defer im.OnRecords()
 
+	if im.throttled > 0 {
+		im.throttled--
+		return nil, ErrThrottled
+	}
+
 	endpoints := make([]*endpoint.Endpoint, 0)
 
 	for zoneID := range im.Zones() {
@@ -130,7 +161,7 @@ func (im *InMemoryProvider) Records() ([]*endpoint.Endpoint, error) {
 		}
 
 		for _, record := range records {
-			endpoints = append(endpoints, endpoint.NewEndpoint(record.Name, record.Target, record.Type))
+			endpoints = append(endpoints, record.Endpoint())
 		}
 	}
 
@@ -142,9 +173,15 @@ func (im *InMemoryProvider) Records() ([]*endpoint.Endpoint, error) {
 // create record - record should not exist
 // update/delete record - record should exist
 // create/update/delete lists should not have overlapping records
+// records are identified by name, type and set identifier
 func (im *InMemoryProvider) ApplyChanges(changes *plan.Changes) error {
 	defer im.OnApplyChanges(changes)
 
+	if im.throttled > 0 {
+		im.throttled--
+		return ErrThrottled
+	}
+
 	perZoneChanges := map[string]*plan.Changes{}
 
 	zones := im.Zones()
@@ -181,34 +218,83 @@ func (im *InMemoryProvider) ApplyChanges(changes *plan.Changes) error {
 		perZoneChanges[zoneID].Delete = append(perZoneChanges[zoneID].Delete, ep)
 	}
 
+	// apply zones in a stable order so that partial applies are reproducible
+	zoneIDs := make([]string, 0, len(perZoneChanges))
 	for zoneID := range perZoneChanges {
+		zoneIDs = append(zoneIDs, zoneID)
+	}
+	sort.Strings(zoneIDs)
+
+	limit := im.partialApplyLimit
+	im.partialApplyLimit = -1
+
+	for _, zoneID := range zoneIDs {
 		change := &inMemoryChange{
 			Create:    convertToInMemoryRecord(perZoneChanges[zoneID].Create),
 			UpdateNew: convertToInMemoryRecord(perZoneChanges[zoneID].UpdateNew),
 			UpdateOld: convertToInMemoryRecord(perZoneChanges[zoneID].UpdateOld),
 			Delete:    convertToInMemoryRecord(perZoneChanges[zoneID].Delete),
 		}
+		truncated := false
+		if limit >= 0 {
+			total := change.Len()
+			change.Truncate(limit)
+			limit -= change.Len()
+			truncated = change.Len() < total
+		}
 		err := im.client.ApplyChanges(zoneID, change)
 		if err != nil {
 			return err
 		}
+		if truncated {
+			return ErrPartialApply
+		}
 	}
 
 	return nil
 }
 
+// SupportsGeoLocations returns true, the records keep their geo location
+func (im *InMemoryProvider) SupportsGeoLocations() bool {
+	return true
+}
+
+// IsTransientError returns true for simulated throttling errors
+func (im *InMemoryProvider) IsTransientError(err error) bool {
+	return err == ErrThrottled
+}
+
 func convertToInMemoryRecord(endpoints []*endpoint.Endpoint) []*inMemoryRecord {
 	records := []*inMemoryRecord{}
 	for _, ep := range endpoints {
 		records = append(records, &inMemoryRecord{
-			Type:   ep.RecordType,
-			Name:   ep.DNSName,
-			Target: ep.Targets[0],
+			Type:          ep.RecordType,
+			Name:          ep.DNSName,
+			Targets:       copyTargets(ep.Targets),
+			TTL:           ep.RecordTTL,
+			SetIdentifier: ep.SetIdentifier,
+			GeoLocation:   copyGeoLocation(ep.GeoLocation),
 		})
 	}
 	return records
 }
 
+// copyTargets copies targets so that stored records don't share memory with the endpoints of callers
+func copyTargets(targets endpoint.Targets) endpoint.Targets {
+	if targets == nil {
+		return nil
+	}
+	return append(endpoint.Targets{}, targets...)
+}
+
+func copyGeoLocation(geo *endpoint.GeoLocation) *endpoint.GeoLocation {
+	if geo == nil {
+		return nil
+	}
+	c := *geo
+	return &c
+}
+
 type filter struct {
 	domain string
 }
@@ -240,11 +326,35 @@ func (f *filter) EndpointZoneID(endpoint *endpoint.Endpoint, zones map[string]st
 // inMemoryRecord - record stored in memory
 // Type - type of record
 // Name - DNS name assigned to the record
-// Target - target of the record
+// Targets - targets of the record
+// TTL - TTL of the record, zero if not configured
+// SetIdentifier - distinguishes records of the same name and type, e.g. for geo routing
+// GeoLocation - location the record answers queries for, nil if not restricted
 type inMemoryRecord struct {
-	Type   string
-	Name   string
-	Target string
+	Type          string
+	Name          string
+	Targets       endpoint.Targets
+	TTL           endpoint.TTL
+	SetIdentifier string
+	GeoLocation   *endpoint.GeoLocation
+}
+
+// Endpoint converts the record into an endpoint
+func (r *inMemoryRecord) Endpoint() *endpoint.Endpoint {
+	return &endpoint.Endpoint{
+		DNSName:       r.Name,
+		Targets:       copyTargets(r.Targets),
+		RecordType:    r.Type,
+		RecordTTL:     r.TTL,
+		Labels:        endpoint.NewLabels(),
+		SetIdentifier: r.SetIdentifier,
+		GeoLocation:   copyGeoLocation(r.GeoLocation),
+	}
+}
+
+// Same returns true if the records have the same data
+func (r *inMemoryRecord) Same(o *inMemoryRecord) bool {
+	return r.Targets.Same(o.Targets) && r.TTL == o.TTL && r.GeoLocation.Same(o.GeoLocation)
 }
 
 type zone map[string][]*inMemoryRecord
@@ -256,6 +366,29 @@ type inMemoryChange struct {
 	Delete    []*inMemoryRecord
 }
 
+// Len returns the number of record changes, an update counts as a single change
+func (c *inMemoryChange) Len() int {
+	return len(c.Create) + len(c.UpdateNew) + len(c.Delete)
+}
+
+// Truncate drops all but the first n record changes
+func (c *inMemoryChange) Truncate(n int) {
+	keep := func(records []*inMemoryRecord) []*inMemoryRecord {
+		if len(records) > n {
+			records = records[:n]
+		}
+		n -= len(records)
+		return records
+	}
+	c.Create = keep(c.Create)
+	c.UpdateNew = keep(c.UpdateNew)
+	// old and new state of an update are at the same index
+	if len(c.UpdateOld) > len(c.UpdateNew) {
+		c.UpdateOld = c.UpdateOld[:len(c.UpdateNew)]
+	}
+	c.Delete = keep(c.Delete)
+}
+
 type inMemoryClient struct {
 	zones map[string]zone
 }
@@ -304,17 +437,16 @@ func (c *inMemoryClient) ApplyChanges(zoneID string, changes *inMemoryChange) er
 		c.zones[zoneID][newEndpoint.Name] = append(c.zones[zoneID][newEndpoint.Name], newEndpoint)
 	}
 	for _, updateEndpoint := range changes.UpdateNew {
-		for _, rec := range c.zones[zoneID][updateEndpoint.Name] {
-			if rec.Type == updateEndpoint.Type {
-				rec.Target = updateEndpoint.Target
-				break
-			}
+		if rec := c.find(updateEndpoint.Type, updateEndpoint.SetIdentifier, c.zones[zoneID][updateEndpoint.Name]); rec != nil {
+			rec.Targets = updateEndpoint.Targets
+			rec.TTL = updateEndpoint.TTL
+			rec.GeoLocation = updateEndpoint.GeoLocation
 		}
 	}
 	for _, deleteEndpoint := range changes.Delete {
 		newSet := make([]*inMemoryRecord, 0)
 		for _, rec := range c.zones[zoneID][deleteEndpoint.Name] {
-			if rec.Type != deleteEndpoint.Type {
+			if rec.Type != deleteEndpoint.Type || rec.SetIdentifier != deleteEndpoint.SetIdentifier {
 				newSet = append(newSet, rec)
 			}
 		}
@@ -323,15 +455,19 @@ func (c *inMemoryClient) ApplyChanges(zoneID string, changes *inMemoryChange) er
 	return nil
 }
 
-func (c *inMemoryClient) updateMesh(mesh map[string]map[string]bool, record *inMemoryRecord) error {
-	if _, exists := mesh[record.Name]; exists {
-		if mesh[record.Name][record.Type] {
-			return ErrDuplicateRecordFound
-		}
-		mesh[record.Name][record.Type] = true
-		return nil
+// inMemoryRecordKey identifies a record within a zone
+type inMemoryRecordKey struct {
+	Name          string
+	Type          string
+	SetIdentifier string
+}
+
+func (c *inMemoryClient) updateMesh(mesh map[inMemoryRecordKey]bool, record *inMemoryRecord) error {
+	key := inMemoryRecordKey{Name: record.Name, Type: record.Type, SetIdentifier: record.SetIdentifier}
+	if mesh[key] {
+		return ErrDuplicateRecordFound
 	}
-	mesh[record.Name] = map[string]bool{record.Type: true}
+	mesh[key] = true
 	return nil
 }
 
@@ -341,9 +477,9 @@ func (c *inMemoryClient) validateChangeBatch(zone string, changes *inMemoryChang
 	if !ok {
 		return ErrZoneNotFound
 	}
-	mesh := map[string]map[string]bool{}
+	mesh := map[inMemoryRecordKey]bool{}
 	for _, newEndpoint := range changes.Create {
-		if c.findByType(newEndpoint.Type, curZone[newEndpoint.Name]) != nil {
+		if c.find(newEndpoint.Type, newEndpoint.SetIdentifier, curZone[newEndpoint.Name]) != nil {
 			return ErrRecordAlreadyExists
 		}
 		if err := c.updateMesh(mesh, newEndpoint); err != nil {
@@ -351,7 +487,7 @@ func (c *inMemoryClient) validateChangeBatch(zone string, changes *inMemoryChang
 		}
 	}
 	for _, updateEndpoint := range changes.UpdateNew {
-		if c.findByType(updateEndpoint.Type, curZone[updateEndpoint.Name]) == nil {
+		if c.find(updateEndpoint.Type, updateEndpoint.SetIdentifier, curZone[updateEndpoint.Name]) == nil {
 			return ErrRecordNotFound
 		}
 		if err := c.updateMesh(mesh, updateEndpoint); err != nil {
@@ -359,12 +495,12 @@ func (c *inMemoryClient) validateChangeBatch(zone string, changes *inMemoryChang
 		}
 	}
 	for _, updateOldEndpoint := range changes.UpdateOld {
-		if rec := c.findByType(updateOldEndpoint.Type, curZone[updateOldEndpoint.Name]); rec == nil || rec.Target != updateOldEndpoint.Target {
+		if rec := c.find(updateOldEndpoint.Type, updateOldEndpoint.SetIdentifier, curZone[updateOldEndpoint.Name]); rec == nil || !rec.Same(updateOldEndpoint) {
 			return ErrRecordNotFound
 		}
 	}
 	for _, deleteEndpoint := range changes.Delete {
-		if rec := c.findByType(deleteEndpoint.Type, curZone[deleteEndpoint.Name]); rec == nil || rec.Target != deleteEndpoint.Target {
+		if rec := c.find(deleteEndpoint.Type, deleteEndpoint.SetIdentifier, curZone[deleteEndpoint.Name]); rec == nil || !rec.Same(deleteEndpoint) {
 			return ErrRecordNotFound
 		}
 		if err := c.updateMesh(mesh, deleteEndpoint); err != nil {
@@ -374,9 +510,9 @@ func (c *inMemoryClient) validateChangeBatch(zone string, changes *inMemoryChang
 	return nil
 }
 
-func (c *inMemoryClient) findByType(recordType string, records []*inMemoryRecord) *inMemoryRecord {
+func (c *inMemoryClient) find(recordType, setIdentifier string, records []*inMemoryRecord) *inMemoryRecord {
 	for _, record := range records {
-		if record.Type == recordType {
+		if record.Type == recordType && record.SetIdentifier == setIdentifier {
 			return record
 		}
 	}
@@ -32,21 +32,25 @@ var (
 )
 
 func TestInMemoryProvider(t *testing.T) {
-	t.Run("findByType", testInMemoryFindByType)
+	t.Run("find", testInMemoryFind)
 	t.Run("Records", testInMemoryRecords)
 	t.Run("validateChangeBatch", testInMemoryValidateChangeBatch)
 	t.Run("ApplyChanges", testInMemoryApplyChanges)
 	t.Run("NewInMemoryProvider", testNewInMemoryProvider)
 	t.Run("CreateZone", testInMemoryCreateZone)
+	t.Run("RoutingPolicies", testInMemoryRoutingPolicies)
+	t.Run("Throttling", testInMemoryThrottling)
+	t.Run("PartialApply", testInMemoryPartialApply)
 }
 
-func testInMemoryFindByType(t *testing.T) {
+func testInMemoryFind(t *testing.T) {
 	for _, ti := range []struct {
-		title         string
-		findType      string
-		records       []*inMemoryRecord
-		expected      *inMemoryRecord
-		expectedEmpty bool
+		title             string
+		findType          string
+		findSetIdentifier string
+		records           []*inMemoryRecord
+		expected          *inMemoryRecord
+		expectedEmpty     bool
 	}{
 		{
 			title:         "no records, empty type",
@@ -111,10 +115,43 @@ func testInMemoryFindByType(t *testing.T) {
 				Type: endpoint.RecordTypeA,
 			},
 		},
+		{
+			title:             "multiple records, right type, wrong set identifier",
+			findType:          endpoint.RecordTypeA,
+			findSetIdentifier: "us",
+			records: []*inMemoryRecord{
+				{
+					Type: endpoint.RecordTypeA,
+				},
+				{
+					Type:          endpoint.RecordTypeA,
+					SetIdentifier: "eu",
+				},
+			},
+			expectedEmpty: true,
+		},
+		{
+			title:             "multiple records, right type and set identifier",
+			findType:          endpoint.RecordTypeA,
+			findSetIdentifier: "eu",
+			records: []*inMemoryRecord{
+				{
+					Type: endpoint.RecordTypeA,
+				},
+				{
+					Type:          endpoint.RecordTypeA,
+					SetIdentifier: "eu",
+				},
+			},
+			expected: &inMemoryRecord{
+				Type:          endpoint.RecordTypeA,
+				SetIdentifier: "eu",
+			},
+		},
 	} {
 		t.Run(ti.title, func(t *testing.T) {
 			c := newInMemoryClient()
-			record := c.findByType(ti.findType, ti.records)
+			record := c.find(ti.findType, ti.findSetIdentifier, ti.records)
 			if ti.expectedEmpty {
 				assert.Nil(t, record)
 			} else {
@@ -155,9 +192,9 @@ func testInMemoryRecords(t *testing.T) {
 				"org": {
 					"example.org": []*inMemoryRecord{
 						{
-							Name:   "example.org",
-							Target: "8.8.8.8",
-							Type:   endpoint.RecordTypeA,
+							Name:    "example.org",
+							Targets: endpoint.Targets{"8.8.8.8"},
+							Type:    endpoint.RecordTypeA,
 						},
 						{
 							Name: "example.org",
@@ -166,18 +203,18 @@ func testInMemoryRecords(t *testing.T) {
 					},
 					"foo.org": []*inMemoryRecord{
 						{
-							Name:   "foo.org",
-							Target: "4.4.4.4",
-							Type:   endpoint.RecordTypeCNAME,
+							Name:    "foo.org",
+							Targets: endpoint.Targets{"4.4.4.4"},
+							Type:    endpoint.RecordTypeCNAME,
 						},
 					},
 				},
 				"com": {
 					"example.com": []*inMemoryRecord{
 						{
-							Name:   "example.com",
-							Target: "4.4.4.4",
-							Type:   endpoint.RecordTypeCNAME,
+							Name:    "example.com",
+							Targets: endpoint.Targets{"4.4.4.4"},
+							Type:    endpoint.RecordTypeCNAME,
 						},
 					},
 				},
@@ -192,7 +229,6 @@ func testInMemoryRecords(t *testing.T) {
 				{
 					DNSName:    "example.org",
 					RecordType: endpoint.RecordTypeTXT,
-					Targets:    endpoint.Targets{""},
 				},
 				{
 					DNSName:    "foo.org",
@@ -226,9 +262,9 @@ func testInMemoryValidateChangeBatch(t *testing.T) {
 		"org": {
 			"example.org": []*inMemoryRecord{
 				{
-					Name:   "example.org",
-					Target: "8.8.8.8",
-					Type:   endpoint.RecordTypeA,
+					Name:    "example.org",
+					Targets: endpoint.Targets{"8.8.8.8"},
+					Type:    endpoint.RecordTypeA,
 				},
 				{
 					Name: "example.org",
@@ -236,25 +272,25 @@ func testInMemoryValidateChangeBatch(t *testing.T) {
 			},
 			"foo.org": []*inMemoryRecord{
 				{
-					Name:   "foo.org",
-					Target: "bar.org",
-					Type:   endpoint.RecordTypeCNAME,
+					Name:    "foo.org",
+					Targets: endpoint.Targets{"bar.org"},
+					Type:    endpoint.RecordTypeCNAME,
 				},
 			},
 			"foo.bar.org": []*inMemoryRecord{
 				{
-					Name:   "foo.bar.org",
-					Target: "5.5.5.5",
-					Type:   endpoint.RecordTypeA,
+					Name:    "foo.bar.org",
+					Targets: endpoint.Targets{"5.5.5.5"},
+					Type:    endpoint.RecordTypeA,
 				},
 			},
 		},
 		"com": {
 			"example.com": []*inMemoryRecord{
 				{
-					Name:   "example.com",
-					Target: "another-example.com",
-					Type:   endpoint.RecordTypeCNAME,
+					Name:    "example.com",
+					Targets: endpoint.Targets{"another-example.com"},
+					Type:    endpoint.RecordTypeCNAME,
 				},
 			},
 		},
@@ -559,9 +595,9 @@ func getInitData() map[string]zone {
 		"org": {
 			"example.org": []*inMemoryRecord{
 				{
-					Name:   "example.org",
-					Target: "8.8.8.8",
-					Type:   endpoint.RecordTypeA,
+					Name:    "example.org",
+					Targets: endpoint.Targets{"8.8.8.8"},
+					Type:    endpoint.RecordTypeA,
 				},
 				{
 					Name: "example.org",
@@ -570,25 +606,25 @@ func getInitData() map[string]zone {
 			},
 			"foo.org": []*inMemoryRecord{
 				{
-					Name:   "foo.org",
-					Target: "4.4.4.4",
-					Type:   endpoint.RecordTypeCNAME,
+					Name:    "foo.org",
+					Targets: endpoint.Targets{"4.4.4.4"},
+					Type:    endpoint.RecordTypeCNAME,
 				},
 			},
 			"foo.bar.org": []*inMemoryRecord{
 				{
-					Name:   "foo.bar.org",
-					Target: "5.5.5.5",
-					Type:   endpoint.RecordTypeA,
+					Name:    "foo.bar.org",
+					Targets: endpoint.Targets{"5.5.5.5"},
+					Type:    endpoint.RecordTypeA,
 				},
 			},
 		},
 		"com": {
 			"example.com": []*inMemoryRecord{
 				{
-					Name:   "example.com",
-					Target: "4.4.4.4",
-					Type:   endpoint.RecordTypeCNAME,
+					Name:    "example.com",
+					Targets: endpoint.Targets{"4.4.4.4"},
+					Type:    endpoint.RecordTypeCNAME,
 				},
 			},
 		},
@@ -660,9 +696,9 @@ func testInMemoryApplyChanges(t *testing.T) {
 					"example.org": []*inMemoryRecord{
 						{
 
-							Name:   "example.org",
-							Target: "8.8.8.8",
-							Type:   endpoint.RecordTypeA,
+							Name:    "example.org",
+							Targets: endpoint.Targets{"8.8.8.8"},
+							Type:    endpoint.RecordTypeA,
 						},
 						{
 
@@ -673,9 +709,9 @@ func testInMemoryApplyChanges(t *testing.T) {
 					"foo.org": []*inMemoryRecord{
 						{
 
-							Name:   "foo.org",
-							Target: "4.4.4.4",
-							Type:   endpoint.RecordTypeCNAME,
+							Name:    "foo.org",
+							Targets: endpoint.Targets{"4.4.4.4"},
+							Type:    endpoint.RecordTypeCNAME,
 						},
 					},
 					"foo.bar.org": []*inMemoryRecord{},
@@ -683,9 +719,9 @@ func testInMemoryApplyChanges(t *testing.T) {
 				"com": {
 					"example.com": []*inMemoryRecord{
 						{
-							Name:   "example.com",
-							Target: "4.4.4.4",
-							Type:   endpoint.RecordTypeCNAME,
+							Name:    "example.com",
+							Targets: endpoint.Targets{"4.4.4.4"},
+							Type:    endpoint.RecordTypeCNAME,
 						},
 					},
 				},
@@ -734,32 +770,32 @@ func testInMemoryApplyChanges(t *testing.T) {
 					},
 					"foo.org": []*inMemoryRecord{
 						{
-							Name:   "foo.org",
-							Target: "4.4.4.4",
-							Type:   endpoint.RecordTypeCNAME,
+							Name:    "foo.org",
+							Targets: endpoint.Targets{"4.4.4.4"},
+							Type:    endpoint.RecordTypeCNAME,
 						},
 					},
 					"foo.bar.org": []*inMemoryRecord{
 						{
-							Name:   "foo.bar.org",
-							Target: "4.8.8.4",
-							Type:   endpoint.RecordTypeA,
+							Name:    "foo.bar.org",
+							Targets: endpoint.Targets{"4.8.8.4"},
+							Type:    endpoint.RecordTypeA,
 						},
 					},
 					"foo.bar.new.org": []*inMemoryRecord{
 						{
-							Name:   "foo.bar.new.org",
-							Target: "4.8.8.9",
-							Type:   endpoint.RecordTypeA,
+							Name:    "foo.bar.new.org",
+							Targets: endpoint.Targets{"4.8.8.9"},
+							Type:    endpoint.RecordTypeA,
 						},
 					},
 				},
 				"com": {
 					"example.com": []*inMemoryRecord{
 						{
-							Name:   "example.com",
-							Target: "4.4.4.4",
-							Type:   endpoint.RecordTypeCNAME,
+							Name:    "example.com",
+							Targets: endpoint.Targets{"4.4.4.4"},
+							Type:    endpoint.RecordTypeCNAME,
 						},
 					},
 				},
@@ -796,3 +832,77 @@ func testInMemoryCreateZone(t *testing.T) {
 	err = im.CreateZone("zone")
 	assert.EqualError(t, err, ErrZoneAlreadyExists.Error())
 }
+
+func newGeoEndpoint(setIdentifier, target string, ttl endpoint.TTL, geo *endpoint.GeoLocation) *endpoint.Endpoint {
+	return endpoint.NewEndpointWithTTL("geo.example.org", target, endpoint.RecordTypeA, ttl).WithSetIdentifier(setIdentifier).WithGeoLocation(geo)
+}
+
+func testInMemoryRoutingPolicies(t *testing.T) {
+	im := NewInMemoryProvider()
+	require.NoError(t, im.CreateZone("example.org"))
+
+	eu := newGeoEndpoint("eu", "1.1.1.1", 300, &endpoint.GeoLocation{ContinentCode: "EU"})
+	eu.Targets = append(eu.Targets, "1.1.1.2")
+	us := newGeoEndpoint("us", "2.2.2.2", 300, &endpoint.GeoLocation{CountryCode: "US"})
+	def := newGeoEndpoint("default", "3.3.3.3", 60, &endpoint.GeoLocation{CountryCode: "*"})
+
+	// records sharing name and type are distinguished by their set identifier
+	require.NoError(t, im.ApplyChanges(&plan.Changes{Create: []*endpoint.Endpoint{eu, us, def}}))
+	records, err := im.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints([]*endpoint.Endpoint{eu, us, def}, records), "unexpected records: %v", records)
+
+	err = im.ApplyChanges(&plan.Changes{Create: []*endpoint.Endpoint{newGeoEndpoint("eu", "4.4.4.4", 300, nil)}})
+	assert.EqualError(t, err, ErrRecordAlreadyExists.Error())
+
+	// updates must match the current state including TTL and geo location
+	staleUS := newGeoEndpoint("us", "2.2.2.2", 60, &endpoint.GeoLocation{CountryCode: "US"})
+	newUS := newGeoEndpoint("us", "2.2.2.2", 300, &endpoint.GeoLocation{CountryCode: "US", SubdivisionCode: "CA"})
+	err = im.ApplyChanges(&plan.Changes{UpdateOld: []*endpoint.Endpoint{staleUS}, UpdateNew: []*endpoint.Endpoint{newUS}})
+	assert.EqualError(t, err, ErrRecordNotFound.Error())
+
+	require.NoError(t, im.ApplyChanges(&plan.Changes{UpdateOld: []*endpoint.Endpoint{us}, UpdateNew: []*endpoint.Endpoint{newUS}}))
+	records, err = im.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints([]*endpoint.Endpoint{eu, newUS, def}, records), "unexpected records: %v", records)
+
+	// deleting one set identifier keeps the others
+	require.NoError(t, im.ApplyChanges(&plan.Changes{Delete: []*endpoint.Endpoint{eu}}))
+	records, err = im.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints([]*endpoint.Endpoint{newUS, def}, records), "unexpected records: %v", records)
+}
+
+func testInMemoryThrottling(t *testing.T) {
+	im := NewInMemoryProvider(InMemoryInitZones([]string{"example.org"}), InMemoryWithThrottling(2))
+	changes := &plan.Changes{Create: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", "1.2.3.4", endpoint.RecordTypeA)}}
+
+	_, err := im.Records()
+	assert.EqualError(t, err, ErrThrottled.Error())
+	assert.EqualError(t, im.ApplyChanges(changes), ErrThrottled.Error())
+
+	require.NoError(t, im.ApplyChanges(changes))
+	records, err := im.Records()
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+}
+
+func testInMemoryPartialApply(t *testing.T) {
+	im := NewInMemoryProvider(InMemoryInitZones([]string{"example.org"}), InMemoryWithPartialApply(2))
+	changes := &plan.Changes{Create: []*endpoint.Endpoint{
+		newGeoEndpoint("eu", "1.1.1.1", 0, &endpoint.GeoLocation{ContinentCode: "EU"}),
+		newGeoEndpoint("us", "2.2.2.2", 0, &endpoint.GeoLocation{CountryCode: "US"}),
+		newGeoEndpoint("default", "3.3.3.3", 0, &endpoint.GeoLocation{CountryCode: "*"}),
+	}}
+
+	assert.EqualError(t, im.ApplyChanges(changes), ErrPartialApply.Error())
+	records, err := im.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(changes.Create[:2], records), "unexpected records: %v", records)
+
+	// the partial apply only affects a single call, retrying the remaining change succeeds
+	require.NoError(t, im.ApplyChanges(&plan.Changes{Create: changes.Create[2:]}))
+	records, err = im.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(changes.Create, records), "unexpected records: %v", records)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+var (
+	providerRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "provider",
+			Name:      "requests_total",
+			Help:      "Number of calls to the DNS provider.",
+		},
+		[]string{"operation"},
+	)
+	providerErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "provider",
+			Name:      "errors_total",
+			Help:      "Number of calls to the DNS provider that failed.",
+		},
+		[]string{"operation"},
+	)
+	providerRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "external_dns",
+			Subsystem: "provider",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of the calls to the DNS provider.",
+		},
+		[]string{"operation"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(providerRequestsTotal)
+	prometheus.MustRegister(providerErrorsTotal)
+	prometheus.MustRegister(providerRequestDuration)
+}
+
+// InstrumentedProvider wraps a Provider and counts its calls and errors in the metrics.
+// It should wrap the provider directly, so that every retry of the RetryProvider is counted.
+type InstrumentedProvider struct {
+	Provider
+}
+
+// NewInstrumentedProvider returns an InstrumentedProvider exporting metrics of the given provider
+func NewInstrumentedProvider(provider Provider) *InstrumentedProvider {
+	return &InstrumentedProvider{Provider: provider}
+}
+
+// Records returns the records of the wrapped provider
+func (i *InstrumentedProvider) Records() ([]*endpoint.Endpoint, error) {
+	var records []*endpoint.Endpoint
+	err := i.instrument("records", func() error {
+		var err error
+		records, err = i.Provider.Records()
+		return err
+	})
+	return records, err
+}
+
+// ApplyChanges applies the changes with the wrapped provider
+func (i *InstrumentedProvider) ApplyChanges(changes *plan.Changes) error {
+	return i.instrument("apply_changes", func() error {
+		return i.Provider.ApplyChanges(changes)
+	})
+}
+
+// AdjustEndpoints forwards to the wrapped provider if it adjusts endpoints
+func (i *InstrumentedProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	if adjuster, ok := i.Provider.(EndpointsAdjuster); ok {
+		return adjuster.AdjustEndpoints(endpoints)
+	}
+	return endpoints, nil
+}
+
+// SupportsGeoLocations forwards to the wrapped provider
+func (i *InstrumentedProvider) SupportsGeoLocations() bool {
+	return SupportsGeoLocations(i.Provider)
+}
+
+// IsTransientError forwards to the wrapped provider if it classifies its errors
+func (i *InstrumentedProvider) IsTransientError(err error) bool {
+	if classifier, ok := i.Provider.(TransientErrorClassifier); ok {
+		return classifier.IsTransientError(err)
+	}
+	return defaultTransientErrorClassifier{}.IsTransientError(err)
+}
+
+func (i *InstrumentedProvider) instrument(operation string, call func() error) error {
+	start := time.Now()
+	err := call()
+	providerRequestDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	providerRequestsTotal.WithLabelValues(operation).Inc()
+	if err != nil {
+		providerErrorsTotal.WithLabelValues(operation).Inc()
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+var _ Provider = &InstrumentedProvider{}
+var _ EndpointsAdjuster = &InstrumentedProvider{}
+var _ TransientErrorClassifier = &InstrumentedProvider{}
+
+func TestInstrumentedProviderForwardsCalls(t *testing.T) {
+	p := NewInMemoryProvider(InMemoryInitZones([]string{"example.org"}))
+	i := NewInstrumentedProvider(p)
+
+	create := endpoint.NewEndpoint("foo.example.org", "1.2.3.4", endpoint.RecordTypeA)
+	require.NoError(t, i.ApplyChanges(&plan.Changes{Create: []*endpoint.Endpoint{create}}))
+
+	records, err := i.Records()
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	err = i.ApplyChanges(&plan.Changes{Create: []*endpoint.Endpoint{create}})
+	assert.Error(t, err)
+}
+
+func TestInstrumentedProviderClassifiesErrors(t *testing.T) {
+	i := NewInstrumentedProvider(&failingProvider{})
+	assert.True(t, i.IsTransientError(timeoutError{}))
+	assert.False(t, i.IsTransientError(errors.New("permanent")))
+
+	throttling := NewInstrumentedProvider(NewInMemoryProvider(InMemoryWithThrottling(1)))
+	assert.True(t, throttling.IsTransientError(ErrThrottled))
+}
+
+func TestRetryProviderRetriesInstrumentedProvider(t *testing.T) {
+	failing := &failingProvider{errs: []error{timeoutError{}}}
+	r, _ := newTestRetryProvider(NewInstrumentedProvider(failing), 1)
+
+	_, err := r.Records()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, failing.calls)
+}
@@ -0,0 +1,426 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/linki/instrumented_http"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+const (
+	linodeAPIURL = "https://api.linode.com/v4"
+	// linodeMaxRetries is the number of times a rate limited request is retried before giving up
+	linodeMaxRetries = 5
+	// linodeDefaultRetryAfter is used when a rate limited response doesn't tell us how long to wait
+	linodeDefaultRetryAfter = 5 * time.Second
+)
+
+// linodeDomain is a Linode DNS zone as returned by the Linode API.
+type linodeDomain struct {
+	ID     int    `json:"id"`
+	Domain string `json:"domain"`
+}
+
+// linodeDomainRecord is a single DNS record of a Linode domain. Names are relative to the domain.
+type linodeDomainRecord struct {
+	ID     int    `json:"id,omitempty"`
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Target string `json:"target"`
+	TTLSec int    `json:"ttl_sec,omitempty"`
+}
+
+// linodeDomainClient is the subset of the Linode API that we actually use.
+type linodeDomainClient interface {
+	ListDomains() ([]linodeDomain, error)
+	ListDomainRecords(domainID int) ([]linodeDomainRecord, error)
+	CreateDomainRecord(domainID int, record linodeDomainRecord) error
+	UpdateDomainRecord(domainID int, recordID int, record linodeDomainRecord) error
+	DeleteDomainRecord(domainID int, recordID int) error
+}
+
+// LinodeProvider is an implementation of Provider for Linode's DNS.
+type LinodeProvider struct {
+	client linodeDomainClient
+	// only consider hosted zones managing domains ending in this suffix
+	domainFilter DomainFilter
+	dryRun       bool
+}
+
+// NewLinodeProvider initializes a new Linode DNS based Provider.
+// The API token is read from the LINODE_TOKEN environment variable.
+func NewLinodeProvider(domainFilter DomainFilter, rateLimiter *RateLimiter, dryRun bool) (*LinodeProvider, error) {
+	token, ok := os.LookupEnv("LINODE_TOKEN")
+	if !ok {
+		return nil, fmt.Errorf("No token found")
+	}
+
+	client := newLinodeAPIClient(linodeAPIURL, token)
+	client.httpClient = rateLimiter.Client(client.httpClient)
+	provider := &LinodeProvider{
+		client:       client,
+		domainFilter: domainFilter,
+		dryRun:       dryRun,
+	}
+	return provider, nil
+}
+
+// Zones returns the list of domains managed by this provider.
+func (p *LinodeProvider) Zones() ([]linodeDomain, error) {
+	domains, err := p.client.ListDomains()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []linodeDomain{}
+	for _, domain := range domains {
+		if p.domainFilter.Match(domain.Domain) {
+			result = append(result, domain)
+		}
+	}
+	return result, nil
+}
+
+// Records returns the list of records in all relevant zones.
+// Records sharing the same name and type are grouped into a single endpoint with multiple targets.
+func (p *LinodeProvider) Records() ([]*endpoint.Endpoint, error) {
+	zones, err := p.Zones()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := []*endpoint.Endpoint{}
+	for _, zone := range zones {
+		records, err := p.client.ListDomainRecords(zone.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		byNameAndType := map[string]*endpoint.Endpoint{}
+		for _, r := range records {
+			if !linodeSupportedRecordType(r.Type) {
+				continue
+			}
+
+			name := linodeFQDN(r.Name, zone.Domain)
+			key := name + "/" + r.Type
+			if ep, ok := byNameAndType[key]; ok {
+				ep.Targets = append(ep.Targets, r.Target)
+				continue
+			}
+
+			ep := endpoint.NewEndpointWithTTL(name, r.Target, r.Type, endpoint.TTL(r.TTLSec))
+			byNameAndType[key] = ep
+			endpoints = append(endpoints, ep)
+		}
+	}
+
+	return endpoints, nil
+}
+
+// ApplyChanges applies a given set of changes in all relevant zones.
+func (p *LinodeProvider) ApplyChanges(changes *plan.Changes) error {
+	if len(changes.Create)+len(changes.UpdateNew)+len(changes.Delete) == 0 {
+		return nil
+	}
+
+	zones, err := p.Zones()
+	if err != nil {
+		return err
+	}
+
+	zoneNameIDMapper := zoneIDName{}
+	for _, z := range zones {
+		zoneNameIDMapper.Add(strconv.Itoa(z.ID), z.Domain)
+	}
+
+	// records are fetched lazily and only once per zone
+	recordsByZone := map[int][]linodeDomainRecord{}
+	zoneRecords := func(zoneID int) ([]linodeDomainRecord, error) {
+		if records, ok := recordsByZone[zoneID]; ok {
+			return records, nil
+		}
+		records, err := p.client.ListDomainRecords(zoneID)
+		if err != nil {
+			return nil, err
+		}
+		recordsByZone[zoneID] = records
+		return records, nil
+	}
+
+	for _, ep := range changes.Create {
+		if err := p.submitChange(zoneNameIDMapper, ep, "CREATE", zoneRecords); err != nil {
+			return err
+		}
+	}
+	for _, ep := range changes.UpdateNew {
+		if err := p.submitChange(zoneNameIDMapper, ep, "UPDATE", zoneRecords); err != nil {
+			return err
+		}
+	}
+	for _, ep := range changes.Delete {
+		if err := p.submitChange(zoneNameIDMapper, ep, "DELETE", zoneRecords); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// submitChange makes the Linode records for the given endpoint match the desired action.
+// Existing records with the same name and type are reused in place where possible.
+func (p *LinodeProvider) submitChange(zones zoneIDName, ep *endpoint.Endpoint, action string, zoneRecords func(int) ([]linodeDomainRecord, error)) error {
+	zoneIDString, zoneName := zones.FindZone(ep.DNSName)
+	if zoneIDString == "" {
+		log.Debugf("Skipping record %s because no hosted zone matching record DNS Name was detected", ep.DNSName)
+		return nil
+	}
+	zoneID, _ := strconv.Atoi(zoneIDString)
+
+	log.WithFields(log.Fields{
+		"record": ep.DNSName,
+		"type":   ep.RecordType,
+		"action": action,
+		"zone":   zoneName,
+	}).Info("Changing record.")
+
+	if p.dryRun {
+		return nil
+	}
+
+	name := linodeRelativeName(ep.DNSName, zoneName)
+
+	var existing []linodeDomainRecord
+	if action != "CREATE" {
+		records, err := zoneRecords(zoneID)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			if r.Name == name && r.Type == ep.RecordType {
+				existing = append(existing, r)
+			}
+		}
+	}
+
+	var targets endpoint.Targets
+	if action != "DELETE" {
+		targets = ep.Targets
+	}
+
+	for i, target := range targets {
+		record := linodeDomainRecord{
+			Type:   ep.RecordType,
+			Name:   name,
+			Target: target,
+			TTLSec: int(ep.RecordTTL),
+		}
+		if i < len(existing) {
+			if err := p.client.UpdateDomainRecord(zoneID, existing[i].ID, record); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := p.client.CreateDomainRecord(zoneID, record); err != nil {
+			return err
+		}
+	}
+	for i := len(targets); i < len(existing); i++ {
+		if err := p.client.DeleteDomainRecord(zoneID, existing[i].ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// linodeSupportedRecordType returns true for the record types managed on Linode.
+func linodeSupportedRecordType(recordType string) bool {
+	switch recordType {
+	case endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME, endpoint.RecordTypeTXT:
+		return true
+	default:
+		return false
+	}
+}
+
+// linodeFQDN turns a record name relative to the domain into a fully qualified name.
+func linodeFQDN(name, domain string) string {
+	if name == "" {
+		return domain
+	}
+	return name + "." + domain
+}
+
+// linodeRelativeName strips the domain from a fully qualified name. The apex is represented by an empty name.
+func linodeRelativeName(dnsName, domain string) string {
+	if dnsName == domain {
+		return ""
+	}
+	return strings.TrimSuffix(dnsName, "."+domain)
+}
+
+// linodeAPIClient is a minimal client for the Linode v4 domains API.
+type linodeAPIClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+	// sleep is replaced in tests to avoid waiting for rate limits to reset
+	sleep func(time.Duration)
+}
+
+func newLinodeAPIClient(baseURL, token string) *linodeAPIClient {
+	return &linodeAPIClient{
+		baseURL: baseURL,
+		token:   token,
+		httpClient: instrumented_http.NewClient(&http.Client{Timeout: 30 * time.Second}, &instrumented_http.Callbacks{
+			PathProcessor: func(path string) string {
+				parts := strings.Split(path, "/")
+				return parts[len(parts)-1]
+			},
+		}),
+		sleep: time.Sleep,
+	}
+}
+
+// linodePage is the envelope of all paginated Linode list responses.
+type linodePage struct {
+	Data  json.RawMessage `json:"data"`
+	Page  int             `json:"page"`
+	Pages int             `json:"pages"`
+}
+
+func (c *linodeAPIClient) ListDomains() ([]linodeDomain, error) {
+	domains := []linodeDomain{}
+	err := c.list("/domains", func(data json.RawMessage) error {
+		var page []linodeDomain
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		domains = append(domains, page...)
+		return nil
+	})
+	return domains, err
+}
+
+func (c *linodeAPIClient) ListDomainRecords(domainID int) ([]linodeDomainRecord, error) {
+	records := []linodeDomainRecord{}
+	err := c.list(fmt.Sprintf("/domains/%d/records", domainID), func(data json.RawMessage) error {
+		var page []linodeDomainRecord
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		records = append(records, page...)
+		return nil
+	})
+	return records, err
+}
+
+func (c *linodeAPIClient) CreateDomainRecord(domainID int, record linodeDomainRecord) error {
+	return c.do(http.MethodPost, fmt.Sprintf("/domains/%d/records", domainID), record, nil)
+}
+
+func (c *linodeAPIClient) UpdateDomainRecord(domainID int, recordID int, record linodeDomainRecord) error {
+	return c.do(http.MethodPut, fmt.Sprintf("/domains/%d/records/%d", domainID, recordID), record, nil)
+}
+
+func (c *linodeAPIClient) DeleteDomainRecord(domainID int, recordID int) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("/domains/%d/records/%d", domainID, recordID), nil, nil)
+}
+
+// list walks through all pages of a list endpoint and hands the data of each page to fn.
+func (c *linodeAPIClient) list(path string, fn func(json.RawMessage) error) error {
+	for page := 1; ; page++ {
+		var resp linodePage
+		if err := c.do(http.MethodGet, fmt.Sprintf("%s?page=%d", path, page), nil, &resp); err != nil {
+			return err
+		}
+		if err := fn(resp.Data); err != nil {
+			return err
+		}
+		if page >= resp.Pages {
+			return nil
+		}
+	}
+}
+
+// do sends a single request to the Linode API. Rate limited requests are retried after
+// waiting for the duration the API asks for in its Retry-After header.
+func (c *linodeAPIClient) do(method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequest(method, c.baseURL+path, reader)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < linodeMaxRetries {
+			wait := linodeDefaultRetryAfter
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				wait = time.Duration(seconds) * time.Second
+			}
+			log.Warnf("Linode API rate limit exceeded, retrying %s %s in %s", method, path, wait)
+			c.sleep(wait)
+			continue
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("linode API %s %s failed with status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+		}
+
+		if out == nil {
+			return nil
+		}
+		return json.Unmarshal(data, out)
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+type mockLinodeClient struct {
+	domains []linodeDomain
+	records map[int][]linodeDomainRecord
+	created map[int][]linodeDomainRecord
+	updated map[int][]linodeDomainRecord
+	deleted map[int][]int
+}
+
+func newMockLinodeClient() *mockLinodeClient {
+	return &mockLinodeClient{
+		domains: []linodeDomain{
+			{ID: 1, Domain: "example.com"},
+			{ID: 2, Domain: "foo.com"},
+		},
+		records: map[int][]linodeDomainRecord{
+			1: {
+				{ID: 11, Type: "A", Name: "", Target: "1.2.3.4", TTLSec: 300},
+				{ID: 12, Type: "A", Name: "www", Target: "1.2.3.4"},
+				{ID: 13, Type: "A", Name: "www", Target: "5.6.7.8"},
+				{ID: 14, Type: "AAAA", Name: "www", Target: "2001:db8::1"},
+				{ID: 15, Type: "TXT", Name: "www", Target: "heritage=external-dns,external-dns/owner=default"},
+				{ID: 16, Type: "MX", Name: "", Target: "mail.example.com"},
+			},
+			2: {
+				{ID: 21, Type: "CNAME", Name: "bar", Target: "foo.elb.amazonaws.com"},
+			},
+		},
+		created: map[int][]linodeDomainRecord{},
+		updated: map[int][]linodeDomainRecord{},
+		deleted: map[int][]int{},
+	}
+}
+
+func (m *mockLinodeClient) ListDomains() ([]linodeDomain, error) {
+	return m.domains, nil
+}
+
+func (m *mockLinodeClient) ListDomainRecords(domainID int) ([]linodeDomainRecord, error) {
+	return m.records[domainID], nil
+}
+
+func (m *mockLinodeClient) CreateDomainRecord(domainID int, record linodeDomainRecord) error {
+	m.created[domainID] = append(m.created[domainID], record)
+	return nil
+}
+
+func (m *mockLinodeClient) UpdateDomainRecord(domainID int, recordID int, record linodeDomainRecord) error {
+	record.ID = recordID
+	m.updated[domainID] = append(m.updated[domainID], record)
+	return nil
+}
+
+func (m *mockLinodeClient) DeleteDomainRecord(domainID int, recordID int) error {
+	m.deleted[domainID] = append(m.deleted[domainID], recordID)
+	return nil
+}
+
+func TestNewLinodeProvider(t *testing.T) {
+	os.Setenv("LINODE_TOKEN", "xxxxxxxxxxxxxxxxx")
+	_, err := NewLinodeProvider(NewDomainFilter([]string{"example.com"}), nil, true)
+	require.NoError(t, err)
+
+	os.Unsetenv("LINODE_TOKEN")
+	_, err = NewLinodeProvider(NewDomainFilter([]string{"example.com"}), nil, true)
+	assert.Error(t, err)
+}
+
+func TestLinodeZones(t *testing.T) {
+	provider := &LinodeProvider{
+		client:       newMockLinodeClient(),
+		domainFilter: NewDomainFilter([]string{"example.com"}),
+	}
+
+	zones, err := provider.Zones()
+	require.NoError(t, err)
+	assert.Equal(t, []linodeDomain{{ID: 1, Domain: "example.com"}}, zones)
+}
+
+func TestLinodeRecords(t *testing.T) {
+	provider := &LinodeProvider{
+		client:       newMockLinodeClient(),
+		domainFilter: NewDomainFilter([]string{""}),
+	}
+
+	records, err := provider.Records()
+	require.NoError(t, err)
+
+	expected := []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("example.com", "1.2.3.4", endpoint.RecordTypeA, 300),
+		{DNSName: "www.example.com", Targets: endpoint.Targets{"1.2.3.4", "5.6.7.8"}, RecordType: endpoint.RecordTypeA, Labels: endpoint.NewLabels()},
+		endpoint.NewEndpoint("www.example.com", "2001:db8::1", endpoint.RecordTypeAAAA),
+		endpoint.NewEndpoint("www.example.com", "heritage=external-dns,external-dns/owner=default", endpoint.RecordTypeTXT),
+		endpoint.NewEndpoint("bar.foo.com", "foo.elb.amazonaws.com", endpoint.RecordTypeCNAME),
+	}
+	assert.Equal(t, expected, records)
+}
+
+func TestLinodeApplyChanges(t *testing.T) {
+	client := newMockLinodeClient()
+	provider := &LinodeProvider{
+		client:       client,
+		domainFilter: NewDomainFilter([]string{""}),
+	}
+
+	err := provider.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("new.example.com", "8.8.8.8", endpoint.RecordTypeA),
+			endpoint.NewEndpoint("new.unknown.org", "8.8.8.8", endpoint.RecordTypeA),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			{DNSName: "www.example.com", Targets: endpoint.Targets{"1.2.3.4", "5.6.7.8"}, RecordType: endpoint.RecordTypeA},
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("www.example.com", "9.9.9.9", endpoint.RecordTypeA, 600),
+		},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("bar.foo.com", "foo.elb.amazonaws.com", endpoint.RecordTypeCNAME),
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []linodeDomainRecord{{Type: "A", Name: "new", Target: "8.8.8.8"}}, client.created[1])
+	assert.Equal(t, []linodeDomainRecord{{ID: 12, Type: "A", Name: "www", Target: "9.9.9.9", TTLSec: 600}}, client.updated[1])
+	assert.Equal(t, []int{13}, client.deleted[1])
+	assert.Equal(t, []int{21}, client.deleted[2])
+}
+
+func TestLinodeApplyChangesDryRun(t *testing.T) {
+	client := newMockLinodeClient()
+	provider := &LinodeProvider{
+		client:       client,
+		domainFilter: NewDomainFilter([]string{""}),
+		dryRun:       true,
+	}
+
+	err := provider.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("new.example.com", "8.8.8.8", endpoint.RecordTypeA)},
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpoint("bar.foo.com", "foo.elb.amazonaws.com", endpoint.RecordTypeCNAME)},
+	})
+	require.NoError(t, err)
+
+	assert.Empty(t, client.created)
+	assert.Empty(t, client.deleted)
+}
+
+func TestLinodeAPIClientPagination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		page := r.URL.Query().Get("page")
+		data, _ := json.Marshal([]linodeDomain{{ID: len(page), Domain: "page" + page + ".com"}})
+		fmt.Fprintf(w, `{"data": %s, "page": %s, "pages": 2}`, data, page)
+	}))
+	defer server.Close()
+
+	client := newLinodeAPIClient(server.URL, "token")
+	domains, err := client.ListDomains()
+	require.NoError(t, err)
+	assert.Equal(t, []linodeDomain{{ID: 1, Domain: "page1.com"}, {ID: 1, Domain: "page2.com"}}, domains)
+}
+
+func TestLinodeAPIClientRateLimiting(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var waited []time.Duration
+	client := newLinodeAPIClient(server.URL, "token")
+	client.sleep = func(d time.Duration) { waited = append(waited, d) }
+
+	require.NoError(t, client.DeleteDomainRecord(1, 2))
+	assert.Equal(t, 3, requests)
+	assert.Equal(t, []time.Duration{7 * time.Second, 7 * time.Second}, waited)
+}
+
+func TestLinodeAPIClientRateLimitExhausted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := newLinodeAPIClient(server.URL, "token")
+	client.sleep = func(time.Duration) {}
+
+	assert.Error(t, client.DeleteDomainRecord(1, 2))
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+// ProviderRoute is a provider of a MultiProvider with the domain filter selecting its records
+type ProviderRoute struct {
+	// Name identifies the provider in the logs, e.g. aws
+	Name     string
+	Provider Provider
+	// DomainFilter selects the records of the provider, it should match the zones of the provider
+	DomainFilter DomainFilter
+}
+
+// MultiProvider publishes the records to multiple providers, e.g. internal names to a private DNS server and public
+// names to a cloud provider. A record labeled with the name of a provider, e.g. by the provider annotation of its resource,
+// goes to that provider. Every other record goes to the provider whose domain filter matches its name most specifically,
+// i.e. with the longest domain suffix, ties go to the provider configured first.
+type MultiProvider struct {
+	routes []ProviderRoute
+}
+
+// NewMultiProvider returns a MultiProvider publishing the records to the providers of the routes
+func NewMultiProvider(routes []ProviderRoute) *MultiProvider {
+	return &MultiProvider{routes: routes}
+}
+
+// Records returns the records of all providers, labeled with the name of their provider unless the registry stores it
+func (m *MultiProvider) Records() ([]*endpoint.Endpoint, error) {
+	records := []*endpoint.Endpoint{}
+	for _, route := range m.routes {
+		routeRecords, err := route.Provider.Records()
+		if err != nil {
+			return nil, err
+		}
+		for _, ep := range routeRecords {
+			if ep.Labels == nil {
+				ep.Labels = endpoint.NewLabels()
+			}
+			if _, ok := ep.Labels[endpoint.ProviderLabelKey]; !ok {
+				ep.Labels[endpoint.ProviderLabelKey] = route.Name
+			}
+		}
+		records = append(records, routeRecords...)
+	}
+	return records, nil
+}
+
+// ApplyChanges applies the changes of every record with its provider. The changes of all providers are applied
+// even if some of them fail, the first error is returned.
+func (m *MultiProvider) ApplyChanges(changes *plan.Changes) error {
+	routed := make([]*plan.Changes, len(m.routes))
+	for i := range routed {
+		routed[i] = &plan.Changes{}
+	}
+	routeChange := func(ep *endpoint.Endpoint) (int, bool) {
+		i, ok := m.route(ep)
+		if !ok {
+			if name, labeled := ep.Labels[endpoint.ProviderLabelKey]; labeled {
+				log.WithFields(ep.LogFields()).Warnf("Skipping the change of a record of the unknown provider %s", name)
+			} else {
+				log.WithFields(ep.LogFields()).Warn("Skipping the change of a record not matching the domain filter of any provider")
+			}
+		}
+		return i, ok
+	}
+	for _, ep := range changes.Create {
+		if i, ok := routeChange(ep); ok {
+			routed[i].Create = append(routed[i].Create, ep)
+		}
+	}
+	for j, ep := range changes.UpdateNew {
+		if j >= len(changes.UpdateOld) {
+			break
+		}
+		// the update goes to the provider of the current record
+		if i, ok := routeChange(changes.UpdateOld[j]); ok {
+			routed[i].UpdateOld = append(routed[i].UpdateOld, changes.UpdateOld[j])
+			routed[i].UpdateNew = append(routed[i].UpdateNew, ep)
+		}
+	}
+	for _, ep := range changes.Delete {
+		if i, ok := routeChange(ep); ok {
+			routed[i].Delete = append(routed[i].Delete, ep)
+		}
+	}
+
+	var firstErr error
+	for i, route := range m.routes {
+		if !routed[i].HasChanges() {
+			continue
+		}
+		if err := route.Provider.ApplyChanges(routed[i]); err != nil {
+			log.Errorf("Failed to apply the changes of provider %s: %v", route.Name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// AdjustEndpoints labels the endpoints with their provider like the records, so that they're planned per provider,
+// and lets every provider adjust the endpoints routed to it
+func (m *MultiProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	routed := make([][]*endpoint.Endpoint, len(m.routes))
+	adjusted := []*endpoint.Endpoint{}
+	for _, ep := range endpoints {
+		if i, ok := m.route(ep); ok {
+			if ep.Labels == nil {
+				ep.Labels = endpoint.NewLabels()
+			}
+			ep.Labels[endpoint.ProviderLabelKey] = m.routes[i].Name
+			routed[i] = append(routed[i], ep)
+		} else {
+			adjusted = append(adjusted, ep)
+		}
+	}
+	for i, route := range m.routes {
+		if adjuster, ok := route.Provider.(EndpointsAdjuster); ok && len(routed[i]) > 0 {
+			var err error
+			if routed[i], err = adjuster.AdjustEndpoints(routed[i]); err != nil {
+				return nil, err
+			}
+		}
+		adjusted = append(adjusted, routed[i]...)
+	}
+	return adjusted, nil
+}
+
+// SupportsGeoLocations returns true if all providers support geo locations, the endpoints of every provider are
+// merged separately
+func (m *MultiProvider) SupportsGeoLocations() bool {
+	for _, route := range m.routes {
+		if !SupportsGeoLocations(route.Provider) {
+			return false
+		}
+	}
+	return len(m.routes) > 0
+}
+
+// IsTransientError returns true if any provider classifies the error as transient
+func (m *MultiProvider) IsTransientError(err error) bool {
+	for _, route := range m.routes {
+		if classifier, ok := route.Provider.(TransientErrorClassifier); ok && classifier.IsTransientError(err) {
+			return true
+		}
+	}
+	return defaultTransientErrorClassifier{}.IsTransientError(err)
+}
+
+// route returns the index of the route of the endpoint, false if its provider is unknown or no domain filter matches
+func (m *MultiProvider) route(ep *endpoint.Endpoint) (int, bool) {
+	if name, ok := ep.Labels[endpoint.ProviderLabelKey]; ok {
+		for i, route := range m.routes {
+			if route.Name == name {
+				return i, true
+			}
+		}
+		return -1, false
+	}
+	best, bestLength := -1, -1
+	for i, route := range m.routes {
+		if length := route.DomainFilter.matchLength(ep.DNSName); length > bestLength {
+			best, bestLength = i, length
+		}
+	}
+	return best, best >= 0
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+var _ Provider = &MultiProvider{}
+var _ EndpointsAdjuster = &MultiProvider{}
+var _ TransientErrorClassifier = &MultiProvider{}
+var _ GeoLocationSupporter = &MultiProvider{}
+
+func newTestMultiProvider() (*MultiProvider, *InMemoryProvider, *InMemoryProvider) {
+	public := NewInMemoryProvider(InMemoryInitZones([]string{"example.org"}))
+	private := NewInMemoryProvider(InMemoryInitZones([]string{"internal.example.org"}))
+	return NewMultiProvider([]ProviderRoute{
+		{Name: "public", Provider: public, DomainFilter: NewDomainFilter([]string{"example.org"})},
+		{Name: "private", Provider: private, DomainFilter: NewDomainFilter([]string{"internal.example.org"})},
+	}), public, private
+}
+
+func TestMultiProviderRoutesChanges(t *testing.T) {
+	m, public, private := newTestMultiProvider()
+
+	require.NoError(t, m.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("www.example.org", "1.2.3.4", endpoint.RecordTypeA),
+			endpoint.NewEndpoint("db.internal.example.org", "10.0.0.1", endpoint.RecordTypeA),
+			endpoint.NewEndpoint("www.example.com", "1.2.3.4", endpoint.RecordTypeA),
+		},
+	}))
+
+	publicRecords, err := public.Records()
+	require.NoError(t, err)
+	require.Len(t, publicRecords, 1)
+	assert.Equal(t, "www.example.org", publicRecords[0].DNSName)
+	privateRecords, err := private.Records()
+	require.NoError(t, err)
+	require.Len(t, privateRecords, 1)
+	assert.Equal(t, "db.internal.example.org", privateRecords[0].DNSName)
+
+	records, err := m.Records()
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	require.NoError(t, m.ApplyChanges(&plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpoint("db.internal.example.org", "10.0.0.1", endpoint.RecordTypeA)},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpoint("db.internal.example.org", "10.0.0.2", endpoint.RecordTypeA)},
+		Delete:    []*endpoint.Endpoint{endpoint.NewEndpoint("www.example.org", "1.2.3.4", endpoint.RecordTypeA)},
+	}))
+	publicRecords, err = public.Records()
+	require.NoError(t, err)
+	assert.Empty(t, publicRecords)
+	privateRecords, err = private.Records()
+	require.NoError(t, err)
+	require.Len(t, privateRecords, 1)
+	assert.Equal(t, endpoint.Targets{"10.0.0.2"}, privateRecords[0].Targets)
+}
+
+func TestMultiProviderAppliesChangesOfAllProviders(t *testing.T) {
+	m, public, _ := newTestMultiProvider()
+
+	// creating an existing record fails with the private provider
+	db := endpoint.NewEndpoint("db.internal.example.org", "10.0.0.1", endpoint.RecordTypeA)
+	require.NoError(t, m.ApplyChanges(&plan.Changes{Create: []*endpoint.Endpoint{db}}))
+	err := m.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{db, endpoint.NewEndpoint("www.example.org", "1.2.3.4", endpoint.RecordTypeA)},
+	})
+	assert.Error(t, err)
+
+	publicRecords, err := public.Records()
+	require.NoError(t, err)
+	assert.Len(t, publicRecords, 1)
+}
+
+func TestMultiProviderRecordsFail(t *testing.T) {
+	m := NewMultiProvider([]ProviderRoute{{Name: "failing", Provider: &failingProvider{errs: []error{timeoutError{}}}}})
+	_, err := m.Records()
+	assert.Error(t, err)
+	assert.True(t, m.IsTransientError(err))
+}
+
+func TestMultiProviderRoutesByProviderLabel(t *testing.T) {
+	m, public, private := newTestMultiProvider()
+
+	labeled := endpoint.NewEndpoint("api.internal.example.org", "10.0.0.1", endpoint.RecordTypeA)
+	labeled.Labels[endpoint.ProviderLabelKey] = "public"
+	unknown := endpoint.NewEndpoint("www.example.org", "1.2.3.4", endpoint.RecordTypeA)
+	unknown.Labels[endpoint.ProviderLabelKey] = "cloudflare"
+	require.NoError(t, m.ApplyChanges(&plan.Changes{Create: []*endpoint.Endpoint{labeled, unknown}}))
+
+	privateRecords, err := private.Records()
+	require.NoError(t, err)
+	assert.Empty(t, privateRecords)
+	publicRecords, err := public.Records()
+	require.NoError(t, err)
+	require.Len(t, publicRecords, 1)
+	assert.Equal(t, "api.internal.example.org", publicRecords[0].DNSName)
+
+	// the records are labeled with their provider, so that their changes go back to it
+	records, err := m.Records()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "public", records[0].Labels[endpoint.ProviderLabelKey])
+
+	update := endpoint.NewEndpoint("api.internal.example.org", "10.0.0.2", endpoint.RecordTypeA)
+	require.NoError(t, m.ApplyChanges(&plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{records[0]},
+		UpdateNew: []*endpoint.Endpoint{update},
+	}))
+	publicRecords, err = public.Records()
+	require.NoError(t, err)
+	require.Len(t, publicRecords, 1)
+	assert.Equal(t, endpoint.Targets{"10.0.0.2"}, publicRecords[0].Targets)
+
+	records, err = m.Records()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.NoError(t, m.ApplyChanges(&plan.Changes{Delete: []*endpoint.Endpoint{records[0]}}))
+	publicRecords, err = public.Records()
+	require.NoError(t, err)
+	assert.Empty(t, publicRecords)
+}
+
+func TestMultiProviderLabelsEndpoints(t *testing.T) {
+	m, _, _ := newTestMultiProvider()
+
+	labeled := endpoint.NewEndpoint("api.internal.example.org", "10.0.0.1", endpoint.RecordTypeA)
+	labeled.Labels[endpoint.ProviderLabelKey] = "public"
+	endpoints, err := m.AdjustEndpoints([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("www.example.org", "1.2.3.4", endpoint.RecordTypeA),
+		endpoint.NewEndpoint("db.internal.example.org", "10.0.0.2", endpoint.RecordTypeA),
+		endpoint.NewEndpoint("www.example.com", "1.2.3.4", endpoint.RecordTypeA),
+		labeled,
+	})
+	require.NoError(t, err)
+
+	providers := map[string]string{}
+	for _, ep := range endpoints {
+		providers[ep.DNSName] = ep.Labels[endpoint.ProviderLabelKey]
+	}
+	assert.Equal(t, map[string]string{
+		"www.example.org":          "public",
+		"db.internal.example.org":  "private",
+		"www.example.com":          "",
+		"api.internal.example.org": "public",
+	}, providers)
+}
+
+func TestMultiProviderSupportsGeoLocations(t *testing.T) {
+	m, _, _ := newTestMultiProvider()
+	assert.True(t, SupportsGeoLocations(m))
+	assert.True(t, SupportsGeoLocations(NewRetryProvider(m, 0, 0)))
+
+	// the endpoints aren't merged if any provider doesn't support geo locations
+	m = NewMultiProvider([]ProviderRoute{
+		{Name: "public", Provider: NewInMemoryProvider(), DomainFilter: NewDomainFilter([]string{"example.org"})},
+		{Name: "private", Provider: struct{ Provider }{NewInMemoryProvider()}, DomainFilter: NewDomainFilter([]string{"internal.example.org"})},
+	})
+	assert.False(t, SupportsGeoLocations(m))
+}
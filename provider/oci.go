@@ -0,0 +1,332 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/common/auth"
+	"github.com/oracle/oci-go-sdk/dns"
+	log "github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+const (
+	// ociDefaultTTL is used for records which don't define a TTL, OCI DNS requires one to be set
+	ociDefaultTTL = 300
+)
+
+// OCIAuthConfig holds the credentials of an OCI API user.
+// They are not needed when authenticating as instance principal.
+type OCIAuthConfig struct {
+	Region      string `yaml:"region"`
+	TenancyID   string `yaml:"tenancy"`
+	UserID      string `yaml:"user"`
+	PrivateKey  string `yaml:"key"`
+	Fingerprint string `yaml:"fingerprint"`
+	Passphrase  string `yaml:"passphrase"`
+}
+
+// OCIConfig holds the configuration of the OCI DNS provider.
+type OCIConfig struct {
+	Auth OCIAuthConfig `yaml:"auth"`
+	// CompartmentID is the OCID of the compartment holding the zones to manage
+	CompartmentID string `yaml:"compartment"`
+	// UseInstancePrincipal authenticates as the compute instance ExternalDNS runs on, e.g. a node of an OKE cluster,
+	// instead of using the credentials in Auth
+	UseInstancePrincipal bool `yaml:"useInstancePrincipal"`
+}
+
+// ociDNSClient is the subset of the OCI DNS API that we actually use.
+type ociDNSClient interface {
+	ListZones(ctx context.Context, request dns.ListZonesRequest) (dns.ListZonesResponse, error)
+	GetZoneRecords(ctx context.Context, request dns.GetZoneRecordsRequest) (dns.GetZoneRecordsResponse, error)
+	PatchZoneRecords(ctx context.Context, request dns.PatchZoneRecordsRequest) (dns.PatchZoneRecordsResponse, error)
+}
+
+// OCIProvider is an implementation of Provider for Oracle Cloud Infrastructure (OCI) DNS.
+type OCIProvider struct {
+	client ociDNSClient
+	// only consider zones of this compartment
+	compartmentID string
+	// only consider hosted zones managing domains ending in this suffix
+	domainFilter DomainFilter
+	// filter zones by their OCID
+	zoneIDFilter ZoneIDFilter
+	dryRun       bool
+}
+
+// ociZone is a zone of the configured compartment.
+type ociZone struct {
+	ID   string
+	Name string
+}
+
+// LoadOCIConfig reads and parses the OCI provider configuration file.
+func LoadOCIConfig(path string) (*OCIConfig, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OCI config file '%s': %v", path, err)
+	}
+
+	cfg := OCIConfig{}
+	if err := yaml.Unmarshal(contents, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse OCI config file '%s': %v", path, err)
+	}
+	return &cfg, nil
+}
+
+// NewOCIProvider initializes a new OCI DNS based Provider.
+func NewOCIProvider(cfg OCIConfig, domainFilter DomainFilter, zoneIDFilter ZoneIDFilter, dryRun bool) (*OCIProvider, error) {
+	if cfg.CompartmentID == "" {
+		return nil, fmt.Errorf("no OCI compartment specified")
+	}
+
+	var configProvider common.ConfigurationProvider
+	if cfg.UseInstancePrincipal {
+		var err error
+		configProvider, err = auth.InstancePrincipalConfigurationProvider()
+		if err != nil {
+			return nil, fmt.Errorf("failed to authenticate as OCI instance principal: %v", err)
+		}
+	} else {
+		var passphrase *string
+		if cfg.Auth.Passphrase != "" {
+			passphrase = common.String(cfg.Auth.Passphrase)
+		}
+		configProvider = common.NewRawConfigurationProvider(
+			cfg.Auth.TenancyID,
+			cfg.Auth.UserID,
+			cfg.Auth.Region,
+			cfg.Auth.Fingerprint,
+			cfg.Auth.PrivateKey,
+			passphrase,
+		)
+	}
+
+	client, err := dns.NewDnsClientWithConfigurationProvider(configProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OCI DNS client: %v", err)
+	}
+	if cfg.Auth.Region != "" {
+		client.SetRegion(cfg.Auth.Region)
+	}
+
+	provider := &OCIProvider{
+		client:        client,
+		compartmentID: cfg.CompartmentID,
+		domainFilter:  domainFilter,
+		zoneIDFilter:  zoneIDFilter,
+		dryRun:        dryRun,
+	}
+	return provider, nil
+}
+
+// zones returns the primary zones of the compartment which pass the filters, keyed by their name.
+func (p *OCIProvider) zones(ctx context.Context) (map[string]ociZone, error) {
+	zones := map[string]ociZone{}
+
+	var page *string
+	for {
+		resp, err := p.client.ListZones(ctx, dns.ListZonesRequest{
+			CompartmentId: common.String(p.compartmentID),
+			ZoneType:      dns.ListZonesZoneTypePrimary,
+			Page:          page,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, zone := range resp.Items {
+			name := strings.TrimSuffix(*zone.Name, ".")
+			if !p.domainFilter.Match(name) || !p.zoneIDFilter.Match(*zone.Id) {
+				continue
+			}
+			zones[name] = ociZone{ID: *zone.Id, Name: name}
+			log.Debugf("Considering zone: %s (%s)", name, *zone.Id)
+		}
+
+		if resp.OpcNextPage == nil {
+			break
+		}
+		page = resp.OpcNextPage
+	}
+
+	return zones, nil
+}
+
+// Records returns the list of records in all relevant zones.
+func (p *OCIProvider) Records() ([]*endpoint.Endpoint, error) {
+	ctx := context.Background()
+
+	zones, err := p.zones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := []*endpoint.Endpoint{}
+	for _, zone := range zones {
+		records, err := p.zoneRecords(ctx, zone)
+		if err != nil {
+			return nil, err
+		}
+
+		// OCI returns one record per rdata, records of the same name and type are merged into one endpoint
+		byNameAndType := map[string]*endpoint.Endpoint{}
+		for _, record := range records {
+			if !supportedRecordType(*record.Rtype) {
+				continue
+			}
+			name := strings.TrimSuffix(*record.Domain, ".")
+			target := strings.TrimSuffix(*record.Rdata, ".")
+
+			key := name + "/" + *record.Rtype
+			if ep, ok := byNameAndType[key]; ok {
+				ep.Targets = append(ep.Targets, target)
+				continue
+			}
+
+			var ttl endpoint.TTL
+			if record.Ttl != nil {
+				ttl = endpoint.TTL(*record.Ttl)
+			}
+			ep := endpoint.NewEndpointWithTTL(name, target, *record.Rtype, ttl)
+			byNameAndType[key] = ep
+			endpoints = append(endpoints, ep)
+		}
+	}
+
+	return endpoints, nil
+}
+
+// zoneRecords returns all records of the zone.
+func (p *OCIProvider) zoneRecords(ctx context.Context, zone ociZone) ([]dns.Record, error) {
+	var records []dns.Record
+
+	var page *string
+	for {
+		resp, err := p.client.GetZoneRecords(ctx, dns.GetZoneRecordsRequest{
+			ZoneNameOrId:  common.String(zone.ID),
+			CompartmentId: common.String(p.compartmentID),
+			Page:          page,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, resp.Items...)
+
+		if resp.OpcNextPage == nil {
+			break
+		}
+		page = resp.OpcNextPage
+	}
+
+	return records, nil
+}
+
+// ApplyChanges applies a given set of changes in all relevant zones.
+// All changes of a zone are sent in a single patch, which OCI applies atomically.
+func (p *OCIProvider) ApplyChanges(changes *plan.Changes) error {
+	ctx := context.Background()
+
+	ops := []dns.RecordOperation{}
+	ops = append(ops, newOCIRecordOperations(changes.Create, dns.RecordOperationOperationAdd)...)
+	ops = append(ops, newOCIRecordOperations(changes.UpdateOld, dns.RecordOperationOperationRemove)...)
+	ops = append(ops, newOCIRecordOperations(changes.UpdateNew, dns.RecordOperationOperationAdd)...)
+	ops = append(ops, newOCIRecordOperations(changes.Delete, dns.RecordOperationOperationRemove)...)
+
+	if len(ops) == 0 {
+		log.Info("All records are already up to date")
+		return nil
+	}
+
+	zones, err := p.zones(ctx)
+	if err != nil {
+		return err
+	}
+	zoneNameIDMapper := zoneIDName{}
+	for _, zone := range zones {
+		zoneNameIDMapper.Add(zone.ID, zone.Name)
+	}
+
+	opsByZone := map[string][]dns.RecordOperation{}
+	for _, op := range ops {
+		zoneID, _ := zoneNameIDMapper.FindZone(*op.Domain)
+		if zoneID == "" {
+			log.Debugf("Skipping record %s because no hosted zone matching record DNS Name was detected", *op.Domain)
+			continue
+		}
+		opsByZone[zoneID] = append(opsByZone[zoneID], op)
+	}
+
+	for zoneID, zoneOps := range opsByZone {
+		for _, op := range zoneOps {
+			log.Infof("Desired change: %s %s %s %s", op.Operation, *op.Domain, *op.Rtype, *op.Rdata)
+		}
+
+		if p.dryRun {
+			continue
+		}
+
+		_, err := p.client.PatchZoneRecords(ctx, dns.PatchZoneRecordsRequest{
+			ZoneNameOrId:  common.String(zoneID),
+			CompartmentId: common.String(p.compartmentID),
+			PatchZoneRecordsDetails: dns.PatchZoneRecordsDetails{
+				Items: zoneOps,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to patch records of zone %s: %v", zoneID, err)
+		}
+		log.Infof("Records in zone %s were successfully updated", zoneID)
+	}
+
+	return nil
+}
+
+// newOCIRecordOperations returns one operation for every target of the given endpoints.
+func newOCIRecordOperations(endpoints []*endpoint.Endpoint, operation dns.RecordOperationOperationEnum) []dns.RecordOperation {
+	ops := []dns.RecordOperation{}
+	for _, ep := range endpoints {
+		ttl := ociDefaultTTL
+		if ep.RecordTTL.IsConfigured() {
+			ttl = int(ep.RecordTTL)
+		}
+		for _, target := range ep.Targets {
+			// OCI stores the targets of CNAME records fully qualified
+			if ep.RecordType == endpoint.RecordTypeCNAME {
+				target = ensureTrailingDot(target)
+			}
+			ops = append(ops, dns.RecordOperation{
+				Domain:    common.String(ep.DNSName),
+				Rdata:     common.String(target),
+				Rtype:     common.String(ep.RecordType),
+				Ttl:       common.Int(ttl),
+				Operation: operation,
+			})
+		}
+	}
+	return ops
+}
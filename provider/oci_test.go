@@ -0,0 +1,239 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+const ociTestCompartment = "ocid1.compartment.oc1..test"
+
+// ociMockClient serves zones and records from memory, returning a single item per page to exercise pagination.
+type ociMockClient struct {
+	zones   []dns.ZoneSummary
+	records map[string][]dns.Record
+	patches []dns.PatchZoneRecordsRequest
+}
+
+func newOCIMockClient() *ociMockClient {
+	return &ociMockClient{
+		zones: []dns.ZoneSummary{
+			{Id: common.String("ocid1.dns-zone.oc1..foo"), Name: common.String("foo.com"), CompartmentId: common.String(ociTestCompartment)},
+			{Id: common.String("ocid1.dns-zone.oc1..bar"), Name: common.String("bar.com"), CompartmentId: common.String(ociTestCompartment)},
+			{Id: common.String("ocid1.dns-zone.oc1..other"), Name: common.String("other.com"), CompartmentId: common.String("ocid1.compartment.oc1..other")},
+		},
+		records: map[string][]dns.Record{
+			"ocid1.dns-zone.oc1..foo": {
+				{Domain: common.String("foo.com"), Rtype: common.String("NS"), Rdata: common.String("ns1.p68.dns.oraclecloud.net."), Ttl: common.Int(86400)},
+				{Domain: common.String("www.foo.com"), Rtype: common.String(endpoint.RecordTypeA), Rdata: common.String("1.2.3.4"), Ttl: common.Int(300)},
+				{Domain: common.String("www.foo.com"), Rtype: common.String(endpoint.RecordTypeA), Rdata: common.String("5.6.7.8"), Ttl: common.Int(300)},
+				{Domain: common.String("www.foo.com"), Rtype: common.String(endpoint.RecordTypeTXT), Rdata: common.String("\"heritage=external-dns,external-dns/owner=default\""), Ttl: common.Int(300)},
+			},
+			"ocid1.dns-zone.oc1..bar": {
+				{Domain: common.String("api.bar.com"), Rtype: common.String(endpoint.RecordTypeCNAME), Rdata: common.String("lb.example.com."), Ttl: common.Int(60)},
+			},
+		},
+	}
+}
+
+// page returns the item at the given page token and the token of the next page
+func ociPage(page *string, count int) (int, *string) {
+	i := 0
+	if page != nil {
+		i, _ = strconv.Atoi(*page)
+	}
+	if i+1 < count {
+		return i, common.String(strconv.Itoa(i + 1))
+	}
+	return i, nil
+}
+
+func (c *ociMockClient) ListZones(ctx context.Context, request dns.ListZonesRequest) (dns.ListZonesResponse, error) {
+	var zones []dns.ZoneSummary
+	for _, zone := range c.zones {
+		if *zone.CompartmentId == *request.CompartmentId {
+			zones = append(zones, zone)
+		}
+	}
+	if len(zones) == 0 {
+		return dns.ListZonesResponse{}, nil
+	}
+	i, next := ociPage(request.Page, len(zones))
+	return dns.ListZonesResponse{Items: zones[i : i+1], OpcNextPage: next}, nil
+}
+
+func (c *ociMockClient) GetZoneRecords(ctx context.Context, request dns.GetZoneRecordsRequest) (dns.GetZoneRecordsResponse, error) {
+	records, ok := c.records[*request.ZoneNameOrId]
+	if !ok {
+		return dns.GetZoneRecordsResponse{}, fmt.Errorf("zone %s not found", *request.ZoneNameOrId)
+	}
+	if len(records) == 0 {
+		return dns.GetZoneRecordsResponse{}, nil
+	}
+	i, next := ociPage(request.Page, len(records))
+	resp := dns.GetZoneRecordsResponse{OpcNextPage: next}
+	resp.Items = records[i : i+1]
+	return resp, nil
+}
+
+func (c *ociMockClient) PatchZoneRecords(ctx context.Context, request dns.PatchZoneRecordsRequest) (dns.PatchZoneRecordsResponse, error) {
+	c.patches = append(c.patches, request)
+
+	zoneID := *request.ZoneNameOrId
+	records := c.records[zoneID]
+	for _, op := range request.Items {
+		switch op.Operation {
+		case dns.RecordOperationOperationAdd:
+			records = append(records, dns.Record{Domain: op.Domain, Rtype: op.Rtype, Rdata: op.Rdata, Ttl: op.Ttl})
+		case dns.RecordOperationOperationRemove:
+			found := false
+			for i, record := range records {
+				if *record.Domain == *op.Domain && *record.Rtype == *op.Rtype && *record.Rdata == *op.Rdata {
+					records = append(records[:i], records[i+1:]...)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return dns.PatchZoneRecordsResponse{}, fmt.Errorf("record %s %s %s not found", *op.Domain, *op.Rtype, *op.Rdata)
+			}
+		}
+	}
+	c.records[zoneID] = records
+	return dns.PatchZoneRecordsResponse{}, nil
+}
+
+func newOCITestProvider(client ociDNSClient, domainFilter DomainFilter, zoneIDFilter ZoneIDFilter, dryRun bool) *OCIProvider {
+	return &OCIProvider{
+		client:        client,
+		compartmentID: ociTestCompartment,
+		domainFilter:  domainFilter,
+		zoneIDFilter:  zoneIDFilter,
+		dryRun:        dryRun,
+	}
+}
+
+func TestNewOCIProviderRequiresCompartment(t *testing.T) {
+	_, err := NewOCIProvider(OCIConfig{UseInstancePrincipal: true}, NewDomainFilter(nil), NewZoneIDFilter(nil), false)
+	assert.Error(t, err)
+}
+
+func TestOCIZones(t *testing.T) {
+	for _, tc := range []struct {
+		title        string
+		domainFilter DomainFilter
+		zoneIDFilter ZoneIDFilter
+		expected     []string
+	}{
+		{"all zones of the compartment", NewDomainFilter(nil), NewZoneIDFilter(nil), []string{"bar.com", "foo.com"}},
+		{"domain filter", NewDomainFilter([]string{"foo.com"}), NewZoneIDFilter(nil), []string{"foo.com"}},
+		{"zone id filter", NewDomainFilter(nil), NewZoneIDFilter([]string{"ocid1.dns-zone.oc1..bar"}), []string{"bar.com"}},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			provider := newOCITestProvider(newOCIMockClient(), tc.domainFilter, tc.zoneIDFilter, false)
+			zones, err := provider.zones(context.Background())
+			require.NoError(t, err)
+
+			names := []string{}
+			for name := range zones {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			assert.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+func TestOCIRecords(t *testing.T) {
+	provider := newOCITestProvider(newOCIMockClient(), NewDomainFilter(nil), NewZoneIDFilter(nil), false)
+
+	records, err := provider.Records()
+	require.NoError(t, err)
+
+	validateEndpoints(t, records, []*endpoint.Endpoint{
+		{DNSName: "www.foo.com", Targets: endpoint.Targets{"1.2.3.4", "5.6.7.8"}, RecordType: endpoint.RecordTypeA, RecordTTL: 300},
+		endpoint.NewEndpointWithTTL("www.foo.com", "\"heritage=external-dns,external-dns/owner=default\"", endpoint.RecordTypeTXT, 300),
+		endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 60),
+	})
+}
+
+func TestOCIApplyChanges(t *testing.T) {
+	client := newOCIMockClient()
+	provider := newOCITestProvider(client, NewDomainFilter(nil), NewZoneIDFilter(nil), false)
+
+	err := provider.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("new.foo.com", "8.8.8.8", endpoint.RecordTypeA),
+			endpoint.NewEndpoint("new.unknown.com", "8.8.8.8", endpoint.RecordTypeA),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			{DNSName: "www.foo.com", Targets: endpoint.Targets{"1.2.3.4", "5.6.7.8"}, RecordType: endpoint.RecordTypeA, RecordTTL: 300},
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			{DNSName: "www.foo.com", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA, RecordTTL: 60},
+		},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 60),
+		},
+	})
+	require.NoError(t, err)
+
+	// one patch per zone
+	assert.Len(t, client.patches, 2)
+
+	records, err := provider.Records()
+	require.NoError(t, err)
+
+	validateEndpoints(t, records, []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("new.foo.com", "8.8.8.8", endpoint.RecordTypeA, ociDefaultTTL),
+		endpoint.NewEndpointWithTTL("www.foo.com", "1.2.3.4", endpoint.RecordTypeA, 60),
+		endpoint.NewEndpointWithTTL("www.foo.com", "\"heritage=external-dns,external-dns/owner=default\"", endpoint.RecordTypeTXT, 300),
+	})
+}
+
+func TestOCIApplyChangesDryRun(t *testing.T) {
+	client := newOCIMockClient()
+	provider := newOCITestProvider(client, NewDomainFilter(nil), NewZoneIDFilter(nil), true)
+
+	err := provider.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("new.foo.com", "8.8.8.8", endpoint.RecordTypeA)},
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 60)},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, client.patches)
+}
+
+func TestOCIApplyChangesFailure(t *testing.T) {
+	provider := newOCITestProvider(newOCIMockClient(), NewDomainFilter(nil), NewZoneIDFilter(nil), false)
+
+	err := provider.ApplyChanges(&plan.Changes{
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpoint("missing.foo.com", "8.8.8.8", endpoint.RecordTypeA)},
+	})
+	assert.Error(t, err)
+}
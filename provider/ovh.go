@@ -0,0 +1,316 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/ovh/go-ovh/ovh"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+const (
+	ovhDefaultTTL = 0 // zero lets OVH apply the zone's default TTL
+	ovhCreate     = "CREATE"
+	ovhDelete     = "DELETE"
+)
+
+// ovhClient is the subset of the OVH API client that we actually use.
+type ovhClient interface {
+	Get(url string, resType interface{}) error
+	Post(url string, reqBody, resType interface{}) error
+	Delete(url string, resType interface{}) error
+}
+
+// OVHProvider is an implementation of Provider for OVH DNS.
+type OVHProvider struct {
+	client ovhClient
+	// only consider hosted zones managing domains ending in this suffix
+	domainFilter DomainFilter
+	dryRun       bool
+}
+
+// ovhRecordFields holds the writable attributes of an OVH zone record.
+type ovhRecordFields struct {
+	FieldType string `json:"fieldType"`
+	SubDomain string `json:"subDomain"`
+	Target    string `json:"target"`
+	TTL       int64  `json:"ttl"`
+}
+
+// ovhRecord is a record of an OVH zone as returned by the API.
+type ovhRecord struct {
+	ovhRecordFields
+	ID   uint64 `json:"id"`
+	Zone string `json:"zone"`
+}
+
+// ovhChange is a single record operation within a zone.
+type ovhChange struct {
+	Action string
+	ovhRecord
+}
+
+// NewOVHProvider initializes a new OVH DNS based Provider.
+// Credentials are read from the OVH_APPLICATION_KEY, OVH_APPLICATION_SECRET and OVH_CONSUMER_KEY environment variables.
+func NewOVHProvider(domainFilter DomainFilter, endpoint string, dryRun bool) (*OVHProvider, error) {
+	client, err := ovh.NewEndpointClient(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := &OVHProvider{
+		client:       client,
+		domainFilter: domainFilter,
+		dryRun:       dryRun,
+	}
+	return provider, nil
+}
+
+// Zones returns the names of the zones managed by this provider.
+func (p *OVHProvider) Zones() ([]string, error) {
+	var zoneNames []string
+	if err := p.client.Get("/domain/zone", &zoneNames); err != nil {
+		return nil, err
+	}
+
+	zones := []string{}
+	for _, zoneName := range zoneNames {
+		if p.domainFilter.Match(zoneName) {
+			zones = append(zones, zoneName)
+		}
+	}
+	return zones, nil
+}
+
+// Records returns the list of records in all relevant zones.
+// Records sharing the same name and type are grouped into a single endpoint with multiple targets.
+func (p *OVHProvider) Records() ([]*endpoint.Endpoint, error) {
+	zones, err := p.Zones()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := []*endpoint.Endpoint{}
+	for _, zone := range zones {
+		records, err := p.records(zone)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, ovhGroupByNameAndType(records)...)
+	}
+	return endpoints, nil
+}
+
+// records fetches all supported records of a zone. OVH only lists record IDs, so every record is fetched on its own.
+func (p *OVHProvider) records(zone string) ([]ovhRecord, error) {
+	var recordIDs []uint64
+	if err := p.client.Get(fmt.Sprintf("/domain/zone/%s/record", url.PathEscape(zone)), &recordIDs); err != nil {
+		return nil, err
+	}
+
+	records := []ovhRecord{}
+	for _, id := range recordIDs {
+		var record ovhRecord
+		if err := p.client.Get(fmt.Sprintf("/domain/zone/%s/record/%d", url.PathEscape(zone), id), &record); err != nil {
+			return nil, err
+		}
+		if !supportedRecordType(record.FieldType) {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// ApplyChanges applies a given set of changes and refreshes every zone that was modified,
+// since OVH only serves changes to a zone after it has been refreshed.
+func (p *OVHProvider) ApplyChanges(changes *plan.Changes) error {
+	if len(changes.Create)+len(changes.UpdateNew)+len(changes.Delete) == 0 {
+		return nil
+	}
+
+	zones, err := p.Zones()
+	if err != nil {
+		return err
+	}
+	zoneNameIDMapper := zoneIDName{}
+	for _, zone := range zones {
+		zoneNameIDMapper.Add(zone, zone)
+	}
+
+	// records of a zone are only needed when removing existing records
+	existing := map[string][]ovhRecord{}
+	existingRecords := func(zone string) ([]ovhRecord, error) {
+		if records, ok := existing[zone]; ok {
+			return records, nil
+		}
+		records, err := p.records(zone)
+		if err != nil {
+			return nil, err
+		}
+		existing[zone] = records
+		return records, nil
+	}
+
+	changesByZone := map[string][]ovhChange{}
+	addChanges := func(action string, endpoints []*endpoint.Endpoint) error {
+		for _, ep := range endpoints {
+			zone, _ := zoneNameIDMapper.FindZone(ep.DNSName)
+			if zone == "" {
+				log.Debugf("Skipping record %s because no hosted zone matching record DNS Name was detected", ep.DNSName)
+				continue
+			}
+			subDomain := ovhSubDomain(ep.DNSName, zone)
+
+			if action == ovhDelete {
+				records, err := existingRecords(zone)
+				if err != nil {
+					return err
+				}
+				for _, record := range records {
+					if record.SubDomain == subDomain && record.FieldType == ep.RecordType {
+						changesByZone[zone] = append(changesByZone[zone], ovhChange{Action: ovhDelete, ovhRecord: record})
+					}
+				}
+				continue
+			}
+
+			ttl := int64(ovhDefaultTTL)
+			if ep.RecordTTL.IsConfigured() {
+				ttl = int64(ep.RecordTTL)
+			}
+			for _, target := range ep.Targets {
+				changesByZone[zone] = append(changesByZone[zone], ovhChange{
+					Action: ovhCreate,
+					ovhRecord: ovhRecord{
+						Zone: zone,
+						ovhRecordFields: ovhRecordFields{
+							FieldType: ep.RecordType,
+							SubDomain: subDomain,
+							Target:    target,
+							TTL:       ttl,
+						},
+					},
+				})
+			}
+		}
+		return nil
+	}
+
+	// updates replace all records of the same name and type, so the old records are removed first
+	if err := addChanges(ovhDelete, changes.Delete); err != nil {
+		return err
+	}
+	if err := addChanges(ovhDelete, changes.UpdateOld); err != nil {
+		return err
+	}
+	if err := addChanges(ovhCreate, changes.UpdateNew); err != nil {
+		return err
+	}
+	if err := addChanges(ovhCreate, changes.Create); err != nil {
+		return err
+	}
+
+	zoneNames := make([]string, 0, len(changesByZone))
+	for zone := range changesByZone {
+		zoneNames = append(zoneNames, zone)
+	}
+	sort.Strings(zoneNames)
+
+	for _, zone := range zoneNames {
+		if err := p.submitChanges(zone, changesByZone[zone]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// submitChanges sends all changes of a zone to OVH and refreshes the zone afterwards.
+func (p *OVHProvider) submitChanges(zone string, changes []ovhChange) error {
+	for _, change := range changes {
+		log.WithFields(log.Fields{
+			"record": change.SubDomain,
+			"type":   change.FieldType,
+			"target": change.Target,
+			"action": change.Action,
+			"zone":   zone,
+		}).Info("Changing record.")
+	}
+
+	if p.dryRun {
+		return nil
+	}
+
+	for _, change := range changes {
+		var err error
+		switch change.Action {
+		case ovhCreate:
+			err = p.client.Post(fmt.Sprintf("/domain/zone/%s/record", url.PathEscape(zone)), change.ovhRecordFields, nil)
+		case ovhDelete:
+			err = p.client.Delete(fmt.Sprintf("/domain/zone/%s/record/%d", url.PathEscape(zone), change.ID), nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Infof("Refreshing zone %s", zone)
+	return p.client.Post(fmt.Sprintf("/domain/zone/%s/refresh", url.PathEscape(zone)), nil, nil)
+}
+
+// ovhGroupByNameAndType converts OVH records into endpoints, merging records of the same name and type.
+func ovhGroupByNameAndType(records []ovhRecord) []*endpoint.Endpoint {
+	endpoints := []*endpoint.Endpoint{}
+	byNameAndType := map[string]*endpoint.Endpoint{}
+
+	for _, record := range records {
+		name := record.Zone
+		if record.SubDomain != "" {
+			name = record.SubDomain + "." + record.Zone
+		}
+		// OVH returns TXT records with the quotes that were sent on creation
+		target := record.Target
+		if record.FieldType == endpoint.RecordTypeTXT {
+			target = strings.Trim(target, "\"")
+		}
+
+		key := name + "/" + record.FieldType
+		if ep, ok := byNameAndType[key]; ok {
+			ep.Targets = append(ep.Targets, target)
+			continue
+		}
+		ep := endpoint.NewEndpointWithTTL(name, target, record.FieldType, endpoint.TTL(record.TTL))
+		byNameAndType[key] = ep
+		endpoints = append(endpoints, ep)
+	}
+	return endpoints
+}
+
+// ovhSubDomain returns the part of a DNS name in front of the zone. The apex is represented by an empty string.
+func ovhSubDomain(dnsName, zone string) string {
+	if dnsName == zone {
+		return ""
+	}
+	return strings.TrimSuffix(dnsName, "."+zone)
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+type mockOVHClient struct {
+	responses map[string]interface{}
+	calls     []string
+}
+
+func newMockOVHClient() *mockOVHClient {
+	return &mockOVHClient{
+		responses: map[string]interface{}{
+			"/domain/zone":                    []string{"example.org", "example.net"},
+			"/domain/zone/example.org/record": []uint64{1, 2, 3, 4},
+			"/domain/zone/example.net/record": []uint64{5},
+			"/domain/zone/example.org/record/1": ovhRecord{ID: 1, Zone: "example.org", ovhRecordFields: ovhRecordFields{
+				FieldType: "A", SubDomain: "", Target: "203.0.113.42", TTL: 10,
+			}},
+			"/domain/zone/example.org/record/2": ovhRecord{ID: 2, Zone: "example.org", ovhRecordFields: ovhRecordFields{
+				FieldType: "A", SubDomain: "www", Target: "203.0.113.42",
+			}},
+			"/domain/zone/example.org/record/3": ovhRecord{ID: 3, Zone: "example.org", ovhRecordFields: ovhRecordFields{
+				FieldType: "A", SubDomain: "www", Target: "203.0.113.43",
+			}},
+			"/domain/zone/example.org/record/4": ovhRecord{ID: 4, Zone: "example.org", ovhRecordFields: ovhRecordFields{
+				FieldType: "NS", SubDomain: "", Target: "dns.ovh.net.",
+			}},
+			"/domain/zone/example.net/record/5": ovhRecord{ID: 5, Zone: "example.net", ovhRecordFields: ovhRecordFields{
+				FieldType: "TXT", SubDomain: "www", Target: "\"heritage=external-dns,external-dns/owner=default\"",
+			}},
+		},
+	}
+}
+
+func (c *mockOVHClient) Get(url string, resType interface{}) error {
+	response, ok := c.responses[url]
+	if !ok {
+		return fmt.Errorf("not found: %s", url)
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, resType)
+}
+
+func (c *mockOVHClient) Post(url string, reqBody, resType interface{}) error {
+	data, _ := json.Marshal(reqBody)
+	c.calls = append(c.calls, fmt.Sprintf("POST %s %s", url, data))
+	return nil
+}
+
+func (c *mockOVHClient) Delete(url string, resType interface{}) error {
+	c.calls = append(c.calls, fmt.Sprintf("DELETE %s", url))
+	return nil
+}
+
+func TestOVHZones(t *testing.T) {
+	provider := &OVHProvider{
+		client:       newMockOVHClient(),
+		domainFilter: NewDomainFilter([]string{"example.org"}),
+	}
+
+	zones, err := provider.Zones()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example.org"}, zones)
+}
+
+func TestOVHRecords(t *testing.T) {
+	provider := &OVHProvider{
+		client:       newMockOVHClient(),
+		domainFilter: NewDomainFilter([]string{""}),
+	}
+
+	endpoints, err := provider.Records()
+	require.NoError(t, err)
+
+	expected := []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("example.org", "203.0.113.42", endpoint.RecordTypeA, 10),
+		{DNSName: "www.example.org", Targets: endpoint.Targets{"203.0.113.42", "203.0.113.43"}, RecordType: endpoint.RecordTypeA, Labels: endpoint.NewLabels()},
+		endpoint.NewEndpoint("www.example.net", "heritage=external-dns,external-dns/owner=default", endpoint.RecordTypeTXT),
+	}
+	assert.Equal(t, expected, endpoints)
+}
+
+func TestOVHApplyChanges(t *testing.T) {
+	client := newMockOVHClient()
+	provider := &OVHProvider{
+		client:       client,
+		domainFilter: NewDomainFilter([]string{""}),
+	}
+
+	err := provider.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("new.example.net", "203.0.113.1", endpoint.RecordTypeA, 60),
+			endpoint.NewEndpoint("new.unknown.com", "203.0.113.1", endpoint.RecordTypeA),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			{DNSName: "www.example.org", Targets: endpoint.Targets{"203.0.113.42", "203.0.113.43"}, RecordType: endpoint.RecordTypeA},
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("www.example.org", "203.0.113.44", endpoint.RecordTypeA),
+		},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("example.org", "203.0.113.42", endpoint.RecordTypeA),
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`POST /domain/zone/example.net/record {"fieldType":"A","subDomain":"new","target":"203.0.113.1","ttl":60}`,
+		`POST /domain/zone/example.net/refresh null`,
+		`DELETE /domain/zone/example.org/record/1`,
+		`DELETE /domain/zone/example.org/record/2`,
+		`DELETE /domain/zone/example.org/record/3`,
+		`POST /domain/zone/example.org/record {"fieldType":"A","subDomain":"www","target":"203.0.113.44","ttl":0}`,
+		`POST /domain/zone/example.org/refresh null`,
+	}, client.calls)
+}
+
+func TestOVHApplyChangesDryRun(t *testing.T) {
+	client := newMockOVHClient()
+	provider := &OVHProvider{
+		client:       client,
+		domainFilter: NewDomainFilter([]string{""}),
+		dryRun:       true,
+	}
+
+	err := provider.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("new.example.net", "203.0.113.1", endpoint.RecordTypeA)},
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpoint("example.org", "203.0.113.42", endpoint.RecordTypeA)},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, client.calls)
+}
+
+func TestOVHApplyChangesNothingToDo(t *testing.T) {
+	client := newMockOVHClient()
+	provider := &OVHProvider{client: client}
+
+	require.NoError(t, provider.ApplyChanges(&plan.Changes{}))
+	assert.Empty(t, client.calls)
+}
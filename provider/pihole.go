@@ -0,0 +1,226 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+const (
+	piholeAPIPath = "/admin/api.php"
+
+	// piholeCustomDNS lists the local A and AAAA records, piholeCustomCNAME the local CNAME records
+	piholeCustomDNS   = "customdns"
+	piholeCustomCNAME = "customcname"
+)
+
+// PiholeProvider is an implementation of Provider for the local DNS records of a Pi-hole.
+// Pi-hole keeps them in a dnsmasq hosts file which only supports A, AAAA and CNAME records without TTLs,
+// it is therefore meant to be used with the noop registry.
+type PiholeProvider struct {
+	server   string
+	apiToken string
+	client   *http.Client
+	// only consider records ending in this suffix
+	domainFilter DomainFilter
+	dryRun       bool
+}
+
+// piholeResponse is the response of the Pi-hole API to all custom DNS requests.
+type piholeResponse struct {
+	// Data holds pairs of domain and IP address or CNAME target
+	Data    [][]string `json:"data"`
+	Success bool       `json:"success"`
+	Message string     `json:"message"`
+}
+
+// NewPiholeProvider initializes a new provider managing the local DNS records of the Pi-hole at the given URL.
+// The API token can be found in the API settings of the Pi-hole admin interface.
+func NewPiholeProvider(server, apiToken string, domainFilter DomainFilter, rateLimiter *RateLimiter, dryRun bool) (*PiholeProvider, error) {
+	if server == "" {
+		return nil, fmt.Errorf("no Pi-hole server specified")
+	}
+
+	p := &PiholeProvider{
+		server:       strings.TrimSuffix(server, "/"),
+		apiToken:     apiToken,
+		client:       rateLimiter.Client(&http.Client{Timeout: 30 * time.Second}),
+		domainFilter: domainFilter,
+		dryRun:       dryRun,
+	}
+	return p, nil
+}
+
+// Records returns the local DNS records of the Pi-hole.
+func (p *PiholeProvider) Records() ([]*endpoint.Endpoint, error) {
+	endpoints := []*endpoint.Endpoint{}
+	byNameAndType := map[string]*endpoint.Endpoint{}
+
+	for _, list := range []string{piholeCustomDNS, piholeCustomCNAME} {
+		resp, err := p.do(list, url.Values{"action": {"get"}})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range resp.Data {
+			if len(entry) != 2 {
+				continue
+			}
+			name, target := entry[0], entry[1]
+			if !p.domainFilter.Match(name) {
+				continue
+			}
+
+			recordType := piholeRecordType(list, target)
+			key := name + "/" + recordType
+			if ep, ok := byNameAndType[key]; ok {
+				ep.Targets = append(ep.Targets, target)
+				continue
+			}
+
+			ep := endpoint.NewEndpoint(name, target, recordType)
+			byNameAndType[key] = ep
+			endpoints = append(endpoints, ep)
+		}
+	}
+
+	return endpoints, nil
+}
+
+// AdjustEndpoints drops the TTLs of the desired endpoints, Pi-hole doesn't support them.
+func (p *PiholeProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	for _, ep := range endpoints {
+		ep.RecordTTL = 0
+	}
+	return endpoints, nil
+}
+
+// ApplyChanges applies a given set of changes to the local DNS records.
+// Obsolete records are deleted first since Pi-hole refuses to add a record for a name which already has one.
+func (p *PiholeProvider) ApplyChanges(changes *plan.Changes) error {
+	for _, endpoints := range [][]*endpoint.Endpoint{changes.Delete, changes.UpdateOld} {
+		for _, ep := range endpoints {
+			if err := p.apply("delete", ep); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, endpoints := range [][]*endpoint.Endpoint{changes.UpdateNew, changes.Create} {
+		for _, ep := range endpoints {
+			if err := p.apply("add", ep); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// apply adds or deletes a local record for every target of the endpoint.
+func (p *PiholeProvider) apply(action string, ep *endpoint.Endpoint) error {
+	var list, targetParam string
+	switch ep.RecordType {
+	case endpoint.RecordTypeA, endpoint.RecordTypeAAAA:
+		list, targetParam = piholeCustomDNS, "ip"
+	case endpoint.RecordTypeCNAME:
+		list, targetParam = piholeCustomCNAME, "target"
+	default:
+		log.Debugf("Skipping record %s because Pi-hole doesn't support records of type %s", ep.DNSName, ep.RecordType)
+		return nil
+	}
+
+	if !p.domainFilter.Match(ep.DNSName) {
+		log.Debugf("Skipping record %s because it was filtered out by the specified --domain-filter", ep.DNSName)
+		return nil
+	}
+
+	for _, target := range ep.Targets {
+		log.Infof("Changing record: %s %s %s %s", action, ep.DNSName, ep.RecordType, target)
+		if p.dryRun {
+			continue
+		}
+
+		params := url.Values{
+			"action":    {action},
+			"domain":    {ep.DNSName},
+			targetParam: {target},
+		}
+		if _, err := p.do(list, params); err != nil {
+			return fmt.Errorf("failed to %s record %s %s %s: %v", action, ep.DNSName, ep.RecordType, target, err)
+		}
+	}
+	return nil
+}
+
+// do sends a request to the given custom DNS list of the Pi-hole API.
+func (p *PiholeProvider) do(list string, params url.Values) (*piholeResponse, error) {
+	params.Set("auth", p.apiToken)
+	query := list + "&" + params.Encode()
+
+	resp, err := p.client.Get(p.server + piholeAPIPath + "?" + query)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Pi-hole API request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	// Pi-hole answers unauthenticated requests with an empty list instead of an error
+	if bytes.Equal(bytes.TrimSpace(data), []byte("[]")) {
+		return nil, fmt.Errorf("Pi-hole API rejected the request, check the API token")
+	}
+
+	result := &piholeResponse{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("failed to parse Pi-hole API response: %v", err)
+	}
+	if params.Get("action") != "get" && !result.Success {
+		return nil, fmt.Errorf("Pi-hole API request failed: %s", result.Message)
+	}
+	return result, nil
+}
+
+// piholeRecordType returns the type of a record of the given list.
+func piholeRecordType(list, target string) string {
+	if list == piholeCustomCNAME {
+		return endpoint.RecordTypeCNAME
+	}
+	if ip := net.ParseIP(target); ip != nil && ip.To4() == nil {
+		return endpoint.RecordTypeAAAA
+	}
+	return endpoint.RecordTypeA
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+var _ Provider = &PiholeProvider{}
+var _ EndpointsAdjuster = &PiholeProvider{}
+
+// piholeServer emulates the custom DNS API of a Pi-hole.
+type piholeServer struct {
+	token   string
+	lists   map[string][][]string
+	changes int
+}
+
+func newPiholeServer() *piholeServer {
+	return &piholeServer{
+		token: "secret",
+		lists: map[string][][]string{
+			piholeCustomDNS: {
+				{"nas.home.lan", "192.168.1.10"},
+				{"www.home.lan", "192.168.1.20"},
+				{"www.home.lan", "192.168.1.21"},
+				{"www.home.lan", "fd00::20"},
+				{"router.other.lan", "192.168.1.1"},
+			},
+			piholeCustomCNAME: {
+				{"files.home.lan", "nas.home.lan"},
+			},
+		},
+	}
+}
+
+func (s *piholeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if r.URL.Path != piholeAPIPath || query.Get("auth") != s.token {
+		w.Write([]byte("[]"))
+		return
+	}
+
+	list, targetParam := piholeCustomDNS, "ip"
+	if _, ok := query[piholeCustomCNAME]; ok {
+		list, targetParam = piholeCustomCNAME, "target"
+	}
+	entry := []string{query.Get("domain"), query.Get(targetParam)}
+
+	resp := piholeResponse{Success: true}
+	switch query.Get("action") {
+	case "get":
+		resp = piholeResponse{Data: s.lists[list]}
+	case "add":
+		s.changes++
+		for _, e := range s.lists[list] {
+			if e[0] == entry[0] && e[1] == entry[1] {
+				resp = piholeResponse{Success: false, Message: "This domain already has a custom DNS entry"}
+			}
+		}
+		if resp.Success {
+			s.lists[list] = append(s.lists[list], entry)
+		}
+	case "delete":
+		s.changes++
+		resp = piholeResponse{Success: false, Message: "This domain/ip association does not exist"}
+		for i, e := range s.lists[list] {
+			if e[0] == entry[0] && e[1] == entry[1] {
+				s.lists[list] = append(s.lists[list][:i], s.lists[list][i+1:]...)
+				resp = piholeResponse{Success: true}
+				break
+			}
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func newPiholeTestProvider(t *testing.T, s *piholeServer, domainFilter DomainFilter, dryRun bool) (*httptest.Server, *PiholeProvider) {
+	server := httptest.NewServer(s)
+	p, err := NewPiholeProvider(server.URL, "secret", domainFilter, nil, dryRun)
+	require.NoError(t, err)
+	return server, p
+}
+
+func TestNewPiholeProviderRequiresServer(t *testing.T) {
+	_, err := NewPiholeProvider("", "secret", NewDomainFilter(nil), nil, false)
+	assert.Error(t, err)
+}
+
+func TestPiholeRecords(t *testing.T) {
+	server, p := newPiholeTestProvider(t, newPiholeServer(), NewDomainFilter([]string{"home.lan"}), false)
+	defer server.Close()
+
+	records, err := p.Records()
+	require.NoError(t, err)
+
+	validateEndpoints(t, records, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("nas.home.lan", "192.168.1.10", endpoint.RecordTypeA),
+		{DNSName: "www.home.lan", Targets: endpoint.Targets{"192.168.1.20", "192.168.1.21"}, RecordType: endpoint.RecordTypeA},
+		endpoint.NewEndpoint("www.home.lan", "fd00::20", endpoint.RecordTypeAAAA),
+		endpoint.NewEndpoint("files.home.lan", "nas.home.lan", endpoint.RecordTypeCNAME),
+	})
+}
+
+func TestPiholeRecordsInvalidToken(t *testing.T) {
+	server, p := newPiholeTestProvider(t, newPiholeServer(), NewDomainFilter(nil), false)
+	defer server.Close()
+	p.apiToken = "wrong"
+
+	_, err := p.Records()
+	assert.Error(t, err)
+}
+
+func TestPiholeApplyChanges(t *testing.T) {
+	s := newPiholeServer()
+	server, p := newPiholeTestProvider(t, s, NewDomainFilter([]string{"home.lan"}), false)
+	defer server.Close()
+
+	err := p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("new.home.lan", "192.168.1.30", endpoint.RecordTypeA),
+			endpoint.NewEndpoint("new.home.lan", "heritage=external-dns", endpoint.RecordTypeTXT),
+			endpoint.NewEndpoint("new.other.lan", "192.168.1.30", endpoint.RecordTypeA),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("files.home.lan", "nas.home.lan", endpoint.RecordTypeCNAME),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("files.home.lan", "www.home.lan", endpoint.RecordTypeCNAME),
+		},
+		Delete: []*endpoint.Endpoint{
+			{DNSName: "www.home.lan", Targets: endpoint.Targets{"192.168.1.20", "192.168.1.21"}, RecordType: endpoint.RecordTypeA},
+		},
+	})
+	require.NoError(t, err)
+
+	records, err := p.Records()
+	require.NoError(t, err)
+
+	validateEndpoints(t, records, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("nas.home.lan", "192.168.1.10", endpoint.RecordTypeA),
+		endpoint.NewEndpoint("www.home.lan", "fd00::20", endpoint.RecordTypeAAAA),
+		endpoint.NewEndpoint("new.home.lan", "192.168.1.30", endpoint.RecordTypeA),
+		endpoint.NewEndpoint("files.home.lan", "www.home.lan", endpoint.RecordTypeCNAME),
+	})
+}
+
+func TestPiholeApplyChangesFailure(t *testing.T) {
+	server, p := newPiholeTestProvider(t, newPiholeServer(), NewDomainFilter(nil), false)
+	defer server.Close()
+
+	err := p.ApplyChanges(&plan.Changes{
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpoint("missing.home.lan", "192.168.1.99", endpoint.RecordTypeA)},
+	})
+	assert.Error(t, err)
+}
+
+func TestPiholeApplyChangesDryRun(t *testing.T) {
+	s := newPiholeServer()
+	server, p := newPiholeTestProvider(t, s, NewDomainFilter(nil), true)
+	defer server.Close()
+
+	err := p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("new.home.lan", "192.168.1.30", endpoint.RecordTypeA)},
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpoint("nas.home.lan", "192.168.1.10", endpoint.RecordTypeA)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, s.changes)
+}
+
+func TestPiholeAdjustEndpoints(t *testing.T) {
+	p, err := NewPiholeProvider("http://pi.hole", "secret", NewDomainFilter(nil), nil, false)
+	require.NoError(t, err)
+
+	endpoints, err := p.AdjustEndpoints([]*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("nas.home.lan", "192.168.1.10", endpoint.RecordTypeA, 300),
+	})
+	require.NoError(t, err)
+	assert.False(t, endpoints[0].RecordTTL.IsConfigured())
+}
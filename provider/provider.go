@@ -30,6 +30,29 @@ type Provider interface {
 	ApplyChanges(changes *plan.Changes) error
 }
 
+// EndpointsAdjuster is an optional interface for providers which need to modify the desired
+// endpoints before they are compared to the current records, e.g. to normalize targets or TTLs
+// in the same way the provider stores them.
+type EndpointsAdjuster interface {
+	AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error)
+}
+
+// GeoLocationSupporter is an optional interface for providers which support records restricted to geo locations,
+// so that the desired endpoints of a name with different geo locations are merged into a geo record set.
+type GeoLocationSupporter interface {
+	SupportsGeoLocations() bool
+}
+
+// SupportsGeoLocations returns true if the provider supports records restricted to geo locations
+func SupportsGeoLocations(p Provider) bool {
+	supporter, ok := p.(GeoLocationSupporter)
+	return ok && supporter.SupportsGeoLocations()
+}
+
+// ProviderManagedProperties are the provider specific properties which providers add to the records themselves,
+// e.g. the id of a health check created by the AWS provider, so the plan only compares them if they're desired.
+var ProviderManagedProperties = []string{providerSpecificHealthCheckID}
+
 // ensureTrailingDot ensures that the hostname receives a trailing dot if it hasn't already.
 func ensureTrailingDot(hostname string) string {
 	if net.ParseIP(hostname) != nil {
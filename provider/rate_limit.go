@@ -0,0 +1,186 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+var providerRateLimitWaitSeconds = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "external_dns",
+		Subsystem: "provider",
+		Name:      "rate_limit_wait_seconds_total",
+		Help:      "Time the requests to the DNS API waited for the rate limit.",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(providerRateLimitWaitSeconds)
+}
+
+// RateLimiter limits the rate of the requests of a provider to the DNS API with a token bucket, e.g. so that multiple
+// instances sharing an account don't exceed the account-wide limit of the API. Every HTTP request takes a token,
+// including the pages of a listing and the retries of the API client. A nil RateLimiter doesn't limit anything.
+type RateLimiter struct {
+	bucket *tokenBucket
+}
+
+// NewRateLimiter returns a RateLimiter allowing qps requests per second on average and up to burst requests at once
+func NewRateLimiter(qps float64, burst int) *RateLimiter {
+	return &RateLimiter{bucket: newTokenBucket(qps, burst)}
+}
+
+// Wait blocks until the rate limit allows the next request
+func (l *RateLimiter) Wait() {
+	if l == nil {
+		return
+	}
+	if delay := l.bucket.wait(); delay > 0 {
+		providerRateLimitWaitSeconds.Add(delay.Seconds())
+	}
+}
+
+// Client returns a copy of the HTTP client whose requests wait for the rate limit
+func (l *RateLimiter) Client(client *http.Client) *http.Client {
+	if l == nil {
+		return client
+	}
+	limited := *client
+	limited.Transport = &rateLimitedTransport{limiter: l, next: client.Transport}
+	return &limited
+}
+
+// awsHandler returns a handler of the AWS SDK waiting for the rate limit before a request is sent, it's run for
+// every retry of the SDK as well
+func (l *RateLimiter) awsHandler() request.NamedHandler {
+	return request.NamedHandler{
+		Name: "externaldns.RateLimiter",
+		Fn:   func(*request.Request) { l.Wait() },
+	}
+}
+
+// RateLimitedProvider waits for the rate limit before every call to the wrapped provider. It's used for providers whose
+// HTTP requests can't be limited, every call takes a single token however many requests it sends.
+type RateLimitedProvider struct {
+	Provider
+	limiter *RateLimiter
+}
+
+// NewRateLimitedProvider returns a RateLimitedProvider limiting the calls to the given provider
+func NewRateLimitedProvider(provider Provider, limiter *RateLimiter) *RateLimitedProvider {
+	return &RateLimitedProvider{Provider: provider, limiter: limiter}
+}
+
+// Records returns the records of the wrapped provider once the rate limit allows it
+func (r *RateLimitedProvider) Records() ([]*endpoint.Endpoint, error) {
+	r.limiter.Wait()
+	return r.Provider.Records()
+}
+
+// ApplyChanges applies the changes with the wrapped provider once the rate limit allows it
+func (r *RateLimitedProvider) ApplyChanges(changes *plan.Changes) error {
+	r.limiter.Wait()
+	return r.Provider.ApplyChanges(changes)
+}
+
+// AdjustEndpoints forwards to the wrapped provider if it adjusts endpoints
+func (r *RateLimitedProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	if adjuster, ok := r.Provider.(EndpointsAdjuster); ok {
+		return adjuster.AdjustEndpoints(endpoints)
+	}
+	return endpoints, nil
+}
+
+// SupportsGeoLocations forwards to the wrapped provider
+func (r *RateLimitedProvider) SupportsGeoLocations() bool {
+	return SupportsGeoLocations(r.Provider)
+}
+
+// IsTransientError forwards to the wrapped provider if it classifies its errors
+func (r *RateLimitedProvider) IsTransientError(err error) bool {
+	if classifier, ok := r.Provider.(TransientErrorClassifier); ok {
+		return classifier.IsTransientError(err)
+	}
+	return defaultTransientErrorClassifier{}.IsTransientError(err)
+}
+
+// rateLimitedTransport is a RoundTripper waiting for the rate limit before every request
+type rateLimitedTransport struct {
+	limiter *RateLimiter
+	next    http.RoundTripper
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.limiter.Wait()
+	if t.next == nil {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// tokenBucket is a token bucket refilled with rate tokens per second up to burst tokens. Every request takes a token,
+// requests finding the bucket empty reserve the next token and wait for it, so that concurrent requests are served in order.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	// now and sleep can be replaced in tests
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+		sleep:  time.Sleep,
+	}
+}
+
+// wait takes a token, waiting until one is available, and returns the time waited
+func (b *tokenBucket) wait() time.Duration {
+	b.mu.Lock()
+	now := b.now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if delay > 0 {
+		b.sleep(delay)
+	}
+	return delay
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+var _ Provider = &RateLimitedProvider{}
+var _ EndpointsAdjuster = &RateLimitedProvider{}
+var _ TransientErrorClassifier = &RateLimitedProvider{}
+var _ GeoLocationSupporter = &RateLimitedProvider{}
+
+// newTestTokenBucket returns a token bucket on a fake clock, sleeping advances the clock
+func newTestTokenBucket(rate float64, burst int) (*tokenBucket, *time.Time) {
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := newTokenBucket(rate, burst)
+	b.last = now
+	b.now = func() time.Time { return now }
+	b.sleep = func(d time.Duration) { now = now.Add(d) }
+	return b, &now
+}
+
+func TestTokenBucketAllowsBurst(t *testing.T) {
+	b, _ := newTestTokenBucket(2, 3)
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, time.Duration(0), b.wait(), "call %d should be within the burst", i)
+	}
+	assert.Equal(t, 500*time.Millisecond, b.wait())
+	assert.Equal(t, 500*time.Millisecond, b.wait())
+}
+
+func TestTokenBucketRefills(t *testing.T) {
+	b, now := newTestTokenBucket(2, 2)
+
+	b.wait()
+	b.wait()
+	*now = now.Add(time.Second)
+	assert.Equal(t, time.Duration(0), b.wait())
+	assert.Equal(t, time.Duration(0), b.wait())
+
+	// the bucket holds at most burst tokens
+	*now = now.Add(time.Hour)
+	b.wait()
+	b.wait()
+	assert.Equal(t, 500*time.Millisecond, b.wait())
+}
+
+func TestTokenBucketQueuesConcurrentCalls(t *testing.T) {
+	b, _ := newTestTokenBucket(1, 1)
+	// calls reserve their token before sleeping, so that waiting calls don't take the same token
+	b.sleep = func(time.Duration) {}
+
+	assert.Equal(t, time.Duration(0), b.wait())
+	assert.Equal(t, time.Second, b.wait())
+	assert.Equal(t, 2*time.Second, b.wait())
+}
+
+func TestRateLimiterLimitsHTTPRequests(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	b, now := newTestTokenBucket(1, 1)
+	start := *now
+	client := (&RateLimiter{bucket: b}).Client(&http.Client{})
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, 3, requests)
+	assert.Equal(t, 2*time.Second, now.Sub(start))
+}
+
+func TestRateLimiterLimitsAWSRequests(t *testing.T) {
+	b, now := newTestTokenBucket(1, 1)
+	start := *now
+	handlers := request.Handlers{}
+	handlers.Send.PushFrontNamed((&RateLimiter{bucket: b}).awsHandler())
+
+	// the handler runs for every attempt of a request
+	for i := 0; i < 3; i++ {
+		handlers.Send.Run(&request.Request{})
+	}
+	assert.Equal(t, 2*time.Second, now.Sub(start))
+}
+
+func TestRateLimitedProviderLimitsCalls(t *testing.T) {
+	b, now := newTestTokenBucket(1, 1)
+	start := *now
+	p := NewInMemoryProvider(InMemoryInitZones([]string{"example.org"}))
+	r := NewRateLimitedProvider(p, &RateLimiter{bucket: b})
+
+	create := endpoint.NewEndpoint("foo.example.org", "1.2.3.4", endpoint.RecordTypeA)
+	require.NoError(t, r.ApplyChanges(&plan.Changes{Create: []*endpoint.Endpoint{create}}))
+	records, err := r.Records()
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+	_, err = r.Records()
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, now.Sub(start))
+
+	assert.True(t, r.SupportsGeoLocations())
+	assert.True(t, r.IsTransientError(ErrThrottled))
+	assert.False(t, r.IsTransientError(errors.New("permanent")))
+}
+
+func TestNilRateLimiter(t *testing.T) {
+	var l *RateLimiter
+	client := &http.Client{}
+	assert.Equal(t, client, l.Client(client))
+	l.Wait()
+}
@@ -17,10 +17,10 @@ limitations under the License.
 package provider
 
 // supportedRecordType returns true only for supported record types.
-// Currently only A, CNAME and TXT record types are supported.
+// Currently only A, AAAA, CNAME and TXT record types are supported.
 func supportedRecordType(recordType string) bool {
 	switch recordType {
-	case "A", "CNAME", "TXT":
+	case "A", "AAAA", "CNAME", "TXT":
 		return true
 	default:
 		return false
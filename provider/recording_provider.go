@@ -0,0 +1,72 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+// ChangeRecorder is informed about the changes applied by a provider, e.g. to report them to the users
+type ChangeRecorder interface {
+	// RecordChanges records changes which were applied successfully
+	RecordChanges(changes *plan.Changes)
+}
+
+// RecordingProvider wraps a Provider and informs a ChangeRecorder about the changes it applied.
+// It should wrap the provider passed to the registry, so that only the changes of the records of this instance,
+// as filtered by the registry, are recorded, and after the retries of the RetryProvider. Changes which failed
+// aren't recorded, since the error of a batch doesn't tell which of its records were rejected.
+type RecordingProvider struct {
+	Provider
+	recorder ChangeRecorder
+}
+
+// NewRecordingProvider returns a RecordingProvider informing the recorder about the changes applied by the given provider
+func NewRecordingProvider(provider Provider, recorder ChangeRecorder) *RecordingProvider {
+	return &RecordingProvider{Provider: provider, recorder: recorder}
+}
+
+// ApplyChanges applies the changes with the wrapped provider and records them if they were applied
+func (r *RecordingProvider) ApplyChanges(changes *plan.Changes) error {
+	if err := r.Provider.ApplyChanges(changes); err != nil {
+		return err
+	}
+	r.recorder.RecordChanges(changes)
+	return nil
+}
+
+// AdjustEndpoints forwards to the wrapped provider if it adjusts endpoints
+func (r *RecordingProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	if adjuster, ok := r.Provider.(EndpointsAdjuster); ok {
+		return adjuster.AdjustEndpoints(endpoints)
+	}
+	return endpoints, nil
+}
+
+// SupportsGeoLocations forwards to the wrapped provider
+func (r *RecordingProvider) SupportsGeoLocations() bool {
+	return SupportsGeoLocations(r.Provider)
+}
+
+// IsTransientError forwards to the wrapped provider if it classifies its errors
+func (r *RecordingProvider) IsTransientError(err error) bool {
+	if classifier, ok := r.Provider.(TransientErrorClassifier); ok {
+		return classifier.IsTransientError(err)
+	}
+	return defaultTransientErrorClassifier{}.IsTransientError(err)
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+var _ Provider = &RecordingProvider{}
+var _ EndpointsAdjuster = &RecordingProvider{}
+var _ TransientErrorClassifier = &RecordingProvider{}
+
+// testChangeRecorder keeps the changes it's informed about
+type testChangeRecorder struct {
+	changes []*plan.Changes
+}
+
+func (r *testChangeRecorder) RecordChanges(changes *plan.Changes) {
+	r.changes = append(r.changes, changes)
+}
+
+func TestRecordingProviderRecordsAppliedChanges(t *testing.T) {
+	recorder := &testChangeRecorder{}
+	p := NewRecordingProvider(NewInMemoryProvider(InMemoryInitZones([]string{"example.org"})), recorder)
+
+	changes := &plan.Changes{Create: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", "1.2.3.4", endpoint.RecordTypeA)}}
+	require.NoError(t, p.ApplyChanges(changes))
+	assert.Equal(t, []*plan.Changes{changes}, recorder.changes)
+
+	// the record exists already, so the changes fail and aren't recorded
+	assert.Error(t, p.ApplyChanges(changes))
+	assert.Len(t, recorder.changes, 1)
+}
+
+func TestRecordingProviderClassifiesErrors(t *testing.T) {
+	p := NewRecordingProvider(NewInMemoryProvider(InMemoryWithThrottling(1)), &testChangeRecorder{})
+	assert.True(t, p.IsTransientError(ErrThrottled))
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+const (
+	// defaultRetryMaxDelay caps the exponential backoff between two attempts
+	defaultRetryMaxDelay = 30 * time.Second
+)
+
+var (
+	providerRetriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "provider",
+			Name:      "retries_total",
+			Help:      "Number of provider calls that were retried after a transient error.",
+		},
+		[]string{"operation"},
+	)
+	providerRetriesExhaustedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "provider",
+			Name:      "retries_exhausted_total",
+			Help:      "Number of provider calls that failed with a transient error after all retries.",
+		},
+		[]string{"operation"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(providerRetriesTotal)
+	prometheus.MustRegister(providerRetriesExhaustedTotal)
+}
+
+// TransientErrorClassifier is implemented by providers which can tell whether an error of their
+// DNS API, e.g. caused by throttling, is temporary and the call is worth retrying.
+type TransientErrorClassifier interface {
+	IsTransientError(err error) bool
+}
+
+// RetryProvider wraps a Provider and retries calls failing with transient errors
+// using exponential backoff with jitter.
+//
+// Errors are classified by the wrapped provider if it implements TransientErrorClassifier,
+// otherwise only network timeouts are considered transient. Note that a retried ApplyChanges
+// submits the same changes again, so providers should only report transient errors for
+// changes which were rejected as a whole.
+type RetryProvider struct {
+	Provider
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+
+	classifier TransientErrorClassifier
+	// sleep waits between two attempts, it can be replaced in tests
+	sleep func(time.Duration)
+}
+
+// NewRetryProvider returns a RetryProvider retrying calls of the given provider up to maxRetries times,
+// waiting baseDelay before the first retry and doubling the delay for every further retry.
+func NewRetryProvider(provider Provider, maxRetries int, baseDelay time.Duration) *RetryProvider {
+	classifier, ok := provider.(TransientErrorClassifier)
+	if !ok {
+		classifier = defaultTransientErrorClassifier{}
+	}
+	return &RetryProvider{
+		Provider:   provider,
+		MaxRetries: maxRetries,
+		BaseDelay:  baseDelay,
+		MaxDelay:   defaultRetryMaxDelay,
+		classifier: classifier,
+		sleep:      time.Sleep,
+	}
+}
+
+// Records returns the records of the wrapped provider, retrying transient errors
+func (r *RetryProvider) Records() ([]*endpoint.Endpoint, error) {
+	var records []*endpoint.Endpoint
+	err := r.retry("records", func() error {
+		var err error
+		records, err = r.Provider.Records()
+		return err
+	})
+	return records, err
+}
+
+// ApplyChanges applies the changes with the wrapped provider, retrying transient errors
+func (r *RetryProvider) ApplyChanges(changes *plan.Changes) error {
+	return r.retry("apply_changes", func() error {
+		return r.Provider.ApplyChanges(changes)
+	})
+}
+
+// AdjustEndpoints forwards to the wrapped provider if it adjusts endpoints
+func (r *RetryProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	if adjuster, ok := r.Provider.(EndpointsAdjuster); ok {
+		return adjuster.AdjustEndpoints(endpoints)
+	}
+	return endpoints, nil
+}
+
+// SupportsGeoLocations forwards to the wrapped provider
+func (r *RetryProvider) SupportsGeoLocations() bool {
+	return SupportsGeoLocations(r.Provider)
+}
+
+func (r *RetryProvider) retry(operation string, call func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := call()
+		if err == nil || !r.classifier.IsTransientError(err) {
+			return err
+		}
+		if attempt >= r.MaxRetries {
+			providerRetriesExhaustedTotal.WithLabelValues(operation).Inc()
+			return err
+		}
+
+		delay := r.backoff(attempt)
+		log.Warnf("Provider call %s failed with transient error, retrying in %s: %v", operation, delay, err)
+		providerRetriesTotal.WithLabelValues(operation).Inc()
+		r.sleep(delay)
+	}
+}
+
+// backoff returns the delay before the given retry, a random duration between half and
+// all of BaseDelay * 2^attempt, capped at MaxDelay
+func (r *RetryProvider) backoff(attempt int) time.Duration {
+	delay := r.BaseDelay
+	for i := 0; i < attempt && delay < r.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > r.MaxDelay {
+		delay = r.MaxDelay
+	}
+	if delay <= 1 {
+		return delay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)))
+}
+
+// defaultTransientErrorClassifier considers network timeouts transient
+type defaultTransientErrorClassifier struct{}
+
+func (defaultTransientErrorClassifier) IsTransientError(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+var _ Provider = &RetryProvider{}
+var _ EndpointsAdjuster = &RetryProvider{}
+var _ TransientErrorClassifier = &InMemoryProvider{}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// failingProvider fails the first calls with the given errors
+type failingProvider struct {
+	errs  []error
+	calls int
+}
+
+func (p *failingProvider) Records() ([]*endpoint.Endpoint, error) {
+	p.calls++
+	if len(p.errs) > 0 {
+		err := p.errs[0]
+		p.errs = p.errs[1:]
+		return nil, err
+	}
+	return []*endpoint.Endpoint{}, nil
+}
+
+func (p *failingProvider) ApplyChanges(changes *plan.Changes) error {
+	_, err := p.Records()
+	return err
+}
+
+func newTestRetryProvider(provider Provider, maxRetries int) (*RetryProvider, *[]time.Duration) {
+	delays := []time.Duration{}
+	r := NewRetryProvider(provider, maxRetries, time.Second)
+	r.sleep = func(d time.Duration) { delays = append(delays, d) }
+	return r, &delays
+}
+
+func TestRetryProviderRetriesTransientErrors(t *testing.T) {
+	backend := NewInMemoryProvider(InMemoryInitZones([]string{"example.org"}), InMemoryWithThrottling(3))
+	r, delays := newTestRetryProvider(backend, 3)
+
+	_, err := r.Records()
+	require.NoError(t, err)
+	require.Len(t, *delays, 3)
+
+	// delays grow exponentially and are jittered within the upper half of the interval
+	for i, d := range *delays {
+		max := time.Second << uint(i)
+		assert.True(t, d >= max/2 && d <= max, "delay %d out of range: %s", i, d)
+	}
+
+	changes := &plan.Changes{Create: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", "1.2.3.4", endpoint.RecordTypeA)}}
+	InMemoryWithThrottling(1)(backend)
+	require.NoError(t, r.ApplyChanges(changes))
+
+	records, err := r.Records()
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+}
+
+func TestRetryProviderGivesUp(t *testing.T) {
+	backend := NewInMemoryProvider(InMemoryWithThrottling(5))
+	r, delays := newTestRetryProvider(backend, 2)
+
+	_, err := r.Records()
+	assert.EqualError(t, err, ErrThrottled.Error())
+	assert.Len(t, *delays, 2)
+}
+
+func TestRetryProviderDoesNotRetryPermanentErrors(t *testing.T) {
+	backend := &failingProvider{errs: []error{errors.New("access denied")}}
+	r, delays := newTestRetryProvider(backend, 3)
+
+	assert.Error(t, r.ApplyChanges(&plan.Changes{}))
+	assert.Equal(t, 1, backend.calls)
+	assert.Empty(t, *delays)
+}
+
+func TestRetryProviderDefaultClassifier(t *testing.T) {
+	backend := &failingProvider{errs: []error{timeoutError{}, timeoutError{}}}
+	r, delays := newTestRetryProvider(backend, 3)
+
+	_, err := r.Records()
+	require.NoError(t, err)
+	assert.Equal(t, 3, backend.calls)
+	assert.Len(t, *delays, 2)
+}
+
+func TestRetryProviderBackoffIsCapped(t *testing.T) {
+	r := NewRetryProvider(NewInMemoryProvider(), 10, time.Second)
+	r.MaxDelay = 5 * time.Second
+
+	for attempt := 0; attempt < 10; attempt++ {
+		assert.True(t, r.backoff(attempt) <= 5*time.Second)
+	}
+}
@@ -0,0 +1,436 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+const (
+	transipAPIURL = "https://api.transip.nl/v6"
+
+	// transipDefaultTTL is used for records which don't define a TTL (TransIP's default)
+	transipDefaultTTL = 86400
+
+	// transipTokenExpiration is the lifetime of the access tokens requested by ExternalDNS
+	transipTokenExpiration = 30 * time.Minute
+
+	// transipApex is the name of records at the apex of a domain
+	transipApex = "@"
+)
+
+// TransIPProvider is an implementation of Provider for TransIP DNS.
+//
+// TransIP authenticates API users with a key pair: requests for an access token are signed with the private key.
+// Record changes are processed asynchronously as domain actions, every change has to be finished before the next
+// one of the same domain is accepted.
+type TransIPProvider struct {
+	apiURL      string
+	accountName string
+	privateKey  *rsa.PrivateKey
+	client      *http.Client
+
+	token           string
+	tokenExpiration time.Time
+
+	// pollInterval and pollTimeout control waiting for domain actions to finish
+	pollInterval time.Duration
+	pollTimeout  time.Duration
+
+	// only consider hosted zones managing domains ending in this suffix
+	domainFilter DomainFilter
+	dryRun       bool
+}
+
+type transipDomain struct {
+	Name string `json:"name"`
+}
+
+type transipDNSEntry struct {
+	Name    string `json:"name"`
+	Expire  int    `json:"expire"`
+	Type    string `json:"type"`
+	Content string `json:"content"`
+}
+
+type transipAction struct {
+	Name      string `json:"name"`
+	Message   string `json:"message"`
+	HasFailed bool   `json:"hasFailed"`
+}
+
+type transipError struct {
+	Error string `json:"error"`
+}
+
+// transipAPIError is returned for requests answered with an unexpected status.
+type transipAPIError struct {
+	statusCode int
+	message    string
+}
+
+func (e *transipAPIError) Error() string {
+	return fmt.Sprintf("TransIP API request failed with status %d: %s", e.statusCode, e.message)
+}
+
+// NewTransIPProvider initializes a new TransIP DNS based Provider authenticating as the given account with the
+// private key of the key pair created in the TransIP control panel.
+func NewTransIPProvider(accountName, privateKeyFile string, domainFilter DomainFilter, rateLimiter *RateLimiter, dryRun bool) (*TransIPProvider, error) {
+	if accountName == "" {
+		return nil, fmt.Errorf("no TransIP account name specified")
+	}
+
+	data, err := ioutil.ReadFile(privateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TransIP private key file '%s': %v", privateKeyFile, err)
+	}
+	privateKey, err := parseTransIPPrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TransIP private key file '%s': %v", privateKeyFile, err)
+	}
+
+	p := &TransIPProvider{
+		apiURL:       transipAPIURL,
+		accountName:  accountName,
+		privateKey:   privateKey,
+		client:       rateLimiter.Client(&http.Client{Timeout: 30 * time.Second}),
+		pollInterval: 2 * time.Second,
+		pollTimeout:  2 * time.Minute,
+		domainFilter: domainFilter,
+		dryRun:       dryRun,
+	}
+	return p, nil
+}
+
+// parseTransIPPrivateKey parses a PEM encoded RSA private key in PKCS#8, as generated by TransIP, or PKCS#1 format.
+func parseTransIPPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM encoded key found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("not an RSA private key")
+	}
+	return rsaKey, nil
+}
+
+// zones returns the names of the domains which pass the domain filter.
+func (p *TransIPProvider) zones() ([]string, error) {
+	resp := struct {
+		Domains []transipDomain `json:"domains"`
+	}{}
+	if err := p.do(http.MethodGet, "/domains", nil, &resp); err != nil {
+		return nil, err
+	}
+
+	zones := []string{}
+	for _, domain := range resp.Domains {
+		if p.domainFilter.Match(domain.Name) {
+			zones = append(zones, domain.Name)
+		}
+	}
+	return zones, nil
+}
+
+// Records returns the list of records in all relevant zones.
+func (p *TransIPProvider) Records() ([]*endpoint.Endpoint, error) {
+	zones, err := p.zones()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := []*endpoint.Endpoint{}
+	for _, zone := range zones {
+		resp := struct {
+			DNSEntries []transipDNSEntry `json:"dnsEntries"`
+		}{}
+		if err := p.do(http.MethodGet, "/domains/"+url.PathEscape(zone)+"/dns", nil, &resp); err != nil {
+			return nil, err
+		}
+
+		// TransIP returns one entry per content, entries of the same name and type are merged into one endpoint
+		byNameAndType := map[string]*endpoint.Endpoint{}
+		for _, entry := range resp.DNSEntries {
+			if !supportedRecordType(entry.Type) {
+				continue
+			}
+
+			name := transipFQDN(entry.Name, zone)
+			target := entry.Content
+			if entry.Type == endpoint.RecordTypeCNAME {
+				target = transipFQDN(target, zone)
+			}
+
+			key := name + "/" + entry.Type
+			if ep, ok := byNameAndType[key]; ok {
+				ep.Targets = append(ep.Targets, target)
+				continue
+			}
+
+			ep := endpoint.NewEndpointWithTTL(name, target, entry.Type, endpoint.TTL(entry.Expire))
+			byNameAndType[key] = ep
+			endpoints = append(endpoints, ep)
+		}
+	}
+
+	return endpoints, nil
+}
+
+// ApplyChanges applies a given set of changes in all relevant zones.
+func (p *TransIPProvider) ApplyChanges(changes *plan.Changes) error {
+	if len(changes.Create) == 0 && len(changes.UpdateNew) == 0 && len(changes.Delete) == 0 {
+		log.Info("All records are already up to date")
+		return nil
+	}
+
+	zones, err := p.zones()
+	if err != nil {
+		return err
+	}
+	zoneNameMapper := zoneIDName{}
+	for _, zone := range zones {
+		zoneNameMapper.Add(zone, zone)
+	}
+
+	for _, endpoints := range [][]*endpoint.Endpoint{changes.Delete, changes.UpdateOld} {
+		for _, ep := range endpoints {
+			if err := p.submitChange(zoneNameMapper, http.MethodDelete, ep); err != nil {
+				return err
+			}
+		}
+	}
+	for _, endpoints := range [][]*endpoint.Endpoint{changes.UpdateNew, changes.Create} {
+		for _, ep := range endpoints {
+			if err := p.submitChange(zoneNameMapper, http.MethodPost, ep); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// submitChange adds (POST) or removes (DELETE) an entry for every target of the endpoint, waiting for every change
+// to be processed before submitting the next one.
+func (p *TransIPProvider) submitChange(zoneNameMapper zoneIDName, method string, ep *endpoint.Endpoint) error {
+	zone, _ := zoneNameMapper.FindZone(ep.DNSName)
+	if zone == "" {
+		log.Debugf("Skipping record %s because no hosted zone matching record DNS Name was detected", ep.DNSName)
+		return nil
+	}
+
+	name := strings.TrimSuffix(strings.TrimSuffix(ep.DNSName, zone), ".")
+	if name == "" {
+		name = transipApex
+	}
+	ttl := transipDefaultTTL
+	if ep.RecordTTL.IsConfigured() {
+		ttl = int(ep.RecordTTL)
+	}
+
+	for _, target := range ep.Targets {
+		if ep.RecordType == endpoint.RecordTypeCNAME {
+			target = ensureTrailingDot(target)
+		}
+		log.Infof("Changing record: %s %s %s %s in zone %s", method, ep.DNSName, ep.RecordType, target, zone)
+		if p.dryRun {
+			continue
+		}
+
+		body := struct {
+			DNSEntry transipDNSEntry `json:"dnsEntry"`
+		}{transipDNSEntry{Name: name, Expire: ttl, Type: ep.RecordType, Content: target}}
+		if err := p.do(method, "/domains/"+url.PathEscape(zone)+"/dns", body, nil); err != nil {
+			return fmt.Errorf("failed to change record %s %s %s: %v", ep.DNSName, ep.RecordType, target, err)
+		}
+		if err := p.waitForDomainAction(zone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitForDomainAction polls the running action of the domain until it finished.
+func (p *TransIPProvider) waitForDomainAction(zone string) error {
+	deadline := time.Now().Add(p.pollTimeout)
+	for {
+		resp := struct {
+			Action transipAction `json:"action"`
+		}{}
+		err := p.do(http.MethodGet, "/domains/"+url.PathEscape(zone)+"/actions", nil, &resp)
+		// no running action
+		if apiErr, ok := err.(*transipAPIError); ok && apiErr.statusCode == http.StatusNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if resp.Action.Name == "" {
+			return nil
+		}
+		if resp.Action.HasFailed {
+			return fmt.Errorf("TransIP action %s of domain %s failed: %s", resp.Action.Name, zone, resp.Action.Message)
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for TransIP action %s of domain %s", resp.Action.Name, zone)
+		}
+		log.Debugf("Waiting for TransIP action %s of domain %s to finish", resp.Action.Name, zone)
+		time.Sleep(p.pollInterval)
+	}
+}
+
+// accessToken returns a valid access token, requesting a new one when the current one is about to expire.
+func (p *TransIPProvider) accessToken() (string, error) {
+	if p.token != "" && time.Now().Before(p.tokenExpiration) {
+		return p.token, nil
+	}
+
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"login":           p.accountName,
+		"nonce":           hex.EncodeToString(nonce),
+		"read_only":       false,
+		"expiration_time": fmt.Sprintf("%d seconds", int(transipTokenExpiration.Seconds())),
+		"label":           fmt.Sprintf("external-dns-%d", time.Now().UnixNano()),
+		"global_key":      true,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	digest := sha512.Sum512(body)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.privateKey, crypto.SHA512, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign TransIP authentication request: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.apiURL+"/auth", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Signature", base64.StdEncoding.EncodeToString(signature))
+
+	resp := struct {
+		Token string `json:"token"`
+	}{}
+	if err := p.send(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to authenticate with TransIP: %v", err)
+	}
+
+	// renew the token a minute before it expires
+	p.token = resp.Token
+	p.tokenExpiration = time.Now().Add(transipTokenExpiration - time.Minute)
+	return p.token, nil
+}
+
+// do sends an authenticated request to the TransIP API.
+func (p *TransIPProvider) do(method, path string, in, out interface{}) error {
+	token, err := p.accessToken()
+	if err != nil {
+		return err
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, p.apiURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return p.send(req, out)
+}
+
+func (p *TransIPProvider) send(req *http.Request, out interface{}) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := transipError{}
+		if err := json.Unmarshal(data, &apiErr); err != nil || apiErr.Error == "" {
+			apiErr.Error = strings.TrimSpace(string(data))
+		}
+		return &transipAPIError{statusCode: resp.StatusCode, message: apiErr.Error}
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// transipFQDN returns the fully qualified name of a name relative to the zone, "@" stands for the apex
+// and names ending with a dot are already fully qualified.
+func transipFQDN(name, zone string) string {
+	switch {
+	case name == transipApex:
+		return zone
+	case strings.HasSuffix(name, "."):
+		return strings.TrimSuffix(name, ".")
+	default:
+		return name + "." + zone
+	}
+}
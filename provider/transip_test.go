@@ -0,0 +1,309 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+// transipServer emulates the TransIP API. Every record change starts a domain action which keeps running
+// for a number of polls, changes submitted while an action is running are rejected.
+type transipServer struct {
+	publicKey *rsa.PublicKey
+	entries   map[string][]transipDNSEntry
+	requests  []string
+	// actionPolls is the number of polls a domain action keeps running
+	actionPolls    int
+	runningActions map[string]int
+	failAction     bool
+	authRequests   int
+}
+
+func newTransIPServer(publicKey *rsa.PublicKey) *transipServer {
+	return &transipServer{
+		publicKey: publicKey,
+		entries: map[string][]transipDNSEntry{
+			"foo.com": {
+				{Name: "@", Expire: 86400, Type: "NS", Content: "ns0.transip.net."},
+				{Name: "www", Expire: 300, Type: endpoint.RecordTypeA, Content: "1.2.3.4"},
+				{Name: "www", Expire: 300, Type: endpoint.RecordTypeA, Content: "5.6.7.8"},
+				{Name: "www", Expire: 300, Type: endpoint.RecordTypeTXT, Content: "\"heritage=external-dns,external-dns/owner=default\""},
+				{Name: "alias", Expire: 300, Type: endpoint.RecordTypeCNAME, Content: "www"},
+			},
+			"bar.com": {
+				{Name: "api", Expire: 3600, Type: endpoint.RecordTypeCNAME, Content: "lb.example.com."},
+			},
+		},
+		actionPolls:    2,
+		runningActions: map[string]int{},
+	}
+}
+
+func (s *transipServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/auth" {
+		s.authRequests++
+		body, _ := ioutil.ReadAll(r.Body)
+		signature, _ := base64.StdEncoding.DecodeString(r.Header.Get("Signature"))
+		digest := sha512.Sum512(body)
+		if err := rsa.VerifyPKCS1v15(s.publicKey, crypto.SHA512, digest[:], signature); err != nil {
+			s.writeError(w, http.StatusUnauthorized, "Signature not valid")
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"token": "token"})
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer token" {
+		s.writeError(w, http.StatusUnauthorized, "Your access token is invalid")
+		return
+	}
+
+	if r.URL.Path == "/domains" {
+		json.NewEncoder(w).Encode(map[string][]transipDomain{"domains": {{Name: "foo.com"}, {Name: "bar.com"}}})
+		return
+	}
+
+	// /domains/{name}/dns and /domains/{name}/actions
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/domains/"), "/")
+	domain := parts[0]
+
+	if parts[1] == "actions" {
+		if s.runningActions[domain] == 0 {
+			s.writeError(w, http.StatusNotFound, "No action is currently running for this domain")
+			return
+		}
+		s.runningActions[domain]--
+		json.NewEncoder(w).Encode(map[string]transipAction{"action": {Name: "changeDns", HasFailed: s.failAction, Message: "failed"}})
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		json.NewEncoder(w).Encode(map[string][]transipDNSEntry{"dnsEntries": s.entries[domain]})
+		return
+	}
+
+	if s.runningActions[domain] > 0 {
+		s.writeError(w, http.StatusConflict, "An action is already running for this domain")
+		return
+	}
+	s.requests = append(s.requests, r.Method+" "+domain)
+
+	body := struct {
+		DNSEntry transipDNSEntry `json:"dnsEntry"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		s.entries[domain] = append(s.entries[domain], body.DNSEntry)
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodDelete:
+		found := false
+		for i, entry := range s.entries[domain] {
+			if entry.Name == body.DNSEntry.Name && entry.Type == body.DNSEntry.Type && entry.Content == body.DNSEntry.Content {
+				s.entries[domain] = append(s.entries[domain][:i], s.entries[domain][i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			s.writeError(w, http.StatusNotFound, "DNS entry not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+	s.runningActions[domain] = s.actionPolls
+}
+
+func (s *transipServer) writeError(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(transipError{Error: message})
+}
+
+func newTransIPTestProvider(t *testing.T, domainFilter DomainFilter, dryRun bool) (*transipServer, *httptest.Server, *TransIPProvider) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	keyFile, err := ioutil.TempFile("", "transip")
+	require.NoError(t, err)
+	defer os.Remove(keyFile.Name())
+	require.NoError(t, pem.Encode(keyFile, &pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	require.NoError(t, keyFile.Close())
+
+	s := newTransIPServer(&key.PublicKey)
+	server := httptest.NewServer(s)
+
+	p, err := NewTransIPProvider("account", keyFile.Name(), domainFilter, nil, dryRun)
+	require.NoError(t, err)
+	p.apiURL = server.URL
+	p.pollInterval = time.Millisecond
+	return s, server, p
+}
+
+func TestNewTransIPProvider(t *testing.T) {
+	_, err := NewTransIPProvider("", "key.pem", NewDomainFilter(nil), nil, false)
+	assert.Error(t, err)
+
+	_, err = NewTransIPProvider("account", "/non/existing/key.pem", NewDomainFilter(nil), nil, false)
+	assert.Error(t, err)
+}
+
+func TestParseTransIPPrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	pkcs1 := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	parsed, err := parseTransIPPrivateKey(pkcs1)
+	require.NoError(t, err)
+	assert.Equal(t, key.N, parsed.N)
+
+	_, err = parseTransIPPrivateKey([]byte("not a key"))
+	assert.Error(t, err)
+}
+
+func TestTransIPRecords(t *testing.T) {
+	s, server, p := newTransIPTestProvider(t, NewDomainFilter(nil), false)
+	defer server.Close()
+
+	records, err := p.Records()
+	require.NoError(t, err)
+
+	validateEndpoints(t, records, []*endpoint.Endpoint{
+		{DNSName: "www.foo.com", Targets: endpoint.Targets{"1.2.3.4", "5.6.7.8"}, RecordType: endpoint.RecordTypeA, RecordTTL: 300},
+		endpoint.NewEndpointWithTTL("www.foo.com", "\"heritage=external-dns,external-dns/owner=default\"", endpoint.RecordTypeTXT, 300),
+		endpoint.NewEndpointWithTTL("alias.foo.com", "www.foo.com", endpoint.RecordTypeCNAME, 300),
+		endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 3600),
+	})
+
+	// the access token is reused
+	assert.Equal(t, 1, s.authRequests)
+}
+
+func TestTransIPRecordsInvalidKey(t *testing.T) {
+	s, server, p := newTransIPTestProvider(t, NewDomainFilter(nil), false)
+	defer server.Close()
+
+	other, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	s.publicKey = &other.PublicKey
+
+	_, err = p.Records()
+	assert.Error(t, err)
+}
+
+func TestTransIPApplyChanges(t *testing.T) {
+	s, server, p := newTransIPTestProvider(t, NewDomainFilter(nil), false)
+	defer server.Close()
+
+	err := p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("foo.com", "9.9.9.9", endpoint.RecordTypeA),
+			endpoint.NewEndpoint("new.bar.com", "www.foo.com", endpoint.RecordTypeCNAME),
+			endpoint.NewEndpoint("new.unknown.com", "9.9.9.9", endpoint.RecordTypeA),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			{DNSName: "www.foo.com", Targets: endpoint.Targets{"1.2.3.4", "5.6.7.8"}, RecordType: endpoint.RecordTypeA, RecordTTL: 300},
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			{DNSName: "www.foo.com", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA, RecordTTL: 60},
+		},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 3600),
+		},
+	})
+	require.NoError(t, err)
+
+	// every change waited for the previous action to finish
+	assert.Equal(t, []string{
+		"DELETE bar.com",
+		"DELETE foo.com",
+		"DELETE foo.com",
+		"POST foo.com",
+		"POST foo.com",
+		"POST bar.com",
+	}, s.requests)
+
+	records, err := p.Records()
+	require.NoError(t, err)
+
+	validateEndpoints(t, records, []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("foo.com", "9.9.9.9", endpoint.RecordTypeA, transipDefaultTTL),
+		endpoint.NewEndpointWithTTL("www.foo.com", "1.2.3.4", endpoint.RecordTypeA, 60),
+		endpoint.NewEndpointWithTTL("www.foo.com", "\"heritage=external-dns,external-dns/owner=default\"", endpoint.RecordTypeTXT, 300),
+		endpoint.NewEndpointWithTTL("alias.foo.com", "www.foo.com", endpoint.RecordTypeCNAME, 300),
+		endpoint.NewEndpointWithTTL("new.bar.com", "www.foo.com", endpoint.RecordTypeCNAME, transipDefaultTTL),
+	})
+}
+
+func TestTransIPApplyChangesFailedAction(t *testing.T) {
+	s, server, p := newTransIPTestProvider(t, NewDomainFilter(nil), false)
+	defer server.Close()
+	s.failAction = true
+
+	err := p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("new.foo.com", "9.9.9.9", endpoint.RecordTypeA)},
+	})
+	assert.Error(t, err)
+}
+
+func TestTransIPApplyChangesActionTimeout(t *testing.T) {
+	s, server, p := newTransIPTestProvider(t, NewDomainFilter(nil), false)
+	defer server.Close()
+	s.actionPolls = 1000
+	p.pollTimeout = 10 * time.Millisecond
+
+	err := p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("new.foo.com", "9.9.9.9", endpoint.RecordTypeA)},
+	})
+	assert.Error(t, err)
+}
+
+func TestTransIPApplyChangesDryRun(t *testing.T) {
+	s, server, p := newTransIPTestProvider(t, NewDomainFilter(nil), true)
+	defer server.Close()
+
+	err := p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("new.foo.com", "9.9.9.9", endpoint.RecordTypeA)},
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("api.bar.com", "lb.example.com", endpoint.RecordTypeCNAME, 3600)},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, s.requests)
+}
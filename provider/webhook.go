@@ -0,0 +1,223 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+const (
+	// WebhookMediaType is the versioned media type spoken between ExternalDNS and webhook providers.
+	// Both sides reject requests and responses with a different version.
+	WebhookMediaType = "application/external.dns.webhook+json;version=1"
+
+	webhookContentTypeHeader   = "Content-Type"
+	webhookAcceptHeader        = "Accept"
+	webhookRecordsPath         = "/records"
+	webhookAdjustEndpointsPath = "/adjustendpoints"
+)
+
+// WebhookProvider is an implementation of Provider which forwards all calls to a provider
+// running out of process, usually as a sidecar listening on localhost.
+//
+// The protocol consists of the following calls:
+//
+//	GET  /                 negotiation, must answer with the WebhookMediaType content type
+//	GET  /records          returns the current records as a JSON list of endpoints
+//	POST /records          applies the JSON encoded plan.Changes, answers with 204 No Content
+//	POST /adjustendpoints  returns the given JSON list of endpoints adjusted by the provider
+type WebhookProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookProvider initializes a new provider talking to the webhook at the given URL.
+// It fails if the webhook is not reachable or speaks a different protocol version.
+func NewWebhookProvider(url string) (*WebhookProvider, error) {
+	p := &WebhookProvider{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	req, err := p.newRequest(http.MethodGet, "/", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to webhook provider: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("webhook provider negotiation failed with status %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get(webhookContentTypeHeader); contentType != WebhookMediaType {
+		return nil, fmt.Errorf("webhook provider speaks unsupported media type %q, expected %q", contentType, WebhookMediaType)
+	}
+
+	log.Infof("Connected to webhook provider at %s", p.url)
+
+	return p, nil
+}
+
+// Records returns the current records known to the webhook provider.
+func (p *WebhookProvider) Records() ([]*endpoint.Endpoint, error) {
+	endpoints := []*endpoint.Endpoint{}
+	if err := p.do(http.MethodGet, webhookRecordsPath, nil, &endpoints); err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+// ApplyChanges sends the changes to the webhook provider.
+func (p *WebhookProvider) ApplyChanges(changes *plan.Changes) error {
+	return p.do(http.MethodPost, webhookRecordsPath, changes, nil)
+}
+
+// AdjustEndpoints lets the webhook provider modify the desired endpoints before planning.
+func (p *WebhookProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	adjusted := []*endpoint.Endpoint{}
+	if err := p.do(http.MethodPost, webhookAdjustEndpointsPath, endpoints, &adjusted); err != nil {
+		return nil, err
+	}
+	return adjusted, nil
+}
+
+func (p *WebhookProvider) newRequest(method, path string, in interface{}) (*http.Request, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, p.url+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(webhookAcceptHeader, WebhookMediaType)
+	if in != nil {
+		req.Header.Set(webhookContentTypeHeader, WebhookMediaType)
+	}
+	return req, nil
+}
+
+func (p *WebhookProvider) do(method, path string, in, out interface{}) error {
+	req, err := p.newRequest(method, path, in)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook provider %s %s failed with status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		return nil
+	}
+	// like the server validates the requests, responses of a different protocol version are rejected
+	if contentType := resp.Header.Get(webhookContentTypeHeader); contentType != WebhookMediaType {
+		return fmt.Errorf("webhook provider %s %s answered with unsupported media type %q, expected %q", method, path, contentType, WebhookMediaType)
+	}
+	return json.Unmarshal(data, out)
+}
+
+// WebhookServer exposes any Provider via the webhook protocol. Out-of-tree providers can use it
+// to implement the server side without re-implementing the wire format.
+type WebhookServer struct {
+	Provider Provider
+}
+
+// ServeHTTP implements http.Handler.
+func (s *WebhookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Header.Get(webhookContentTypeHeader) != WebhookMediaType {
+		http.Error(w, fmt.Sprintf("unsupported media type, expected %q", WebhookMediaType), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/" && r.Method == http.MethodGet:
+		w.Header().Set(webhookContentTypeHeader, WebhookMediaType)
+		w.WriteHeader(http.StatusOK)
+	case r.URL.Path == webhookRecordsPath && r.Method == http.MethodGet:
+		records, err := s.Provider.Records()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.writeJSON(w, records)
+	case r.URL.Path == webhookRecordsPath && r.Method == http.MethodPost:
+		changes := &plan.Changes{}
+		if err := json.NewDecoder(r.Body).Decode(changes); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.Provider.ApplyChanges(changes); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Path == webhookAdjustEndpointsPath && r.Method == http.MethodPost:
+		endpoints := []*endpoint.Endpoint{}
+		if err := json.NewDecoder(r.Body).Decode(&endpoints); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// providers without adjustments simply return the endpoints unmodified
+		if adjuster, ok := s.Provider.(EndpointsAdjuster); ok {
+			var err error
+			if endpoints, err = adjuster.AdjustEndpoints(endpoints); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		s.writeJSON(w, endpoints)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *WebhookServer) writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set(webhookContentTypeHeader, WebhookMediaType)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("Failed to encode webhook response: %v", err)
+	}
+}
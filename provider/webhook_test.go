@@ -0,0 +1,150 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/internal/testutils"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+var _ Provider = &WebhookProvider{}
+var _ EndpointsAdjuster = &WebhookProvider{}
+
+// ttlAdjustingProvider wraps a provider and enforces a fixed TTL on all desired endpoints.
+type ttlAdjustingProvider struct {
+	Provider
+}
+
+func (p *ttlAdjustingProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	for _, ep := range endpoints {
+		ep.RecordTTL = 300
+	}
+	return endpoints, nil
+}
+
+func newWebhookTestServer(t *testing.T, p Provider) (*httptest.Server, *WebhookProvider) {
+	server := httptest.NewServer(&WebhookServer{Provider: p})
+	client, err := NewWebhookProvider(server.URL)
+	require.NoError(t, err)
+	return server, client
+}
+
+func TestWebhookProviderNegotiation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/external.dns.webhook+json;version=2")
+	}))
+	defer server.Close()
+
+	_, err := NewWebhookProvider(server.URL)
+	assert.Error(t, err)
+
+	_, err = NewWebhookProvider("http://127.0.0.1:0")
+	assert.Error(t, err)
+}
+
+func TestWebhookProviderRecordsAndApplyChanges(t *testing.T) {
+	backend := NewInMemoryProvider()
+	require.NoError(t, backend.CreateZone("example.org"))
+
+	server, client := newWebhookTestServer(t, backend)
+	defer server.Close()
+
+	records, err := client.Records()
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	err = client.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("foo.example.org", "1.2.3.4", endpoint.RecordTypeA),
+			endpoint.NewEndpoint("foo.example.org", "\"heritage=external-dns,external-dns/owner=default\"", endpoint.RecordTypeTXT),
+		},
+	})
+	require.NoError(t, err)
+
+	records, err = client.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", "1.2.3.4", endpoint.RecordTypeA),
+		endpoint.NewEndpoint("foo.example.org", "\"heritage=external-dns,external-dns/owner=default\"", endpoint.RecordTypeTXT),
+	}, records))
+
+	// errors of the backing provider are passed on to the caller
+	err = client.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", "1.2.3.4", endpoint.RecordTypeA)},
+	})
+	assert.Error(t, err)
+}
+
+func TestWebhookProviderAdjustEndpoints(t *testing.T) {
+	desired := []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", "1.2.3.4", endpoint.RecordTypeA)}
+
+	server, client := newWebhookTestServer(t, NewInMemoryProvider())
+	adjusted, err := client.AdjustEndpoints(desired)
+	require.NoError(t, err)
+	assert.Equal(t, endpoint.TTL(0), adjusted[0].RecordTTL)
+	server.Close()
+
+	server, client = newWebhookTestServer(t, &ttlAdjustingProvider{NewInMemoryProvider()})
+	defer server.Close()
+	adjusted, err = client.AdjustEndpoints(desired)
+	require.NoError(t, err)
+	assert.Equal(t, endpoint.TTL(300), adjusted[0].RecordTTL)
+}
+
+func TestWebhookServerRejectsUnknownMediaType(t *testing.T) {
+	handler := &WebhookServer{Provider: NewInMemoryProvider()}
+
+	req := httptest.NewRequest(http.MethodPost, "/records", bytes.NewBufferString("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/unknown", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestWebhookProviderRejectsUnknownResponseMediaType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the negotiation succeeds, the responses are of a different version
+		if r.URL.Path == "/" {
+			w.Header().Set("Content-Type", WebhookMediaType)
+			return
+		}
+		w.Header().Set("Content-Type", "application/external.dns.webhook+json;version=2")
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	client, err := NewWebhookProvider(server.URL)
+	require.NoError(t, err)
+	_, err = client.Records()
+	assert.Error(t, err)
+	_, err = client.AdjustEndpoints([]*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", "1.2.3.4", endpoint.RecordTypeA)})
+	assert.Error(t, err)
+}
@@ -16,19 +16,29 @@ limitations under the License.
 
 package provider
 
-import "strings"
+import (
+	"strings"
+	"unicode"
+)
 
 // ZoneIDFilter holds a list of zone ids to filter by
 type ZoneIDFilter struct {
 	zoneIDs []string
 }
 
-// NewZoneIDFilter returns a new ZoneIDFilter given a list of zone ids
+// NewZoneIDFilter returns a new ZoneIDFilter given a list of zone ids, empty ids are ignored
 func NewZoneIDFilter(zoneIDs []string) ZoneIDFilter {
-	return ZoneIDFilter{zoneIDs}
+	ids := []string{}
+	for _, id := range zoneIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ZoneIDFilter{ids}
 }
 
-// Match checks whether a zone matches one of the provided zone ids
+// Match checks whether a zone matches one of the provided zone ids. An id matches the whole zone id or its
+// trailing part following a separator, e.g. ZTST1 matches /hostedzone/ZTST1 but not /hostedzone/ABZTST1.
 func (f ZoneIDFilter) Match(zoneID string) bool {
 	// An empty filter includes all zones.
 	if len(f.zoneIDs) == 0 {
@@ -36,10 +46,21 @@ func (f ZoneIDFilter) Match(zoneID string) bool {
 	}
 
 	for _, id := range f.zoneIDs {
-		if strings.HasSuffix(zoneID, id) {
+		if zoneID == id {
 			return true
 		}
+		if strings.HasSuffix(zoneID, id) {
+			separator := rune(zoneID[len(zoneID)-len(id)-1])
+			if !unicode.IsLetter(separator) && !unicode.IsDigit(separator) {
+				return true
+			}
+		}
 	}
 
 	return false
 }
+
+// IsConfigured returns true if the filter restricts the zones, false otherwise
+func (f ZoneIDFilter) IsConfigured() bool {
+	return len(f.zoneIDs) > 0
+}
@@ -72,8 +72,44 @@ func TestZoneIDFilterMatch(t *testing.T) {
 			zone,
 			true,
 		},
+		{
+			[]string{""},
+			zone,
+			true,
+		},
+		{
+			[]string{"TST1"},
+			zone,
+			false,
+		},
+		{
+			[]string{"hostedzone/ZTST1"},
+			zone,
+			true,
+		},
+		{
+			[]string{"example.com"},
+			"example.com",
+			true,
+		},
+		{
+			[]string{"example.com"},
+			"zone_auth/ZG5zLnpvbmUk:example.com/default",
+			false,
+		},
+		{
+			[]string{"example.com/default"},
+			"zone_auth/ZG5zLnpvbmUk:example.com/default",
+			true,
+		},
 	} {
 		zoneIDFilter := NewZoneIDFilter(tt.zoneIDFilter)
 		assert.Equal(t, tt.expected, zoneIDFilter.Match(tt.zone))
 	}
 }
+
+func TestZoneIDFilterIsConfigured(t *testing.T) {
+	assert.False(t, NewZoneIDFilter([]string{}).IsConfigured())
+	assert.False(t, NewZoneIDFilter([]string{""}).IsConfigured())
+	assert.True(t, NewZoneIDFilter([]string{"/hostedzone/ZTST1"}).IsConfigured())
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"errors"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+	"github.com/kubernetes-incubator/external-dns/provider"
+)
+
+// AWSSDRegistry implements registry interface with ownership information kept in the description of the
+// AWS Cloud Map services, so that no TXT records are needed. It only works along with the aws-sd provider.
+type AWSSDRegistry struct {
+	provider provider.Provider
+	ownerID  string //refers to the owner id of the current instance
+}
+
+// NewAWSSDRegistry returns new AWSSDRegistry object
+func NewAWSSDRegistry(provider provider.Provider, ownerID string) (*AWSSDRegistry, error) {
+	if ownerID == "" {
+		return nil, errors.New("owner id cannot be empty")
+	}
+	return &AWSSDRegistry{
+		provider: provider,
+		ownerID:  ownerID,
+	}, nil
+}
+
+// Records returns the current records from the dns provider with the labels parsed from the description of their services.
+// Records of services without a description of external-dns have empty labels, i.e. they are not owned by any instance.
+func (sdr *AWSSDRegistry) Records() ([]*endpoint.Endpoint, error) {
+	records, err := sdr.provider.Records()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		labels, err := endpoint.NewLabelsFromString(record.Labels[endpoint.AWSSDDescriptionLabel])
+		if err != nil {
+			// if the description doesn't contain the labels the record is unmanaged
+			labels = endpoint.NewLabels()
+		}
+		setStoredLabels(record, labels)
+	}
+
+	return records, nil
+}
+
+// ApplyChanges filters out the records of other owners and propagates the changes to the dns provider,
+// which writes the labels of created and updated records into the description of their services.
+func (sdr *AWSSDRegistry) ApplyChanges(changes *plan.Changes) error {
+	filteredChanges := &plan.Changes{
+		Create:    changes.Create,
+		UpdateNew: filterOwnedRecords(sdr.ownerID, changes.UpdateNew),
+		UpdateOld: filterOwnedRecords(sdr.ownerID, changes.UpdateOld),
+		Delete:    filterOwnedRecords(sdr.ownerID, changes.Delete),
+	}
+
+	sdr.updateLabels(filteredChanges.Create)
+	sdr.updateLabels(filteredChanges.UpdateNew)
+	sdr.updateLabels(filteredChanges.UpdateOld)
+	sdr.updateLabels(filteredChanges.Delete)
+
+	return sdr.provider.ApplyChanges(filteredChanges)
+}
+
+// updateLabels sets the owner of the endpoints and serializes their labels into the description label.
+func (sdr *AWSSDRegistry) updateLabels(endpoints []*endpoint.Endpoint) {
+	for _, ep := range endpoints {
+		if ep.Labels == nil {
+			ep.Labels = endpoint.NewLabels()
+		}
+		ep.Labels[endpoint.OwnerLabelKey] = sdr.ownerID
+		delete(ep.Labels, endpoint.AWSSDDescriptionLabel)
+		ep.Labels[endpoint.AWSSDDescriptionLabel] = ep.Labels.Serialize(false)
+	}
+}
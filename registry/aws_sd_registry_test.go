@@ -0,0 +1,119 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"testing"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/internal/testutils"
+	"github.com/kubernetes-incubator/external-dns/plan"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inMemoryAWSSDProvider returns fixed records and keeps the applied changes
+type inMemoryAWSSDProvider struct {
+	records []*endpoint.Endpoint
+	changes *plan.Changes
+}
+
+func (p *inMemoryAWSSDProvider) Records() ([]*endpoint.Endpoint, error) {
+	return p.records, nil
+}
+
+func (p *inMemoryAWSSDProvider) ApplyChanges(changes *plan.Changes) error {
+	p.changes = changes
+	return nil
+}
+
+func newEndpointWithDescription(dnsName, target, description string) *endpoint.Endpoint {
+	e := endpoint.NewEndpoint(dnsName, target, endpoint.RecordTypeA)
+	if description != "" {
+		e.Labels[endpoint.AWSSDDescriptionLabel] = description
+	}
+	return e
+}
+
+func TestAWSSDRegistryNew(t *testing.T) {
+	p := &inMemoryAWSSDProvider{}
+
+	_, err := NewAWSSDRegistry(p, "")
+	assert.Error(t, err)
+
+	r, err := NewAWSSDRegistry(p, "owner")
+	require.NoError(t, err)
+	assert.Equal(t, "owner", r.ownerID)
+}
+
+func TestAWSSDRegistryRecords(t *testing.T) {
+	p := &inMemoryAWSSDProvider{records: []*endpoint.Endpoint{
+		newEndpointWithDescription("foo.example.org", "1.2.3.4", "heritage=external-dns,external-dns/owner=owner,external-dns/resource=service/default/foo"),
+		newEndpointWithDescription("bar.example.org", "1.2.3.4", "heritage=external-dns,external-dns/owner=owner-2"),
+		newEndpointWithDescription("baz.example.org", "1.2.3.4", "created by hand"),
+		newEndpointWithDescription("qux.example.org", "1.2.3.4", ""),
+	}}
+	r, _ := NewAWSSDRegistry(p, "owner")
+
+	records, err := r.Records()
+	require.NoError(t, err)
+
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		newEndpointWithOwnerResource("foo.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner", "service/default/foo"),
+		newEndpointWithOwner("bar.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner-2"),
+		endpoint.NewEndpoint("baz.example.org", "1.2.3.4", endpoint.RecordTypeA),
+		endpoint.NewEndpoint("qux.example.org", "1.2.3.4", endpoint.RecordTypeA),
+	}))
+}
+
+func TestAWSSDRegistryApplyChanges(t *testing.T) {
+	p := &inMemoryAWSSDProvider{}
+	r, _ := NewAWSSDRegistry(p, "owner")
+
+	changes := &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwnerResource("new.example.org", "1.2.3.4", endpoint.RecordTypeA, "", "service/default/new"),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner"),
+			newEndpointWithOwner("bar.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner-2"),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.example.org", "5.6.7.8", endpoint.RecordTypeA, "owner"),
+			newEndpointWithOwner("bar.example.org", "5.6.7.8", endpoint.RecordTypeA, "owner-2"),
+		},
+		Delete: []*endpoint.Endpoint{
+			newEndpointWithOwner("baz.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner"),
+			newEndpointWithOwner("qux.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		},
+	}
+	require.NoError(t, r.ApplyChanges(changes))
+
+	require.Len(t, p.changes.Create, 1)
+	assert.Equal(t, "heritage=external-dns,external-dns/owner=owner,external-dns/resource=service/default/new",
+		p.changes.Create[0].Labels[endpoint.AWSSDDescriptionLabel])
+
+	require.Len(t, p.changes.UpdateNew, 1)
+	assert.Equal(t, "foo.example.org", p.changes.UpdateNew[0].DNSName)
+	assert.Equal(t, "heritage=external-dns,external-dns/owner=owner", p.changes.UpdateNew[0].Labels[endpoint.AWSSDDescriptionLabel])
+	require.Len(t, p.changes.UpdateOld, 1)
+	assert.Equal(t, "foo.example.org", p.changes.UpdateOld[0].DNSName)
+
+	require.Len(t, p.changes.Delete, 1)
+	assert.Equal(t, "baz.example.org", p.changes.Delete[0].DNSName)
+}
@@ -0,0 +1,351 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/linki/instrumented_http"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+	"github.com/kubernetes-incubator/external-dns/provider"
+)
+
+const (
+	// dynamoDBKeyAttribute is the partition key of the table, identifying a record by its name, type and set identifier
+	dynamoDBKeyAttribute = "k"
+	// dynamoDBOwnerAttribute stores the owner id of the record
+	dynamoDBOwnerAttribute = "o"
+	// dynamoDBLabelsAttribute stores the other labels of the record as a map
+	dynamoDBLabelsAttribute = "l"
+)
+
+// DynamoDBAPI is the subset of the AWS DynamoDB API that we actually use. Add methods as required. Signatures must match exactly.
+type DynamoDBAPI interface {
+	DescribeTable(*dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error)
+	ScanPages(*dynamodb.ScanInput, func(*dynamodb.ScanOutput, bool) bool) error
+	PutItem(*dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
+	DeleteItem(*dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error)
+}
+
+// DynamoDBConfig contains configuration to create a new DynamoDB registry.
+type DynamoDBConfig struct {
+	Table                string
+	Region               string
+	AssumeRole           string
+	AssumeRoleExternalID string
+	DryRun               bool
+}
+
+// DynamoDBRegistry implements registry interface with ownership information stored in a DynamoDB table.
+// Unlike the TXT registry it doesn't create any additional DNS records.
+type DynamoDBRegistry struct {
+	provider provider.Provider
+	ownerID  string //refers to the owner id of the current instance
+	client   DynamoDBAPI
+	table    string
+	dryRun   bool
+
+	// orphans are the keys of the items of this instance without a record found by the last call to Records
+	orphans []string
+}
+
+// NewDynamoDBRegistry returns a new DynamoDBRegistry storing the ownership in the table of the given config.
+func NewDynamoDBRegistry(provider provider.Provider, ownerID string, config DynamoDBConfig) (*DynamoDBRegistry, error) {
+	awsConfig := aws.NewConfig()
+	if config.Region != "" {
+		awsConfig = awsConfig.WithRegion(config.Region)
+	}
+	awsConfig = awsConfig.WithHTTPClient(
+		instrumented_http.NewClient(awsConfig.HTTPClient, &instrumented_http.Callbacks{
+			PathProcessor: func(path string) string {
+				return "dynamodb"
+			},
+		}),
+	)
+
+	session, err := session.NewSessionWithOptions(session.Options{
+		Config:            *awsConfig,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	client := dynamodb.New(session)
+	if config.AssumeRole != "" {
+		log.Infof("Assuming role %s", config.AssumeRole)
+		creds := stscreds.NewCredentials(session, config.AssumeRole, func(p *stscreds.AssumeRoleProvider) {
+			if config.AssumeRoleExternalID != "" {
+				p.ExternalID = aws.String(config.AssumeRoleExternalID)
+			}
+		})
+		client = dynamodb.New(session, &aws.Config{Credentials: creds})
+	}
+
+	return newDynamoDBRegistry(provider, ownerID, client, config.Table, config.DryRun)
+}
+
+// newDynamoDBRegistry returns a new DynamoDBRegistry using the given client, after making sure that the table exists
+// and is keyed as expected.
+func newDynamoDBRegistry(provider provider.Provider, ownerID string, client DynamoDBAPI, table string, dryRun bool) (*DynamoDBRegistry, error) {
+	if ownerID == "" {
+		return nil, errors.New("owner id cannot be empty")
+	}
+	if table == "" {
+		return nil, errors.New("dynamodb table cannot be empty")
+	}
+
+	out, err := client.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String(table)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe dynamodb table %s: %v", table, err)
+	}
+	if out.Table == nil || len(out.Table.KeySchema) != 1 ||
+		aws.StringValue(out.Table.KeySchema[0].AttributeName) != dynamoDBKeyAttribute ||
+		aws.StringValue(out.Table.KeySchema[0].KeyType) != dynamodb.KeyTypeHash {
+		return nil, fmt.Errorf("dynamodb table %s must have the single partition key %q of type string", table, dynamoDBKeyAttribute)
+	}
+	for _, attribute := range out.Table.AttributeDefinitions {
+		if aws.StringValue(attribute.AttributeName) == dynamoDBKeyAttribute && aws.StringValue(attribute.AttributeType) != dynamodb.ScalarAttributeTypeS {
+			return nil, fmt.Errorf("dynamodb table %s must have the single partition key %q of type string", table, dynamoDBKeyAttribute)
+		}
+	}
+
+	return &DynamoDBRegistry{
+		provider: provider,
+		ownerID:  ownerID,
+		client:   client,
+		table:    table,
+		dryRun:   dryRun,
+	}, nil
+}
+
+// Records returns the current records from the dns provider with the labels stored in the table.
+// Records without an item have empty labels, i.e. they are not owned by any instance.
+func (im *DynamoDBRegistry) Records() ([]*endpoint.Endpoint, error) {
+	records, err := im.provider.Records()
+	if err != nil {
+		return nil, err
+	}
+
+	labelMap, err := im.readLabels()
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]bool{}
+	for _, record := range records {
+		key := dynamoDBKey(record)
+		if labels, ok := labelMap[key]; ok {
+			setStoredLabels(record, labels)
+			found[key] = true
+			continue
+		}
+		setStoredLabels(record, endpoint.NewLabels())
+	}
+
+	im.orphans = nil
+	for key, labels := range labelMap {
+		if !found[key] && labels[endpoint.OwnerLabelKey] == im.ownerID {
+			im.orphans = append(im.orphans, key)
+		}
+	}
+	sort.Strings(im.orphans)
+
+	return records, nil
+}
+
+// ApplyChanges updates dns provider with the changes and the table with the ownership of the records.
+// Items are written before the records are created, so that two instances can't claim the same record,
+// and deleted after the records are deleted, so that a failure never leaves a record without an owner.
+func (im *DynamoDBRegistry) ApplyChanges(changes *plan.Changes) error {
+	filteredChanges := &plan.Changes{
+		UpdateNew: filterOwnedRecords(im.ownerID, changes.UpdateNew),
+		UpdateOld: filterOwnedRecords(im.ownerID, changes.UpdateOld),
+		Delete:    filterOwnedRecords(im.ownerID, changes.Delete),
+	}
+
+	written := map[string]bool{}
+	for _, r := range changes.Create {
+		r.Labels[endpoint.OwnerLabelKey] = im.ownerID
+		if err := im.putItem(r); err != nil {
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+				log.WithFields(r.LogFields()).Warn("Skipping creation because the record is owned by another instance")
+				continue
+			}
+			return err
+		}
+		written[dynamoDBKey(r)] = true
+		filteredChanges.Create = append(filteredChanges.Create, r)
+	}
+	// the labels of updated records may have changed
+	for _, r := range filteredChanges.UpdateNew {
+		if err := im.putItem(r); err != nil {
+			return err
+		}
+		written[dynamoDBKey(r)] = true
+	}
+
+	if err := im.provider.ApplyChanges(filteredChanges); err != nil {
+		return err
+	}
+
+	for _, r := range filteredChanges.Delete {
+		if err := im.deleteItem(dynamoDBKey(r)); err != nil {
+			return err
+		}
+	}
+	// items whose records are gone, e.g. because their creation failed, unless they were just written again
+	for _, key := range im.orphans {
+		if written[key] {
+			continue
+		}
+		if err := im.deleteItem(key); err != nil {
+			return err
+		}
+	}
+	im.orphans = nil
+
+	return nil
+}
+
+// writeOwnership writes the items marking the records as owned by this instance
+func (im *DynamoDBRegistry) writeOwnership(endpoints []*endpoint.Endpoint) error {
+	for _, r := range endpoints {
+		if err := im.putItem(r); err != nil {
+			return fmt.Errorf("failed to write the ownership of %s %s: %v", r.RecordType, r.DNSName, err)
+		}
+	}
+	return nil
+}
+
+// deleteOwnership deletes the items of the records
+func (im *DynamoDBRegistry) deleteOwnership(endpoints []*endpoint.Endpoint) error {
+	for _, r := range endpoints {
+		if err := im.deleteItem(dynamoDBKey(r)); err != nil {
+			return fmt.Errorf("failed to delete the ownership of %s %s: %v", r.RecordType, r.DNSName, err)
+		}
+	}
+	return nil
+}
+
+// readLabels scans the table for the labels of all records, including the ones of other instances, keyed by dynamoDBKey.
+func (im *DynamoDBRegistry) readLabels() (map[string]endpoint.Labels, error) {
+	labelMap := map[string]endpoint.Labels{}
+	input := &dynamodb.ScanInput{
+		TableName:      aws.String(im.table),
+		ConsistentRead: aws.Bool(true),
+	}
+	err := im.client.ScanPages(input, func(out *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range out.Items {
+			key, ok := item[dynamoDBKeyAttribute]
+			if !ok || key.S == nil {
+				continue
+			}
+			labels := endpoint.NewLabels()
+			if labelsAttribute, ok := item[dynamoDBLabelsAttribute]; ok {
+				for name, value := range labelsAttribute.M {
+					labels[name] = aws.StringValue(value.S)
+				}
+			}
+			if owner, ok := item[dynamoDBOwnerAttribute]; ok {
+				labels[endpoint.OwnerLabelKey] = aws.StringValue(owner.S)
+			}
+			labelMap[aws.StringValue(key.S)] = labels
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan dynamodb table %s: %v", im.table, err)
+	}
+	return labelMap, nil
+}
+
+// putItem writes the labels of the record, unless the record is owned by another instance.
+func (im *DynamoDBRegistry) putItem(r *endpoint.Endpoint) error {
+	key := dynamoDBKey(r)
+	item := map[string]*dynamodb.AttributeValue{
+		dynamoDBKeyAttribute:   {S: aws.String(key)},
+		dynamoDBOwnerAttribute: {S: aws.String(im.ownerID)},
+	}
+	labels := map[string]*dynamodb.AttributeValue{}
+	for name, value := range r.Labels {
+		if name != endpoint.OwnerLabelKey && value != "" {
+			labels[name] = &dynamodb.AttributeValue{S: aws.String(value)}
+		}
+	}
+	if len(labels) > 0 {
+		item[dynamoDBLabelsAttribute] = &dynamodb.AttributeValue{M: labels}
+	}
+
+	log.Debugf("Writing the ownership of %s to dynamodb table %s", key, im.table)
+	if im.dryRun {
+		return nil
+	}
+	_, err := im.client.PutItem(&dynamodb.PutItemInput{
+		TableName:           aws.String(im.table),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(#k) OR #o = :owner"),
+		ExpressionAttributeNames: map[string]*string{
+			"#k": aws.String(dynamoDBKeyAttribute),
+			"#o": aws.String(dynamoDBOwnerAttribute),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":owner": {S: aws.String(im.ownerID)},
+		},
+	})
+	return err
+}
+
+// deleteItem deletes the item of the given key, unless it's owned by another instance.
+func (im *DynamoDBRegistry) deleteItem(key string) error {
+	log.Debugf("Deleting the ownership of %s from dynamodb table %s", key, im.table)
+	if im.dryRun {
+		return nil
+	}
+	_, err := im.client.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName: aws.String(im.table),
+		Key: map[string]*dynamodb.AttributeValue{
+			dynamoDBKeyAttribute: {S: aws.String(key)},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(#k) OR #o = :owner"),
+		ExpressionAttributeNames: map[string]*string{
+			"#k": aws.String(dynamoDBKeyAttribute),
+			"#o": aws.String(dynamoDBOwnerAttribute),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":owner": {S: aws.String(im.ownerID)},
+		},
+	})
+	return err
+}
+
+// dynamoDBKey returns the partition key of the item storing the labels of the record,
+// e.g. foo.example.org#A#europe
+func dynamoDBKey(r *endpoint.Endpoint) string {
+	return strings.Join([]string{r.DNSName, r.RecordType, r.SetIdentifier}, "#")
+}
@@ -0,0 +1,337 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/internal/testutils"
+	"github.com/kubernetes-incubator/external-dns/plan"
+	"github.com/kubernetes-incubator/external-dns/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Compile time check for interface conformance
+var _ DynamoDBAPI = &dynamodb.DynamoDB{}
+
+// fakeDynamoDB is an in-memory table which evaluates the ownership condition of the registry.
+type fakeDynamoDB struct {
+	keySchema []*dynamodb.KeySchemaElement
+	items     map[string]map[string]*dynamodb.AttributeValue
+	failScan  bool
+}
+
+func newFakeDynamoDB() *fakeDynamoDB {
+	return &fakeDynamoDB{
+		keySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String(dynamoDBKeyAttribute), KeyType: aws.String(dynamodb.KeyTypeHash)},
+		},
+		items: map[string]map[string]*dynamodb.AttributeValue{},
+	}
+}
+
+func (f *fakeDynamoDB) DescribeTable(input *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+	if aws.StringValue(input.TableName) != "external-dns" {
+		return nil, awserr.New(dynamodb.ErrCodeResourceNotFoundException, "table not found", nil)
+	}
+	return &dynamodb.DescribeTableOutput{Table: &dynamodb.TableDescription{
+		TableName: input.TableName,
+		KeySchema: f.keySchema,
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String(dynamoDBKeyAttribute), AttributeType: aws.String(dynamodb.ScalarAttributeTypeS)},
+		},
+	}}, nil
+}
+
+// ScanPages returns a page per item
+func (f *fakeDynamoDB) ScanPages(input *dynamodb.ScanInput, fn func(*dynamodb.ScanOutput, bool) bool) error {
+	if f.failScan {
+		return errors.New("scan failed")
+	}
+	keys := []string{}
+	for key := range f.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		if !fn(&dynamodb.ScanOutput{Items: []map[string]*dynamodb.AttributeValue{f.items[key]}}, i == len(keys)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeDynamoDB) PutItem(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	key := aws.StringValue(input.Item[dynamoDBKeyAttribute].S)
+	if err := f.checkOwner(key, input.ExpressionAttributeValues); err != nil {
+		return nil, err
+	}
+	f.items[key] = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) DeleteItem(input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	key := aws.StringValue(input.Key[dynamoDBKeyAttribute].S)
+	if err := f.checkOwner(key, input.ExpressionAttributeValues); err != nil {
+		return nil, err
+	}
+	delete(f.items, key)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) checkOwner(key string, values map[string]*dynamodb.AttributeValue) error {
+	item, ok := f.items[key]
+	if ok && aws.StringValue(item[dynamoDBOwnerAttribute].S) != aws.StringValue(values[":owner"].S) {
+		return awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+	}
+	return nil
+}
+
+func (f *fakeDynamoDB) owner(key string) string {
+	if item, ok := f.items[key]; ok {
+		return aws.StringValue(item[dynamoDBOwnerAttribute].S)
+	}
+	return ""
+}
+
+func newTestDynamoDBRegistry(t *testing.T, p provider.Provider, client DynamoDBAPI, ownerID string) *DynamoDBRegistry {
+	r, err := newDynamoDBRegistry(p, ownerID, client, "external-dns", false)
+	require.NoError(t, err)
+	return r
+}
+
+func TestNewDynamoDBRegistry(t *testing.T) {
+	p := provider.NewInMemoryProvider()
+	client := newFakeDynamoDB()
+
+	_, err := newDynamoDBRegistry(p, "", client, "external-dns", false)
+	assert.Error(t, err, "should require an owner id")
+
+	_, err = newDynamoDBRegistry(p, "owner", client, "", false)
+	assert.Error(t, err, "should require a table")
+
+	_, err = newDynamoDBRegistry(p, "owner", client, "missing", false)
+	assert.Error(t, err, "should require the table to exist")
+
+	r, err := newDynamoDBRegistry(p, "owner", client, "external-dns", true)
+	require.NoError(t, err)
+	assert.Equal(t, "owner", r.ownerID)
+	assert.Equal(t, "external-dns", r.table)
+	assert.True(t, r.dryRun)
+
+	client.keySchema = []*dynamodb.KeySchemaElement{
+		{AttributeName: aws.String("name"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+	}
+	_, err = newDynamoDBRegistry(p, "owner", client, "external-dns", false)
+	assert.Error(t, err, "should require the expected key schema")
+}
+
+func TestDynamoDBRegistryRecords(t *testing.T) {
+	p := provider.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	require.NoError(t, p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("foo.test-zone.example.org", "2001:db8::1", endpoint.RecordTypeAAAA, ""),
+			newEndpointWithOwner("bar.test-zone.example.org", "bar.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
+			newEndpointWithOwner("baz.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		},
+	}))
+
+	client := newFakeDynamoDB()
+	client.items["foo.test-zone.example.org#A#"] = map[string]*dynamodb.AttributeValue{
+		dynamoDBKeyAttribute:   {S: aws.String("foo.test-zone.example.org#A#")},
+		dynamoDBOwnerAttribute: {S: aws.String("owner")},
+		dynamoDBLabelsAttribute: {M: map[string]*dynamodb.AttributeValue{
+			endpoint.ResourceLabelKey: {S: aws.String("service/default/foo")},
+		}},
+	}
+	client.items["bar.test-zone.example.org#CNAME#"] = map[string]*dynamodb.AttributeValue{
+		dynamoDBKeyAttribute:   {S: aws.String("bar.test-zone.example.org#CNAME#")},
+		dynamoDBOwnerAttribute: {S: aws.String("owner-2")},
+	}
+	client.items["gone.test-zone.example.org#A#"] = map[string]*dynamodb.AttributeValue{
+		dynamoDBKeyAttribute:   {S: aws.String("gone.test-zone.example.org#A#")},
+		dynamoDBOwnerAttribute: {S: aws.String("owner")},
+	}
+
+	r := newTestDynamoDBRegistry(t, p, client, "owner")
+	records, err := r.Records()
+	require.NoError(t, err)
+
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		newEndpointWithOwnerResource("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner", "service/default/foo"),
+		{
+			DNSName:    "foo.test-zone.example.org",
+			Targets:    endpoint.Targets{"2001:db8::1"},
+			RecordType: endpoint.RecordTypeAAAA,
+			Labels:     endpoint.Labels{},
+		},
+		newEndpointWithOwner("bar.test-zone.example.org", "bar.loadbalancer.com", endpoint.RecordTypeCNAME, "owner-2"),
+		{
+			DNSName:    "baz.test-zone.example.org",
+			Targets:    endpoint.Targets{"1.2.3.4"},
+			RecordType: endpoint.RecordTypeA,
+			Labels:     endpoint.Labels{},
+		},
+	}))
+	assert.Equal(t, []string{"gone.test-zone.example.org#A#"}, r.orphans)
+
+	client.failScan = true
+	_, err = r.Records()
+	assert.Error(t, err)
+}
+
+func TestDynamoDBRegistryApplyChanges(t *testing.T) {
+	p := provider.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	client := newFakeDynamoDB()
+	r := newTestDynamoDBRegistry(t, p, client, "owner")
+
+	_, err := r.Records()
+	require.NoError(t, err)
+	require.NoError(t, r.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwnerResource("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "", "service/default/foo"),
+			newEndpointWithOwnerResource("bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "", "service/default/bar"),
+		},
+	}))
+	assert.Equal(t, "owner", client.owner("foo.test-zone.example.org#A#"))
+	assert.Equal(t, "owner", client.owner("bar.test-zone.example.org#A#"))
+
+	records, err := p.Records()
+	require.NoError(t, err)
+	assert.Len(t, records, 2, "should not create any additional records")
+
+	records, err = r.Records()
+	require.NoError(t, err)
+	var foo, bar *endpoint.Endpoint
+	for _, record := range records {
+		switch record.DNSName {
+		case "foo.test-zone.example.org":
+			foo = record
+		case "bar.test-zone.example.org":
+			bar = record
+		}
+	}
+	require.NotNil(t, foo)
+	require.NotNil(t, bar)
+	assert.Equal(t, "service/default/foo", foo.Labels[endpoint.ResourceLabelKey])
+
+	updated := newEndpointWithOwnerResource("foo.test-zone.example.org", "5.6.7.8", endpoint.RecordTypeA, "owner", "service/default/qux")
+	require.NoError(t, r.ApplyChanges(&plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{foo},
+		UpdateNew: []*endpoint.Endpoint{updated},
+		Delete:    []*endpoint.Endpoint{bar},
+	}))
+	assert.Equal(t, "service/default/qux", aws.StringValue(client.items["foo.test-zone.example.org#A#"][dynamoDBLabelsAttribute].M[endpoint.ResourceLabelKey].S))
+	assert.NotContains(t, client.items, "bar.test-zone.example.org#A#")
+
+	records, err = p.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.test-zone.example.org", "5.6.7.8", endpoint.RecordTypeA),
+	}))
+}
+
+func TestDynamoDBRegistryOwnership(t *testing.T) {
+	p := provider.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	client := newFakeDynamoDB()
+	client.items["foo.test-zone.example.org#A#"] = map[string]*dynamodb.AttributeValue{
+		dynamoDBKeyAttribute:   {S: aws.String("foo.test-zone.example.org#A#")},
+		dynamoDBOwnerAttribute: {S: aws.String("owner-2")},
+	}
+	r := newTestDynamoDBRegistry(t, p, client, "owner")
+
+	// another instance claimed the record before it was created
+	require.NoError(t, r.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		},
+	}))
+	assert.Equal(t, "owner-2", client.owner("foo.test-zone.example.org#A#"))
+	assert.Equal(t, "owner", client.owner("bar.test-zone.example.org#A#"))
+
+	records, err := p.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA),
+	}))
+
+	// records of other instances are neither updated nor deleted
+	require.NoError(t, r.ApplyChanges(&plan.Changes{
+		Delete: []*endpoint.Endpoint{
+			newEndpointWithOwner("bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner-2"),
+		},
+	}))
+	assert.Equal(t, "owner", client.owner("bar.test-zone.example.org#A#"))
+}
+
+func TestDynamoDBRegistryOrphans(t *testing.T) {
+	p := provider.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	client := newFakeDynamoDB()
+	for _, key := range []string{"foo.test-zone.example.org#A#", "bar.test-zone.example.org#A#"} {
+		client.items[key] = map[string]*dynamodb.AttributeValue{
+			dynamoDBKeyAttribute:   {S: aws.String(key)},
+			dynamoDBOwnerAttribute: {S: aws.String("owner")},
+		}
+	}
+	client.items["baz.test-zone.example.org#A#"] = map[string]*dynamodb.AttributeValue{
+		dynamoDBKeyAttribute:   {S: aws.String("baz.test-zone.example.org#A#")},
+		dynamoDBOwnerAttribute: {S: aws.String("owner-2")},
+	}
+	r := newTestDynamoDBRegistry(t, p, client, "owner")
+
+	_, err := r.Records()
+	require.NoError(t, err)
+	require.NoError(t, r.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		},
+	}))
+
+	assert.Contains(t, client.items, "foo.test-zone.example.org#A#", "should keep the items of created records")
+	assert.NotContains(t, client.items, "bar.test-zone.example.org#A#", "should delete the items without records")
+	assert.Contains(t, client.items, "baz.test-zone.example.org#A#", "should keep the items of other instances")
+}
+
+func TestDynamoDBRegistryDryRun(t *testing.T) {
+	p := provider.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	client := newFakeDynamoDB()
+	r, err := newDynamoDBRegistry(p, "owner", client, "external-dns", true)
+	require.NoError(t, err)
+
+	require.NoError(t, r.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		},
+	}))
+	assert.Empty(t, client.items)
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import "github.com/kubernetes-incubator/external-dns/endpoint"
+
+// OwnedRecords returns copies of the records of the registry owned by ownerID without the labels internal to the
+// registry, e.g. to export them. All records are owned with a registry that doesn't track ownership.
+func OwnedRecords(r Registry, ownerID string) ([]*endpoint.Endpoint, error) {
+	records, err := r.Records()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := r.(*NoopRegistry); !ok {
+		records = filterOwnedRecords(ownerID, records)
+	}
+
+	owned := make([]*endpoint.Endpoint, 0, len(records))
+	for _, record := range records {
+		owned = append(owned, migratedRecord(record))
+	}
+	return owned, nil
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+	"github.com/kubernetes-incubator/external-dns/provider"
+)
+
+func TestOwnedRecords(t *testing.T) {
+	p := provider.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	require.NoError(t, p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=other\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("unowned.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		},
+	}))
+
+	r, err := NewTXTRegistry(p, "", "", "", "owner", 0, nil, false)
+	require.NoError(t, err)
+	owned, err := OwnedRecords(r, "owner")
+	require.NoError(t, err)
+	require.Len(t, owned, 1)
+	assert.Equal(t, "foo.test-zone.example.org", owned[0].DNSName)
+	assert.Equal(t, "owner", owned[0].Labels[endpoint.OwnerLabelKey])
+
+	noop, err := NewNoopRegistry(p)
+	require.NoError(t, err)
+	owned, err = OwnedRecords(noop, "owner")
+	require.NoError(t, err)
+	assert.Len(t, owned, 5)
+}
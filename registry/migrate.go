@@ -0,0 +1,150 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+)
+
+// ownershipStore is implemented by registries which can write and delete the ownership of records
+// without changing the records themselves, so that the ownership can be migrated between them.
+type ownershipStore interface {
+	Registry
+	// writeOwnership marks the records as owned by this instance, keeping their labels
+	writeOwnership(endpoints []*endpoint.Endpoint) error
+	// deleteOwnership removes the ownership of the records, which carry the labels returned by Records
+	deleteOwnership(endpoints []*endpoint.Endpoint) error
+}
+
+var (
+	_ ownershipStore = &TXTRegistry{}
+	_ ownershipStore = &DynamoDBRegistry{}
+)
+
+// MigrateOwnership moves the ownership of the records of ownerID from one registry to another.
+// The ownership is written to the target registry and verified for every record before it's deleted from
+// the source registry, so that a failed migration never leaves records without an owner. Records already
+// owned by ownerID in the target registry are skipped, so that an interrupted migration can be resumed.
+// In dry-run mode the records to migrate are only logged.
+func MigrateOwnership(from, to Registry, ownerID string, dryRun bool) error {
+	source, ok := from.(ownershipStore)
+	if !ok {
+		return fmt.Errorf("registry %T doesn't support migrating ownership", from)
+	}
+	target, ok := to.(ownershipStore)
+	if !ok {
+		return fmt.Errorf("registry %T doesn't support migrating ownership", to)
+	}
+
+	records, err := source.Records()
+	if err != nil {
+		return err
+	}
+	owned := filterOwnedRecords(ownerID, records)
+
+	targetRecords, err := target.Records()
+	if err != nil {
+		return err
+	}
+	targetLabels := recordLabels(targetRecords)
+
+	pending := []*endpoint.Endpoint{}
+	for _, r := range owned {
+		owner := targetLabels[ownershipKey(r)][endpoint.OwnerLabelKey]
+		switch owner {
+		case ownerID:
+			log.WithFields(r.LogFields()).Debug("Ownership is already migrated")
+		case "":
+			pending = append(pending, migratedRecord(r))
+		default:
+			return fmt.Errorf("%s %s is owned by %q in the target registry", r.RecordType, r.DNSName, owner)
+		}
+	}
+
+	log.Infof("Migrating the ownership of %d records, %d of them are already migrated", len(owned), len(owned)-len(pending))
+	if dryRun {
+		for _, r := range pending {
+			log.WithFields(r.LogFields()).Info("Would migrate the ownership")
+		}
+		return nil
+	}
+
+	if len(pending) > 0 {
+		if err := target.writeOwnership(pending); err != nil {
+			return fmt.Errorf("failed to write the ownership to the target registry: %v", err)
+		}
+	}
+
+	targetRecords, err = target.Records()
+	if err != nil {
+		return err
+	}
+	targetLabels = recordLabels(targetRecords)
+	unverified := 0
+	for _, r := range owned {
+		if !sameOwnership(migratedRecord(r).Labels, targetLabels[ownershipKey(r)]) {
+			log.WithFields(r.LogFields()).Error("Ownership couldn't be verified in the target registry")
+			unverified++
+		}
+	}
+	if unverified > 0 {
+		return fmt.Errorf("ownership of %d records couldn't be verified, the source registry was left unchanged", unverified)
+	}
+
+	if len(owned) > 0 {
+		if err := source.deleteOwnership(owned); err != nil {
+			return fmt.Errorf("failed to delete the ownership from the source registry: %v", err)
+		}
+	}
+	log.Infof("Migrated the ownership of %d records", len(owned))
+	return nil
+}
+
+// ownershipKey identifies a record independently of the registry
+func ownershipKey(r *endpoint.Endpoint) txtRecordKey {
+	return txtRecordKey{dnsName: r.DNSName, recordType: r.RecordType, setIdentifier: r.SetIdentifier}
+}
+
+// recordLabels returns the labels of the records by their ownershipKey
+func recordLabels(records []*endpoint.Endpoint) map[txtRecordKey]endpoint.Labels {
+	labels := map[txtRecordKey]endpoint.Labels{}
+	for _, r := range records {
+		labels[ownershipKey(r)] = r.Labels
+	}
+	return labels
+}
+
+// migratedRecord returns a copy of the record without the labels internal to the source registry
+func migratedRecord(r *endpoint.Endpoint) *endpoint.Endpoint {
+	c := copyEndpoint(r)
+	delete(c.Labels, txtEncryptionNonceLabelKey)
+	return c
+}
+
+// sameOwnership returns whether the target registry has all the labels written by the migration
+func sameOwnership(expected, actual endpoint.Labels) bool {
+	for key, value := range expected {
+		if actual[key] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"testing"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/internal/testutils"
+	"github.com/kubernetes-incubator/external-dns/plan"
+	"github.com/kubernetes-incubator/external-dns/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMigrationTestProvider returns a provider with records owned by owner, owner-2 and nobody by TXT records
+func newMigrationTestProvider(t *testing.T) *provider.InMemoryProvider {
+	p := provider.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	require.NoError(t, p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("a-foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=A,external-dns/resource=ingress/default/foo\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("bar.test-zone.example.org", "bar.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
+			newEndpointWithOwner("bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("baz.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("a-baz.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner-2,external-dns/record-type=A\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("qux.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		},
+	}))
+	return p
+}
+
+func TestMigrateOwnershipTXTToDynamoDB(t *testing.T) {
+	p := newMigrationTestProvider(t)
+	from, _ := NewTXTRegistry(p, "", "", "", "owner", 0, nil, false)
+	client := newFakeDynamoDB()
+	to := newTestDynamoDBRegistry(t, p, client, "owner")
+
+	require.NoError(t, MigrateOwnership(from, to, "owner", false))
+
+	assert.Equal(t, "owner", client.owner("foo.test-zone.example.org#A#"))
+	assert.Equal(t, "owner", client.owner("bar.test-zone.example.org#CNAME#"))
+	assert.Len(t, client.items, 2)
+
+	records, err := to.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		newEndpointWithOwnerResource("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner", "ingress/default/foo"),
+		newEndpointWithOwner("bar.test-zone.example.org", "bar.loadbalancer.com", endpoint.RecordTypeCNAME, "owner"),
+		newEndpointWithOwner("baz.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		newEndpointWithOwner("qux.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		newEndpointWithOwner("a-baz.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner-2,external-dns/record-type=A\"", endpoint.RecordTypeTXT, ""),
+	}), "only the TXT records of other owners should be left")
+}
+
+func TestMigrateOwnershipDynamoDBToTXT(t *testing.T) {
+	p := provider.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	require.NoError(t, p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		},
+	}))
+	client := newFakeDynamoDB()
+	from := newTestDynamoDBRegistry(t, p, client, "owner")
+	require.NoError(t, from.writeOwnership([]*endpoint.Endpoint{
+		newEndpointWithOwnerResource("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner", "ingress/default/foo"),
+	}))
+	to, _ := NewTXTRegistry(p, "", "", "", "owner", 0, nil, false)
+
+	require.NoError(t, MigrateOwnership(from, to, "owner", false))
+
+	assert.Empty(t, client.items)
+	records, err := p.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		newEndpointWithOwner("a-foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=A,external-dns/resource=ingress/default/foo\"", endpoint.RecordTypeTXT, ""),
+	}))
+}
+
+func TestMigrateOwnershipResume(t *testing.T) {
+	p := newMigrationTestProvider(t)
+	from, _ := NewTXTRegistry(p, "", "", "", "owner", 0, nil, false)
+	client := newFakeDynamoDB()
+	to := newTestDynamoDBRegistry(t, p, client, "owner")
+	require.NoError(t, to.writeOwnership([]*endpoint.Endpoint{
+		newEndpointWithOwnerResource("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner", "ingress/default/foo"),
+	}))
+
+	require.NoError(t, MigrateOwnership(from, to, "owner", false))
+	assert.Equal(t, "owner", client.owner("bar.test-zone.example.org#CNAME#"))
+	assert.Len(t, client.items, 2)
+}
+
+func TestMigrateOwnershipConflict(t *testing.T) {
+	p := newMigrationTestProvider(t)
+	from, _ := NewTXTRegistry(p, "", "", "", "owner", 0, nil, false)
+	client := newFakeDynamoDB()
+	other := newTestDynamoDBRegistry(t, p, client, "owner-2")
+	require.NoError(t, other.writeOwnership([]*endpoint.Endpoint{
+		newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner-2"),
+	}))
+	to := newTestDynamoDBRegistry(t, p, client, "owner")
+
+	assert.Error(t, MigrateOwnership(from, to, "owner", false))
+	assert.Len(t, client.items, 1, "nothing should be written")
+	records, err := p.Records()
+	require.NoError(t, err)
+	assert.Len(t, records, 7, "the TXT records should be kept")
+}
+
+func TestMigrateOwnershipVerificationFailure(t *testing.T) {
+	p := newMigrationTestProvider(t)
+	from, _ := NewTXTRegistry(p, "", "", "", "owner", 0, nil, false)
+	client := newFakeDynamoDB()
+	// a dry-run registry doesn't write anything, so the verification fails
+	to, err := newDynamoDBRegistry(p, "owner", client, "external-dns", true)
+	require.NoError(t, err)
+
+	assert.Error(t, MigrateOwnership(from, to, "owner", false))
+	records, err := p.Records()
+	require.NoError(t, err)
+	assert.Len(t, records, 7, "the TXT records should be kept")
+}
+
+func TestMigrateOwnershipDryRun(t *testing.T) {
+	p := newMigrationTestProvider(t)
+	from, _ := NewTXTRegistry(p, "", "", "", "owner", 0, nil, false)
+	client := newFakeDynamoDB()
+	to := newTestDynamoDBRegistry(t, p, client, "owner")
+
+	require.NoError(t, MigrateOwnership(from, to, "owner", true))
+	assert.Empty(t, client.items)
+	records, err := p.Records()
+	require.NoError(t, err)
+	assert.Len(t, records, 7)
+}
+
+func TestMigrateOwnershipUnsupportedRegistry(t *testing.T) {
+	p := newMigrationTestProvider(t)
+	txt, _ := NewTXTRegistry(p, "", "", "", "owner", 0, nil, false)
+	noop, _ := NewNoopRegistry(p)
+
+	assert.Error(t, MigrateOwnership(noop, txt, "owner", false))
+	assert.Error(t, MigrateOwnership(txt, noop, "owner", false))
+}
@@ -36,10 +36,23 @@ func filterOwnedRecords(ownerID string, eps []*endpoint.Endpoint) []*endpoint.En
 	filtered := []*endpoint.Endpoint{}
 	for _, ep := range eps {
 		if endpointOwner, ok := ep.Labels[endpoint.OwnerLabelKey]; !ok || endpointOwner != ownerID {
-			log.Debugf(`Skipping endpoint %v because owner id does not match, found: "%s", required: "%s"`, ep, endpointOwner, ownerID)
+			log.WithFields(ep.LogFields()).Debugf(`Skipping endpoint because owner id does not match, found: "%s", required: "%s"`, endpointOwner, ownerID)
 			continue
 		}
 		filtered = append(filtered, ep)
 	}
 	return filtered
 }
+
+// setStoredLabels replaces the labels of a record listed by the provider with the ones stored by the registry.
+// The provider label set by the MultiProvider is kept, since it identifies the provider the record was listed from.
+func setStoredLabels(record *endpoint.Endpoint, stored endpoint.Labels) {
+	labels := make(endpoint.Labels, len(stored)+1)
+	for key, value := range stored {
+		labels[key] = value
+	}
+	if provider, ok := record.Labels[endpoint.ProviderLabelKey]; ok {
+		labels[endpoint.ProviderLabelKey] = provider
+	}
+	record.Labels = labels
+}
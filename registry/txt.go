@@ -18,40 +18,122 @@ package registry
 
 import (
 	"errors"
-
 	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
 
 	"github.com/kubernetes-incubator/external-dns/endpoint"
 	"github.com/kubernetes-incubator/external-dns/plan"
 	"github.com/kubernetes-incubator/external-dns/provider"
 )
 
+const (
+	// recordTypePlaceholder is replaced by the lower case type of the owned record in the TXT prefix and suffix
+	recordTypePlaceholder = "%{record_type}"
+
+	// txtRecordTypeLabelKey stores the type of the record owned by a TXT record of the current format
+	txtRecordTypeLabelKey = "record-type"
+	// txtSetIdentifierLabelKey stores the set identifier of the record owned by a TXT record of the current format
+	txtSetIdentifierLabelKey = "set-identifier"
+	// txtEncryptionNonceLabelKey keeps the nonce of an encrypted TXT record, so that its value can be reconstructed
+	txtEncryptionNonceLabelKey = "txt-encryption-nonce"
+)
+
+// txtRoutingProperties are the provider specific properties which belong to the routing policy of a record.
+// The TXT record has to share them, e.g. failover records only accept other failover records with the same name.
+var txtRoutingProperties = []string{"aws/failover"}
+
 // TXTRegistry implements registry interface with ownership implemented via associated TXT records
 type TXTRegistry struct {
 	provider provider.Provider
 	ownerID  string //refers to the owner id of the current instance
 	mapper   nameMapper
+
+	// encryptor encrypts the payload of the TXT records, nil if they are written in plain text
+	encryptor *TXTEncryptor
+
+	// migration holds the changes converting the TXT records of a legacy format or encryption found by the last call to Records
+	migration *plan.Changes
+
+	// writeLegacy keeps a TXT record of the legacy format for every name owned by this instance, so that
+	// releases only understanding the legacy format still recognize the records
+	writeLegacy bool
+	// legacyRecords holds the TXT records of the legacy format by the name of their records, ownedRecords counts
+	// the records of this instance by name. Both are listed by Records and kept up to date with the applied changes.
+	legacyRecords map[string]legacyTXTRecord
+	ownedRecords  map[string]int
+
+	// cacheInterval is the duration the records are served from recordsCache before they're listed again, 0 disables the cache.
+	// Unlike the provider cache, recordsCache is kept up to date with the applied changes instead of being dropped.
+	cacheInterval           time.Duration
+	recordsCache            []*endpoint.Endpoint
+	recordsCacheRefreshTime time.Time
+	// now is used to determine the age of the cache, it can be replaced in tests
+	now func() time.Time
+}
+
+// legacyTXTRecord is a TXT record of the legacy format and the owner found in its labels
+type legacyTXTRecord struct {
+	record *endpoint.Endpoint
+	owner  string
+}
+
+// txtRecordKey identifies the record owned by a TXT record
+type txtRecordKey struct {
+	dnsName       string
+	recordType    string
+	setIdentifier string
 }
 
 // NewTXTRegistry returns new TXTRegistry object
-func NewTXTRegistry(provider provider.Provider, txtPrefix, ownerID string) (*TXTRegistry, error) {
+// The TXT records are named after their records with either the prefix or the suffix applied,
+// both may contain the recordTypePlaceholder. The wildcard label of wildcard records is replaced by
+// txtWildcardReplacement in the names of their TXT records if it's set.
+// The records are cached for cacheInterval if it's positive.
+// The payload of the TXT records is encrypted if an encryptor is given.
+// If writeLegacy is set, the TXT records of the legacy format are kept until the last record of their name is deleted.
+func NewTXTRegistry(provider provider.Provider, txtPrefix, txtSuffix, txtWildcardReplacement, ownerID string, cacheInterval time.Duration, encryptor *TXTEncryptor, writeLegacy bool) (*TXTRegistry, error) {
 	if ownerID == "" {
 		return nil, errors.New("owner id cannot be empty")
 	}
+	if txtPrefix != "" && txtSuffix != "" {
+		return nil, errors.New("txt prefix and suffix are mutually exclusive")
+	}
 
-	mapper := newPrefixNameMapper(txtPrefix)
+	mapper := newAffixNameMapper(txtPrefix, txtSuffix, txtWildcardReplacement)
 
 	return &TXTRegistry{
-		provider: provider,
-		ownerID:  ownerID,
-		mapper:   mapper,
+		provider:      provider,
+		ownerID:       ownerID,
+		mapper:        mapper,
+		encryptor:     encryptor,
+		writeLegacy:   writeLegacy,
+		legacyRecords: map[string]legacyTXTRecord{},
+		ownedRecords:  map[string]int{},
+		cacheInterval: cacheInterval,
+		now:           time.Now,
 	}, nil
 }
 
 // Records returns the current records from the registry excluding TXT Records
 // If TXT records was created previously to indicate ownership its corresponding value
 // will be added to the endpoints Labels map
+//
+// TXT records of the current format are named after the type of the record they own, e.g. a-foo.example.org,
+// and store its type and set identifier, so that records of the same name but different types or set
+// identifiers are owned separately. TXT records of the legacy format, named exactly like the records they
+// own, are still understood; the records of this instance relying on them are migrated on the next call to ApplyChanges.
+// The TXT records of the legacy format of this instance are deleted by the migration unless writeLegacy is set,
+// in which case the names of this instance missing one get a TXT record of the legacy format instead.
+//
+// If the cache is enabled and younger than the cache interval, the records are served from the cache.
 func (im *TXTRegistry) Records() ([]*endpoint.Endpoint, error) {
+	if im.cacheValid() {
+		log.Debug("TXT registry: using records from cache")
+		return copyEndpoints(im.recordsCache), nil
+	}
+
 	records, err := im.provider.Records()
 	if err != nil {
 		return nil, err
@@ -59,7 +141,10 @@ func (im *TXTRegistry) Records() ([]*endpoint.Endpoint, error) {
 
 	endpoints := []*endpoint.Endpoint{}
 
-	labelMap := map[string]endpoint.Labels{}
+	labelMap := map[txtRecordKey]endpoint.Labels{}
+	im.legacyRecords = map[string]legacyTXTRecord{}
+	im.ownedRecords = map[string]int{}
+	im.migration = &plan.Changes{}
 
 	for _, record := range records {
 		if record.RecordType != endpoint.RecordTypeTXT {
@@ -67,7 +152,12 @@ func (im *TXTRegistry) Records() ([]*endpoint.Endpoint, error) {
 			continue
 		}
 		// We simply assume that TXT records for the registry will always have only one target.
-		labels, err := endpoint.NewLabelsFromString(record.Targets[0])
+		// Its character-strings are joined, long payloads are split into multiple strings.
+		payload, nonce, outdated := unquoteTXTValue(record.Targets[0]), "", false
+		if im.encryptor != nil {
+			payload, nonce, outdated = im.encryptor.decrypt(payload)
+		}
+		labels, err := endpoint.NewLabelsFromString(payload)
 		if err == endpoint.ErrInvalidHeritage {
 			//if no heritage is found or it is invalid
 			//case when value of txt record cannot be identified
@@ -78,59 +168,410 @@ func (im *TXTRegistry) Records() ([]*endpoint.Endpoint, error) {
 		if err != nil {
 			return nil, err
 		}
-		endpointDNSName := im.mapper.toEndpointName(record.DNSName)
-		labelMap[endpointDNSName] = labels
+		if nonce != "" {
+			labels[txtEncryptionNonceLabelKey] = nonce
+		}
+		// re-encrypt the TXT records of this instance, legacy ones are replaced by the migration below
+		if outdated && labels[endpoint.OwnerLabelKey] == im.ownerID && labels[txtRecordTypeLabelKey] != "" {
+			txt, err := im.reencryptTXTRecord(record, labels)
+			if err != nil {
+				return nil, err
+			}
+			im.migration.UpdateOld = append(im.migration.UpdateOld, record)
+			im.migration.UpdateNew = append(im.migration.UpdateNew, txt)
+		}
+		key := txtRecordKey{
+			recordType:    labels[txtRecordTypeLabelKey],
+			setIdentifier: labels[txtSetIdentifierLabelKey],
+		}
+		key.dnsName = im.mapper.toEndpointName(record.DNSName, key.recordType)
+		delete(labels, txtRecordTypeLabelKey)
+		delete(labels, txtSetIdentifierLabelKey)
+
+		if key.recordType == "" {
+			im.legacyRecords[key.dnsName] = legacyTXTRecord{record: record, owner: labels[endpoint.OwnerLabelKey]}
+		}
+		labelMap[key] = labels
 	}
 
 	for _, ep := range endpoints {
-		if labels, ok := labelMap[ep.DNSName]; ok {
-			ep.Labels = labels
+		if labels, ok := labelMap[txtRecordKey{ep.DNSName, ep.RecordType, ep.SetIdentifier}]; ok {
+			setStoredLabels(ep, labels)
+		} else if labels, ok := labelMap[txtRecordKey{dnsName: ep.DNSName}]; ok {
+			setStoredLabels(ep, labels)
+			if labels[endpoint.OwnerLabelKey] == im.ownerID {
+				txt, err := im.newTXTRecord(ep)
+				if err != nil {
+					return nil, err
+				}
+				im.migration.Create = append(im.migration.Create, txt)
+			}
 		} else {
 			//this indicates that owner could not be identified, as there is no corresponding TXT record
-			ep.Labels = endpoint.NewLabels()
+			setStoredLabels(ep, endpoint.NewLabels())
+		}
+		if ep.Labels[endpoint.OwnerLabelKey] == im.ownerID {
+			im.ownedRecords[ep.DNSName]++
 		}
 	}
 
+	if im.writeLegacy {
+		// every name of this instance keeps a legacy TXT record, e.g. the ones of records created while it was disabled
+		legacy, err := im.legacyTXTChanges(&plan.Changes{Create: filterOwnedRecords(im.ownerID, endpoints)})
+		if err != nil {
+			return nil, err
+		}
+		im.migration.Create = append(im.migration.Create, legacy.Create...)
+	} else {
+		// every record of a name with a legacy TXT record of this instance has or got its own TXT record
+		for dnsName, txt := range im.legacyRecords {
+			if txt.owner == im.ownerID && im.ownedRecords[dnsName] > 0 {
+				im.migration.Delete = append(im.migration.Delete, txt.record)
+				delete(im.legacyRecords, dnsName)
+			}
+		}
+	}
+
+	if im.cacheInterval > 0 {
+		im.recordsCache = copyEndpoints(endpoints)
+		im.recordsCacheRefreshTime = im.now()
+	}
+
 	return endpoints, nil
 }
 
 // ApplyChanges updates dns provider with the changes
 // for each created/deleted record it will also take into account TXT records for creation/deletion
 func (im *TXTRegistry) ApplyChanges(changes *plan.Changes) error {
+	if im.migration != nil && im.migration.HasChanges() {
+		log.Infof("Converting TXT records to the current format: %d to create, %d to update, %d to delete",
+			len(im.migration.Create), len(im.migration.UpdateNew), len(im.migration.Delete))
+		if err := im.provider.ApplyChanges(im.migration); err != nil {
+			im.resetCache()
+			return err
+		}
+	}
+	im.migration = nil
+
 	filteredChanges := &plan.Changes{
 		Create:    changes.Create,
 		UpdateNew: filterOwnedRecords(im.ownerID, changes.UpdateNew),
 		UpdateOld: filterOwnedRecords(im.ownerID, changes.UpdateOld),
 		Delete:    filterOwnedRecords(im.ownerID, changes.Delete),
 	}
+	// the changes without the TXT records of the registry, which aren't returned by Records
+	recordChanges := *filteredChanges
 	for _, r := range filteredChanges.Create {
 		r.Labels[endpoint.OwnerLabelKey] = im.ownerID
-		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName), r.Labels.Serialize(true), endpoint.RecordTypeTXT)
+		if err := im.setEncryptionNonce(r); err != nil {
+			return err
+		}
+		txt, err := im.newTXTRecord(r)
+		if err != nil {
+			return err
+		}
 		filteredChanges.Create = append(filteredChanges.Create, txt)
 	}
 
 	for _, r := range filteredChanges.Delete {
-		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName), r.Labels.Serialize(true), endpoint.RecordTypeTXT)
-
 		// when we delete TXT records for which value has changed (due to new label) this would still work because
 		// !!! TXT record value is uniquely generated from the Labels of the endpoint. Hence old TXT record can be uniquely reconstructed
+		// encrypted values are reconstructed with the nonce kept in the labels
+		txt, err := im.newTXTRecord(r)
+		if err != nil {
+			return err
+		}
 		filteredChanges.Delete = append(filteredChanges.Delete, txt)
 	}
 
 	// make sure TXT records are consistently updated as well
 	for _, r := range filteredChanges.UpdateNew {
-		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName), r.Labels.Serialize(true), endpoint.RecordTypeTXT)
+		if err := im.setEncryptionNonce(r); err != nil {
+			return err
+		}
+		txt, err := im.newTXTRecord(r)
+		if err != nil {
+			return err
+		}
 		filteredChanges.UpdateNew = append(filteredChanges.UpdateNew, txt)
 	}
 	// make sure TXT records are consistently updated as well
 	for _, r := range filteredChanges.UpdateOld {
-		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName), r.Labels.Serialize(true), endpoint.RecordTypeTXT)
 		// when we updateOld TXT records for which value has changed (due to new label) this would still work because
 		// !!! TXT record value is uniquely generated from the Labels of the endpoint. Hence old TXT record can be uniquely reconstructed
+		txt, err := im.newTXTRecord(r)
+		if err != nil {
+			return err
+		}
 		filteredChanges.UpdateOld = append(filteredChanges.UpdateOld, txt)
 	}
+	if im.writeLegacy {
+		legacy, err := im.legacyTXTChanges(&recordChanges)
+		if err != nil {
+			return err
+		}
+		filteredChanges.Create = append(filteredChanges.Create, legacy.Create...)
+		filteredChanges.Delete = append(filteredChanges.Delete, legacy.Delete...)
+	}
+	im.countOwnedRecords(&recordChanges)
 
-	return im.provider.ApplyChanges(filteredChanges)
+	if err := im.provider.ApplyChanges(filteredChanges); err != nil {
+		// the changes may have been applied partially, so the cache can't be trusted anymore
+		im.resetCache()
+		return err
+	}
+	im.updateCache(&recordChanges)
+	return nil
+}
+
+// writeOwnership creates the TXT records marking the records as owned by this instance
+func (im *TXTRegistry) writeOwnership(endpoints []*endpoint.Endpoint) error {
+	creates := []*endpoint.Endpoint{}
+	for _, ep := range endpoints {
+		r := copyEndpoint(ep)
+		if r.Labels == nil {
+			r.Labels = endpoint.NewLabels()
+		}
+		r.Labels[endpoint.OwnerLabelKey] = im.ownerID
+		txt, err := im.newTXTRecord(r)
+		if err != nil {
+			return err
+		}
+		creates = append(creates, txt)
+	}
+	if im.writeLegacy {
+		legacy, err := im.legacyTXTChanges(&plan.Changes{Create: endpoints})
+		if err != nil {
+			return err
+		}
+		creates = append(creates, legacy.Create...)
+	}
+	im.resetCache()
+	return im.provider.ApplyChanges(&plan.Changes{Create: creates})
+}
+
+// deleteOwnership deletes the TXT records of the records. The TXT records of legacy formats or encryptions
+// found by the last call to Records are converted first, so that all TXT records can be reconstructed from the labels.
+func (im *TXTRegistry) deleteOwnership(endpoints []*endpoint.Endpoint) error {
+	if err := im.ApplyChanges(&plan.Changes{}); err != nil {
+		return err
+	}
+	deletes := []*endpoint.Endpoint{}
+	owned := filterOwnedRecords(im.ownerID, endpoints)
+	for _, ep := range owned {
+		txt, err := im.newTXTRecord(ep)
+		if err != nil {
+			return err
+		}
+		deletes = append(deletes, txt)
+	}
+	if im.writeLegacy {
+		legacy, err := im.legacyTXTChanges(&plan.Changes{Delete: owned})
+		if err != nil {
+			return err
+		}
+		deletes = append(deletes, legacy.Delete...)
+	}
+	im.resetCache()
+	return im.provider.ApplyChanges(&plan.Changes{Delete: deletes})
+}
+
+// legacyTXTChanges returns the changes of the TXT records of the legacy format following the given changes of records
+// of this instance: the first record of a name gets a legacy TXT record, unless the name already has one, and the
+// legacy TXT record of this instance is deleted with the last record of its name.
+// The legacy TXT records are recorded as applied, a failure to apply them resets the cache so that they're listed again.
+func (im *TXTRegistry) legacyTXTChanges(changes *plan.Changes) (*plan.Changes, error) {
+	legacy := &plan.Changes{}
+	remaining := map[string]int{}
+	for _, r := range changes.Create {
+		remaining[r.DNSName]++
+	}
+	for _, r := range changes.Delete {
+		remaining[r.DNSName]--
+	}
+
+	for _, r := range changes.Create {
+		if _, ok := im.legacyRecords[r.DNSName]; ok {
+			continue
+		}
+		txt, err := im.newLegacyTXTRecord(r)
+		if err != nil {
+			return nil, err
+		}
+		legacy.Create = append(legacy.Create, txt)
+		im.legacyRecords[r.DNSName] = legacyTXTRecord{record: txt, owner: im.ownerID}
+	}
+	for _, r := range changes.Delete {
+		txt, ok := im.legacyRecords[r.DNSName]
+		if !ok || txt.owner != im.ownerID || im.ownedRecords[r.DNSName]+remaining[r.DNSName] > 0 {
+			continue
+		}
+		legacy.Delete = append(legacy.Delete, txt.record)
+		delete(im.legacyRecords, r.DNSName)
+	}
+	return legacy, nil
+}
+
+// countOwnedRecords counts the created and deleted records of this instance by name
+func (im *TXTRegistry) countOwnedRecords(changes *plan.Changes) {
+	for _, r := range changes.Create {
+		im.ownedRecords[r.DNSName]++
+	}
+	for _, r := range changes.Delete {
+		im.ownedRecords[r.DNSName]--
+	}
+}
+
+// cacheValid returns whether the records can be served from the cache
+func (im *TXTRegistry) cacheValid() bool {
+	if im.cacheInterval <= 0 || im.recordsCacheRefreshTime.IsZero() {
+		return false
+	}
+	return im.now().Sub(im.recordsCacheRefreshTime) < im.cacheInterval
+}
+
+// resetCache drops the cached records, so that they're listed again by the next call to Records
+func (im *TXTRegistry) resetCache() {
+	im.recordsCache = nil
+	im.recordsCacheRefreshTime = time.Time{}
+}
+
+// updateCache applies the changes to the cached records
+func (im *TXTRegistry) updateCache(changes *plan.Changes) {
+	if !im.cacheValid() {
+		return
+	}
+	removed := map[txtRecordKey]bool{}
+	for _, ep := range append(append([]*endpoint.Endpoint{}, changes.UpdateOld...), changes.Delete...) {
+		removed[txtRecordKey{ep.DNSName, ep.RecordType, ep.SetIdentifier}] = true
+	}
+	records := []*endpoint.Endpoint{}
+	for _, ep := range im.recordsCache {
+		if !removed[txtRecordKey{ep.DNSName, ep.RecordType, ep.SetIdentifier}] {
+			records = append(records, ep)
+		}
+	}
+	for _, ep := range append(append([]*endpoint.Endpoint{}, changes.Create...), changes.UpdateNew...) {
+		records = append(records, copyEndpoint(ep))
+	}
+	im.recordsCache = records
+}
+
+// newTXTRecord returns the TXT record of the current format owning the given record.
+// It shares the routing policy of the record, so that it can coexist with the records of other set identifiers.
+func (im *TXTRegistry) newTXTRecord(r *endpoint.Endpoint) (*endpoint.Endpoint, error) {
+	labels := endpoint.NewLabels()
+	for key, value := range r.Labels {
+		labels[key] = value
+	}
+	if r.RecordType != "" {
+		labels[txtRecordTypeLabelKey] = r.RecordType
+	}
+	if r.SetIdentifier != "" {
+		labels[txtSetIdentifierLabelKey] = r.SetIdentifier
+	}
+	payload, err := im.txtPayload(labels)
+	if err != nil {
+		return nil, err
+	}
+
+	txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName, r.RecordType), payload, endpoint.RecordTypeTXT)
+	txt.SetIdentifier = r.SetIdentifier
+	if r.GeoLocation != nil {
+		geo := *r.GeoLocation
+		txt.GeoLocation = &geo
+	}
+	for _, property := range txtRoutingProperties {
+		if value, ok := r.GetProviderSpecificProperty(property); ok {
+			txt.WithProviderSpecific(property, value)
+		}
+	}
+	// the TXT record goes to the provider of the record
+	if provider, ok := r.Labels[endpoint.ProviderLabelKey]; ok {
+		txt.Labels[endpoint.ProviderLabelKey] = provider
+	}
+	return txt, nil
+}
+
+// setEncryptionNonce stores a new nonce in the labels of a record without one when the TXT records are encrypted.
+// The cached record keeps the nonce, so that its TXT record can be reconstructed without listing it again.
+func (im *TXTRegistry) setEncryptionNonce(r *endpoint.Endpoint) error {
+	if im.encryptor == nil || im.encryptor.key == nil || r.Labels[txtEncryptionNonceLabelKey] != "" {
+		return nil
+	}
+	nonce, err := im.encryptor.newNonce()
+	if err != nil {
+		return err
+	}
+	if r.Labels == nil {
+		r.Labels = endpoint.NewLabels()
+	}
+	r.Labels[txtEncryptionNonceLabelKey] = nonce
+	return nil
+}
+
+// newLegacyTXTRecord returns the TXT record of the legacy format owning the records of the name of the given record.
+// It's named exactly like the record and doesn't share its type, set identifier or routing policy.
+// Its payload is encrypted with a nonce of its own, the record is deleted as listed rather than reconstructed.
+func (im *TXTRegistry) newLegacyTXTRecord(r *endpoint.Endpoint) (*endpoint.Endpoint, error) {
+	labels := endpoint.NewLabels()
+	for key, value := range r.Labels {
+		if key != txtEncryptionNonceLabelKey {
+			labels[key] = value
+		}
+	}
+	labels[endpoint.OwnerLabelKey] = im.ownerID
+	payload, err := im.txtPayload(labels)
+	if err != nil {
+		return nil, err
+	}
+
+	txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName, ""), payload, endpoint.RecordTypeTXT)
+	if provider, ok := r.Labels[endpoint.ProviderLabelKey]; ok {
+		txt.Labels[endpoint.ProviderLabelKey] = provider
+	}
+	return txt, nil
+}
+
+// reencryptTXTRecord returns a copy of the TXT record with its labels encrypted as currently configured.
+// The nonce of the labels is kept, so that the new value can be reconstructed from the labels of the owned record.
+func (im *TXTRegistry) reencryptTXTRecord(record *endpoint.Endpoint, labels endpoint.Labels) (*endpoint.Endpoint, error) {
+	if im.encryptor.key != nil && labels[txtEncryptionNonceLabelKey] == "" {
+		nonce, err := im.encryptor.newNonce()
+		if err != nil {
+			return nil, err
+		}
+		labels[txtEncryptionNonceLabelKey] = nonce
+	}
+	payload, err := im.txtPayload(labels)
+	if err != nil {
+		return nil, err
+	}
+
+	txt := *record
+	txt.Targets = endpoint.Targets{payload}
+	txt.Labels = endpoint.NewLabels()
+	return &txt, nil
+}
+
+// txtPayload serializes the labels into the value of a TXT record, encrypting them if a key is configured.
+// Values exceeding the length of a single character-string are split into multiple strings.
+func (im *TXTRegistry) txtPayload(labels endpoint.Labels) (string, error) {
+	nonce := labels[txtEncryptionNonceLabelKey]
+	plain := endpoint.NewLabels()
+	for key, value := range labels {
+		if key != txtEncryptionNonceLabelKey {
+			plain[key] = value
+		}
+	}
+	if im.encryptor == nil || im.encryptor.key == nil {
+		return quoteTXTValue(plain.Serialize(false)), nil
+	}
+	encrypted, err := im.encryptor.encrypt(plain.Serialize(false), nonce)
+	if err != nil {
+		return "", err
+	}
+	return quoteTXTValue(encrypted), nil
 }
 
 /**
@@ -139,31 +580,102 @@ func (im *TXTRegistry) ApplyChanges(changes *plan.Changes) error {
 
 /**
   nameMapper defines interface which maps the dns name defined for the source
-  to the dns name which TXT record will be created with
+  to the dns name which TXT record will be created with.
+  An empty record type maps to the name of the legacy format shared by all record types.
 */
 
 type nameMapper interface {
-	toEndpointName(string) string
-	toTXTName(string) string
+	toEndpointName(txtDNSName, recordType string) string
+	toTXTName(endpointDNSName, recordType string) string
 }
 
-type prefixNameMapper struct {
-	prefix string
+// affixNameMapper names the TXT records by prefixing the dns name or by appending a suffix to its first label,
+// e.g. foo.example.org becomes txt.a-foo.example.org or foo-a-txt.example.org.
+// The type of the record is prefixed to the dns name unless the affix contains the recordTypePlaceholder.
+// The wildcard label of wildcard records is replaced by wildcardReplacement if it's set, since
+// names like a-*.example.org aren't accepted by all providers, e.g. *.example.org becomes a-wildcard.example.org.
+type affixNameMapper struct {
+	prefix              string
+	suffix              string
+	wildcardReplacement string
 }
 
-var _ nameMapper = prefixNameMapper{}
+var _ nameMapper = affixNameMapper{}
 
-func newPrefixNameMapper(prefix string) prefixNameMapper {
-	return prefixNameMapper{prefix: prefix}
+func newAffixNameMapper(prefix, suffix, wildcardReplacement string) affixNameMapper {
+	return affixNameMapper{prefix: prefix, suffix: suffix, wildcardReplacement: wildcardReplacement}
 }
 
-func (pr prefixNameMapper) toEndpointName(txtDNSName string) string {
-	if strings.HasPrefix(txtDNSName, pr.prefix) {
-		return strings.TrimPrefix(txtDNSName, pr.prefix)
+// affixes returns the prefix and suffix of the TXT record owning a record of the given type
+func (am affixNameMapper) affixes(recordType string) (string, string) {
+	recordType = strings.ToLower(recordType)
+	prefix := strings.Replace(am.prefix, recordTypePlaceholder, recordType, -1)
+	suffix := strings.Replace(am.suffix, recordTypePlaceholder, recordType, -1)
+
+	if recordType != "" && !strings.Contains(am.prefix+am.suffix, recordTypePlaceholder) {
+		prefix += recordType + "-"
+	}
+	return prefix, suffix
+}
+
+func (am affixNameMapper) toEndpointName(txtDNSName, recordType string) string {
+	prefix, suffix := am.affixes(recordType)
+	if !strings.HasPrefix(txtDNSName, prefix) {
+		return ""
+	}
+	labels := strings.SplitN(strings.TrimPrefix(txtDNSName, prefix), ".", 2)
+	if !strings.HasSuffix(labels[0], suffix) {
+		return ""
+	}
+	labels[0] = strings.TrimSuffix(labels[0], suffix)
+	if am.replacesWildcard(recordType) && labels[0] == am.wildcardReplacement {
+		labels[0] = "*"
+	}
+	return strings.Join(labels, ".")
+}
+
+func (am affixNameMapper) toTXTName(endpointDNSName, recordType string) string {
+	prefix, suffix := am.affixes(recordType)
+	labels := strings.SplitN(endpointDNSName, ".", 2)
+	if am.replacesWildcard(recordType) && labels[0] == "*" {
+		labels[0] = am.wildcardReplacement
+	}
+	labels[0] += suffix
+	return prefix + strings.Join(labels, ".")
+}
+
+// replacesWildcard returns whether the wildcard label is replaced in the names of TXT records of the given type.
+// The legacy format never replaced it, so that legacy TXT records of wildcard records are still found.
+func (am affixNameMapper) replacesWildcard(recordType string) bool {
+	return am.wildcardReplacement != "" && recordType != ""
+}
+
+// copyEndpoints returns deep copies of the endpoints, so that the cache doesn't share memory with the endpoints of callers
+func copyEndpoints(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	copies := make([]*endpoint.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		copies = append(copies, copyEndpoint(ep))
 	}
-	return ""
+	return copies
 }
 
-func (pr prefixNameMapper) toTXTName(endpointDNSName string) string {
-	return pr.prefix + endpointDNSName
+func copyEndpoint(ep *endpoint.Endpoint) *endpoint.Endpoint {
+	c := *ep
+	if ep.Targets != nil {
+		c.Targets = append(endpoint.Targets{}, ep.Targets...)
+	}
+	if ep.Labels != nil {
+		c.Labels = endpoint.NewLabels()
+		for key, value := range ep.Labels {
+			c.Labels[key] = value
+		}
+	}
+	if ep.GeoLocation != nil {
+		geo := *ep.GeoLocation
+		c.GeoLocation = &geo
+	}
+	if ep.ProviderSpecific != nil {
+		c.ProviderSpecific = append(endpoint.ProviderSpecific{}, ep.ProviderSpecific...)
+	}
+	return &c
 }
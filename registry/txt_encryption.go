@@ -0,0 +1,124 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TXTEncryptor encrypts the payload of the TXT records with AES-GCM, so that the owner id and labels
+// of the records aren't readable by anyone querying the DNS.
+// Payloads are decrypted with the current key or any of the old keys, which allows rotating the key:
+// payloads encrypted with an old key are re-encrypted with the current one.
+type TXTEncryptor struct {
+	// key encrypts new payloads, payloads are written in plain text if it's nil
+	key     cipher.AEAD
+	oldKeys []cipher.AEAD
+}
+
+// NewTXTEncryptor returns a TXTEncryptor for the base64 encoded AES keys of 16, 24 or 32 bytes.
+// Without a current key the payloads are decrypted but written in plain text, which allows turning off the encryption.
+func NewTXTEncryptor(key string, oldKeys []string) (*TXTEncryptor, error) {
+	e := &TXTEncryptor{}
+	if key != "" {
+		aead, err := newAESGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		e.key = aead
+	}
+	for _, oldKey := range oldKeys {
+		if oldKey == "" {
+			continue
+		}
+		aead, err := newAESGCM(oldKey)
+		if err != nil {
+			return nil, err
+		}
+		e.oldKeys = append(e.oldKeys, aead)
+	}
+	if e.key == nil && len(e.oldKeys) == 0 {
+		return nil, fmt.Errorf("no TXT encryption key specified")
+	}
+	return e, nil
+}
+
+func newAESGCM(key string) (cipher.AEAD, error) {
+	data, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("TXT encryption key is not base64 encoded: %v", err)
+	}
+	block, err := aes.NewCipher(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TXT encryption key: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// encrypt returns the base64 encoded nonce and cipher text of the payload.
+// The encoded nonce is reused if given, so that the value of an existing TXT record can be reconstructed,
+// otherwise a new one is generated.
+func (e *TXTEncryptor) encrypt(payload, encodedNonce string) (string, error) {
+	if encodedNonce == "" {
+		var err error
+		if encodedNonce, err = e.newNonce(); err != nil {
+			return "", err
+		}
+	}
+	nonce, err := base64.RawURLEncoding.DecodeString(encodedNonce)
+	if err != nil || len(nonce) != e.key.NonceSize() {
+		return "", fmt.Errorf("invalid TXT encryption nonce %q", encodedNonce)
+	}
+	data := e.key.Seal(nonce, nonce, []byte(payload), nil)
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// newNonce returns a random, encoded nonce for the current key.
+func (e *TXTEncryptor) newNonce() (string, error) {
+	nonce := make([]byte, e.key.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(nonce), nil
+}
+
+// decrypt returns the payload of the TXT record value and its encoded nonce, the value is returned as is
+// if it isn't encrypted with any of the keys. outdated is true if the value isn't written as encrypt would,
+// i.e. it's in plain text although there is a current key, or it's encrypted with an old key.
+func (e *TXTEncryptor) decrypt(value string) (payload, encodedNonce string, outdated bool) {
+	data, err := base64.StdEncoding.DecodeString(strings.Trim(value, "\""))
+	if err == nil {
+		for i, aead := range append([]cipher.AEAD{e.key}, e.oldKeys...) {
+			if aead == nil || len(data) < aead.NonceSize() {
+				continue
+			}
+			nonce := data[:aead.NonceSize()]
+			plain, err := aead.Open(nil, nonce, data[aead.NonceSize():], nil)
+			if err != nil {
+				continue
+			}
+			return string(plain), base64.RawURLEncoding.EncodeToString(nonce), i > 0 || e.key == nil
+		}
+	}
+	return value, "", e.key != nil
+}
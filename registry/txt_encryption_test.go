@@ -0,0 +1,259 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/internal/testutils"
+	"github.com/kubernetes-incubator/external-dns/plan"
+	"github.com/kubernetes-incubator/external-dns/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// base64 encoded keys of 32 bytes
+	testAESKey    = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	testOldAESKey = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+func newTestTXTEncryptor(t *testing.T, key string, oldKeys ...string) *TXTEncryptor {
+	e, err := NewTXTEncryptor(key, oldKeys)
+	require.NoError(t, err)
+	return e
+}
+
+func TestNewTXTEncryptor(t *testing.T) {
+	_, err := NewTXTEncryptor("", []string{""})
+	assert.Error(t, err, "should require a key")
+
+	_, err = NewTXTEncryptor("not base64", nil)
+	assert.Error(t, err)
+
+	_, err = NewTXTEncryptor("c2hvcnQ=", nil)
+	assert.Error(t, err, "should reject keys of invalid length")
+
+	_, err = NewTXTEncryptor(testAESKey, []string{"not base64"})
+	assert.Error(t, err)
+
+	_, err = NewTXTEncryptor("", []string{testOldAESKey})
+	assert.NoError(t, err, "should accept old keys only to turn off the encryption")
+}
+
+func TestTXTEncryptorEncryptDecrypt(t *testing.T) {
+	e := newTestTXTEncryptor(t, testAESKey)
+	payload := "heritage=external-dns,external-dns/owner=owner"
+
+	value, err := e.encrypt(payload, "")
+	require.NoError(t, err)
+	assert.NotContains(t, value, "owner")
+	assert.NotContains(t, value, "\"")
+
+	decrypted, nonce, outdated := e.decrypt(value)
+	assert.Equal(t, payload, decrypted)
+	assert.NotEmpty(t, nonce)
+	assert.False(t, outdated)
+
+	again, err := e.encrypt(payload, nonce)
+	require.NoError(t, err)
+	assert.Equal(t, value, again, "should reconstruct the value with the same nonce")
+
+	other, err := e.encrypt(payload, "")
+	require.NoError(t, err)
+	assert.NotEqual(t, value, other, "should use a new nonce by default")
+
+	_, err = e.encrypt(payload, "invalid")
+	assert.Error(t, err)
+}
+
+func TestTXTEncryptorDecryptOutdated(t *testing.T) {
+	old := newTestTXTEncryptor(t, testOldAESKey)
+	value, err := old.encrypt("heritage=external-dns,external-dns/owner=owner", "")
+	require.NoError(t, err)
+	plain := "\"heritage=external-dns,external-dns/owner=owner\""
+
+	rotated := newTestTXTEncryptor(t, testAESKey, testOldAESKey)
+	payload, nonce, outdated := rotated.decrypt(value)
+	assert.Equal(t, "heritage=external-dns,external-dns/owner=owner", payload)
+	assert.NotEmpty(t, nonce)
+	assert.True(t, outdated, "values encrypted with an old key are outdated")
+
+	payload, nonce, outdated = rotated.decrypt(plain)
+	assert.Equal(t, plain, payload)
+	assert.Empty(t, nonce)
+	assert.True(t, outdated, "plain values are outdated if there is a key")
+
+	unknown := newTestTXTEncryptor(t, testAESKey)
+	payload, _, _ = unknown.decrypt(value)
+	assert.Equal(t, value, payload, "values encrypted with unknown keys are returned as is")
+
+	disabled := newTestTXTEncryptor(t, "", testOldAESKey)
+	_, _, outdated = disabled.decrypt(value)
+	assert.True(t, outdated, "encrypted values are outdated without a key")
+	_, _, outdated = disabled.decrypt(plain)
+	assert.False(t, outdated)
+}
+
+func TestTXTRegistryEncryption(t *testing.T) {
+	p := provider.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	r, err := NewTXTRegistry(p, "", "", "", "owner", 0, newTestTXTEncryptor(t, testAESKey), false)
+	require.NoError(t, err)
+
+	require.NoError(t, r.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwnerResource("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "", "service/default/foo"),
+		},
+	}))
+
+	txt := findTestRecord(t, p, "a-foo.test-zone.example.org")
+	assert.NotContains(t, txt.Targets[0], "owner", "should not publish the owner id")
+
+	records, err := r.Records()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "owner", records[0].Labels[endpoint.OwnerLabelKey])
+	assert.Equal(t, "service/default/foo", records[0].Labels[endpoint.ResourceLabelKey])
+	assert.False(t, r.migration.HasChanges())
+
+	// the encrypted value is reconstructed from the labels to update and delete it
+	updated := newEndpointWithOwnerResource("foo.test-zone.example.org", "5.6.7.8", endpoint.RecordTypeA, "owner", "service/default/foo")
+	require.NoError(t, r.ApplyChanges(&plan.Changes{
+		UpdateOld: records,
+		UpdateNew: []*endpoint.Endpoint{updated},
+	}))
+
+	records, err = r.Records()
+	require.NoError(t, err)
+	require.NoError(t, r.ApplyChanges(&plan.Changes{Delete: records}))
+
+	records, err = p.Records()
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestTXTRegistryEncryptionCache(t *testing.T) {
+	inmemory := provider.NewInMemoryProvider()
+	inmemory.CreateZone(testZone)
+	p := &countingProvider{Provider: inmemory}
+	r, err := NewTXTRegistry(p, "", "", "", "owner", time.Minute, newTestTXTEncryptor(t, testAESKey), false)
+	require.NoError(t, err)
+	_, err = r.Records()
+	require.NoError(t, err)
+
+	// the cached records keep the nonces of the written values, so that they're reconstructed without listing them
+	require.NoError(t, r.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		},
+	}))
+	records, err := r.Records()
+	require.NoError(t, err)
+	require.NoError(t, r.ApplyChanges(&plan.Changes{
+		UpdateOld: records,
+		UpdateNew: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "5.6.7.8", endpoint.RecordTypeA, "owner"),
+		},
+	}))
+	records, err = r.Records()
+	require.NoError(t, err)
+	require.NoError(t, r.ApplyChanges(&plan.Changes{Delete: records}))
+	assert.Equal(t, 1, p.reads)
+
+	records, err = inmemory.Records()
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestTXTRegistryEncryptionKeyRotation(t *testing.T) {
+	p := provider.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	old, _ := NewTXTRegistry(p, "", "", "", "owner", 0, newTestTXTEncryptor(t, testOldAESKey), false)
+	require.NoError(t, old.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		},
+	}))
+
+	r, _ := NewTXTRegistry(p, "", "", "", "owner", 0, newTestTXTEncryptor(t, testAESKey, testOldAESKey), false)
+	records, err := r.Records()
+	require.NoError(t, err)
+	assert.Equal(t, "owner", records[0].Labels[endpoint.OwnerLabelKey])
+	require.NoError(t, r.ApplyChanges(&plan.Changes{}))
+
+	current := newTestTXTEncryptor(t, testAESKey)
+	_, _, outdated := current.decrypt(findTestRecord(t, p, "a-foo.test-zone.example.org").Targets[0])
+	assert.False(t, outdated, "should re-encrypt with the current key")
+
+	// the labels of the records read before the rotation still reconstruct the value
+	require.NoError(t, r.ApplyChanges(&plan.Changes{Delete: records}))
+}
+
+func TestTXTRegistryEncryptionEnableAndDisable(t *testing.T) {
+	p := provider.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	plain, _ := NewTXTRegistry(p, "", "", "", "owner", 0, nil, false)
+	require.NoError(t, plain.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		},
+	}))
+	require.NoError(t, p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("baz.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("a-baz.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=other,external-dns/record-type=A\"", endpoint.RecordTypeTXT, ""),
+		},
+	}))
+
+	encrypted, _ := NewTXTRegistry(p, "", "", "", "owner", 0, newTestTXTEncryptor(t, testAESKey), false)
+	_, err := encrypted.Records()
+	require.NoError(t, err)
+	require.NoError(t, encrypted.ApplyChanges(&plan.Changes{}))
+	assert.NotContains(t, findTestRecord(t, p, "a-foo.test-zone.example.org").Targets[0], "owner")
+	assert.NotContains(t, findTestRecord(t, p, "a-bar.test-zone.example.org").Targets[0], "owner")
+	assert.Contains(t, findTestRecord(t, p, "a-baz.test-zone.example.org").Targets[0], "owner=other", "should not touch records of other owners")
+
+	disabled, _ := NewTXTRegistry(p, "", "", "", "owner", 0, newTestTXTEncryptor(t, "", testAESKey), false)
+	records, err := disabled.Records()
+	require.NoError(t, err)
+	require.NoError(t, disabled.ApplyChanges(&plan.Changes{}))
+	assert.Equal(t, "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=A\"", findTestRecord(t, p, "a-foo.test-zone.example.org").Targets[0])
+
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner"),
+		newEndpointWithOwner("bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner"),
+		newEndpointWithOwner("baz.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "other"),
+	}))
+}
+
+// findTestRecord returns the record of the given name from the provider
+func findTestRecord(t *testing.T, p provider.Provider, dnsName string) *endpoint.Endpoint {
+	records, err := p.Records()
+	require.NoError(t, err)
+	for _, record := range records {
+		if record.DNSName == dnsName {
+			return record
+		}
+	}
+	t.Fatalf("record %s not found", dnsName)
+	return nil
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package registry
 
 import (
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kubernetes-incubator/external-dns/endpoint"
 	"github.com/kubernetes-incubator/external-dns/internal/testutils"
@@ -36,31 +39,38 @@ func TestTXTRegistry(t *testing.T) {
 	t.Run("TestNewTXTRegistry", testTXTRegistryNew)
 	t.Run("TestRecords", testTXTRegistryRecords)
 	t.Run("TestApplyChanges", testTXTRegistryApplyChanges)
+	t.Run("TestMigration", testTXTRegistryMigration)
+	t.Run("TestMigrationKeepsLegacy", testTXTRegistryMigrationKeepsLegacy)
+	t.Run("TestWriteLegacy", testTXTRegistryWriteLegacy)
 }
 
 func testTXTRegistryNew(t *testing.T) {
 	p := provider.NewInMemoryProvider()
-	_, err := NewTXTRegistry(p, "txt", "")
+	_, err := NewTXTRegistry(p, "txt", "", "", "", 0, nil, false)
 	require.Error(t, err)
 
-	r, err := NewTXTRegistry(p, "txt", "owner")
+	r, err := NewTXTRegistry(p, "txt", "", "", "owner", 0, nil, false)
 	require.NoError(t, err)
 
-	_, ok := r.mapper.(prefixNameMapper)
+	_, ok := r.mapper.(affixNameMapper)
 	require.True(t, ok)
 	assert.Equal(t, "owner", r.ownerID)
 	assert.Equal(t, p, r.provider)
 
-	r, err = NewTXTRegistry(p, "", "owner")
+	r, err = NewTXTRegistry(p, "", "", "", "owner", 0, nil, false)
 	require.NoError(t, err)
 
-	_, ok = r.mapper.(prefixNameMapper)
+	_, ok = r.mapper.(affixNameMapper)
 	assert.True(t, ok)
+
+	_, err = NewTXTRegistry(p, "txt.", "-txt", "", "owner", 0, nil, false)
+	require.Error(t, err)
 }
 
 func testTXTRegistryRecords(t *testing.T) {
 	t.Run("With prefix", testTXTRegistryRecordsPrefixed)
 	t.Run("No prefix", testTXTRegistryRecordsNoPrefix)
+	t.Run("Record types and set identifiers", testTXTRegistryRecordsTypesAndSetIdentifiers)
 }
 
 func testTXTRegistryRecordsPrefixed(t *testing.T) {
@@ -130,7 +140,7 @@ func testTXTRegistryRecordsPrefixed(t *testing.T) {
 		},
 	}
 
-	r, _ := NewTXTRegistry(p, "txt.", "owner")
+	r, _ := NewTXTRegistry(p, "txt.", "", "", "owner", 0, nil, false)
 	records, _ := r.Records()
 
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
@@ -204,12 +214,47 @@ func testTXTRegistryRecordsNoPrefix(t *testing.T) {
 		},
 	}
 
-	r, _ := NewTXTRegistry(p, "", "owner")
+	r, _ := NewTXTRegistry(p, "", "", "", "owner", 0, nil, false)
 	records, _ := r.Records()
 
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
 }
 
+func testTXTRegistryRecordsTypesAndSetIdentifiers(t *testing.T) {
+	p := provider.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("foo.test-zone.example.org", "2001:db8::1", endpoint.RecordTypeAAAA, ""),
+			newEndpointWithOwner("txt.a-foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=A\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("txt.aaaa-foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner-2,external-dns/record-type=AAAA\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("geo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "").WithSetIdentifier("europe"),
+			newEndpointWithOwner("geo.test-zone.example.org", "5.6.7.8", endpoint.RecordTypeA, "").WithSetIdentifier("default"),
+			newEndpointWithOwner("txt.a-geo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=A,external-dns/set-identifier=europe\"", endpoint.RecordTypeTXT, "").WithSetIdentifier("europe"),
+			newEndpointWithOwner("bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("txt.bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+		},
+	})
+	expectedRecords := []*endpoint.Endpoint{
+		newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner"),
+		newEndpointWithOwner("foo.test-zone.example.org", "2001:db8::1", endpoint.RecordTypeAAAA, "owner-2"),
+		newEndpointWithOwner("geo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner").WithSetIdentifier("europe"),
+		newEndpointWithOwner("geo.test-zone.example.org", "5.6.7.8", endpoint.RecordTypeA, "").WithSetIdentifier("default"),
+		newEndpointWithOwner("bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner"),
+	}
+
+	r, _ := NewTXTRegistry(p, "txt.", "", "", "owner", 0, nil, false)
+	records, err := r.Records()
+	require.NoError(t, err)
+
+	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
+	for _, record := range records {
+		assert.NotContains(t, record.Labels, txtRecordTypeLabelKey)
+		assert.NotContains(t, record.Labels, txtSetIdentifierLabelKey)
+	}
+}
+
 func testTXTRegistryApplyChanges(t *testing.T) {
 	t.Run("With Prefix", testTXTRegistryApplyChangesWithPrefix)
 	t.Run("No prefix", testTXTRegistryApplyChangesNoPrefix)
@@ -222,20 +267,20 @@ func testTXTRegistryApplyChangesWithPrefix(t *testing.T) {
 		Create: []*endpoint.Endpoint{
 			newEndpointWithOwner("foo.test-zone.example.org", "foo.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
 			newEndpointWithOwner("bar.test-zone.example.org", "my-domain.com", endpoint.RecordTypeCNAME, ""),
-			newEndpointWithOwner("txt.bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("txt.cname-bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=CNAME\"", endpoint.RecordTypeTXT, ""),
 			newEndpointWithOwner("txt.bar.test-zone.example.org", "baz.test-zone.example.org", endpoint.RecordTypeCNAME, ""),
 			newEndpointWithOwner("qux.test-zone.example.org", "random", endpoint.RecordTypeTXT, ""),
 			newEndpointWithOwner("tar.test-zone.example.org", "tar.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
-			newEndpointWithOwner("txt.tar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("txt.cname-tar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=CNAME\"", endpoint.RecordTypeTXT, ""),
 			newEndpointWithOwner("foobar.test-zone.example.org", "foobar.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
-			newEndpointWithOwner("txt.foobar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("txt.cname-foobar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=CNAME\"", endpoint.RecordTypeTXT, ""),
 		},
 	})
-	r, _ := NewTXTRegistry(p, "txt.", "owner")
+	r, _ := NewTXTRegistry(p, "txt.", "", "", "owner", 0, nil, false)
 
 	changes := &plan.Changes{
 		Create: []*endpoint.Endpoint{
-			newEndpointWithOwnerResource("new-record-1.test-zone.example.org", "new-loadbalancer-1.lb.com", "", "", "ingress/default/my-ingress"),
+			newEndpointWithOwnerResource("new-record-1.test-zone.example.org", "new-loadbalancer-1.lb.com", endpoint.RecordTypeCNAME, "", "ingress/default/my-ingress"),
 		},
 		Delete: []*endpoint.Endpoint{
 			newEndpointWithOwner("foobar.test-zone.example.org", "foobar.loadbalancer.com", endpoint.RecordTypeCNAME, "owner"),
@@ -249,20 +294,20 @@ func testTXTRegistryApplyChangesWithPrefix(t *testing.T) {
 	}
 	expected := &plan.Changes{
 		Create: []*endpoint.Endpoint{
-			newEndpointWithOwnerResource("new-record-1.test-zone.example.org", "new-loadbalancer-1.lb.com", "", "owner", "ingress/default/my-ingress"),
-			newEndpointWithOwner("txt.new-record-1.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/resource=ingress/default/my-ingress\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwnerResource("new-record-1.test-zone.example.org", "new-loadbalancer-1.lb.com", endpoint.RecordTypeCNAME, "owner", "ingress/default/my-ingress"),
+			newEndpointWithOwner("txt.cname-new-record-1.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=CNAME,external-dns/resource=ingress/default/my-ingress\"", endpoint.RecordTypeTXT, ""),
 		},
 		Delete: []*endpoint.Endpoint{
 			newEndpointWithOwner("foobar.test-zone.example.org", "foobar.loadbalancer.com", endpoint.RecordTypeCNAME, "owner"),
-			newEndpointWithOwner("txt.foobar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("txt.cname-foobar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=CNAME\"", endpoint.RecordTypeTXT, ""),
 		},
 		UpdateNew: []*endpoint.Endpoint{
 			newEndpointWithOwnerResource("tar.test-zone.example.org", "new-tar.loadbalancer.com", endpoint.RecordTypeCNAME, "owner", "ingress/default/my-ingress-2"),
-			newEndpointWithOwner("txt.tar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/resource=ingress/default/my-ingress-2\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("txt.cname-tar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=CNAME,external-dns/resource=ingress/default/my-ingress-2\"", endpoint.RecordTypeTXT, ""),
 		},
 		UpdateOld: []*endpoint.Endpoint{
 			newEndpointWithOwner("tar.test-zone.example.org", "tar.loadbalancer.com", endpoint.RecordTypeCNAME, "owner"),
-			newEndpointWithOwner("txt.tar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("txt.cname-tar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=CNAME\"", endpoint.RecordTypeTXT, ""),
 		},
 	}
 	p.OnApplyChanges = func(got *plan.Changes) {
@@ -291,16 +336,16 @@ func testTXTRegistryApplyChangesNoPrefix(t *testing.T) {
 		Create: []*endpoint.Endpoint{
 			newEndpointWithOwner("foo.test-zone.example.org", "foo.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
 			newEndpointWithOwner("bar.test-zone.example.org", "my-domain.com", endpoint.RecordTypeCNAME, ""),
-			newEndpointWithOwner("txt.bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("txt.cname-bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=CNAME\"", endpoint.RecordTypeTXT, ""),
 			newEndpointWithOwner("txt.bar.test-zone.example.org", "baz.test-zone.example.org", endpoint.RecordTypeCNAME, ""),
 			newEndpointWithOwner("qux.test-zone.example.org", "random", endpoint.RecordTypeTXT, ""),
 			newEndpointWithOwner("tar.test-zone.example.org", "tar.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
-			newEndpointWithOwner("txt.tar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("txt.cname-tar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=CNAME\"", endpoint.RecordTypeTXT, ""),
 			newEndpointWithOwner("foobar.test-zone.example.org", "foobar.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
-			newEndpointWithOwner("foobar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("cname-foobar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=CNAME\"", endpoint.RecordTypeTXT, ""),
 		},
 	})
-	r, _ := NewTXTRegistry(p, "", "owner")
+	r, _ := NewTXTRegistry(p, "", "", "", "owner", 0, nil, false)
 
 	changes := &plan.Changes{
 		Create: []*endpoint.Endpoint{
@@ -319,11 +364,11 @@ func testTXTRegistryApplyChangesNoPrefix(t *testing.T) {
 	expected := &plan.Changes{
 		Create: []*endpoint.Endpoint{
 			newEndpointWithOwner("new-record-1.test-zone.example.org", "new-loadbalancer-1.lb.com", endpoint.RecordTypeCNAME, "owner"),
-			newEndpointWithOwner("new-record-1.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("cname-new-record-1.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=CNAME\"", endpoint.RecordTypeTXT, ""),
 		},
 		Delete: []*endpoint.Endpoint{
 			newEndpointWithOwner("foobar.test-zone.example.org", "foobar.loadbalancer.com", endpoint.RecordTypeCNAME, "owner"),
-			newEndpointWithOwner("foobar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("cname-foobar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=CNAME\"", endpoint.RecordTypeTXT, ""),
 		},
 		UpdateNew: []*endpoint.Endpoint{},
 		UpdateOld: []*endpoint.Endpoint{},
@@ -347,12 +392,534 @@ func testTXTRegistryApplyChangesNoPrefix(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func testTXTRegistryMigration(t *testing.T) {
+	p := provider.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("foo.test-zone.example.org", "2001:db8::1", endpoint.RecordTypeAAAA, ""),
+			newEndpointWithOwner("txt.foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/resource=service/default/foo\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("txt.bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner-2\"", endpoint.RecordTypeTXT, ""),
+		},
+	})
+	r, _ := NewTXTRegistry(p, "txt.", "", "", "owner", 0, nil, false)
+
+	records, err := r.Records()
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	require.NoError(t, r.ApplyChanges(&plan.Changes{}))
+
+	providerRecords, err := p.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(providerRecords, []*endpoint.Endpoint{
+		newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		newEndpointWithOwner("foo.test-zone.example.org", "2001:db8::1", endpoint.RecordTypeAAAA, ""),
+		newEndpointWithOwner("txt.a-foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=A,external-dns/resource=service/default/foo\"", endpoint.RecordTypeTXT, ""),
+		newEndpointWithOwner("txt.aaaa-foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=AAAA,external-dns/resource=service/default/foo\"", endpoint.RecordTypeTXT, ""),
+		newEndpointWithOwner("bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		newEndpointWithOwner("txt.bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner-2\"", endpoint.RecordTypeTXT, ""),
+	}), "should migrate the legacy TXT records of its own records only")
+
+	// the ownership is unchanged after the migration
+	records, err = r.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		newEndpointWithOwnerResource("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner", "service/default/foo"),
+		newEndpointWithOwnerResource("foo.test-zone.example.org", "2001:db8::1", endpoint.RecordTypeAAAA, "owner", "service/default/foo"),
+		newEndpointWithOwner("bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner-2"),
+	}))
+	assert.False(t, r.migration.HasChanges())
+}
+
+func testTXTRegistryMigrationKeepsLegacy(t *testing.T) {
+	p := provider.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("txt.foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("txt.a-bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=A\"", endpoint.RecordTypeTXT, ""),
+		},
+	})
+	r, _ := NewTXTRegistry(p, "txt.", "", "", "owner", 0, nil, true)
+
+	_, err := r.Records()
+	require.NoError(t, err)
+	require.NoError(t, r.ApplyChanges(&plan.Changes{}))
+
+	providerRecords, err := p.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(providerRecords, []*endpoint.Endpoint{
+		newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		newEndpointWithOwner("txt.foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+		newEndpointWithOwner("txt.a-foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=A\"", endpoint.RecordTypeTXT, ""),
+		newEndpointWithOwner("bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		newEndpointWithOwner("txt.a-bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=A\"", endpoint.RecordTypeTXT, ""),
+		newEndpointWithOwner("txt.bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+	}), "should keep the legacy TXT records and add the missing ones")
+
+	_, err = r.Records()
+	require.NoError(t, err)
+	assert.False(t, r.migration.HasChanges())
+
+	// the legacy TXT records are deleted once they aren't written anymore
+	r, _ = NewTXTRegistry(p, "txt.", "", "", "owner", 0, nil, false)
+	_, err = r.Records()
+	require.NoError(t, err)
+	require.NoError(t, r.ApplyChanges(&plan.Changes{}))
+
+	providerRecords, err = p.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(providerRecords, []*endpoint.Endpoint{
+		newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		newEndpointWithOwner("txt.a-foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=A\"", endpoint.RecordTypeTXT, ""),
+		newEndpointWithOwner("bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		newEndpointWithOwner("txt.a-bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=A\"", endpoint.RecordTypeTXT, ""),
+	}))
+}
+
+func testTXTRegistryWriteLegacy(t *testing.T) {
+	p := provider.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("taken.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("txt.taken.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner-2\"", endpoint.RecordTypeTXT, ""),
+		},
+	})
+	r, _ := NewTXTRegistry(p, "txt.", "", "", "owner", 0, nil, true)
+	legacyTXT := newEndpointWithOwner("txt.foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "")
+	otherTXT := newEndpointWithOwner("txt.taken.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner-2\"", endpoint.RecordTypeTXT, "")
+
+	_, err := r.Records()
+	require.NoError(t, err)
+	require.NoError(t, r.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("foo.test-zone.example.org", "2001:db8::1", endpoint.RecordTypeAAAA, ""),
+			newEndpointWithOwner("taken.test-zone.example.org", "2001:db8::1", endpoint.RecordTypeAAAA, ""),
+		},
+	}))
+	providerRecords, err := p.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(filterTXTRecords(providerRecords, "txt.foo", "txt.taken"), []*endpoint.Endpoint{legacyTXT, otherTXT}),
+		"should create a single legacy TXT record for the records of a name, unless it has one already")
+
+	records, err := r.Records()
+	require.NoError(t, err)
+	assert.False(t, r.migration.HasChanges())
+	owned := filterOwnedRecords("owner", records)
+	require.Len(t, owned, 3)
+
+	// the legacy TXT record is kept until the last record of its name is deleted
+	for _, deleted := range []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA} {
+		records, err := r.Records()
+		require.NoError(t, err)
+		for _, ep := range filterOwnedRecords("owner", records) {
+			if ep.DNSName == "foo.test-zone.example.org" && ep.RecordType == deleted {
+				require.NoError(t, r.ApplyChanges(&plan.Changes{Delete: []*endpoint.Endpoint{ep}}))
+			}
+		}
+		providerRecords, err = p.Records()
+		require.NoError(t, err)
+		if deleted == endpoint.RecordTypeA {
+			assert.True(t, testutils.SameEndpoints(filterTXTRecords(providerRecords, "txt.foo"), []*endpoint.Endpoint{legacyTXT}))
+		} else {
+			assert.Empty(t, filterTXTRecords(providerRecords, "txt.foo"))
+		}
+	}
+}
+
+// filterTXTRecords returns the TXT records of the given first labels
+func filterTXTRecords(records []*endpoint.Endpoint, names ...string) []*endpoint.Endpoint {
+	filtered := []*endpoint.Endpoint{}
+	for _, r := range records {
+		for _, name := range names {
+			if r.RecordType == endpoint.RecordTypeTXT && r.DNSName == name+".test-zone.example.org" {
+				filtered = append(filtered, r)
+			}
+		}
+	}
+	return filtered
+}
+
+func TestAffixNameMapper(t *testing.T) {
+	for _, tc := range []struct {
+		title      string
+		prefix     string
+		suffix     string
+		recordType string
+		txtName    string
+	}{
+		{"no affix", "", "", endpoint.RecordTypeCNAME, "cname-foo.example.org"},
+		{"no affix legacy", "", "", "", "foo.example.org"},
+		{"prefix", "txt.", "", endpoint.RecordTypeCNAME, "txt.cname-foo.example.org"},
+		{"prefix legacy", "txt.", "", "", "txt.foo.example.org"},
+		{"prefix template", "%{record_type}-txt.", "", endpoint.RecordTypeCNAME, "cname-txt.foo.example.org"},
+		{"suffix", "", "-txt", endpoint.RecordTypeA, "a-foo-txt.example.org"},
+		{"suffix legacy", "", "-txt", "", "foo-txt.example.org"},
+		{"suffix template", "", "-%{record_type}", endpoint.RecordTypeAAAA, "foo-aaaa.example.org"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			mapper := newAffixNameMapper(tc.prefix, tc.suffix, "")
+			assert.Equal(t, tc.txtName, mapper.toTXTName("foo.example.org", tc.recordType))
+			assert.Equal(t, "foo.example.org", mapper.toEndpointName(tc.txtName, tc.recordType))
+		})
+	}
+
+	mapper := newAffixNameMapper("txt.", "", "")
+	assert.Equal(t, "", mapper.toEndpointName("txt.a-foo.example.org", endpoint.RecordTypeCNAME))
+	assert.Equal(t, "", mapper.toEndpointName("foo.example.org", ""))
+
+	mapper = newAffixNameMapper("", "-%{record_type}", "")
+	assert.Equal(t, "", mapper.toEndpointName("foo-a.example.org", endpoint.RecordTypeCNAME))
+	assert.Equal(t, "example-txt", mapper.toTXTName("example", "txt"))
+	assert.Equal(t, "example", mapper.toEndpointName("example-txt", "txt"))
+}
+
+func TestAffixNameMapperWildcardReplacement(t *testing.T) {
+	for _, tc := range []struct {
+		title      string
+		prefix     string
+		suffix     string
+		recordType string
+		txtName    string
+	}{
+		{"no affix", "", "", endpoint.RecordTypeA, "a-wildcard.example.org"},
+		{"no affix legacy", "", "", "", "*.example.org"},
+		{"prefix", "txt.", "", endpoint.RecordTypeCNAME, "txt.cname-wildcard.example.org"},
+		{"prefix template", "%{record_type}.", "", endpoint.RecordTypeCNAME, "cname.wildcard.example.org"},
+		{"suffix", "", "-%{record_type}", endpoint.RecordTypeA, "wildcard-a.example.org"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			mapper := newAffixNameMapper(tc.prefix, tc.suffix, "wildcard")
+			assert.Equal(t, tc.txtName, mapper.toTXTName("*.example.org", tc.recordType))
+			assert.Equal(t, "*.example.org", mapper.toEndpointName(tc.txtName, tc.recordType))
+		})
+	}
+
+	mapper := newAffixNameMapper("", "", "wildcard")
+	assert.Equal(t, "a-foo.wildcard.example.org", mapper.toTXTName("foo.wildcard.example.org", endpoint.RecordTypeA), "should only replace the first label")
+	assert.Equal(t, "foo.wildcard.example.org", mapper.toEndpointName("a-foo.wildcard.example.org", endpoint.RecordTypeA))
+
+	mapper = newAffixNameMapper("", "", "")
+	assert.Equal(t, "a-*.example.org", mapper.toTXTName("*.example.org", endpoint.RecordTypeA))
+	assert.Equal(t, "*.example.org", mapper.toEndpointName("a-*.example.org", endpoint.RecordTypeA))
+}
+
+func TestTXTRegistryWildcardReplacement(t *testing.T) {
+	p := provider.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	require.NoError(t, p.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("*.bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("*.bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+		},
+	}))
+	r, _ := NewTXTRegistry(p, "", "", "wildcard", "owner", 0, nil, false)
+
+	require.NoError(t, r.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("*.foo.test-zone.example.org", "foo.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
+		},
+	}))
+
+	records, err := p.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		newEndpointWithOwner("*.bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		newEndpointWithOwner("*.bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+		newEndpointWithOwner("*.foo.test-zone.example.org", "foo.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
+		newEndpointWithOwner("cname-wildcard.foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=CNAME\"", endpoint.RecordTypeTXT, ""),
+	}))
+
+	records, err = r.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		newEndpointWithOwner("*.bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner"),
+		newEndpointWithOwner("*.foo.test-zone.example.org", "foo.loadbalancer.com", endpoint.RecordTypeCNAME, "owner"),
+	}))
+
+	// the legacy TXT record of the wildcard record is migrated to the replaced name
+	require.NoError(t, r.ApplyChanges(&plan.Changes{}))
+	records, err = p.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		newEndpointWithOwner("*.bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		newEndpointWithOwner("a-wildcard.bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=A\"", endpoint.RecordTypeTXT, ""),
+		newEndpointWithOwner("*.foo.test-zone.example.org", "foo.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
+		newEndpointWithOwner("cname-wildcard.foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=CNAME\"", endpoint.RecordTypeTXT, ""),
+	}))
+}
+
+func TestTXTRegistrySuffix(t *testing.T) {
+	p := provider.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	r, _ := NewTXTRegistry(p, "", "-%{record_type}-owner", "", "owner", 0, nil, false)
+
+	require.NoError(t, r.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "foo.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
+		},
+	}))
+
+	records, err := p.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		newEndpointWithOwner("foo.test-zone.example.org", "foo.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
+		newEndpointWithOwner("foo-cname-owner.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=CNAME\"", endpoint.RecordTypeTXT, ""),
+	}))
+
+	records, err = r.Records()
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		newEndpointWithOwner("foo.test-zone.example.org", "foo.loadbalancer.com", endpoint.RecordTypeCNAME, "owner"),
+	}))
+}
+
+func TestTXTRecordSharesRoutingPolicy(t *testing.T) {
+	r, _ := NewTXTRegistry(provider.NewInMemoryProvider(), "", "", "", "owner", 0, nil, false)
+
+	ep := newEndpointWithOwner("geo.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner").
+		WithSetIdentifier("germany").
+		WithGeoLocation(&endpoint.GeoLocation{CountryCode: "DE"}).
+		WithProviderSpecific("aws/failover", "primary").
+		WithProviderSpecific("aws/health-check-type", "http")
+
+	txt, err := r.newTXTRecord(ep)
+	require.NoError(t, err)
+	assert.Equal(t, "a-geo.example.org", txt.DNSName)
+	assert.Equal(t, endpoint.Targets{"\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=A,external-dns/set-identifier=germany\""}, txt.Targets)
+	assert.Equal(t, "germany", txt.SetIdentifier)
+	assert.Equal(t, &endpoint.GeoLocation{CountryCode: "DE"}, txt.GeoLocation)
+	assert.Equal(t, endpoint.ProviderSpecific{{Name: "aws/failover", Value: "primary"}}, txt.ProviderSpecific)
+}
+
+func TestTXTRecordSharesProvider(t *testing.T) {
+	r, _ := NewTXTRegistry(provider.NewInMemoryProvider(), "", "", "", "owner", 0, nil, false)
+
+	ep := newEndpointWithOwner("foo.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner")
+	ep.Labels[endpoint.ProviderLabelKey] = "cloudflare"
+
+	txt, err := r.newTXTRecord(ep)
+	require.NoError(t, err)
+	assert.Equal(t, endpoint.Targets{"\"heritage=external-dns,external-dns/owner=owner,external-dns/provider=cloudflare,external-dns/record-type=A\""}, txt.Targets)
+	assert.Equal(t, "cloudflare", txt.Labels[endpoint.ProviderLabelKey])
+}
+
+func TestTXTRegistryKeepsProviderOfMultiProvider(t *testing.T) {
+	public := provider.NewInMemoryProvider(provider.InMemoryInitZones([]string{"example.org"}))
+	private := provider.NewInMemoryProvider(provider.InMemoryInitZones([]string{"internal.example.org"}))
+	m := provider.NewMultiProvider([]provider.ProviderRoute{
+		{Name: "public", Provider: public, DomainFilter: provider.NewDomainFilter([]string{"example.org"})},
+		{Name: "private", Provider: private, DomainFilter: provider.NewDomainFilter([]string{"internal.example.org"})},
+	})
+
+	// a record without TXT record and an owned record whose TXT record doesn't store its provider
+	require.NoError(t, public.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("www.example.org", "1.2.3.4", endpoint.RecordTypeA)},
+	}))
+	single, _ := NewTXTRegistry(private, "", "", "", "owner", 0, nil, false)
+	require.NoError(t, single.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("api.internal.example.org", "10.0.0.1", endpoint.RecordTypeA)},
+	}))
+
+	r, _ := NewTXTRegistry(m, "", "", "", "owner", 0, nil, false)
+	records, err := r.Records()
+	require.NoError(t, err)
+	providers := map[string]string{}
+	for _, record := range records {
+		providers[record.DNSName] = record.Labels[endpoint.ProviderLabelKey]
+	}
+	assert.Equal(t, map[string]string{"www.example.org": "public", "api.internal.example.org": "private"}, providers)
+
+	// the records are planned together with the desired endpoints of their provider
+	desired, err := m.AdjustEndpoints([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("www.example.org", "1.2.3.4", endpoint.RecordTypeA),
+		endpoint.NewEndpoint("api.internal.example.org", "10.0.0.1", endpoint.RecordTypeA),
+	})
+	require.NoError(t, err)
+	changes := (&plan.Plan{
+		Policies:    []plan.Policy{&plan.SyncPolicy{}},
+		Current:     records,
+		Desired:     desired,
+		PerProvider: true,
+	}).Calculate().Changes
+	assert.False(t, changes.HasChanges())
+}
+
+func TestTXTRegistryLongPayload(t *testing.T) {
+	// the providers return the strings of TXT records as they were written, joined with or without quotes
+	for _, tc := range []struct {
+		title     string
+		normalize func(string) string
+	}{
+		{"as written", func(value string) string { return value }},
+		{"without separators", func(value string) string { return strings.Replace(value, `" "`, `""`, -1) }},
+		{"joined", unquoteTXTValue},
+		{"joined and quoted", func(value string) string { return `"` + unquoteTXTValue(value) + `"` }},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			inmemory := provider.NewInMemoryProvider()
+			inmemory.CreateZone(testZone)
+			p := &normalizingProvider{Provider: inmemory, normalize: tc.normalize}
+			r, _ := NewTXTRegistry(p, "", "", "", "owner", 0, nil, false)
+
+			resource := "ingress/default/" + strings.Repeat("a", 250)
+			require.NoError(t, r.ApplyChanges(&plan.Changes{
+				Create: []*endpoint.Endpoint{newEndpointWithOwnerResource("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "", resource)},
+			}))
+
+			txts, err := inmemory.Records()
+			require.NoError(t, err)
+			for _, txt := range txts {
+				if txt.RecordType == endpoint.RecordTypeTXT {
+					assert.Contains(t, txt.Targets[0], `" "`, "the payload should be split")
+				}
+			}
+
+			records, err := r.Records()
+			require.NoError(t, err)
+			require.Len(t, records, 1)
+			assert.Equal(t, "owner", records[0].Labels[endpoint.OwnerLabelKey])
+			assert.Equal(t, resource, records[0].Labels[endpoint.ResourceLabelKey])
+		})
+	}
+}
+
 /**
 
 helper methods
 
 */
 
+// countingProvider counts the calls to Records of the wrapped provider and can fail ApplyChanges
+type countingProvider struct {
+	provider.Provider
+	reads    int
+	applyErr error
+}
+
+func (p *countingProvider) Records() ([]*endpoint.Endpoint, error) {
+	p.reads++
+	return p.Provider.Records()
+}
+
+func (p *countingProvider) ApplyChanges(changes *plan.Changes) error {
+	if p.applyErr != nil {
+		return p.applyErr
+	}
+	return p.Provider.ApplyChanges(changes)
+}
+
+// normalizingProvider returns the values of TXT records normalized like some providers do
+type normalizingProvider struct {
+	provider.Provider
+	normalize func(string) string
+}
+
+func (p *normalizingProvider) Records() ([]*endpoint.Endpoint, error) {
+	records, err := p.Provider.Records()
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.RecordType == endpoint.RecordTypeTXT {
+			record.Targets = endpoint.Targets{p.normalize(record.Targets[0])}
+		}
+	}
+	return records, nil
+}
+
+func TestTXTRegistryCache(t *testing.T) {
+	inmemory := provider.NewInMemoryProvider()
+	inmemory.CreateZone(testZone)
+	require.NoError(t, inmemory.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("a-foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=A\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("a-bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/record-type=A\"", endpoint.RecordTypeTXT, ""),
+		},
+	}))
+	p := &countingProvider{Provider: inmemory}
+	r, _ := NewTXTRegistry(p, "", "", "", "owner", time.Minute, nil, false)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	records, err := r.Records()
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.reads)
+
+	// the cached records don't share memory with the returned ones
+	records[0].Labels[endpoint.OwnerLabelKey] = "modified"
+	records, err = r.Records()
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.reads)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner"),
+		newEndpointWithOwner("bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner"),
+	}))
+
+	// the applied changes are reflected by the cache
+	require.NoError(t, r.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("new.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner"),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "5.6.7.8", endpoint.RecordTypeA, "owner"),
+		},
+		Delete: []*endpoint.Endpoint{
+			newEndpointWithOwner("bar.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner"),
+		},
+	}))
+	cached, err := r.Records()
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.reads)
+	assert.True(t, testutils.SameEndpoints(cached, []*endpoint.Endpoint{
+		newEndpointWithOwner("foo.test-zone.example.org", "5.6.7.8", endpoint.RecordTypeA, "owner"),
+		newEndpointWithOwner("new.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner"),
+	}))
+
+	// the records are listed again once the cache interval has passed, and they match the cache
+	now = now.Add(time.Minute)
+	records, err = r.Records()
+	require.NoError(t, err)
+	assert.Equal(t, 2, p.reads)
+	assert.True(t, testutils.SameEndpoints(records, cached))
+
+	// the cache is dropped if the changes fail
+	p.applyErr = errors.New("failed")
+	assert.Error(t, r.ApplyChanges(&plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("fail.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		},
+	}))
+	_, err = r.Records()
+	require.NoError(t, err)
+	assert.Equal(t, 3, p.reads)
+}
+
+func TestTXTRegistryCacheDisabled(t *testing.T) {
+	inmemory := provider.NewInMemoryProvider()
+	inmemory.CreateZone(testZone)
+	p := &countingProvider{Provider: inmemory}
+	r, _ := NewTXTRegistry(p, "", "", "", "owner", 0, nil, false)
+
+	for i := 0; i < 3; i++ {
+		_, err := r.Records()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, p.reads)
+}
+
 func newEndpointWithOwner(dnsName, target, recordType, ownerID string) *endpoint.Endpoint {
 	e := endpoint.NewEndpoint(dnsName, target, recordType)
 	e.Labels[endpoint.OwnerLabelKey] = ownerID
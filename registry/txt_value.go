@@ -0,0 +1,65 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"bytes"
+	"strings"
+)
+
+// txtMaxStringLength is the maximum length of a single character-string of a TXT record
+const txtMaxStringLength = 255
+
+// quoteTXTValue returns the payload as the value of a TXT record, i.e. as quoted character-strings separated by spaces.
+// Payloads longer than txtMaxStringLength are split into multiple strings, shorter ones are a single string as before.
+func quoteTXTValue(payload string) string {
+	strs := []string{}
+	for len(payload) > txtMaxStringLength {
+		strs = append(strs, "\""+payload[:txtMaxStringLength]+"\"")
+		payload = payload[txtMaxStringLength:]
+	}
+	strs = append(strs, "\""+payload+"\"")
+	return strings.Join(strs, " ")
+}
+
+// unquoteTXTValue returns the payload of the value of a TXT record, joining its character-strings. Providers normalize
+// the value differently, it may be quoted strings separated by spaces, e.g. "abc" "def", quoted strings without
+// separator, e.g. "abc""def", or the strings already joined without quotes, e.g. abcdef.
+func unquoteTXTValue(value string) string {
+	if !strings.HasPrefix(value, "\"") {
+		return value
+	}
+
+	var payload bytes.Buffer
+	quoted, escaped := false, false
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case escaped:
+			payload.WriteByte(c)
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+			payload.WriteByte(c)
+		}
+		// the separators between the strings are dropped
+	}
+	return payload.String()
+}
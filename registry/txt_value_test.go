@@ -0,0 +1,56 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuoteTXTValue(t *testing.T) {
+	assert.Equal(t, `"heritage=external-dns"`, quoteTXTValue("heritage=external-dns"))
+	assert.Equal(t, `""`, quoteTXTValue(""))
+
+	exact := strings.Repeat("a", txtMaxStringLength)
+	assert.Equal(t, `"`+exact+`"`, quoteTXTValue(exact))
+
+	long := strings.Repeat("a", txtMaxStringLength) + strings.Repeat("b", txtMaxStringLength) + "c"
+	assert.Equal(t, `"`+strings.Repeat("a", txtMaxStringLength)+`" "`+strings.Repeat("b", txtMaxStringLength)+`" "c"`, quoteTXTValue(long))
+}
+
+func TestUnquoteTXTValue(t *testing.T) {
+	for _, tc := range []struct {
+		title, value, expected string
+	}{
+		{"single string", `"abc"`, "abc"},
+		{"strings separated by spaces", `"abc" "def"`, "abcdef"},
+		{"strings without separator", `"abc""def"`, "abcdef"},
+		{"strings separated by tabs", "\"abc\"\t\"def\"", "abcdef"},
+		{"joined without quotes", "abcdef", "abcdef"},
+		{"escaped characters", `"a\"b" "c\\d"`, `a"bc\d`},
+		{"empty string", `""`, ""},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			assert.Equal(t, tc.expected, unquoteTXTValue(tc.value))
+		})
+	}
+
+	long := strings.Repeat("x", 3*txtMaxStringLength)
+	assert.Equal(t, long, unquoteTXTValue(quoteTXTValue(long)))
+}
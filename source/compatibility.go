@@ -19,7 +19,7 @@ package source
 import (
 	"strings"
 
-	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/api/core/v1"
 
 	"github.com/kubernetes-incubator/external-dns/endpoint"
 )
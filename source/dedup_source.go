@@ -44,9 +44,12 @@ func (ms *dedupSource) Endpoints() ([]*endpoint.Endpoint, error) {
 
 	for _, ep := range endpoints {
 		identifier := ep.DNSName + " / " + ep.Targets.String()
+		if ep.SetIdentifier != "" {
+			identifier += " / " + ep.SetIdentifier
+		}
 
 		if _, ok := collected[identifier]; ok {
-			log.Debugf("Removing duplicate endpoint %s", ep)
+			log.WithFields(ep.LogFields()).Debugf("Removing duplicate endpoint %s", ep)
 			continue
 		}
 
@@ -89,6 +89,17 @@ func testDedupEndpoints(t *testing.T) {
 				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
 			},
 		},
+		{
+			"two endpoints with same dnsname and same target but different set identifiers return two endpoints",
+			[]*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, SetIdentifier: "eu"},
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, SetIdentifier: "us"},
+			},
+			[]*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, SetIdentifier: "eu"},
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, SetIdentifier: "us"},
+			},
+		},
 	} {
 		t.Run(tc.title, func(t *testing.T) {
 			mockSource := new(testutils.MockSource)
@@ -0,0 +1,59 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"sync"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+)
+
+// EndpointsSource is a Source returning the endpoints set by code, e.g. by an operator embedding the controller
+// to create records for the resources it manages. It's safe to set the endpoints while the controller is running.
+type EndpointsSource struct {
+	mu        sync.Mutex
+	endpoints []*endpoint.Endpoint
+}
+
+// NewEndpointsSource returns an EndpointsSource returning the given endpoints
+func NewEndpointsSource(endpoints ...*endpoint.Endpoint) *EndpointsSource {
+	s := &EndpointsSource{}
+	s.SetEndpoints(endpoints)
+	return s
+}
+
+// SetEndpoints replaces the endpoints returned, records of endpoints removed are deleted by the next synchronization
+func (s *EndpointsSource) SetEndpoints(endpoints []*endpoint.Endpoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.endpoints = copyEndpoints(endpoints)
+}
+
+// Endpoints returns copies of the endpoints, so that neither the caller nor the controller modify them
+func (s *EndpointsSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyEndpoints(s.endpoints), nil
+}
+
+func copyEndpoints(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	copies := make([]*endpoint.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		copies = append(copies, ep.DeepCopy())
+	}
+	return copies
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"testing"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(EndpointsSource))
+
+	foo := endpoint.NewEndpoint("foo.example.org", "1.2.3.4", endpoint.RecordTypeA)
+	s := NewEndpointsSource(foo)
+
+	endpoints, err := s.Endpoints()
+	require.NoError(t, err)
+	assert.Equal(t, []*endpoint.Endpoint{foo}, endpoints)
+
+	// modifying the returned endpoints doesn't change the source
+	endpoints[0].Labels[endpoint.OwnerLabelKey] = "default"
+	endpoints, err = s.Endpoints()
+	require.NoError(t, err)
+	assert.Empty(t, endpoints[0].Labels)
+
+	bar := endpoint.NewEndpoint("bar.example.org", "bar.elb.amazonaws.com", endpoint.RecordTypeCNAME)
+	s.SetEndpoints([]*endpoint.Endpoint{bar})
+	endpoints, err = s.Endpoints()
+	require.NoError(t, err)
+	assert.Equal(t, []*endpoint.Endpoint{bar}, endpoints)
+
+	s.SetEndpoints(nil)
+	endpoints, err = s.Endpoints()
+	require.NoError(t, err)
+	assert.Empty(t, endpoints)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"errors"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	extensionsv1beta1listers "k8s.io/client-go/listers/extensions/v1beta1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+// EventRecorder emits Kubernetes events on the Services and Ingresses the records originate from, so that
+// users can follow what happens to their records with kubectl describe. A nil EventRecorder emits no events.
+// The resources are looked up in a cache kept up to date by watching them, rather than by calling the API
+// server for every record.
+type EventRecorder struct {
+	recorder  record.EventRecorder
+	services  corev1listers.ServiceLister
+	ingresses extensionsv1beta1listers.IngressLister
+}
+
+// NewEventRecorder returns an EventRecorder emitting the events with the given client.
+// It watches the Services and Ingresses until stopChan is closed and returns once their cache is filled.
+func NewEventRecorder(client kubernetes.Interface, stopChan <-chan struct{}) (*EventRecorder, error) {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	return newEventRecorder(client, broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "external-dns"}), stopChan)
+}
+
+func newEventRecorder(client kubernetes.Interface, recorder record.EventRecorder, stopChan <-chan struct{}) (*EventRecorder, error) {
+	factory := informers.NewSharedInformerFactory(client, 0)
+	services := factory.Core().V1().Services()
+	ingresses := factory.Extensions().V1beta1().Ingresses()
+	// the informers are only started by the factory if they were requested before
+	servicesSynced := services.Informer().HasSynced
+	ingressesSynced := ingresses.Informer().HasSynced
+	factory.Start(stopChan)
+	if !cache.WaitForCacheSync(stopChan, servicesSynced, ingressesSynced) {
+		return nil, errors.New("failed to sync the cache of the resources to emit events on")
+	}
+
+	return &EventRecorder{recorder: recorder, services: services.Lister(), ingresses: ingresses.Lister()}, nil
+}
+
+// RecordChanges emits an event for every changed record on its resource. It implements provider.ChangeRecorder,
+// so that only the changes actually applied to the records of this instance are reported.
+func (r *EventRecorder) RecordChanges(changes *plan.Changes) {
+	if r == nil {
+		return
+	}
+	r.recordChanges(changes.Create, "RecordCreated", "Created")
+	r.recordChanges(changes.UpdateNew, "RecordUpdated", "Updated")
+	r.recordChanges(changes.Delete, "RecordDeleted", "Deleted")
+}
+
+func (r *EventRecorder) recordChanges(endpoints []*endpoint.Endpoint, reason, action string) {
+	for _, ep := range endpoints {
+		object := r.resource(ep.Labels[endpoint.ResourceLabelKey])
+		if object == nil {
+			continue
+		}
+		r.recorder.Eventf(object, v1.EventTypeNormal, reason, "%s %s record %s with targets %s", action, ep.RecordType, ep.DNSName, ep.Targets)
+	}
+}
+
+// recordInvalidAnnotations emits a warning on the resource whose annotations were rejected
+func (r *EventRecorder) recordInvalidAnnotations(object runtime.Object, err error) {
+	if r == nil {
+		return
+	}
+	r.recorder.Eventf(object, v1.EventTypeWarning, "InvalidAnnotation", "Invalid annotation: %v", err)
+}
+
+// resource returns the Service or Ingress identified by the resource label, e.g. service/default/nginx.
+// It returns nil for other resources and for resources which don't exist anymore, e.g. of deleted records.
+func (r *EventRecorder) resource(resource string) runtime.Object {
+	parts := strings.SplitN(resource, "/", 3)
+	if len(parts) != 3 {
+		return nil
+	}
+
+	var (
+		object runtime.Object
+		err    error
+	)
+	switch parts[0] {
+	case "service":
+		object, err = r.services.Services(parts[1]).Get(parts[2])
+	case "ingress":
+		object, err = r.ingresses.Ingresses(parts[1]).Get(parts[2])
+	default:
+		return nil
+	}
+	if err != nil {
+		log.Debugf("No event recorded on %s: %v", resource, err)
+		return nil
+	}
+	return object
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/plan"
+)
+
+func newEventRecorderTestEndpoint(name, resource string) *endpoint.Endpoint {
+	ep := endpoint.NewEndpoint(name, "1.2.3.4", endpoint.RecordTypeA)
+	ep.Labels[endpoint.ResourceLabelKey] = resource
+	return ep
+}
+
+// recordedEvents returns the events recorded so far
+func recordedEvents(recorder *record.FakeRecorder) []string {
+	events := []string{}
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestEventRecorderRecordChanges(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	_, err := client.CoreV1().Services("default").Create(&v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}})
+	require.NoError(t, err)
+	_, err = client.Extensions().Ingresses("default").Create(&v1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "bar"}})
+	require.NoError(t, err)
+
+	stopChan := make(chan struct{})
+	defer close(stopChan)
+	fakeRecorder := record.NewFakeRecorder(10)
+	recorder, err := newEventRecorder(client, fakeRecorder, stopChan)
+	require.NoError(t, err)
+	changes := &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEventRecorderTestEndpoint("foo.example.org", "service/default/foo"),
+			// resources which don't exist anymore or aren't supported don't get events
+			newEventRecorderTestEndpoint("gone.example.org", "service/default/gone"),
+			newEventRecorderTestEndpoint("static.example.org", "configmap/kube-system/dns-records"),
+			endpoint.NewEndpoint("unowned.example.org", "1.2.3.4", endpoint.RecordTypeA),
+		},
+		UpdateNew: []*endpoint.Endpoint{newEventRecorderTestEndpoint("bar.example.org", "ingress/default/bar")},
+		Delete:    []*endpoint.Endpoint{newEventRecorderTestEndpoint("old.example.org", "ingress/default/bar")},
+	}
+
+	recorder.RecordChanges(changes)
+	assert.Equal(t, []string{
+		"Normal RecordCreated Created A record foo.example.org with targets 1.2.3.4",
+		"Normal RecordUpdated Updated A record bar.example.org with targets 1.2.3.4",
+		"Normal RecordDeleted Deleted A record old.example.org with targets 1.2.3.4",
+	}, recordedEvents(fakeRecorder))
+}
+
+func TestNewEventRecorderStopped(t *testing.T) {
+	stopChan := make(chan struct{})
+	close(stopChan)
+	_, err := newEventRecorder(fake.NewSimpleClientset(), record.NewFakeRecorder(10), stopChan)
+	assert.Error(t, err)
+}
+
+func TestEventRecorderRecordInvalidAnnotations(t *testing.T) {
+	fakeRecorder := record.NewFakeRecorder(10)
+	recorder := &EventRecorder{recorder: fakeRecorder}
+
+	recorder.recordInvalidAnnotations(&v1.Service{}, errors.New("invalid country code"))
+	assert.Equal(t, []string{"Warning InvalidAnnotation Invalid annotation: invalid country code"}, recordedEvents(fakeRecorder))
+}
+
+func TestNilEventRecorder(t *testing.T) {
+	var recorder *EventRecorder
+	recorder.RecordChanges(&plan.Changes{Create: []*endpoint.Endpoint{newEventRecorderTestEndpoint("foo.example.org", "service/default/foo")}})
+	recorder.recordInvalidAnnotations(&v1.Service{}, errors.New("invalid country code"))
+}
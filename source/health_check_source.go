@@ -0,0 +1,175 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+)
+
+// healthCheckSource is a Source that probes the targets of endpoints with a health check and withdraws the failing ones.
+type healthCheckSource struct {
+	source  Source
+	timeout time.Duration
+	// probe returns an error if the target fails the check, it's replaced in tests
+	probe func(check *url.URL, dnsName, target string, timeout time.Duration) error
+}
+
+// NewHealthCheckSource creates a new healthCheckSource wrapping the provided Source. The targets of endpoints carrying
+// a health check from the annotation of their resource are probed in parallel and the failing targets are withdrawn,
+// e.g. for providers without native health checks. If all targets of an endpoint fail, the endpoint is kept unchanged,
+// so that a failing check never removes a record completely.
+func NewHealthCheckSource(source Source, timeout time.Duration) Source {
+	return &healthCheckSource{source: source, timeout: timeout, probe: probeTarget}
+}
+
+// Endpoints collects endpoints from its wrapped source and withdraws the targets failing their health check.
+func (hs *healthCheckSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	endpoints, err := hs.source.Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	// the results of the checks by endpoint and target, nil for endpoints without a check
+	results := make([][]error, len(endpoints))
+	var wg sync.WaitGroup
+	for i, ep := range endpoints {
+		value, ok := ep.Labels[endpoint.HealthCheckLabelKey]
+		if !ok || ep.RecordType == endpoint.RecordTypeTXT {
+			continue
+		}
+		check, err := parseHealthCheck(value)
+		if err != nil {
+			log.WithFields(ep.LogFields()).Warn(err)
+			continue
+		}
+		results[i] = make([]error, len(ep.Targets))
+		for j, target := range ep.Targets {
+			wg.Add(1)
+			go func(i, j int, dnsName, target string) {
+				defer wg.Done()
+				results[i][j] = hs.probe(check, dnsName, target, hs.timeout)
+			}(i, j, ep.DNSName, target)
+		}
+	}
+	wg.Wait()
+
+	for i, ep := range endpoints {
+		if results[i] == nil {
+			continue
+		}
+		healthy := endpoint.Targets{}
+		for j, target := range ep.Targets {
+			if results[i][j] == nil {
+				healthy = append(healthy, target)
+			}
+		}
+		if len(healthy) == 0 {
+			log.WithFields(ep.LogFields()).Warnf("All targets fail the health check, keeping them: %v", results[i][0])
+			continue
+		}
+		for j, target := range ep.Targets {
+			if results[i][j] != nil {
+				log.WithFields(ep.LogFields()).Warnf("Withdrawing target %s failing the health check: %v", target, results[i][j])
+			}
+		}
+		ep.Targets = healthy
+	}
+
+	return endpoints, nil
+}
+
+// parseHealthCheck parses a health check like tcp://:5432 or http://:8080/healthz. The host is left out,
+// as the targets of the records are probed.
+func parseHealthCheck(value string) (*url.URL, error) {
+	check, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid health check %q: %v", value, err)
+	}
+	if check.Hostname() != "" {
+		return nil, fmt.Errorf("invalid health check %q, the host must be left out, e.g. tcp://:443", value)
+	}
+	switch check.Scheme {
+	case "tcp":
+		if check.Port() == "" {
+			return nil, fmt.Errorf("invalid health check %q, a port is required", value)
+		}
+	case "http", "https":
+	default:
+		return nil, fmt.Errorf("invalid health check %q, the protocol must be tcp, http or https", value)
+	}
+	return check, nil
+}
+
+// probeTarget returns an error if the target fails the health check. A TCP check has to connect, an HTTP check has to
+// get a response below 400. HTTP checks pass the DNS name as host and for TLS, so that the target knows the site checked.
+func probeTarget(check *url.URL, dnsName, target string, timeout time.Duration) error {
+	port := check.Port()
+	switch check.Scheme {
+	case "tcp":
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(target, port), timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	case "https":
+		if port == "" {
+			port = "443"
+		}
+	default:
+		if port == "" {
+			port = "80"
+		}
+	}
+
+	u := *check
+	u.Host = net.JoinHostPort(target, port)
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Host = dnsName
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{ServerName: dnsName},
+			DisableKeepAlives: true,
+		},
+		// a redirect is the answer of a healthy server
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("unhealthy status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+)
+
+// Validates that healthCheckSource is a Source
+var _ Source = &healthCheckSource{}
+
+func TestHealthCheckSource(t *testing.T) {
+	t.Run("Endpoints", testHealthCheckSourceEndpoints)
+	t.Run("ParseHealthCheck", testParseHealthCheck)
+	t.Run("ProbeTarget", testProbeTarget)
+}
+
+func withHealthCheck(ep *endpoint.Endpoint, check string) *endpoint.Endpoint {
+	ep.Labels[endpoint.HealthCheckLabelKey] = check
+	return ep
+}
+
+// testHealthCheckSourceEndpoints tests that failing targets are withdrawn unless all targets of an endpoint fail.
+func testHealthCheckSourceEndpoints(t *testing.T) {
+	partial := withHealthCheck(endpoint.NewEndpoint("partial.example.org", "1.2.3.4", endpoint.RecordTypeA), "tcp://:443")
+	partial.Targets = append(partial.Targets, "10.0.0.1")
+	failing := withHealthCheck(endpoint.NewEndpoint("failing.example.org", "10.0.0.2", endpoint.RecordTypeA), "tcp://:443")
+	unchecked := endpoint.NewEndpoint("unchecked.example.org", "10.0.0.3", endpoint.RecordTypeA)
+	txt := withHealthCheck(endpoint.NewEndpoint("txt.example.org", "10.0.0.4", endpoint.RecordTypeTXT), "tcp://:443")
+
+	// the probes run in parallel, they report the targets probed
+	probed := make(chan string, 10)
+	hs := &healthCheckSource{
+		source:  NewEndpointsSource(partial, failing, unchecked, txt),
+		timeout: time.Second,
+		probe: func(check *url.URL, dnsName, target string, timeout time.Duration) error {
+			probed <- target
+			if target == "1.2.3.4" {
+				return nil
+			}
+			return errors.New("connection refused")
+		},
+	}
+
+	endpoints, err := hs.Endpoints()
+	require.NoError(t, err)
+	require.Len(t, endpoints, 4)
+	assert.Equal(t, endpoint.Targets{"1.2.3.4"}, endpoints[0].Targets)
+	assert.Equal(t, endpoint.Targets{"10.0.0.2"}, endpoints[1].Targets)
+	assert.Equal(t, endpoint.Targets{"10.0.0.3"}, endpoints[2].Targets)
+	assert.Equal(t, endpoint.Targets{"10.0.0.4"}, endpoints[3].Targets)
+	close(probed)
+	checked := map[string]bool{}
+	for target := range probed {
+		checked[target] = true
+	}
+	assert.Equal(t, map[string]bool{"1.2.3.4": true, "10.0.0.1": true, "10.0.0.2": true}, checked)
+}
+
+// testParseHealthCheck tests that only TCP checks with a port and HTTP checks without host are accepted.
+func testParseHealthCheck(t *testing.T) {
+	for _, valid := range []string{"tcp://:443", "http://:8080/healthz", "https:///healthz", "http://"} {
+		_, err := parseHealthCheck(valid)
+		assert.NoError(t, err, valid)
+	}
+	for _, invalid := range []string{"tcp://", "tcp://example.org:443", "udp://:53", ":443", "http://[::1"} {
+		_, err := parseHealthCheck(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+// testProbeTarget tests that the targets are probed with the DNS name as host.
+func testProbeTarget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "healthy.example.org" || r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	check, err := parseHealthCheck("http://:" + port + "/healthz")
+	require.NoError(t, err)
+	assert.NoError(t, probeTarget(check, "healthy.example.org", host, time.Second))
+	assert.Error(t, probeTarget(check, "unhealthy.example.org", host, time.Second))
+
+	check, err = parseHealthCheck("tcp://:" + port)
+	require.NoError(t, err)
+	assert.NoError(t, probeTarget(check, "healthy.example.org", host, time.Second))
+
+	server.Close()
+	assert.Error(t, probeTarget(check, "healthy.example.org", host, time.Second))
+}
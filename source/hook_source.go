@@ -0,0 +1,129 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+)
+
+// HookMediaType is the versioned media type spoken between ExternalDNS and webhook hooks.
+const HookMediaType = "application/external.dns.hook+json;version=1"
+
+// Hook mutates or validates the desired endpoints before planning, e.g. to enforce naming conventions, to add the geo
+// location of the cluster's region or to strip disallowed TTLs. It returns the endpoints to publish, a hook rejecting an
+// endpoint simply leaves it out. An error fails the synchronization, so that no records are changed.
+type Hook interface {
+	Apply(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error)
+}
+
+// HookFunc is a function implementing Hook
+type HookFunc func(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error)
+
+// Apply calls the function
+func (f HookFunc) Apply(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	return f(endpoints)
+}
+
+// hookSource is a Source that passes the endpoints of its wrapped source through hooks.
+type hookSource struct {
+	source Source
+	hooks  []Hook
+}
+
+// NewHookSource creates a new hookSource wrapping the provided Source. The hooks are applied in the given order,
+// each one gets the endpoints returned by the previous one.
+func NewHookSource(source Source, hooks ...Hook) Source {
+	return &hookSource{source: source, hooks: hooks}
+}
+
+// Endpoints collects endpoints from its wrapped source and applies the hooks to them.
+func (hs *hookSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	endpoints, err := hs.source.Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hook := range hs.hooks {
+		if endpoints, err = hook.Apply(endpoints); err != nil {
+			return nil, err
+		}
+	}
+	return endpoints, nil
+}
+
+// webhookHook is a Hook running out of process, usually as a sidecar listening on localhost.
+// It's called with a POST of the JSON list of endpoints and answers with the JSON list of endpoints to publish.
+type webhookHook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookHook returns a Hook posting the endpoints to the given URL, each call is limited by the timeout
+func NewWebhookHook(url string, timeout time.Duration) Hook {
+	return &webhookHook{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+// Apply sends the endpoints to the webhook and returns the endpoints of its response
+func (h *webhookHook) Apply(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	data, err := json.Marshal(endpoints)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, h.url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", HookMediaType)
+	req.Header.Set("Accept", HookMediaType)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call hook %s: %v", h.url, err)
+	}
+	defer resp.Body.Close()
+
+	data, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("hook %s failed with status %d: %s", h.url, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != HookMediaType {
+		return nil, fmt.Errorf("hook %s answered with unsupported media type %q, expected %q", h.url, contentType, HookMediaType)
+	}
+
+	mutated := []*endpoint.Endpoint{}
+	if err := json.Unmarshal(data, &mutated); err != nil {
+		return nil, fmt.Errorf("failed to parse the response of hook %s: %v", h.url, err)
+	}
+	for _, ep := range mutated {
+		// hooks may leave out empty labels, the registry and the conflict resolvers expect them to be set
+		if ep.Labels == nil {
+			ep.Labels = endpoint.NewLabels()
+		}
+	}
+	return mutated, nil
+}
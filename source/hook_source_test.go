@@ -0,0 +1,127 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+)
+
+// Validates that hookSource is a Source
+var _ Source = &hookSource{}
+
+func TestHookSource(t *testing.T) {
+	t.Run("Endpoints", testHookSourceEndpoints)
+	t.Run("Error", testHookSourceError)
+	t.Run("Webhook", testWebhookHook)
+	t.Run("WebhookError", testWebhookHookError)
+}
+
+// testHookSourceEndpoints tests that the hooks are applied in order.
+func testHookSourceEndpoints(t *testing.T) {
+	suffix := HookFunc(func(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+		valid := []*endpoint.Endpoint{}
+		for _, ep := range endpoints {
+			if strings.HasSuffix(ep.DNSName, ".prod.example.org") {
+				valid = append(valid, ep)
+			}
+		}
+		return valid, nil
+	})
+	geo := HookFunc(func(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+		for _, ep := range endpoints {
+			ep.GeoLocation = &endpoint.GeoLocation{ContinentCode: "EU"}
+		}
+		return endpoints, nil
+	})
+
+	hs := NewHookSource(NewEndpointsSource(
+		endpoint.NewEndpoint("api.prod.example.org", "1.2.3.4", endpoint.RecordTypeA),
+		endpoint.NewEndpoint("api.example.org", "1.2.3.4", endpoint.RecordTypeA),
+	), suffix, geo)
+
+	endpoints, err := hs.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "api.prod.example.org", Targets: endpoint.Targets{"1.2.3.4"}, GeoLocation: &endpoint.GeoLocation{ContinentCode: "EU"}},
+	})
+}
+
+// testHookSourceError tests that an error of a hook fails the source.
+func testHookSourceError(t *testing.T) {
+	hs := NewHookSource(NewEndpointsSource(), HookFunc(func([]*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+		return nil, errors.New("rejected")
+	}))
+
+	_, err := hs.Endpoints()
+	assert.EqualError(t, err, "rejected")
+}
+
+// testWebhookHook tests that the webhook gets the endpoints and returns the mutated ones.
+func testWebhookHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, HookMediaType, r.Header.Get("Content-Type"))
+
+		endpoints := []*endpoint.Endpoint{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&endpoints))
+		for _, ep := range endpoints {
+			ep.RecordTTL = 0
+			ep.Labels = nil
+		}
+		w.Header().Set("Content-Type", HookMediaType)
+		require.NoError(t, json.NewEncoder(w).Encode(endpoints))
+	}))
+	defer server.Close()
+
+	endpoints, err := NewWebhookHook(server.URL, time.Second).Apply([]*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("api.example.org", "1.2.3.4", endpoint.RecordTypeA, 10),
+	})
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "api.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+	})
+	assert.NotNil(t, endpoints[0].Labels)
+}
+
+// testWebhookHookError tests that failed calls and unexpected responses are errors.
+func testWebhookHookError(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid name", http.StatusUnprocessableEntity)
+	}))
+	defer failing.Close()
+	_, err := NewWebhookHook(failing.URL, time.Second).Apply([]*endpoint.Endpoint{})
+	assert.EqualError(t, err, "hook "+failing.URL+" failed with status 422: invalid name")
+
+	unversioned := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+	}))
+	defer unversioned.Close()
+	_, err = NewWebhookHook(unversioned.URL, time.Second).Apply([]*endpoint.Endpoint{})
+	assert.Error(t, err)
+}
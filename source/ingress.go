@@ -25,10 +25,10 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/pkg/apis/extensions/v1beta1"
 
 	"github.com/kubernetes-incubator/external-dns/endpoint"
 )
@@ -43,10 +43,12 @@ type ingressSource struct {
 	annotationFilter      string
 	fqdnTemplate          *template.Template
 	combineFQDNAnnotation bool
+	ipFamilyPolicy        string
+	eventRecorder         *EventRecorder
 }
 
 // NewIngressSource creates a new ingressSource with the given config.
-func NewIngressSource(kubeClient kubernetes.Interface, namespace, annotationFilter string, fqdnTemplate string, combineFqdnAnnotation bool) (Source, error) {
+func NewIngressSource(kubeClient kubernetes.Interface, namespace, annotationFilter string, fqdnTemplate string, combineFqdnAnnotation bool, ipFamilyPolicy string, eventRecorder *EventRecorder) (Source, error) {
 	var (
 		tmpl *template.Template
 		err  error
@@ -66,6 +68,8 @@ func NewIngressSource(kubeClient kubernetes.Interface, namespace, annotationFilt
 		annotationFilter:      annotationFilter,
 		fqdnTemplate:          tmpl,
 		combineFQDNAnnotation: combineFqdnAnnotation,
+		ipFamilyPolicy:        ipFamilyPolicy,
+		eventRecorder:         eventRecorder,
 	}, nil
 }
 
@@ -108,12 +112,24 @@ func (sc *ingressSource) Endpoints() ([]*endpoint.Endpoint, error) {
 			}
 		}
 
+		policy, err := getIPFamilyPolicyFromAnnotations(ing.Annotations, sc.ipFamilyPolicy)
+		if err != nil {
+			log.WithField("resource", ingressResource(&ing)).Warnf("Invalid annotations: %v", err)
+			sc.eventRecorder.recordInvalidAnnotations(&ing, err)
+		}
+		ingEndpoints = filterByIPFamily(ingEndpoints, policy)
+
 		if len(ingEndpoints) == 0 {
 			log.Debugf("No endpoints could be generated from ingress %s/%s", ing.Namespace, ing.Name)
 			continue
 		}
 
 		log.Debugf("Endpoints generated from ingress: %s/%s: %v", ing.Namespace, ing.Name, ingEndpoints)
+		if err := setRoutingPolicyFromAnnotations(ing.Annotations, ingEndpoints); err != nil {
+			log.WithField("resource", ingressResource(&ing)).Warnf("Invalid annotations, skipping the endpoints: %v", err)
+			sc.eventRecorder.recordInvalidAnnotations(&ing, err)
+			continue
+		}
 		sc.setResourceLabel(ing, ingEndpoints)
 		endpoints = append(endpoints, ingEndpoints...)
 	}
@@ -155,7 +171,7 @@ func (sc *ingressSource) endpointsFromTemplate(ing *v1beta1.Ingress) ([]*endpoin
 
 	ttl, err := getTTLFromAnnotations(ing.Annotations)
 	if err != nil {
-		log.Warn(err)
+		log.WithField("resource", ingressResource(ing)).Warn(err)
 	}
 
 	targets := getTargetsFromTargetAnnotation(ing)
@@ -207,8 +223,16 @@ func (sc *ingressSource) filterByAnnotations(ingresses []v1beta1.Ingress) ([]v1b
 
 func (sc *ingressSource) setResourceLabel(ingress v1beta1.Ingress, endpoints []*endpoint.Endpoint) {
 	for _, ep := range endpoints {
-		ep.Labels[endpoint.ResourceLabelKey] = fmt.Sprintf("ingress/%s/%s", ingress.Namespace, ingress.Name)
+		ep.Labels[endpoint.ResourceLabelKey] = ingressResource(&ingress)
 	}
+	setResolutionLabels(ingress.ObjectMeta, endpoints)
+	setHealthCheckLabel(ingress.ObjectMeta, endpoints)
+	setProviderLabel(ingress.ObjectMeta, endpoints)
+}
+
+// ingressResource returns the name of the ingress used in the resource label and logs
+func ingressResource(ing *v1beta1.Ingress) string {
+	return fmt.Sprintf("ingress/%s/%s", ing.Namespace, ing.Name)
 }
 
 // endpointsFromIngress extracts the endpoints from ingress object
@@ -217,7 +241,7 @@ func endpointsFromIngress(ing *v1beta1.Ingress) []*endpoint.Endpoint {
 
 	ttl, err := getTTLFromAnnotations(ing.Annotations)
 	if err != nil {
-		log.Warn(err)
+		log.WithField("resource", ingressResource(ing)).Warn(err)
 	}
 
 	targets := getTargetsFromTargetAnnotation(ing)
@@ -239,12 +263,15 @@ func endpointsForHostname(hostname string, targets endpoint.Targets, ttl endpoin
 	var endpoints []*endpoint.Endpoint
 
 	var aTargets endpoint.Targets
+	var aaaaTargets endpoint.Targets
 	var cnameTargets endpoint.Targets
 
 	for _, t := range targets {
 		switch suitableType(t) {
 		case endpoint.RecordTypeA:
 			aTargets = append(aTargets, t)
+		case endpoint.RecordTypeAAAA:
+			aaaaTargets = append(aaaaTargets, t)
 		default:
 			cnameTargets = append(cnameTargets, t)
 		}
@@ -261,6 +288,17 @@ func endpointsForHostname(hostname string, targets endpoint.Targets, ttl endpoin
 		endpoints = append(endpoints, epA)
 	}
 
+	if len(aaaaTargets) > 0 {
+		epAAAA := &endpoint.Endpoint{
+			DNSName:    strings.TrimSuffix(hostname, "."),
+			Targets:    aaaaTargets,
+			RecordTTL:  ttl,
+			RecordType: endpoint.RecordTypeAAAA,
+			Labels:     endpoint.NewLabels(),
+		}
+		endpoints = append(endpoints, epAAAA)
+	}
+
 	if len(cnameTargets) > 0 {
 		epCNAME := &endpoint.Endpoint{
 			DNSName:    strings.TrimSuffix(hostname, "."),
@@ -19,10 +19,10 @@ package source
 import (
 	"testing"
 
+	"k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
-	"k8s.io/client-go/pkg/api/v1"
-	"k8s.io/client-go/pkg/apis/extensions/v1beta1"
 
 	"github.com/kubernetes-incubator/external-dns/endpoint"
 
@@ -50,6 +50,8 @@ func (suite *IngressSuite) SetupTest() {
 		"",
 		"{{.Name}}",
 		false,
+		"",
+		nil,
 	)
 	suite.NoError(err, "should initialize ingress source")
 
@@ -123,6 +125,8 @@ func TestNewIngressSource(t *testing.T) {
 				ti.annotationFilter,
 				ti.fqdnTemplate,
 				ti.combineFQDNAndAnnotation,
+				"",
+				nil,
 			)
 			if ti.expectError {
 				assert.Error(t, err)
@@ -720,6 +724,8 @@ func testIngressEndpoints(t *testing.T) {
 				ti.annotationFilter,
 				ti.fqdnTemplate,
 				ti.combineFQDNAndAnnotation,
+				"",
+				nil,
 			)
 			for _, ingress := range ingresses {
 				_, err := fakeClient.Extensions().Ingresses(ingress.Namespace).Create(ingress)
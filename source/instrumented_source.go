@@ -0,0 +1,71 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+)
+
+var (
+	sourceEndpoints = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "source",
+			Name:      "endpoints_total",
+			Help:      "Number of endpoints returned by the source in the last synchronization.",
+		},
+		[]string{"source"},
+	)
+	sourceErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "source",
+			Name:      "errors_total",
+			Help:      "Number of times the source failed to return its endpoints.",
+		},
+		[]string{"source"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(sourceEndpoints)
+	prometheus.MustRegister(sourceErrorsTotal)
+}
+
+// instrumentedSource is a Source that exports the number of endpoints of its wrapped source in the metrics.
+type instrumentedSource struct {
+	name   string
+	source Source
+}
+
+// NewInstrumentedSource creates a new instrumentedSource wrapping the provided Source, name labels its metrics.
+func NewInstrumentedSource(name string, source Source) Source {
+	return &instrumentedSource{name: name, source: source}
+}
+
+// Endpoints collects endpoints from its wrapped source and counts them.
+func (is *instrumentedSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	endpoints, err := is.source.Endpoints()
+	if err != nil {
+		sourceErrorsTotal.WithLabelValues(is.name).Inc()
+		return nil, err
+	}
+	sourceEndpoints.WithLabelValues(is.name).Set(float64(len(endpoints)))
+	return endpoints, nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/internal/testutils"
+)
+
+// Validates that instrumentedSource is a Source
+var _ Source = &instrumentedSource{}
+
+func TestInstrumentedSource(t *testing.T) {
+	t.Run("Endpoints", testInstrumentedSourceEndpoints)
+	t.Run("EndpointsError", testInstrumentedSourceEndpointsError)
+}
+
+// testInstrumentedSourceEndpoints tests that the endpoints of the wrapped source are returned unchanged.
+func testInstrumentedSourceEndpoints(t *testing.T) {
+	endpoints := []*endpoint.Endpoint{
+		{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
+		{DNSName: "bar.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordTTL: 10},
+	}
+
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return(endpoints, nil)
+
+	source := NewInstrumentedSource("fake", mockSource)
+
+	result, err := source.Endpoints()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validateEndpoints(t, result, endpoints)
+
+	mockSource.AssertExpectations(t)
+}
+
+// testInstrumentedSourceEndpointsError tests that errors of the wrapped source are returned.
+func testInstrumentedSourceEndpointsError(t *testing.T) {
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return(nil, errors.New("some error"))
+
+	source := NewInstrumentedSource("fake", mockSource)
+
+	if _, err := source.Endpoints(); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	mockSource.AssertExpectations(t)
+}
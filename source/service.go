@@ -25,10 +25,10 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/pkg/api/v1"
 
 	"github.com/kubernetes-incubator/external-dns/endpoint"
 )
@@ -51,10 +51,12 @@ type serviceSource struct {
 	fqdnTemplate          *template.Template
 	combineFQDNAnnotation bool
 	publishInternal       bool
+	ipFamilyPolicy        string
+	eventRecorder         *EventRecorder
 }
 
 // NewServiceSource creates a new serviceSource with the given config.
-func NewServiceSource(kubeClient kubernetes.Interface, namespace, annotationFilter string, fqdnTemplate string, combineFqdnAnnotation bool, compatibility string, publishInternal bool) (Source, error) {
+func NewServiceSource(kubeClient kubernetes.Interface, namespace, annotationFilter string, fqdnTemplate string, combineFqdnAnnotation bool, compatibility string, publishInternal bool, ipFamilyPolicy string, eventRecorder *EventRecorder) (Source, error) {
 	var (
 		tmpl *template.Template
 		err  error
@@ -76,6 +78,8 @@ func NewServiceSource(kubeClient kubernetes.Interface, namespace, annotationFilt
 		fqdnTemplate:          tmpl,
 		combineFQDNAnnotation: combineFqdnAnnotation,
 		publishInternal:       publishInternal,
+		ipFamilyPolicy:        ipFamilyPolicy,
+		eventRecorder:         eventRecorder,
 	}, nil
 }
 
@@ -122,12 +126,24 @@ func (sc *serviceSource) Endpoints() ([]*endpoint.Endpoint, error) {
 			}
 		}
 
+		policy, err := getIPFamilyPolicyFromAnnotations(svc.Annotations, sc.ipFamilyPolicy)
+		if err != nil {
+			log.WithField("resource", serviceResource(&svc)).Warnf("Invalid annotations: %v", err)
+			sc.eventRecorder.recordInvalidAnnotations(&svc, err)
+		}
+		svcEndpoints = filterByIPFamily(svcEndpoints, policy)
+
 		if len(svcEndpoints) == 0 {
 			log.Debugf("No endpoints could be generated from service %s/%s", svc.Namespace, svc.Name)
 			continue
 		}
 
 		log.Debugf("Endpoints generated from service: %s/%s: %v", svc.Namespace, svc.Name, svcEndpoints)
+		if err := setRoutingPolicyFromAnnotations(svc.Annotations, svcEndpoints); err != nil {
+			log.WithField("resource", serviceResource(&svc)).Warnf("Invalid annotations, skipping the endpoints: %v", err)
+			sc.eventRecorder.recordInvalidAnnotations(&svc, err)
+			continue
+		}
 		sc.setResourceLabel(svc, svcEndpoints)
 		endpoints = append(endpoints, svcEndpoints...)
 	}
@@ -159,7 +175,7 @@ func (sc *serviceSource) extractHeadlessEndpoints(svc *v1.Service, hostname stri
 		log.Debugf("Generating matching endpoint %s with PodIP %s", headlessDomain, v.Status.PodIP)
 		// To reduce traffice on the DNS API only add record for running Pods. Good Idea?
 		if v.Status.Phase == v1.PodRunning {
-			endpoints = append(endpoints, endpoint.NewEndpoint(headlessDomain, v.Status.PodIP, endpoint.RecordTypeA))
+			endpoints = append(endpoints, endpoint.NewEndpoint(headlessDomain, v.Status.PodIP, suitableType(v.Status.PodIP)))
 		} else {
 			log.Debugf("Pod %s is not in running phase", v.Spec.Hostname)
 		}
@@ -236,15 +252,23 @@ func (sc *serviceSource) filterByAnnotations(services []v1.Service) ([]v1.Servic
 
 func (sc *serviceSource) setResourceLabel(service v1.Service, endpoints []*endpoint.Endpoint) {
 	for _, ep := range endpoints {
-		ep.Labels[endpoint.ResourceLabelKey] = fmt.Sprintf("service/%s/%s", service.Namespace, service.Name)
+		ep.Labels[endpoint.ResourceLabelKey] = serviceResource(&service)
 	}
+	setResolutionLabels(service.ObjectMeta, endpoints)
+	setHealthCheckLabel(service.ObjectMeta, endpoints)
+	setProviderLabel(service.ObjectMeta, endpoints)
+}
+
+// serviceResource returns the name of the service used in the resource label and logs
+func serviceResource(svc *v1.Service) string {
+	return fmt.Sprintf("service/%s/%s", svc.Namespace, svc.Name)
 }
 
 func (sc *serviceSource) generateEndpoints(svc *v1.Service, hostname string) []*endpoint.Endpoint {
 	hostname = strings.TrimSuffix(hostname, ".")
 	ttl, err := getTTLFromAnnotations(svc.Annotations)
 	if err != nil {
-		log.Warn(err)
+		log.WithField("resource", serviceResource(svc)).Warn(err)
 	}
 
 	epA := &endpoint.Endpoint{
@@ -255,6 +279,14 @@ func (sc *serviceSource) generateEndpoints(svc *v1.Service, hostname string) []*
 		DNSName:    hostname,
 	}
 
+	epAAAA := &endpoint.Endpoint{
+		RecordTTL:  ttl,
+		RecordType: endpoint.RecordTypeAAAA,
+		Labels:     endpoint.NewLabels(),
+		Targets:    make(endpoint.Targets, 0, defaultTargetsCapacity),
+		DNSName:    hostname,
+	}
+
 	epCNAME := &endpoint.Endpoint{
 		RecordTTL:  ttl,
 		RecordType: endpoint.RecordTypeCNAME,
@@ -280,10 +312,12 @@ func (sc *serviceSource) generateEndpoints(svc *v1.Service, hostname string) []*
 	}
 
 	for _, t := range targets {
-		if suitableType(t) == endpoint.RecordTypeA {
+		switch suitableType(t) {
+		case endpoint.RecordTypeA:
 			epA.Targets = append(epA.Targets, t)
-		}
-		if suitableType(t) == endpoint.RecordTypeCNAME {
+		case endpoint.RecordTypeAAAA:
+			epAAAA.Targets = append(epAAAA.Targets, t)
+		case endpoint.RecordTypeCNAME:
 			epCNAME.Targets = append(epCNAME.Targets, t)
 		}
 	}
@@ -291,6 +325,9 @@ func (sc *serviceSource) generateEndpoints(svc *v1.Service, hostname string) []*
 	if len(epA.Targets) > 0 {
 		endpoints = append(endpoints, epA)
 	}
+	if len(epAAAA.Targets) > 0 {
+		endpoints = append(endpoints, epAAAA)
+	}
 	if len(epCNAME.Targets) > 0 {
 		endpoints = append(endpoints, epCNAME)
 	}
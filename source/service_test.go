@@ -20,9 +20,9 @@ import (
 	"net"
 	"testing"
 
+	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
-	"k8s.io/client-go/pkg/api/v1"
 
 	"github.com/kubernetes-incubator/external-dns/endpoint"
 
@@ -49,6 +49,8 @@ func (suite *ServiceSuite) SetupTest() {
 		false,
 		"",
 		false,
+		"",
+		nil,
 	)
 	suite.fooWithTargets = &v1.Service{
 		Spec: v1.ServiceSpec{
@@ -132,6 +134,8 @@ func testServiceSourceNewServiceSource(t *testing.T) {
 				false,
 				"",
 				false,
+				"",
+				nil,
 			)
 
 			if ti.expectError {
@@ -828,6 +832,108 @@ func testServiceSourceEndpoints(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"routing policy annotations are applied to all endpoints",
+			"",
+			"",
+			"testing",
+			"foo",
+			v1.ServiceTypeLoadBalancer,
+			"",
+			"",
+			false,
+			map[string]string{},
+			map[string]string{
+				hostnameAnnotationKey:                           "foo.example.org.",
+				setIdentifierAnnotationKey:                      "germany",
+				geoCountryCodeAnnotationKey:                     "DE",
+				"external-dns.alpha.kubernetes.io/aws-failover": "PRIMARY",
+			},
+			"",
+			[]string{"1.2.3.4", "lb.example.com"},
+			[]*endpoint.Endpoint{
+				{
+					DNSName:          "foo.example.org",
+					Targets:          endpoint.Targets{"1.2.3.4"},
+					SetIdentifier:    "germany",
+					GeoLocation:      &endpoint.GeoLocation{CountryCode: "DE"},
+					ProviderSpecific: endpoint.ProviderSpecific{{Name: "aws/failover", Value: "PRIMARY"}},
+				},
+				{
+					DNSName:          "foo.example.org",
+					Targets:          endpoint.Targets{"lb.example.com"},
+					SetIdentifier:    "germany",
+					GeoLocation:      &endpoint.GeoLocation{CountryCode: "DE"},
+					ProviderSpecific: endpoint.ProviderSpecific{{Name: "aws/failover", Value: "PRIMARY"}},
+				},
+			},
+			false,
+		},
+		{
+			"services with invalid geo annotations are skipped",
+			"",
+			"",
+			"testing",
+			"foo",
+			v1.ServiceTypeLoadBalancer,
+			"",
+			"",
+			false,
+			map[string]string{},
+			map[string]string{
+				hostnameAnnotationKey:       "foo.example.org.",
+				setIdentifierAnnotationKey:  "germany",
+				geoCountryCodeAnnotationKey: "Germany",
+			},
+			"",
+			[]string{"1.2.3.4"},
+			[]*endpoint.Endpoint{},
+			false,
+		},
+		{
+			"dual-stack load balancer returns A and AAAA records",
+			"",
+			"",
+			"testing",
+			"foo",
+			v1.ServiceTypeLoadBalancer,
+			"",
+			"",
+			false,
+			map[string]string{},
+			map[string]string{
+				hostnameAnnotationKey: "foo.example.org.",
+			},
+			"",
+			[]string{"1.2.3.4", "2001:db8::1"},
+			[]*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA},
+			},
+			false,
+		},
+		{
+			"ip-family-policy annotation restricts a dual-stack load balancer to AAAA records",
+			"",
+			"",
+			"testing",
+			"foo",
+			v1.ServiceTypeLoadBalancer,
+			"",
+			"",
+			false,
+			map[string]string{},
+			map[string]string{
+				hostnameAnnotationKey:       "foo.example.org.",
+				ipFamilyPolicyAnnotationKey: "ipv6",
+			},
+			"",
+			[]string{"1.2.3.4", "2001:db8::1"},
+			[]*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA},
+			},
+			false,
+		},
 	} {
 		t.Run(tc.title, func(t *testing.T) {
 			// Create a Kubernetes testing client
@@ -873,6 +979,8 @@ func testServiceSourceEndpoints(t *testing.T) {
 				tc.combineFQDNAndAnnotation,
 				tc.compatibility,
 				false,
+				"",
+				nil,
 			)
 			require.NoError(t, err)
 
@@ -1006,6 +1114,8 @@ func TestClusterIpServices(t *testing.T) {
 				false,
 				tc.compatibility,
 				true,
+				"",
+				nil,
 			)
 			require.NoError(t, err)
 
@@ -1177,6 +1287,8 @@ func TestHeadlessServices(t *testing.T) {
 				false,
 				tc.compatibility,
 				true,
+				"",
+				nil,
 			)
 			require.NoError(t, err)
 
@@ -1217,7 +1329,7 @@ func BenchmarkServiceEndpoints(b *testing.B) {
 	_, err := kubernetes.CoreV1().Services(service.Namespace).Create(service)
 	require.NoError(b, err)
 
-	client, err := NewServiceSource(kubernetes, v1.NamespaceAll, "", "", false, "", false)
+	client, err := NewServiceSource(kubernetes, v1.NamespaceAll, "", "", false, "", false, "", nil)
 	require.NoError(b, err)
 
 	for i := 0; i < b.N; i++ {
@@ -47,6 +47,18 @@ func validateEndpoint(t *testing.T, endpoint, expected *endpoint.Endpoint) {
 		t.Errorf("expected %v, got %v", expected.RecordTTL, endpoint.RecordTTL)
 	}
 
+	if endpoint.SetIdentifier != expected.SetIdentifier {
+		t.Errorf("expected set identifier %s, got %s", expected.SetIdentifier, endpoint.SetIdentifier)
+	}
+
+	if !endpoint.GeoLocation.Same(expected.GeoLocation) {
+		t.Errorf("expected geo location %s, got %s", expected.GeoLocation, endpoint.GeoLocation)
+	}
+
+	if !endpoint.ProviderSpecific.Same(expected.ProviderSpecific) {
+		t.Errorf("expected provider specific %v, got %v", expected.ProviderSpecific, endpoint.ProviderSpecific)
+	}
+
 	// if non-empty record type is expected, check that it matches.
 	if expected.RecordType != "" && endpoint.RecordType != expected.RecordType {
 		t.Errorf("expected %s, got %s", expected.RecordType, endpoint.RecordType)
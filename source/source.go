@@ -20,7 +20,14 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/kubernetes-incubator/external-dns/endpoint"
 )
@@ -34,10 +41,36 @@ const (
 	targetAnnotationKey = "external-dns.alpha.kubernetes.io/target"
 	// The annotation used for defining the desired DNS record TTL
 	ttlAnnotationKey = "external-dns.alpha.kubernetes.io/ttl"
+	// The annotation used for distinguishing records of the same name and type, e.g. per geo location
+	setIdentifierAnnotationKey = "external-dns.alpha.kubernetes.io/set-identifier"
+	// The annotations used for restricting records to queries from a geo location
+	geoContinentCodeAnnotationKey   = "external-dns.alpha.kubernetes.io/geo-continent-code"
+	geoCountryCodeAnnotationKey     = "external-dns.alpha.kubernetes.io/geo-country-code"
+	geoSubdivisionCodeAnnotationKey = "external-dns.alpha.kubernetes.io/geo-subdivision-code"
+	// The annotation used for probing the targets of the records, e.g. tcp://:443 or http://:8080/healthz
+	healthCheckAnnotationKey = "external-dns.alpha.kubernetes.io/health-check"
+	// The annotation used for choosing the DNS provider of the records when multiple providers are configured
+	providerAnnotationKey = "external-dns.alpha.kubernetes.io/provider"
+	// The annotation used for overriding the IP family policy of the resource, i.e. ipv4, ipv6 or dual
+	ipFamilyPolicyAnnotationKey = "external-dns.alpha.kubernetes.io/ip-family-policy"
+	// The annotation used for resolving conflicts between resources with the priority conflict resolution
+	priorityAnnotationKey = "external-dns.alpha.kubernetes.io/priority"
+	// The prefix of annotations holding AWS specific config, e.g. external-dns.alpha.kubernetes.io/aws-failover
+	awsAnnotationPrefix = "external-dns.alpha.kubernetes.io/aws-"
 	// The value of the controller annotation so that we feel responsible
 	controllerAnnotationValue = "dns-controller"
 )
 
+// The IP family policies choosing the records published for the IP addresses of resources
+const (
+	// IPFamilyPolicyIPv4 publishes A records only
+	IPFamilyPolicyIPv4 = "ipv4"
+	// IPFamilyPolicyIPv6 publishes AAAA records only
+	IPFamilyPolicyIPv6 = "ipv6"
+	// IPFamilyPolicyDual publishes both A and AAAA records
+	IPFamilyPolicyDual = "dual"
+)
+
 const (
 	ttlMinimum = 1
 	ttlMaximum = math.MaxUint32
@@ -64,10 +97,157 @@ func getTTLFromAnnotations(annotations map[string]string) (endpoint.TTL, error)
 	return endpoint.TTL(ttlValue), nil
 }
 
+// getGeoLocationFromAnnotations returns the geo location configured by the annotations, nil if there is none
+func getGeoLocationFromAnnotations(annotations map[string]string) (*endpoint.GeoLocation, error) {
+	continent, hasContinent := annotations[geoContinentCodeAnnotationKey]
+	country, hasCountry := annotations[geoCountryCodeAnnotationKey]
+	subdivision, hasSubdivision := annotations[geoSubdivisionCodeAnnotationKey]
+	if !hasContinent && !hasCountry && !hasSubdivision {
+		return nil, nil
+	}
+
+	geo := &endpoint.GeoLocation{}
+	if hasContinent {
+		if err := geo.SetContinentCode(continent); err != nil {
+			return nil, err
+		}
+	}
+	if hasCountry {
+		if err := geo.SetCountryCode(country); err != nil {
+			return nil, err
+		}
+	}
+	if hasSubdivision {
+		if err := geo.SetSubdivisionCode(subdivision); err != nil {
+			return nil, err
+		}
+	}
+	return geo, nil
+}
+
+// getProviderSpecificAnnotations returns the provider specific config of the annotations,
+// e.g. external-dns.alpha.kubernetes.io/aws-failover becomes the property aws/failover
+func getProviderSpecificAnnotations(annotations map[string]string) endpoint.ProviderSpecific {
+	var providerSpecific endpoint.ProviderSpecific
+	for key, value := range annotations {
+		if strings.HasPrefix(key, awsAnnotationPrefix) {
+			providerSpecific = append(providerSpecific, endpoint.ProviderSpecificProperty{
+				Name:  "aws/" + strings.TrimPrefix(key, awsAnnotationPrefix),
+				Value: value,
+			})
+		}
+	}
+	sort.Slice(providerSpecific, func(i, j int) bool {
+		return providerSpecific[i].Name < providerSpecific[j].Name
+	})
+	return providerSpecific
+}
+
+// getIPFamilyPolicyFromAnnotations returns the IP family policy configured by the annotation, defaultPolicy if there is none
+func getIPFamilyPolicyFromAnnotations(annotations map[string]string, defaultPolicy string) (string, error) {
+	policy, exists := annotations[ipFamilyPolicyAnnotationKey]
+	if !exists {
+		return defaultPolicy, nil
+	}
+	switch policy {
+	case IPFamilyPolicyIPv4, IPFamilyPolicyIPv6, IPFamilyPolicyDual:
+		return policy, nil
+	}
+	return defaultPolicy, fmt.Errorf("\"%v\" is not a valid IP family policy, it must be one of ipv4, ipv6, dual", policy)
+}
+
+// filterByIPFamily drops the AAAA records if the policy is ipv4 and the A records if it's ipv6
+func filterByIPFamily(endpoints []*endpoint.Endpoint, policy string) []*endpoint.Endpoint {
+	filtered := []*endpoint.Endpoint{}
+	for _, ep := range endpoints {
+		if (policy == IPFamilyPolicyIPv4 && ep.RecordType == endpoint.RecordTypeAAAA) ||
+			(policy == IPFamilyPolicyIPv6 && ep.RecordType == endpoint.RecordTypeA) {
+			continue
+		}
+		filtered = append(filtered, ep)
+	}
+	return filtered
+}
+
+// setRoutingPolicyFromAnnotations applies the set identifier, geo location and provider specific annotations to the endpoints.
+// Invalid geo annotations are returned as error without changing the endpoints, they must not be published then, since
+// a record of the set identifier without its location would answer the queries of other locations.
+func setRoutingPolicyFromAnnotations(annotations map[string]string, endpoints []*endpoint.Endpoint) error {
+	geo, err := getGeoLocationFromAnnotations(annotations)
+	if err != nil {
+		return err
+	}
+	setIdentifier := annotations[setIdentifierAnnotationKey]
+	providerSpecific := getProviderSpecificAnnotations(annotations)
+
+	for _, ep := range endpoints {
+		ep.SetIdentifier = setIdentifier
+		ep.GeoLocation = nil
+		if geo != nil {
+			epGeo := *geo
+			ep.GeoLocation = &epGeo
+		}
+		ep.ProviderSpecific = append(endpoint.ProviderSpecific(nil), providerSpecific...)
+	}
+	return nil
+}
+
+// setResolutionLabels sets the labels used to resolve conflicts between resources wanting the same DNS name,
+// i.e. the creation time and the priority of the resource.
+func setResolutionLabels(meta metav1.ObjectMeta, endpoints []*endpoint.Endpoint) {
+	priority := ""
+	if value, ok := meta.Annotations[priorityAnnotationKey]; ok {
+		if _, err := strconv.Atoi(value); err != nil {
+			log.Warnf("%s/%s has an invalid priority %q, it must be an integer", meta.Namespace, meta.Name, value)
+		} else {
+			priority = value
+		}
+	}
+
+	for _, ep := range endpoints {
+		if !meta.CreationTimestamp.IsZero() {
+			ep.Labels[endpoint.ResourceCreatedLabelKey] = meta.CreationTimestamp.UTC().Format(time.RFC3339)
+		}
+		if priority != "" {
+			ep.Labels[endpoint.PriorityLabelKey] = priority
+		}
+	}
+}
+
+// setProviderLabel sets the label carrying the DNS provider chosen by the annotation of the resource
+func setProviderLabel(meta metav1.ObjectMeta, endpoints []*endpoint.Endpoint) {
+	value, ok := meta.Annotations[providerAnnotationKey]
+	if !ok || value == "" {
+		return
+	}
+	for _, ep := range endpoints {
+		ep.Labels[endpoint.ProviderLabelKey] = value
+	}
+}
+
+// setHealthCheckLabel sets the label carrying the health check of the resource from its annotation,
+// it's only used when the health checks are enabled.
+func setHealthCheckLabel(meta metav1.ObjectMeta, endpoints []*endpoint.Endpoint) {
+	value, ok := meta.Annotations[healthCheckAnnotationKey]
+	if !ok {
+		return
+	}
+	if _, err := parseHealthCheck(value); err != nil {
+		log.Warnf("%s/%s: %v", meta.Namespace, meta.Name, err)
+		return
+	}
+	for _, ep := range endpoints {
+		ep.Labels[endpoint.HealthCheckLabelKey] = value
+	}
+}
+
 // suitableType returns the DNS resource record type suitable for the target.
-// In this case type A for IPs and type CNAME for everything else.
+// In this case type A for IPv4 addresses, type AAAA for IPv6 addresses and type CNAME for everything else.
 func suitableType(target string) string {
-	if net.ParseIP(target) != nil {
+	if ip := net.ParseIP(target); ip != nil {
+		if ip.To4() == nil {
+			return endpoint.RecordTypeAAAA
+		}
 		return endpoint.RecordTypeA
 	}
 	return endpoint.RecordTypeCNAME
@@ -19,6 +19,9 @@ package source
 import (
 	"fmt"
 	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/kubernetes-incubator/external-dns/endpoint"
 	"github.com/stretchr/testify/assert"
@@ -81,6 +84,8 @@ func TestSuitableType(t *testing.T) {
 		target, recordType, expected string
 	}{
 		{"8.8.8.8", "", "A"},
+		{"2001:db8::1", "", "AAAA"},
+		{"::ffff:8.8.8.8", "", "A"},
 		{"foo.example.org", "", "CNAME"},
 		{"bar.eu-central-1.elb.amazonaws.com", "", "CNAME"},
 	} {
@@ -92,3 +97,188 @@ func TestSuitableType(t *testing.T) {
 		}
 	}
 }
+
+func TestGetGeoLocationFromAnnotations(t *testing.T) {
+	for _, tc := range []struct {
+		title       string
+		annotations map[string]string
+		expectedGeo *endpoint.GeoLocation
+		expectErr   bool
+	}{
+		{
+			title:       "geo annotations not present",
+			annotations: map[string]string{"foo": "bar"},
+		},
+		{
+			title:       "continent code",
+			annotations: map[string]string{geoContinentCodeAnnotationKey: "eu"},
+			expectedGeo: &endpoint.GeoLocation{ContinentCode: "EU"},
+		},
+		{
+			title:       "country and subdivision code",
+			annotations: map[string]string{geoCountryCodeAnnotationKey: "US", geoSubdivisionCodeAnnotationKey: "US-CA"},
+			expectedGeo: &endpoint.GeoLocation{CountryCode: "US", SubdivisionCode: "CA"},
+		},
+		{
+			title:       "default location",
+			annotations: map[string]string{geoCountryCodeAnnotationKey: "*"},
+			expectedGeo: &endpoint.GeoLocation{CountryCode: "*"},
+		},
+		{
+			title:       "invalid country code",
+			annotations: map[string]string{geoCountryCodeAnnotationKey: "Germany"},
+			expectErr:   true,
+		},
+		{
+			title:       "continent and country code",
+			annotations: map[string]string{geoContinentCodeAnnotationKey: "EU", geoCountryCodeAnnotationKey: "DE"},
+			expectErr:   true,
+		},
+		{
+			title:       "subdivision code without country code",
+			annotations: map[string]string{geoSubdivisionCodeAnnotationKey: "CA"},
+			expectErr:   true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			geo, err := getGeoLocationFromAnnotations(tc.annotations)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedGeo, geo)
+		})
+	}
+}
+
+func TestSetRoutingPolicyFromAnnotations(t *testing.T) {
+	endpoints := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("example.org", "1.2.3.4", endpoint.RecordTypeA),
+		endpoint.NewEndpoint("example.org", "lb.example.com", endpoint.RecordTypeCNAME),
+	}
+
+	err := setRoutingPolicyFromAnnotations(map[string]string{
+		setIdentifierAnnotationKey:                               "eu",
+		geoContinentCodeAnnotationKey:                            "EU",
+		"external-dns.alpha.kubernetes.io/aws-health-check-type": "HTTP",
+		"external-dns.alpha.kubernetes.io/aws-failover":          "PRIMARY",
+		"external-dns.alpha.kubernetes.io/ttl":                   "60",
+	}, endpoints)
+	assert.NoError(t, err)
+
+	for _, ep := range endpoints {
+		assert.Equal(t, "eu", ep.SetIdentifier)
+		assert.Equal(t, &endpoint.GeoLocation{ContinentCode: "EU"}, ep.GeoLocation)
+		assert.Equal(t, endpoint.ProviderSpecific{
+			{Name: "aws/failover", Value: "PRIMARY"},
+			{Name: "aws/health-check-type", Value: "HTTP"},
+		}, ep.ProviderSpecific)
+	}
+
+	// endpoints don't share their routing policy
+	endpoints[0].GeoLocation.ContinentCode = "NA"
+	endpoints[0].ProviderSpecific[0].Value = "SECONDARY"
+	assert.Equal(t, "EU", endpoints[1].GeoLocation.ContinentCode)
+	assert.Equal(t, "PRIMARY", endpoints[1].ProviderSpecific[0].Value)
+
+	// invalid geo annotations leave the endpoints unchanged, the set identifier isn't applied without its location
+	invalid := endpoint.NewEndpoint("example.org", "1.2.3.4", endpoint.RecordTypeA)
+	err = setRoutingPolicyFromAnnotations(map[string]string{
+		setIdentifierAnnotationKey:  "germany",
+		geoCountryCodeAnnotationKey: "Germany",
+	}, []*endpoint.Endpoint{invalid})
+	assert.Error(t, err)
+	assert.Nil(t, invalid.GeoLocation)
+	assert.Empty(t, invalid.SetIdentifier)
+}
+
+func TestSetResolutionLabels(t *testing.T) {
+	for _, tc := range []struct {
+		title            string
+		meta             metav1.ObjectMeta
+		expectedCreated  string
+		expectedPriority string
+	}{
+		{
+			title: "no creation time and priority",
+			meta:  metav1.ObjectMeta{Name: "foo"},
+		},
+		{
+			title: "creation time and priority",
+			meta: metav1.ObjectMeta{
+				Name:              "foo",
+				CreationTimestamp: metav1.NewTime(time.Date(2018, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))),
+				Annotations:       map[string]string{priorityAnnotationKey: "10"},
+			},
+			expectedCreated:  "2018-03-01T11:00:00Z",
+			expectedPriority: "10",
+		},
+		{
+			title: "invalid priority",
+			meta: metav1.ObjectMeta{
+				Name:        "foo",
+				Annotations: map[string]string{priorityAnnotationKey: "high"},
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			ep := endpoint.NewEndpoint("foo.example.org", "1.2.3.4", endpoint.RecordTypeA)
+			setResolutionLabels(tc.meta, []*endpoint.Endpoint{ep})
+			assert.Equal(t, tc.expectedCreated, ep.Labels[endpoint.ResourceCreatedLabelKey])
+			assert.Equal(t, tc.expectedPriority, ep.Labels[endpoint.PriorityLabelKey])
+		})
+	}
+}
+
+func TestSetProviderLabel(t *testing.T) {
+	ep := endpoint.NewEndpoint("foo.example.org", "1.2.3.4", endpoint.RecordTypeA)
+	setProviderLabel(metav1.ObjectMeta{Name: "foo"}, []*endpoint.Endpoint{ep})
+	assert.NotContains(t, ep.Labels, endpoint.ProviderLabelKey)
+
+	setProviderLabel(metav1.ObjectMeta{
+		Name:        "foo",
+		Annotations: map[string]string{providerAnnotationKey: "cloudflare"},
+	}, []*endpoint.Endpoint{ep})
+	assert.Equal(t, "cloudflare", ep.Labels[endpoint.ProviderLabelKey])
+}
+
+func TestGetIPFamilyPolicyFromAnnotations(t *testing.T) {
+	policy, err := getIPFamilyPolicyFromAnnotations(map[string]string{}, IPFamilyPolicyDual)
+	assert.NoError(t, err)
+	assert.Equal(t, IPFamilyPolicyDual, policy)
+
+	policy, err = getIPFamilyPolicyFromAnnotations(map[string]string{ipFamilyPolicyAnnotationKey: "ipv4"}, IPFamilyPolicyDual)
+	assert.NoError(t, err)
+	assert.Equal(t, IPFamilyPolicyIPv4, policy)
+
+	policy, err = getIPFamilyPolicyFromAnnotations(map[string]string{ipFamilyPolicyAnnotationKey: "ipv5"}, IPFamilyPolicyIPv6)
+	assert.Error(t, err)
+	assert.Equal(t, IPFamilyPolicyIPv6, policy)
+}
+
+func TestFilterByIPFamily(t *testing.T) {
+	endpoints := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", "1.2.3.4", endpoint.RecordTypeA),
+		endpoint.NewEndpoint("foo.example.org", "2001:db8::1", endpoint.RecordTypeAAAA),
+		endpoint.NewEndpoint("bar.example.org", "lb.example.com", endpoint.RecordTypeCNAME),
+	}
+
+	for _, tc := range []struct {
+		policy   string
+		expected []string
+	}{
+		{IPFamilyPolicyIPv4, []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}},
+		{IPFamilyPolicyIPv6, []string{endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME}},
+		{IPFamilyPolicyDual, []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME}},
+		{"", []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME}},
+	} {
+		t.Run(tc.policy, func(t *testing.T) {
+			recordTypes := []string{}
+			for _, ep := range filterByIPFamily(endpoints, tc.policy) {
+				recordTypes = append(recordTypes, ep.RecordType)
+			}
+			assert.Equal(t, tc.expected, recordTypes)
+		})
+	}
+}
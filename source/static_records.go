@@ -0,0 +1,216 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+)
+
+// staticRecord is a single hand-curated record as written by operators, e.g.
+//
+//   - name: example.org
+//     type: TXT
+//     targets: ["v=spf1 include:_spf.example.org ~all"]
+//     ttl: 3600
+type staticRecord struct {
+	Name             string            `yaml:"name"`
+	Type             string            `yaml:"type"`
+	Targets          []string          `yaml:"targets"`
+	TTL              int64             `yaml:"ttl"`
+	SetIdentifier    string            `yaml:"setIdentifier"`
+	Geo              *staticRecordGeo  `yaml:"geo"`
+	ProviderSpecific map[string]string `yaml:"providerSpecific"`
+}
+
+// staticRecordGeo restricts a static record to queries from a geo location.
+type staticRecordGeo struct {
+	Continent   string `yaml:"continent"`
+	Country     string `yaml:"country"`
+	Subdivision string `yaml:"subdivision"`
+}
+
+// staticRecordsSource is an implementation of Source for records listed in a ConfigMap or Secret.
+// It allows managing records which don't belong to any service or ingress, e.g. SPF or DKIM
+// records, alongside the dynamic ones.
+type staticRecordsSource struct {
+	client    kubernetes.Interface
+	kind      string
+	namespace string
+	name      string
+	key       string
+}
+
+// NewStaticRecordsSource creates a new staticRecordsSource reading the records from the given key
+// of either a ConfigMap or a Secret, both referenced as namespace/name.
+func NewStaticRecordsSource(kubeClient kubernetes.Interface, configMap, secret, key string) (Source, error) {
+	if (configMap == "") == (secret == "") {
+		return nil, fmt.Errorf("exactly one of a ConfigMap or a Secret must be specified for static records")
+	}
+	kind, ref := "configmap", configMap
+	if secret != "" {
+		kind, ref = "secret", secret
+	}
+
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid static records %s %q, expected namespace/name", kind, ref)
+	}
+
+	return &staticRecordsSource{
+		client:    kubeClient,
+		kind:      kind,
+		namespace: parts[0],
+		name:      parts[1],
+		key:       key,
+	}, nil
+}
+
+// Endpoints returns the endpoints listed in the ConfigMap or Secret.
+// Failing to read them is an error rather than an empty list, so that a missing
+// ConfigMap doesn't result in the deletion of all static records.
+func (sc *staticRecordsSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	var (
+		data  []byte
+		found bool
+	)
+
+	switch sc.kind {
+	case "secret":
+		secret, err := sc.client.CoreV1().Secrets(sc.namespace).Get(sc.name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		data, found = secret.Data[sc.key]
+	default:
+		configMap, err := sc.client.CoreV1().ConfigMaps(sc.namespace).Get(sc.name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		var value string
+		value, found = configMap.Data[sc.key]
+		data = []byte(value)
+	}
+
+	resource := fmt.Sprintf("%s/%s/%s", sc.kind, sc.namespace, sc.name)
+	if !found {
+		return nil, fmt.Errorf("%s has no key %q", resource, sc.key)
+	}
+
+	endpoints, err := parseStaticRecords(data, resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse static records of %s: %v", resource, err)
+	}
+	return endpoints, nil
+}
+
+// parseStaticRecords converts the YAML list of static records into endpoints labeled with the given resource.
+func parseStaticRecords(data []byte, resource string) ([]*endpoint.Endpoint, error) {
+	records := []staticRecord{}
+	if err := yaml.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+
+	endpoints := []*endpoint.Endpoint{}
+	for i, record := range records {
+		ep, err := record.endpoint()
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %v", i, err)
+		}
+		ep.Labels[endpoint.ResourceLabelKey] = resource
+		endpoints = append(endpoints, ep)
+	}
+	return endpoints, nil
+}
+
+// endpoint validates the static record and converts it into an endpoint.
+func (r staticRecord) endpoint() (*endpoint.Endpoint, error) {
+	name := strings.TrimSuffix(r.Name, ".")
+	if name == "" {
+		return nil, fmt.Errorf("no name specified")
+	}
+
+	recordType := strings.ToUpper(r.Type)
+	switch recordType {
+	case endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME, endpoint.RecordTypeTXT:
+	default:
+		return nil, fmt.Errorf("unsupported record type %q of %s", r.Type, name)
+	}
+
+	if len(r.Targets) == 0 {
+		return nil, fmt.Errorf("no targets specified for %s", name)
+	}
+	if r.TTL != 0 && (r.TTL < ttlMinimum || r.TTL > ttlMaximum) {
+		return nil, fmt.Errorf("TTL value of %s must be between [%d, %d]", name, ttlMinimum, ttlMaximum)
+	}
+
+	ep := endpoint.NewEndpointWithTTL(name, "", recordType, endpoint.TTL(r.TTL))
+	ep.Targets = make(endpoint.Targets, 0, len(r.Targets))
+	for _, target := range r.Targets {
+		ep.Targets = append(ep.Targets, strings.TrimSuffix(target, "."))
+	}
+	ep.SetIdentifier = r.SetIdentifier
+
+	if r.Geo != nil {
+		geo, err := r.Geo.geoLocation()
+		if err != nil {
+			return nil, fmt.Errorf("invalid geo location of %s: %v", name, err)
+		}
+		ep.GeoLocation = geo
+	}
+
+	for property, value := range r.ProviderSpecific {
+		ep.ProviderSpecific = append(ep.ProviderSpecific, endpoint.ProviderSpecificProperty{Name: property, Value: value})
+	}
+	sort.Slice(ep.ProviderSpecific, func(i, j int) bool {
+		return ep.ProviderSpecific[i].Name < ep.ProviderSpecific[j].Name
+	})
+
+	return ep, nil
+}
+
+// geoLocation validates the codes which are set and converts them into a geo location.
+func (g *staticRecordGeo) geoLocation() (*endpoint.GeoLocation, error) {
+	geo := &endpoint.GeoLocation{}
+	if g.Continent != "" {
+		if err := geo.SetContinentCode(g.Continent); err != nil {
+			return nil, err
+		}
+	}
+	if g.Country != "" {
+		if err := geo.SetCountryCode(g.Country); err != nil {
+			return nil, err
+		}
+	}
+	if g.Subdivision != "" {
+		if err := geo.SetSubdivisionCode(g.Subdivision); err != nil {
+			return nil, err
+		}
+	}
+	if *geo == (endpoint.GeoLocation{}) {
+		return nil, fmt.Errorf("no continent or country specified")
+	}
+	return geo, nil
+}
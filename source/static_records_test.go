@@ -0,0 +1,171 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"testing"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Validates that staticRecordsSource is a Source
+var _ Source = &staticRecordsSource{}
+
+const testStaticRecords = `
+- name: example.org.
+  type: TXT
+  targets: ["v=spf1 include:_spf.example.org ~all"]
+  ttl: 3600
+- name: www.example.org
+  type: cname
+  targets: [example.github.io.]
+- name: geo.example.org
+  type: A
+  targets: [1.2.3.4, 5.6.7.8]
+  setIdentifier: california
+  geo:
+    country: US
+    subdivision: CA
+  providerSpecific:
+    aws/evaluate-target-health: "true"
+    aws/health-check-id: abc
+`
+
+func TestNewStaticRecordsSource(t *testing.T) {
+	for _, tc := range []struct {
+		title     string
+		configMap string
+		secret    string
+		expectErr bool
+	}{
+		{"configmap", "default/records", "", false},
+		{"secret", "", "default/records", false},
+		{"neither", "", "", true},
+		{"both", "default/records", "default/records", true},
+		{"missing namespace", "records", "", true},
+		{"missing name", "default/", "", true},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			_, err := NewStaticRecordsSource(fake.NewSimpleClientset(), tc.configMap, tc.secret, "records.yaml")
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseStaticRecords(t *testing.T) {
+	endpoints, err := parseStaticRecords([]byte(testStaticRecords), "configmap/default/records")
+	require.NoError(t, err)
+	require.Len(t, endpoints, 3)
+
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "example.org", Targets: endpoint.Targets{"v=spf1 include:_spf.example.org ~all"}, RecordType: endpoint.RecordTypeTXT, RecordTTL: 3600},
+		{DNSName: "www.example.org", Targets: endpoint.Targets{"example.github.io"}, RecordType: endpoint.RecordTypeCNAME},
+		{
+			DNSName:       "geo.example.org",
+			Targets:       endpoint.Targets{"1.2.3.4", "5.6.7.8"},
+			RecordType:    endpoint.RecordTypeA,
+			SetIdentifier: "california",
+			GeoLocation:   &endpoint.GeoLocation{CountryCode: "US", SubdivisionCode: "CA"},
+			ProviderSpecific: endpoint.ProviderSpecific{
+				{Name: "aws/evaluate-target-health", Value: "true"},
+				{Name: "aws/health-check-id", Value: "abc"},
+			},
+		},
+	})
+
+	for _, ep := range endpoints {
+		assert.Equal(t, "configmap/default/records", ep.Labels[endpoint.ResourceLabelKey])
+	}
+}
+
+func TestParseStaticRecordsInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		title   string
+		records string
+	}{
+		{"malformed", "- name: [example.org"},
+		{"no name", "- {type: A, targets: [1.2.3.4]}"},
+		{"unsupported type", "- {name: example.org, type: MX, targets: [mail.example.org]}"},
+		{"no targets", "- {name: example.org, type: A}"},
+		{"negative ttl", "- {name: example.org, type: A, targets: [1.2.3.4], ttl: -1}"},
+		{"empty geo", "- {name: example.org, type: A, targets: [1.2.3.4], geo: {}}"},
+		{"invalid continent", "- {name: example.org, type: A, targets: [1.2.3.4], geo: {continent: XX}}"},
+		{"continent and country", "- {name: example.org, type: A, targets: [1.2.3.4], geo: {continent: EU, country: DE}}"},
+		{"subdivision without country", "- {name: example.org, type: A, targets: [1.2.3.4], geo: {subdivision: CA}}"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			_, err := parseStaticRecords([]byte(tc.records), "configmap/default/records")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestStaticRecordsSourceConfigMap(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	source, err := NewStaticRecordsSource(client, "kube-system/dns-records", "", "records.yaml")
+	require.NoError(t, err)
+
+	_, err = source.Endpoints()
+	assert.Error(t, err, "should fail if the ConfigMap doesn't exist")
+
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "dns-records"},
+		Data:       map[string]string{"other.yaml": testStaticRecords},
+	}
+	_, err = client.CoreV1().ConfigMaps("kube-system").Create(configMap)
+	require.NoError(t, err)
+
+	_, err = source.Endpoints()
+	assert.Error(t, err, "should fail if the key doesn't exist")
+
+	configMap.Data["records.yaml"] = testStaticRecords
+	_, err = client.CoreV1().ConfigMaps("kube-system").Update(configMap)
+	require.NoError(t, err)
+
+	endpoints, err := source.Endpoints()
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 3)
+	assert.Equal(t, "configmap/kube-system/dns-records", endpoints[0].Labels[endpoint.ResourceLabelKey])
+}
+
+func TestStaticRecordsSourceSecret(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	_, err := client.CoreV1().Secrets("kube-system").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "dns-records"},
+		Data:       map[string][]byte{"records.yaml": []byte(testStaticRecords)},
+	})
+	require.NoError(t, err)
+
+	source, err := NewStaticRecordsSource(client, "", "kube-system/dns-records", "records.yaml")
+	require.NoError(t, err)
+
+	endpoints, err := source.Endpoints()
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 3)
+	assert.Equal(t, "secret/kube-system/dns-records", endpoints[0].Labels[endpoint.ResourceLabelKey])
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+)
+
+// RecordState is the state of the record of a desired endpoint after a synchronization
+type RecordState string
+
+const (
+	// RecordSynced means that the record matches the endpoint
+	RecordSynced RecordState = "Synced"
+	// RecordPending means that the change of the record is applied in a later synchronization, e.g. because of
+	// the limit of changes per synchronization, or never in dry-run mode
+	RecordPending RecordState = "Pending"
+	// RecordFailed means that applying the change of the record failed
+	RecordFailed RecordState = "Failed"
+	// RecordRejected means that the record isn't changed, e.g. because another resource owns its name or the policy
+	// doesn't allow the change
+	RecordRejected RecordState = "Rejected"
+)
+
+// RecordStatus is the state of the record of a desired endpoint
+type RecordStatus struct {
+	Endpoint *endpoint.Endpoint
+	State    RecordState
+	// Message explains the state, e.g. the error of a failed change
+	Message string
+}
+
+// StatusReporter is informed about the state of the records of the desired endpoints after every synchronization,
+// so that a source can report it on the resources the endpoints originate from, e.g. in the status of a custom
+// resource. The resources are identified by the resource label of the endpoints. Endpoints rejected by the source
+// itself, e.g. because of invalid annotations, never reach the controller and are reported by the source.
+type StatusReporter interface {
+	ReportStatus(statuses []*RecordStatus)
+}
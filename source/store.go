@@ -41,6 +41,13 @@ type Config struct {
 	CombineFQDNAndAnnotation bool
 	Compatibility            string
 	PublishInternal          bool
+	// IPFamilyPolicy chooses the records of IP addresses, i.e. ipv4, ipv6 or dual, resources may override it
+	IPFamilyPolicy         string
+	StaticRecordsConfigMap string
+	StaticRecordsSecret    string
+	StaticRecordsKey       string
+	// EventRecorder optionally emits events on resources with invalid annotations
+	EventRecorder *EventRecorder
 }
 
 // ClientGenerator provides clients
@@ -66,7 +73,7 @@ func (p *SingletonClientGenerator) KubeClient() (kubernetes.Interface, error) {
 	return p.client, err
 }
 
-// ByNames returns multiple Sources given multiple names.
+// ByNames returns multiple Sources given multiple names, exporting metrics labeled with their names.
 func ByNames(p ClientGenerator, names []string, cfg *Config) ([]Source, error) {
 	sources := []Source{}
 	for _, name := range names {
@@ -74,7 +81,7 @@ func ByNames(p ClientGenerator, names []string, cfg *Config) ([]Source, error) {
 		if err != nil {
 			return nil, err
 		}
-		sources = append(sources, source)
+		sources = append(sources, NewInstrumentedSource(name, source))
 	}
 
 	return sources, nil
@@ -88,13 +95,19 @@ func BuildWithConfig(source string, p ClientGenerator, cfg *Config) (Source, err
 		if err != nil {
 			return nil, err
 		}
-		return NewServiceSource(client, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.Compatibility, cfg.PublishInternal)
+		return NewServiceSource(client, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.Compatibility, cfg.PublishInternal, cfg.IPFamilyPolicy, cfg.EventRecorder)
 	case "ingress":
 		client, err := p.KubeClient()
 		if err != nil {
 			return nil, err
 		}
-		return NewIngressSource(client, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation)
+		return NewIngressSource(client, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.IPFamilyPolicy, cfg.EventRecorder)
+	case "static-records":
+		client, err := p.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+		return NewStaticRecordsSource(client, cfg.StaticRecordsConfigMap, cfg.StaticRecordsSecret, cfg.StaticRecordsKey)
 	case "fake":
 		return NewFakeSource(cfg.FQDNTemplate)
 	}
@@ -82,6 +82,21 @@ func (suite *ByNamesTestSuite) TestKubeClientFails() {
 
 	_, err = ByNames(mockClientGenerator, []string{"ingress"}, &Config{})
 	suite.Error(err, "should return an error if client cannot be created")
+
+	_, err = ByNames(mockClientGenerator, []string{"static-records"}, &Config{StaticRecordsConfigMap: "default/records"})
+	suite.Error(err, "should return an error if client cannot be created")
+}
+
+func (suite *ByNamesTestSuite) TestStaticRecords() {
+	mockClientGenerator := new(MockClientGenerator)
+	mockClientGenerator.On("KubeClient").Return(fake.NewSimpleClientset(), nil)
+
+	sources, err := ByNames(mockClientGenerator, []string{"static-records"}, &Config{StaticRecordsConfigMap: "default/records", StaticRecordsKey: "records.yaml"})
+	suite.NoError(err, "should not generate errors")
+	suite.Len(sources, 1, "should generate the static records source")
+
+	_, err = ByNames(mockClientGenerator, []string{"static-records"}, &Config{})
+	suite.Error(err, "should return an error without a ConfigMap or Secret")
 }
 
 func TestByNames(t *testing.T) {
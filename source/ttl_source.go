@@ -0,0 +1,56 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+)
+
+// ttlSource is a Source that enforces a default and a minimum TTL on the endpoints of its wrapped source.
+type ttlSource struct {
+	source     Source
+	defaultTTL endpoint.TTL
+	minTTL     endpoint.TTL
+}
+
+// NewTTLSource creates a new ttlSource wrapping the provided Source. Endpoints without a TTL get defaultTTL,
+// TTLs below minTTL are raised to minTTL. A zero value disables either of them.
+func NewTTLSource(source Source, defaultTTL, minTTL endpoint.TTL) Source {
+	return &ttlSource{source: source, defaultTTL: defaultTTL, minTTL: minTTL}
+}
+
+// Endpoints collects endpoints from its wrapped source and enforces the default and minimum TTL on them.
+func (ts *ttlSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	endpoints, err := ts.source.Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ep := range endpoints {
+		if !ep.RecordTTL.IsConfigured() {
+			ep.RecordTTL = ts.defaultTTL
+		}
+		if ep.RecordTTL.IsConfigured() && ep.RecordTTL < ts.minTTL {
+			log.WithFields(ep.LogFields()).Debugf("Raising the TTL from %d to the minimum of %d", ep.RecordTTL, ts.minTTL)
+			ep.RecordTTL = ts.minTTL
+		}
+	}
+
+	return endpoints, nil
+}
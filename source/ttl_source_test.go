@@ -0,0 +1,113 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"testing"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+	"github.com/kubernetes-incubator/external-dns/internal/testutils"
+)
+
+// Validates that ttlSource is a Source
+var _ Source = &ttlSource{}
+
+func TestTTLSource(t *testing.T) {
+	t.Run("Endpoints", testTTLSourceEndpoints)
+}
+
+// testTTLSourceEndpoints tests that the default and minimum TTL are enforced on the endpoints of the wrapped source.
+func testTTLSourceEndpoints(t *testing.T) {
+	for _, tc := range []struct {
+		title      string
+		defaultTTL endpoint.TTL
+		minTTL     endpoint.TTL
+		endpoints  []*endpoint.Endpoint
+		expected   []*endpoint.Endpoint
+	}{
+		{
+			"disabled keeps the TTLs",
+			0,
+			0,
+			[]*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
+				{DNSName: "bar.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordTTL: 10},
+			},
+			[]*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
+				{DNSName: "bar.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordTTL: 10},
+			},
+		},
+		{
+			"default TTL is set on endpoints without TTL",
+			300,
+			0,
+			[]*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
+				{DNSName: "bar.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordTTL: 10},
+			},
+			[]*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordTTL: 300},
+				{DNSName: "bar.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordTTL: 10},
+			},
+		},
+		{
+			"TTLs below the minimum are raised",
+			0,
+			60,
+			[]*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
+				{DNSName: "bar.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordTTL: 10},
+				{DNSName: "baz.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordTTL: 600},
+			},
+			[]*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
+				{DNSName: "bar.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordTTL: 60},
+				{DNSName: "baz.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordTTL: 600},
+			},
+		},
+		{
+			"default and minimum TTL combined",
+			300,
+			60,
+			[]*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
+				{DNSName: "bar.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordTTL: 10},
+			},
+			[]*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordTTL: 300},
+				{DNSName: "bar.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordTTL: 60},
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			mockSource := new(testutils.MockSource)
+			mockSource.On("Endpoints").Return(tc.endpoints, nil)
+
+			source := NewTTLSource(mockSource, tc.defaultTTL, tc.minTTL)
+
+			endpoints, err := source.Endpoints()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			validateEndpoints(t, endpoints, tc.expected)
+
+			mockSource.AssertExpectations(t)
+		})
+	}
+}
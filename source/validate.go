@@ -0,0 +1,171 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/kubernetes-incubator/external-dns/endpoint"
+)
+
+// AnnotationError is an invalid annotation of a resource
+type AnnotationError struct {
+	Key string
+	Err error
+}
+
+func (e *AnnotationError) Error() string {
+	return fmt.Sprintf("invalid annotation %s: %v", e.Key, e.Err)
+}
+
+// ValidateAnnotations validates the annotations of a Service or Ingress like the sources parse them, i.e. the TTL,
+// the geo location, the priority, the health check and the IP family policy. It returns an error for every invalid
+// annotation.
+func ValidateAnnotations(annotations map[string]string) []*AnnotationError {
+	errs := []*AnnotationError{}
+	invalid := func(key string, err error) {
+		errs = append(errs, &AnnotationError{Key: key, Err: err})
+	}
+
+	if _, err := getTTLFromAnnotations(annotations); err != nil {
+		invalid(ttlAnnotationKey, err)
+	}
+
+	geo := &endpoint.GeoLocation{}
+	if code, ok := annotations[geoContinentCodeAnnotationKey]; ok {
+		if err := geo.SetContinentCode(code); err != nil {
+			invalid(geoContinentCodeAnnotationKey, err)
+		}
+	}
+	countryValid := true
+	if code, ok := annotations[geoCountryCodeAnnotationKey]; ok {
+		if err := geo.SetCountryCode(code); err != nil {
+			invalid(geoCountryCodeAnnotationKey, err)
+			countryValid = false
+		}
+	}
+	// a subdivision can't be checked without a valid country
+	if code, ok := annotations[geoSubdivisionCodeAnnotationKey]; ok && countryValid {
+		if err := geo.SetSubdivisionCode(code); err != nil {
+			invalid(geoSubdivisionCodeAnnotationKey, err)
+		}
+	}
+
+	if value, ok := annotations[priorityAnnotationKey]; ok {
+		if _, err := strconv.Atoi(value); err != nil {
+			invalid(priorityAnnotationKey, fmt.Errorf("%q is not a valid priority, it must be an integer", value))
+		}
+	}
+	if value, ok := annotations[healthCheckAnnotationKey]; ok {
+		if _, err := parseHealthCheck(value); err != nil {
+			invalid(healthCheckAnnotationKey, err)
+		}
+	}
+	if _, err := getIPFamilyPolicyFromAnnotations(annotations, IPFamilyPolicyDual); err != nil {
+		invalid(ipFamilyPolicyAnnotationKey, err)
+	}
+	return errs
+}
+
+// ManifestError is an error of a resource in a manifest
+type ManifestError struct {
+	// Line is the line of the invalid annotation in the manifest, or of the document if it can't be parsed
+	Line int
+	// Resource identifies the resource, e.g. service/default/nginx, it's empty if the document can't be parsed
+	Resource string
+	Err      error
+}
+
+func (e *ManifestError) Error() string {
+	if e.Resource == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Resource, e.Err)
+}
+
+// manifestObject holds the fields of a Kubernetes object needed to validate its annotations
+type manifestObject struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name        string            `yaml:"name"`
+		Namespace   string            `yaml:"namespace"`
+		Annotations map[string]string `yaml:"annotations"`
+	} `yaml:"metadata"`
+}
+
+// ValidateManifest validates the annotations of the Services and Ingresses in a YAML or JSON manifest of one or more
+// documents, e.g. the output of a template engine in CI. Other resources are skipped. The returned error is only set
+// if the manifest can't be read.
+func ValidateManifest(r io.Reader) ([]*ManifestError, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := []*ManifestError{}
+	lines := strings.Split(string(data), "\n")
+	for start := 0; start < len(lines); {
+		end := start + 1
+		for end < len(lines) && !strings.HasPrefix(lines[end], "---") {
+			end++
+		}
+		errs = append(errs, validateManifestDocument(lines[start:end], start+1)...)
+		start = end
+	}
+	return errs, nil
+}
+
+// validateManifestDocument validates the document of the given lines starting at the given line of the manifest
+func validateManifestDocument(lines []string, firstLine int) []*ManifestError {
+	object := manifestObject{}
+	if err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), &object); err != nil {
+		return []*ManifestError{{Line: firstLine, Err: fmt.Errorf("failed to parse the document: %v", err)}}
+	}
+	kind := strings.ToLower(object.Kind)
+	if kind != "service" && kind != "ingress" {
+		return nil
+	}
+
+	resource := kind + "/" + object.Metadata.Name
+	if object.Metadata.Namespace != "" {
+		resource = kind + "/" + object.Metadata.Namespace + "/" + object.Metadata.Name
+	}
+	errs := []*ManifestError{}
+	for _, err := range ValidateAnnotations(object.Metadata.Annotations) {
+		errs = append(errs, &ManifestError{Line: firstLine + annotationLine(lines, err.Key), Resource: resource, Err: err})
+	}
+	return errs
+}
+
+// annotationLine returns the index of the line of the annotation in the document, 0 if it isn't found
+func annotationLine(lines []string, key string) int {
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		for _, quote := range []string{"", "\"", "'"} {
+			if strings.HasPrefix(line, quote+key+quote+":") {
+				return i
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAnnotations(t *testing.T) {
+	for _, tc := range []struct {
+		title       string
+		annotations map[string]string
+		invalid     []string
+	}{
+		{
+			title: "valid annotations",
+			annotations: map[string]string{
+				hostnameAnnotationKey:           "foo.example.org",
+				ttlAnnotationKey:                "60",
+				geoCountryCodeAnnotationKey:     "US",
+				geoSubdivisionCodeAnnotationKey: "CA",
+				priorityAnnotationKey:           "10",
+				healthCheckAnnotationKey:        "http://:8080/healthz",
+				ipFamilyPolicyAnnotationKey:     "ipv4",
+			},
+		},
+		{
+			title:       "no annotations",
+			annotations: map[string]string{},
+		},
+		{
+			title: "invalid annotations",
+			annotations: map[string]string{
+				ttlAnnotationKey:            "forever",
+				priorityAnnotationKey:       "high",
+				ipFamilyPolicyAnnotationKey: "ipv5",
+			},
+			invalid: []string{ttlAnnotationKey, priorityAnnotationKey, ipFamilyPolicyAnnotationKey},
+		},
+		{
+			title: "continent combined with country",
+			annotations: map[string]string{
+				geoContinentCodeAnnotationKey: "EU",
+				geoCountryCodeAnnotationKey:   "DE",
+			},
+			invalid: []string{geoCountryCodeAnnotationKey},
+		},
+		{
+			title: "subdivision of an invalid country is reported once",
+			annotations: map[string]string{
+				geoCountryCodeAnnotationKey:     "USA",
+				geoSubdivisionCodeAnnotationKey: "CA",
+			},
+			invalid: []string{geoCountryCodeAnnotationKey},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			invalid := []string{}
+			for _, err := range ValidateAnnotations(tc.annotations) {
+				assert.Error(t, err.Err)
+				invalid = append(invalid, err.Key)
+			}
+			if tc.invalid == nil {
+				tc.invalid = []string{}
+			}
+			assert.Equal(t, tc.invalid, invalid)
+		})
+	}
+}
+
+func TestValidateManifest(t *testing.T) {
+	manifest := `apiVersion: v1
+kind: Service
+metadata:
+  name: valid
+  annotations:
+    external-dns.alpha.kubernetes.io/ttl: "60"
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+  namespace: default
+  annotations:
+    external-dns.alpha.kubernetes.io/hostname: nginx.example.org
+    "external-dns.alpha.kubernetes.io/ttl": forever
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+  annotations:
+    external-dns.alpha.kubernetes.io/ttl: forever
+---
+apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+  annotations:
+    external-dns.alpha.kubernetes.io/priority: high
+---
+kind: [
+`
+	errs, err := ValidateManifest(strings.NewReader(manifest))
+	require.NoError(t, err)
+	require.Len(t, errs, 3)
+
+	assert.Equal(t, 15, errs[0].Line)
+	assert.Equal(t, "service/default/nginx", errs[0].Resource)
+	assert.Contains(t, errs[0].Error(), "service/default/nginx: invalid annotation external-dns.alpha.kubernetes.io/ttl")
+
+	assert.Equal(t, 29, errs[1].Line)
+	assert.Equal(t, "ingress/web", errs[1].Resource)
+
+	assert.Equal(t, 30, errs[2].Line)
+	assert.Empty(t, errs[2].Resource)
+}
+
+func TestValidateManifestReadError(t *testing.T) {
+	_, err := ValidateManifest(&failingReader{})
+	assert.EqualError(t, err, "read failed")
+}
+
+type failingReader struct{}
+
+func (r *failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}